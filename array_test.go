@@ -131,3 +131,28 @@ func BenchmarkArraySetEmpty(b *testing.B) {
 		a.self.setOwnIdx(0, valueTrue, true)
 	}
 }
+
+func BenchmarkArrayBuild(b *testing.B) {
+	prg := MustCompile("test.js", `
+	(function() {
+		var a = [];
+		for (var i = 0; i < 100000; i++) {
+			a.push(i);
+		}
+		var sum = 0;
+		for (var i = 0; i < a.length; i++) {
+			sum += a[i] & 1023;
+		}
+		return sum;
+	})();
+	`, false)
+	vm := New()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := vm.RunProgram(prg)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
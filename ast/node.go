@@ -136,6 +136,8 @@ type (
 		ParameterList *ParameterList
 		Body          *BlockStatement
 		Source        string
+		Generator     bool
+		Async         bool
 
 		DeclarationList []*VariableDeclaration
 	}
@@ -164,6 +166,7 @@ type (
 		Body            ConciseBody
 		Source          string
 		DeclarationList []*VariableDeclaration
+		Async           bool
 	}
 
 	Identifier struct {
@@ -286,6 +289,17 @@ type (
 		Meta, Property *Identifier
 		Idx            file.Idx
 	}
+
+	YieldExpression struct {
+		Yield    file.Idx
+		Argument Expression // nil if there is no argument
+		Delegate bool       // yield*
+	}
+
+	AwaitExpression struct {
+		Await    file.Idx
+		Argument Expression
+	}
 )
 
 // _expressionNode
@@ -316,6 +330,8 @@ func (*ThisExpression) _expressionNode()        {}
 func (*SuperExpression) _expressionNode()       {}
 func (*UnaryExpression) _expressionNode()       {}
 func (*MetaProperty) _expressionNode()          {}
+func (*YieldExpression) _expressionNode()       {}
+func (*AwaitExpression) _expressionNode()       {}
 func (*ObjectPattern) _expressionNode()         {}
 func (*ArrayPattern) _expressionNode()          {}
 func (*Binding) _expressionNode()               {}
@@ -393,6 +409,7 @@ type (
 		Into   ForInto
 		Source Expression
 		Body   Statement
+		Await  bool
 	}
 
 	ForStatement struct {
@@ -650,6 +667,8 @@ func (self *ThisExpression) Idx0() file.Idx        { return self.Idx }
 func (self *SuperExpression) Idx0() file.Idx       { return self.Idx }
 func (self *UnaryExpression) Idx0() file.Idx       { return self.Idx }
 func (self *MetaProperty) Idx0() file.Idx          { return self.Idx }
+func (self *YieldExpression) Idx0() file.Idx       { return self.Yield }
+func (self *AwaitExpression) Idx0() file.Idx       { return self.Await }
 
 func (self *BadStatement) Idx0() file.Idx        { return self.From }
 func (self *BlockStatement) Idx0() file.Idx      { return self.LeftBrace }
@@ -736,6 +755,15 @@ func (self *UnaryExpression) Idx1() file.Idx {
 func (self *MetaProperty) Idx1() file.Idx {
 	return self.Property.Idx1()
 }
+func (self *YieldExpression) Idx1() file.Idx {
+	if self.Argument != nil {
+		return self.Argument.Idx1()
+	}
+	return self.Yield + 5
+}
+func (self *AwaitExpression) Idx1() file.Idx {
+	return self.Argument.Idx1()
+}
 
 func (self *BadStatement) Idx1() file.Idx        { return self.To }
 func (self *BlockStatement) Idx1() file.Idx      { return self.RightBrace + 1 }
@@ -537,19 +537,18 @@ func (r *Runtime) arrayproto_unshift(call FunctionCall) Value {
 
 func (r *Runtime) arrayproto_at(call FunctionCall) Value {
 	o := call.This.ToObject(r)
-	idx := call.Argument(0).ToInteger()
 	length := toLength(o.self.getStr("length", nil))
+	idx := call.Argument(0).ToInteger()
 	if idx < 0 {
 		idx = length + idx
 	}
 	if idx >= length || idx < 0 {
 		return _undefined
 	}
-	i := valueInt(idx)
-	if o.self.hasPropertyIdx(i) {
-		return o.self.getIdx(i, nil)
+	if arr := r.checkStdArrayObj(o); arr != nil && idx < int64(len(arr.values)) {
+		return arr.values[idx]
 	}
-	return _undefined
+	return nilSafe(o.self.getIdx(valueInt(idx), nil))
 }
 
 func (r *Runtime) arrayproto_indexOf(call FunctionCall) Value {
@@ -610,13 +609,10 @@ func (r *Runtime) arrayproto_includes(call FunctionCall) Value {
 	}
 
 	searchElement := call.Argument(0)
-	if searchElement == _negativeZero {
-		searchElement = _positiveZero
-	}
 
 	if arr := r.checkStdArrayObj(o); arr != nil {
 		for _, val := range arr.values[n:] {
-			if searchElement.SameAs(val) {
+			if searchElement.SameValueZero(nilSafe(val)) {
 				return valueTrue
 			}
 		}
@@ -626,7 +622,7 @@ func (r *Runtime) arrayproto_includes(call FunctionCall) Value {
 	for ; n < length; n++ {
 		idx := valueInt(n)
 		val := nilSafe(o.self.getIdx(idx, nil))
-		if searchElement.SameAs(val) {
+		if searchElement.SameValueZero(val) {
 			return valueTrue
 		}
 	}
@@ -1,6 +1,9 @@
 package goja
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestArrayProtoProp(t *testing.T) {
 	const SCRIPT = `
@@ -99,6 +102,51 @@ func TestArraySetLengthWithPropItems(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestArraySetLengthNonConfigurableElement(t *testing.T) {
+	const SCRIPT = `
+	function makeArray() {
+		var a = [0, 1, 2, 3, 4, 5];
+		Object.defineProperty(a, 3, {get: function() { return 3; }, configurable: false});
+		return a;
+	}
+
+	var a = makeArray();
+	a.length = 1;
+	assert.sameValue(a.length, 4, "sloppy: length");
+	assert(!(5 in a) && !(4 in a), "sloppy: elements above the non-configurable one are deleted");
+	assert(3 in a && 2 in a && 1 in a, "sloppy: elements at and below the non-configurable one are kept");
+
+	a = makeArray();
+	assert.throws(TypeError, function() {
+		"use strict";
+		a.length = 1;
+	}, "strict");
+	assert.sameValue(a.length, 4, "strict: length");
+	assert(!(4 in a) && 3 in a && 2 in a, "strict: elements");
+
+	a = makeArray();
+	assert.sameValue(Reflect.set(a, "length", 0), false, "Reflect.set");
+	assert.sameValue(a.length, 4, "Reflect.set: length");
+
+	a = makeArray();
+	assert.sameValue(Reflect.defineProperty(a, "length", {value: 0, writable: false}), false, "Reflect.defineProperty");
+	assert.sameValue(a.length, 4, "Reflect.defineProperty: length");
+	assert.sameValue(Object.getOwnPropertyDescriptor(a, "length").writable, false, "Reflect.defineProperty: writable");
+	assert(!(4 in a), "Reflect.defineProperty: elements");
+
+	a = [];
+	a[1000] = "a";
+	a[10] = "b";
+	Object.defineProperty(a, 500, {value: "c", configurable: false});
+	a.length = 0;
+	assert.sameValue(a.length, 501, "sparse: length");
+	assert(!(1000 in a), "sparse: elements above");
+	assert.sameValue(a[10], "b", "sparse: elements below");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestArrayFrom(t *testing.T) {
 	const SCRIPT = `
 	function checkDestHoles(dest, prefix) {
@@ -323,3 +371,85 @@ func TestArrayFlatMap(t *testing.T) {
 	`
 	testScriptWithTestLibX(SCRIPT, _undefined, t)
 }
+
+func TestArrayIncludesSameValueZero(t *testing.T) {
+	const SCRIPT = `
+	assert([-0].includes(0), "-0 includes 0");
+	assert([0].includes(-0), "0 includes -0");
+	assert([-0].includes(-0), "-0 includes -0");
+	assert([NaN].includes(NaN), "NaN");
+	assert([, 1].includes(undefined), "hole");
+	assert(!["0"].includes(0), "no type conversion");
+	assert(Array.prototype.includes.call({length: 1, 0: -0}, 0), "array-like");
+
+	assert(new Float64Array([-0]).includes(0), "Float64Array -0 includes 0");
+	assert(new Float32Array([0]).includes(-0), "Float32Array 0 includes -0");
+	assert(new Float64Array([NaN]).includes(NaN), "Float64Array NaN");
+	assert(new Int8Array([0]).includes(-0), "Int8Array 0 includes -0");
+	assert(!new Int8Array([1]).includes(NaN), "Int8Array NaN");
+
+	assert(!Object.is(0, -0), "Object.is(0, -0)");
+	assert(Object.is(NaN, NaN), "Object.is(NaN, NaN)");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestArrayAt(t *testing.T) {
+	const SCRIPT = `
+	const a = [1, 2, 3];
+	assert.sameValue(a.at(0), 1, "0");
+	assert.sameValue(a.at(-1), 3, "-1");
+	assert.sameValue(a.at(-3), 1, "-3");
+	assert.sameValue(a.at(3), undefined, "out of range");
+	assert.sameValue(a.at(-4), undefined, "negative out of range");
+	assert.sameValue(a.at(1.9), 2, "truncated");
+	assert.sameValue(a.at(-1.9), 3, "negative truncated");
+	assert.sameValue(a.at("1"), 2, "string");
+	assert.sameValue(a.at(), 1, "undefined");
+	assert.sameValue(a.at(NaN), 1, "NaN");
+	assert.sameValue([1, , 3].at(1), undefined, "hole");
+	assert.sameValue(Array.prototype.at.call({length: 2, 1: "x"}, -1), "x", "array-like");
+	assert.sameValue(Array.prototype.at.call("abc", -1), "c", "string this");
+
+	// the length is read before the index is converted and the element is read with [[Get]] only
+	const log = [];
+	const p = new Proxy([1, 2], {
+		get(t, k) { log.push("get " + String(k)); return t[k]; },
+		has(t, k) { log.push("has " + String(k)); return k in t; },
+	});
+	Array.prototype.at.call(p, {valueOf() { log.push("index"); return -1; }});
+	assert(compareArray(log, ["get length", "index", "get 1"]), log.join());
+
+	const arr = [1, 2];
+	assert.sameValue(arr.at({valueOf() { arr.length = 0; return 1; }}), undefined, "shrunk");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestValueSameValueZero(t *testing.T) {
+	negZero := valueFloat(math.Copysign(0, -1))
+	nan := valueFloat(math.NaN())
+	for _, tc := range []struct {
+		a, b                   Value
+		strict, same, sameZero bool
+	}{
+		{valueInt(0), negZero, true, false, true},
+		{negZero, valueInt(0), true, false, true},
+		{negZero, negZero, true, true, true},
+		{nan, nan, false, true, true},
+		{valueFloat(1.5), valueFloat(1.5), true, true, true},
+		{valueInt(1), asciiString("1"), false, false, false},
+		{_undefined, _null, false, false, false},
+		{asciiString("a"), newStringValue("a"), true, true, true},
+	} {
+		if res := tc.a.StrictEquals(tc.b); res != tc.strict {
+			t.Errorf("%v === %v: %v", tc.a, tc.b, res)
+		}
+		if res := tc.a.SameAs(tc.b); res != tc.same {
+			t.Errorf("SameValue(%v, %v): %v", tc.a, tc.b, res)
+		}
+		if res := tc.a.SameValueZero(tc.b); res != tc.sameZero {
+			t.Errorf("SameValueZero(%v, %v): %v", tc.a, tc.b, res)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package goja
+
+// asyncFrame is a call of an async function: the generatorObject that holds its frame (see initGenerator)
+// and the capability of the Promise it returns.
+type asyncFrame struct {
+	g    *generatorObject
+	pcap *promiseCapability
+}
+
+// runAsync starts an async function. start() calls the function body which suspends immediately returning
+// the generatorObject that holds its frame. The frame is then driven by asyncStep().
+func (r *Runtime) runAsync(start func() Value) Value {
+	f := &asyncFrame{
+		pcap: r.newPromiseCapability(r.global.Promise),
+	}
+	if ex := r.vm.try(func() {
+		f.g = start().(*Object).self.(*generatorObject)
+	}); ex != nil {
+		f.pcap.reject(ex.val)
+		return f.pcap.promise
+	}
+	r.asyncStep(f, resumeNext, _undefined)
+	return f.pcap.promise
+}
+
+// asyncStep resumes the frame of an async function until it reaches an await expression or completes.
+// In the former case the frame is resumed again by a promise job once the awaited value is settled (see
+// resumeAsync()), in the latter the Promise returned by the function is resolved or rejected.
+func (r *Runtime) asyncStep(f *asyncFrame, mode resumeMode, v Value) {
+	for {
+		var res Value
+		var done bool
+		if ex := r.vm.try(func() {
+			res, done = f.g.gen.resume(r.vm, mode, v)
+		}); ex != nil {
+			f.pcap.reject(ex.val)
+			return
+		}
+		if done {
+			f.pcap.resolve(res)
+			return
+		}
+		var p *Promise
+		if ex := r.vm.try(func() {
+			p = r.awaitPromise(res)
+		}); ex != nil {
+			mode, v = resumeThrow, ex.val
+			continue
+		}
+		p.awaiter = f
+		onFulfilled := &jobCallback{callback: func(call FunctionCall) Value {
+			r.resumeAsync(f, p, resumeNext, call.Argument(0))
+			return _undefined
+		}}
+		onRejected := &jobCallback{callback: func(call FunctionCall) Value {
+			r.resumeAsync(f, p, resumeThrow, call.Argument(0))
+			return _undefined
+		}}
+		r.performPromiseThenJobs(p, onFulfilled, onRejected, nil)
+		return
+	}
+}
+
+// resumeAsync continues an async function from the promise job that runs once the awaited Promise p is settled.
+// The job is replaced in the stack traces captured meanwhile by the async functions awaiting the function
+// (see vm.captureStack()).
+func (r *Runtime) resumeAsync(f *asyncFrame, p *Promise, mode resumeMode, v Value) {
+	if p.awaiter == f {
+		p.awaiter = nil
+	}
+	vm := r.vm
+	savedFrame, savedLen := vm.asyncResumed, vm.asyncResumedAt
+	vm.asyncResumed, vm.asyncResumedAt = f, len(vm.callStack)
+	defer func() {
+		vm.asyncResumed, vm.asyncResumedAt = savedFrame, savedLen
+	}()
+	r.asyncStep(f, mode, v)
+}
+
+// appendAwaiters appends the frames of the suspended async functions awaiting (directly or through each other)
+// the Promise returned by f.
+func (f *asyncFrame) appendAwaiters(stack []StackFrame) []StackFrame {
+	for a := f.pcap.promise.self.(*Promise).awaiter; a != nil; a = a.pcap.promise.self.(*Promise).awaiter {
+		ctx := &a.g.gen.ctx
+		funcName := ctx.funcName
+		if ctx.prg != nil {
+			funcName = ctx.prg.funcName
+		}
+		stack = append(stack, StackFrame{prg: ctx.prg, pc: ctx.pc, funcName: funcName, async: true})
+	}
+	return stack
+}
+
+// awaitPromise returns the Promise an await expression waits for, i.e. PromiseResolve(%Promise%, v).
+func (r *Runtime) awaitPromise(v Value) *Promise {
+	if o, ok := v.(*Object); ok {
+		if p, ok := o.self.(*Promise); ok && nilSafe(o.self.getStr("constructor", nil)).SameAs(r.global.Promise) {
+			return p
+		}
+	}
+	pcap := r.newPromiseCapability(r.global.Promise)
+	pcap.resolve(v)
+	return pcap.promise.self.(*Promise)
+}
+
+func (r *Runtime) builtin_AsyncFunction(args []Value, proto *Object) *Object {
+	return r.createDynamicFunction("async function", args, proto)
+}
+
+func (r *Runtime) createAsyncFunctionProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.FunctionPrototype, classObject)
+	o._putProp("constructor", r.global.AsyncFunction, false, false, true)
+
+	o._putSym(SymToStringTag, valueProp(asciiString(classAsyncFunction), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createAsyncFunction(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.constructToCall(r.builtin_AsyncFunction, r.global.AsyncFunctionPrototype),
+		r.builtin_AsyncFunction, "AsyncFunction", r.global.AsyncFunctionPrototype, intToValue(1))
+	o.prototype = r.global.Function
+
+	return o
+}
+
+func (r *Runtime) initAsyncFunctions() {
+	r.global.AsyncFunctionPrototype = r.newLazyObject(r.createAsyncFunctionProto)
+	r.global.AsyncFunction = r.newLazyObject(r.createAsyncFunction)
+}
@@ -0,0 +1,330 @@
+package goja
+
+import (
+	"strings"
+	"testing"
+)
+
+// testAsyncScript runs a script which evaluates to a Promise and fails if the Promise is not fulfilled once
+// the jobs have been run.
+func testAsyncScript(script string, t *testing.T) {
+	vm := New()
+	vm.RunProgram(testLib())
+	v, err := vm.RunString(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := v.Export().(*Promise)
+	if !ok {
+		t.Fatalf("Not a Promise: %v", v)
+	}
+	switch p.State() {
+	case PromiseStatePending:
+		t.Fatal("the Promise is pending")
+	case PromiseStateRejected:
+		t.Fatalf("the Promise is rejected: %v", p.Result())
+	}
+}
+
+func TestAsyncFunctionBasic(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	async function f(x) {
+		log.push("start");
+		var a = await x;
+		log.push("got " + a);
+		return a * 2;
+	}
+	var p = f(21);
+	log.push("after call");
+	Promise.resolve().then(function() { log.push("job"); });
+
+	(async function() {
+		assert(p instanceof Promise, "returns a Promise");
+		assert.sameValue(await p, 42, "result");
+		assert(compareArray(log, ["start", "after call", "got 21", "job"]), "order: " + log);
+		assert.sameValue(await {then: function(resolve) { resolve(99); }}, 99, "thenable");
+		assert.sameValue(await 1 + await Promise.resolve(2), 3, "await in an expression");
+
+		var sum = 0;
+		for (var x of [1, 2, 3]) {
+			sum += await x;
+		}
+		assert.sameValue(sum, 6, "await in a loop");
+
+		var nested = async function() { return await (async function() { return 5; })() + 1; };
+		assert.sameValue(await nested(), 6, "nested");
+	})();
+	`
+	testAsyncScript(SCRIPT, t)
+}
+
+func TestAsyncFunctionThrow(t *testing.T) {
+	const SCRIPT = `
+	(async function() {
+		var caught;
+		try {
+			await Promise.reject(new Error("boom"));
+		} catch (e) {
+			caught = e.message;
+		} finally {
+			caught += "!";
+		}
+		assert.sameValue(caught, "boom!", "try/catch across await");
+
+		var g = async () => { throw new TypeError("x"); };
+		var p = g();
+		assert(p instanceof Promise, "throw does not propagate synchronously");
+		try {
+			await p;
+			throw new Test262Error("not rejected");
+		} catch (e) {
+			assert(e instanceof TypeError, "rejected with the exception");
+		}
+
+		var paramErr;
+		try {
+			await (async function(a = (function() { throw 5; })()) {})();
+		} catch (e) {
+			paramErr = e;
+		}
+		assert.sameValue(paramErr, 5, "parameter initialiser error rejects");
+	})();
+	`
+	testAsyncScript(SCRIPT, t)
+}
+
+func TestAsyncFunctionStack(t *testing.T) {
+	vm := New()
+	var stacks [][]StackFrame
+	vm.Set("capture", func() {
+		stacks = append(stacks, vm.CaptureCallStack(0, nil))
+	})
+	_, err := vm.RunString(`
+	var errStack;
+	async function inner() {
+		capture();
+		await null;
+		capture();
+		throw new Error("boom");
+	}
+	async function middle() {
+		await inner();
+	}
+	async function outer() {
+		await middle();
+	}
+	outer().catch(function(e) {
+		errStack = e.stack;
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(frames []StackFrame) string {
+		var b strings.Builder
+		for _, f := range frames[1:] {
+			if f.Async() {
+				b.WriteString("async ")
+			}
+			b.WriteString(f.FuncName())
+			b.WriteByte(' ')
+		}
+		return b.String()
+	}
+	if len(stacks) != 2 {
+		t.Fatalf("Unexpected number of stacks: %d", len(stacks))
+	}
+	// before the first await the functions are on the call stack
+	if s := names(stacks[0]); s != "inner middle outer <anonymous> " {
+		t.Fatalf("Unexpected stack before await: %q", s)
+	}
+	// after it, the promise job is replaced by the awaiting functions
+	if s := names(stacks[1]); s != "inner async middle async outer " {
+		t.Fatalf("Unexpected stack after await: %q", s)
+	}
+	errStack := vm.Get("errStack").String()
+	if !strings.Contains(errStack, "at inner (") || !strings.Contains(errStack, "at async middle (") ||
+		!strings.Contains(errStack, "at async outer (") {
+		t.Fatalf("Unexpected error stack: %s", errStack)
+	}
+}
+
+func TestAsyncFunctionThisAndSuper(t *testing.T) {
+	const SCRIPT = `
+	class A {
+		m() { return "A"; }
+	}
+	class B extends A {
+		constructor() { super(); this.v = 7; }
+		async m() { await 0; return super.m() + "B" + this.v; }
+	}
+	var obj = {
+		async am(a) { return arguments.length + a; }
+	};
+	async function sloppyThis() { return this; }
+
+	(async function() {
+		assert.sameValue(await new B().m(), "AB7", "class method with super");
+		assert.sameValue(await obj.am(5, 6), 7, "object literal method with arguments");
+		assert.sameValue(await sloppyThis(), this, "sloppy this");
+		var o = {};
+		var arrow = function() { return async () => { await null; return this; }; }.call(o);
+		assert.sameValue(await arrow(), o, "arrow this");
+	})();
+	`
+	testAsyncScript(SCRIPT, t)
+}
+
+func TestAsyncFunctionObjects(t *testing.T) {
+	const SCRIPT = `
+	async function f() {}
+	var AsyncFunction = Object.getPrototypeOf(f).constructor;
+	assert.sameValue(AsyncFunction.name, "AsyncFunction", "name");
+	assert.sameValue(Object.getPrototypeOf(AsyncFunction), Function, "AsyncFunction proto");
+	assert.sameValue(Object.getPrototypeOf(async () => {}), AsyncFunction.prototype, "arrow proto");
+	assert.sameValue(AsyncFunction.prototype[Symbol.toStringTag], "AsyncFunction", "toStringTag");
+	assert.sameValue(typeof f, "function", "typeof");
+	assert.sameValue(f.hasOwnProperty("prototype"), false, "no prototype");
+	assert.sameValue(f.toString(), "async function f() {}", "toString");
+	assert.throws(TypeError, function() { new f(); }, "not a constructor");
+	assert(new AsyncFunction("a", "return await a")(1) instanceof Promise, "AsyncFunction()");
+
+	var async = function(x) { return x + 1; };
+	assert.sameValue(async(1), 2, "async as an identifier");
+	var g = async => async * 2;
+	assert.sameValue(g(2), 4, "async as a parameter name");
+	var await = 3;
+	assert.sameValue(await, 3, "await as an identifier");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestAsyncForAwaitOf(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function asyncIterable(values, opts) {
+		opts = opts || {};
+		return {
+			[Symbol.asyncIterator]() {
+				var i = 0;
+				return {
+					next() {
+						log.push("next");
+						if (opts.reject && i === opts.reject) {
+							return Promise.reject(new Error("rejected"));
+						}
+						if (i < values.length) {
+							return Promise.resolve({value: values[i++], done: false});
+						}
+						return Promise.resolve({value: undefined, done: true});
+					},
+					return() {
+						log.push("return");
+						return Promise.resolve({done: true});
+					}
+				};
+			}
+		};
+	}
+
+	(async function() {
+		assert.sameValue(typeof Symbol.asyncIterator, "symbol", "Symbol.asyncIterator");
+
+		var res = [];
+		for await (const x of asyncIterable([1, 2, 3])) {
+			res.push(x);
+		}
+		assert(compareArray(res, [1, 2, 3]), "values: " + res);
+		assert(compareArray(log, ["next", "next", "next", "next"]), "log: " + log);
+
+		log = [];
+		res = [];
+		for await (var y of asyncIterable([1, 2, 3])) {
+			if (y === 2) break;
+			res.push(y);
+		}
+		assert(compareArray(res, [1]), "break");
+		assert(compareArray(log, ["next", "next", "return"]), "break log: " + log);
+
+		log = [];
+		try {
+			for await (let z of asyncIterable([1, 2], {reject: 1})) {
+			}
+			throw new Test262Error("not rejected");
+		} catch (e) {
+			assert.sameValue(e.message, "rejected", "rejection");
+		}
+		assert(compareArray(log, ["next", "next"]), "no return on rejection: " + log);
+
+		log = [];
+		try {
+			for await (let z of asyncIterable([1, 2])) {
+				throw new Error("body");
+			}
+		} catch (e) {
+			assert.sameValue(e.message, "body", "body throw");
+		}
+		assert(compareArray(log, ["next", "return"]), "return on throw: " + log);
+
+		res = [];
+		for await (const v of [1, Promise.resolve(2), 3]) {
+			res.push(v);
+		}
+		assert(compareArray(res, [1, 2, 3]), "sync iterable: " + res);
+
+		async function ret() {
+			log = [];
+			for await (const x of asyncIterable([1, 2])) {
+				return x * 10;
+			}
+		}
+		assert.sameValue(await ret(), 10, "return from loop");
+		assert(compareArray(log, ["next", "return"]), "return log: " + log);
+
+		log = [];
+		res = [];
+		outer: for await (const a of asyncIterable(["a", "b"])) {
+			for await (const b of asyncIterable([1, 2])) {
+				if (b === 2) continue outer;
+				res.push(a + b);
+			}
+		}
+		assert(compareArray(res, ["a1", "b1"]), "labeled continue: " + res);
+
+		var obj = {};
+		for await (obj.p of [Promise.resolve("p")]) {}
+		assert.sameValue(obj.p, "p", "expression target");
+		for await (const [k, v] of new Map([["k", "v"]])) {
+			assert.sameValue(k + v, "kv", "destructuring");
+		}
+
+		try {
+			for await (const x of {[Symbol.asyncIterator]() { return {next() { return 1; }}; }}) {}
+			throw new Test262Error("not thrown");
+		} catch (e) {
+			assert(e instanceof TypeError, "non-object result: " + e);
+		}
+	})();
+	`
+	testAsyncScript(SCRIPT, t)
+}
+
+func TestAsyncFunctionSyntaxErrors(t *testing.T) {
+	for _, src := range []string{
+		"function f() { await 1; }",
+		"async function f(a = await 1) {}",
+		"async function f() { var await; }",
+		"async function* g() {}",
+		"class C { async constructor() {} }",
+		"async function f() { for await (var x in {}) {} }",
+		"async function f() { for await (;;) {} }",
+		"function f() { for await (var x of []) {} }",
+		"function* g() { for await (var x of []) {} }",
+	} {
+		_, err := Compile("", src, false)
+		if err == nil {
+			t.Errorf("Expected a SyntaxError for %q", src)
+		}
+	}
+}
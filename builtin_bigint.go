@@ -0,0 +1,127 @@
+package goja
+
+import (
+	"math"
+	"math/big"
+)
+
+func (r *Runtime) builtin_BigInt(call FunctionCall) Value {
+	prim := toPrimitiveNumber(call.Argument(0))
+	switch v := prim.(type) {
+	case valueInt:
+		return newBigInt(big.NewInt(int64(v)))
+	case valueFloat:
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) || math.Trunc(f) != f {
+			panic(r.newError(r.global.RangeError, "The number %s cannot be converted to a BigInt because it is not an integer", v.String()))
+		}
+		b, _ := new(big.Float).SetFloat64(f).Int(nil)
+		return newBigInt(b)
+	}
+	return r.toBigInt(prim)
+}
+
+// toBigInt implements ToBigInt.
+func (r *Runtime) toBigInt(v Value) *valueBigInt {
+	switch v := toPrimitiveNumber(v).(type) {
+	case *valueBigInt:
+		return v
+	case valueBool:
+		if v {
+			return newBigInt(big.NewInt(1))
+		}
+		return newBigInt(new(big.Int))
+	case valueString:
+		if b, ok := stringToBigInt(v); ok {
+			return newBigInt(b)
+		}
+		panic(r.newError(r.global.SyntaxError, "Cannot convert %s to a BigInt", v.String()))
+	case *Symbol:
+		panic(r.NewTypeError("Cannot convert %s to a BigInt", v.descriptiveString()))
+	default:
+		panic(r.NewTypeError("Cannot convert %s to a BigInt", v.String()))
+	}
+}
+
+func (r *Runtime) thisBigIntValue(v Value, method string) *valueBigInt {
+	switch t := v.(type) {
+	case *valueBigInt:
+		return t
+	case *Object:
+		if pVal, ok := t.self.(*primitiveValueObject); ok {
+			if b, ok := pVal.pValue.(*valueBigInt); ok {
+				return b
+			}
+		}
+	}
+	panic(r.NewTypeError("BigInt.prototype.%s requires that 'this' be a BigInt", method))
+}
+
+func (r *Runtime) bigintproto_toString(call FunctionCall) Value {
+	b := r.thisBigIntValue(call.This, "toString")
+	radix := 10
+	if arg := call.Argument(0); arg != _undefined {
+		rdx := arg.ToInteger()
+		if rdx < 2 || rdx > 36 {
+			panic(r.newError(r.global.RangeError, "toString() radix argument must be between 2 and 36"))
+		}
+		radix = int(rdx)
+	}
+	return asciiString(b.big().Text(radix))
+}
+
+func (r *Runtime) bigintproto_toLocaleString(call FunctionCall) Value {
+	return r.thisBigIntValue(call.This, "toLocaleString").toString()
+}
+
+func (r *Runtime) bigintproto_valueOf(call FunctionCall) Value {
+	return r.thisBigIntValue(call.This, "valueOf")
+}
+
+func (r *Runtime) bigint_asIntN(call FunctionCall) Value {
+	bits := r.toIndex(call.Argument(0))
+	b := r.toBigInt(call.Argument(1))
+	return newBigInt(bigIntAsIntN(int64(bits), b.big()))
+}
+
+func (r *Runtime) bigint_asUintN(call FunctionCall) Value {
+	bits := r.toIndex(call.Argument(0))
+	b := r.toBigInt(call.Argument(1))
+	return newBigInt(bigIntAsUintN(int64(bits), b.big()))
+}
+
+func (r *Runtime) createBigIntProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.BigInt, true, false, true)
+	o._putProp("toLocaleString", r.newNativeFunc(r.bigintproto_toLocaleString, nil, "toLocaleString", nil, 0), true, false, true)
+	o._putProp("toString", r.newNativeFunc(r.bigintproto_toString, nil, "toString", nil, 0), true, false, true)
+	o._putProp("valueOf", r.newNativeFunc(r.bigintproto_valueOf, nil, "valueOf", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classBigInt), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createBigInt(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.builtin_BigInt, func(args []Value, proto *Object) *Object {
+		panic(r.NewTypeError("BigInt is not a constructor"))
+	}, "BigInt", r.global.BigIntPrototype, intToValue(1))
+
+	o._putProp("asIntN", r.newNativeFunc(r.bigint_asIntN, nil, "asIntN", nil, 2), true, false, true)
+	o._putProp("asUintN", r.newNativeFunc(r.bigint_asUintN, nil, "asUintN", nil, 2), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) initBigInt() {
+	r.global.BigIntPrototype = r.newLazyObject(r.createBigIntProto)
+
+	r.global.BigInt = r.newLazyObject(r.createBigInt)
+	r.addToGlobal("BigInt", r.global.BigInt)
+}
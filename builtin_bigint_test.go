@@ -0,0 +1,191 @@
+package goja
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigIntArithmetic(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(typeof 1n, "bigint", "typeof");
+	assert.sameValue(typeof Object(1n), "object", "typeof wrapper");
+	assert.sameValue(9007199254740993n + 1n, 9007199254740994n, "add");
+	assert.sameValue(1n - 3n, -2n, "sub");
+	assert.sameValue(2n ** 64n, 18446744073709551616n, "exp");
+	assert.sameValue(123456789012345678901234567890n * 10n, 1234567890123456789012345678900n, "mul");
+	assert.sameValue(7n / 2n, 3n, "div truncates");
+	assert.sameValue(-7n / 2n, -3n, "div truncates towards zero");
+	assert.sameValue(-7n % 2n, -1n, "mod has the sign of the dividend");
+	assert.sameValue(-(3n), -3n, "neg");
+	assert.sameValue(0x1fn + 0o7n + 0b1n, 39n, "prefixed literals");
+
+	var x = 5n;
+	x++;
+	assert.sameValue(x, 6n, "inc");
+	x -= 2n;
+	assert.sameValue(x--, 4n, "postfix dec");
+	assert.sameValue(x, 3n, "dec");
+
+	assert.sameValue(5n & 3n, 1n, "and");
+	assert.sameValue(5n | 3n, 7n, "or");
+	assert.sameValue(5n ^ 3n, 6n, "xor");
+	assert.sameValue(~5n, -6n, "not");
+	assert.sameValue(-1n & 0xffn, 255n, "two's complement and");
+	assert.sameValue(1n << 70n, 1180591620717411303424n, "shl");
+	assert.sameValue(8n << -2n, 2n, "shl by a negative amount");
+	assert.sameValue(-5n >> 1n, -3n, "sar rounds towards -Infinity");
+	assert.sameValue(-5n >> 100n, -1n, "sar by a large amount");
+	assert.sameValue(1n >> -3n, 8n, "sar by a negative amount");
+
+	assert.throws(TypeError, function() { 1n + 1; }, "mixed add");
+	assert.throws(TypeError, function() { 1 * 1n; }, "mixed mul");
+	assert.throws(TypeError, function() { 1n & 1; }, "mixed and");
+	assert.throws(TypeError, function() { 1n << 1; }, "mixed shl");
+	assert.throws(TypeError, function() { 1n >>> 0n; }, "unsigned shr");
+	assert.throws(TypeError, function() { +1n; }, "unary plus");
+	assert.throws(TypeError, function() { Math.abs(1n); }, "Math");
+	assert.throws(RangeError, function() { 1n / 0n; }, "div by zero");
+	assert.throws(RangeError, function() { 1n % 0n; }, "mod by zero");
+	assert.throws(RangeError, function() { 2n ** -1n; }, "negative exponent");
+	assert.throws(RangeError, function() { 2n ** 10000000000n; }, "too large");
+
+	assert.sameValue(1n + "", "1", "string concatenation");
+	assert.sameValue(` + "`${-12n}`" + `, "-12", "template");
+	assert.sameValue(Object(2n) * 3n, 6n, "wrapper object");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntComparison(t *testing.T) {
+	const SCRIPT = `
+	assert(1n == 1, "1n == 1");
+	assert(1n !== 1, "1n !== 1");
+	assert(1n == "1", "1n == '1'");
+	assert(255n == "0xff", "hex string");
+	assert(1n != "1.0", "non-integer string");
+	assert(1n == true, "bool");
+	assert(0n != NaN, "NaN");
+	assert(2n ** 64n == 18446744073709551616, "large number");
+	assert(Object(1n) == 1n, "wrapper object");
+	assert.sameValue(Object.is(0n, -0n), true, "no negative zero");
+
+	assert(2n > 1, "bigint > number");
+	assert(1n < 1.5, "bigint < fraction");
+	assert(10n > "9", "bigint > string");
+	assert(!(1n < "x") && !(1n >= "x"), "invalid string");
+	assert(!(1n < NaN) && !(1n >= NaN), "NaN");
+	assert(1n < Infinity && -Infinity < -(2n ** 1100n), "Infinity");
+	assert.sameValue([3n, 1, 2n].sort(function(a, b) { return a < b ? -1 : 1; }).join(), "1,2,3", "sort");
+
+	var m = new Map([[1n, "a"]]);
+	assert.sameValue(m.get(1n), "a", "Map key");
+	assert.sameValue(m.get(1), undefined, "Map key is not a Number");
+	assert(new Set([1n, 1n, 2n]).size === 2, "Set");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntBuiltin(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(BigInt(10), 10n, "number");
+	assert.sameValue(BigInt(1e21), 1000000000000000000000n, "large number");
+	assert.sameValue(BigInt(" 0xff "), 255n, "hex string");
+	assert.sameValue(BigInt(""), 0n, "empty string");
+	assert.sameValue(BigInt("-12"), -12n, "negative string");
+	assert.sameValue(BigInt(true), 1n, "bool");
+	assert.sameValue(BigInt({valueOf: function() { return 5n; }}), 5n, "object");
+	assert.throws(RangeError, function() { BigInt(1.5); }, "fraction");
+	assert.throws(RangeError, function() { BigInt(NaN); }, "NaN");
+	assert.throws(SyntaxError, function() { BigInt("1n"); }, "suffix in string");
+	assert.throws(SyntaxError, function() { BigInt("-0x1"); }, "signed hex string");
+	assert.throws(TypeError, function() { BigInt(undefined); }, "undefined");
+	assert.throws(TypeError, function() { BigInt(Symbol()); }, "symbol");
+	assert.throws(TypeError, function() { new BigInt(1); }, "new");
+
+	assert.sameValue(BigInt.asIntN(8, 255n), -1n, "asIntN");
+	assert.sameValue(BigInt.asIntN(8, 127n), 127n, "asIntN positive");
+	assert.sameValue(BigInt.asIntN(0, 5n), 0n, "asIntN 0 bits");
+	assert.sameValue(BigInt.asUintN(8, -1n), 255n, "asUintN");
+	assert.sameValue(BigInt.asUintN(64, 2n ** 64n + 5n), 5n, "asUintN 64");
+
+	assert.sameValue((255n).toString(16), "ff", "toString radix");
+	assert.sameValue((-255n).toString(2), "-11111111", "toString negative");
+	assert.sameValue(Object(3n).valueOf(), 3n, "valueOf");
+	assert.sameValue(Object.prototype.toString.call(1n), "[object BigInt]", "toStringTag");
+	assert.throws(RangeError, function() { 1n.toString(1); }, "bad radix");
+	assert.throws(TypeError, function() { BigInt.prototype.valueOf.call(1); }, "valueOf on a number");
+
+	assert.sameValue(Number(2n ** 53n + 1n), 9007199254740992, "Number() rounds");
+	assert.sameValue(Number(-3n), -3, "Number()");
+	assert.sameValue(String(12n), "12", "String()");
+	assert.sameValue(!!0n, false, "ToBoolean 0n");
+	assert.sameValue(!!1n, true, "ToBoolean 1n");
+	assert.throws(TypeError, function() { JSON.stringify({a: 1n}); }, "JSON");
+	var o = {};
+	o[1n] = "x";
+	assert.sameValue(o["1"], "x", "property key");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntProperties(t *testing.T) {
+	const SCRIPT = `
+	function verifyProperty(o, name, exp) {
+		var d = Object.getOwnPropertyDescriptor(o, name);
+		for (var k in exp) assert.sameValue(d[k], exp[k], String(name) + " " + k);
+	}
+	verifyProperty(BigInt, "length", {value: 1, writable: false, enumerable: false, configurable: true});
+	verifyProperty(BigInt, "name", {value: "BigInt", writable: false, enumerable: false, configurable: true});
+	verifyProperty(BigInt, "prototype", {writable: false, enumerable: false, configurable: false});
+	verifyProperty(BigInt.asIntN, "length", {value: 2});
+	verifyProperty(BigInt.asUintN, "length", {value: 2});
+	verifyProperty(BigInt.prototype.toString, "length", {value: 0});
+	verifyProperty(BigInt.prototype.toLocaleString, "length", {value: 0});
+	verifyProperty(BigInt.prototype, Symbol.toStringTag, {value: "BigInt", writable: false, enumerable: false, configurable: true});
+	verifyProperty(this, "BigInt", {writable: true, enumerable: false, configurable: true});
+	assert.sameValue(Object.getPrototypeOf(BigInt), Function.prototype);
+	assert.sameValue(Object.getPrototypeOf(1n), BigInt.prototype);
+	assert.sameValue(BigInt.asIntN(2**53-1, 5n), 5n);
+	assert.throws(RangeError, function() { BigInt.asIntN(2**53, 5n) });
+	assert.sameValue(BigInt.asIntN("3", "25"), 1n);
+	assert.throws(TypeError, function() { BigInt.asIntN(3, 25) });
+	assert.throws(TypeError, function() { new Uint8Array([1n]) });
+	assert.sameValue(1n.constructor, BigInt);
+	var o = {a: 2n};
+	o.a **= 3n;
+	o["a"] >>= 1n;
+	assert.sameValue(o.a, 4n);
+	assert.sameValue(0n ** 0n, 1n);
+	assert.sameValue((-2n) ** 3n, -8n);
+	assert.sameValue(BigInt.prototype.toString.call(Object(10n), 36), "a");
+	assert.throws(TypeError, function() { Reflect.construct(BigInt, [1]) });
+	assert.sameValue(BigInt("0b101"), 5n);
+	assert.sameValue(BigInt("0o17"), 15n);
+	assert.sameValue(BigInt("\n\t 7  "), 7n);
+	assert.throws(SyntaxError, function() { BigInt("1_0") });
+	assert.throws(SyntaxError, function() { BigInt("0x") });
+	assert.throws(SyntaxError, function() { BigInt("+0x1") });
+	assert.sameValue(BigInt("+12"), 12n);
+	assert.sameValue(BigInt(-0), 0n);
+	assert.sameValue(typeof Object(1n), "object");
+	assert.sameValue(Object(1n) instanceof BigInt, true);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestBigIntExport(t *testing.T) {
+	vm := New()
+	b, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	vm.Set("b", b)
+	v, err := vm.RunString(`b * 2n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, _ := new(big.Int).SetString("246913578024691357802469135780", 10)
+	if res, ok := v.Export().(*big.Int); !ok || res.Cmp(exp) != 0 {
+		t.Fatalf("Unexpected result: %v", v.Export())
+	}
+	if b.String() != "123456789012345678901234567890" {
+		t.Fatal("the original value was modified")
+	}
+}
@@ -30,10 +30,18 @@ func (e *errorObject) formatStack() valueString {
 func (e *errorObject) addStackProp() Value {
 	if !e.stackPropAdded {
 		res := e._putProp(propNameStack, e.formatStack(), true, false, true)
-		if len(e.propNames) > 1 {
-			// reorder property names to ensure 'stack' is the first one
-			copy(e.propNames[1:], e.propNames)
-			e.propNames[0] = propNameStack
+		e.ensurePropOrder()
+		if k := e.idxPropCount; k < len(e.propNames)-1 {
+			// reorder property names to ensure 'stack' is the first one after the integer keys
+			names := e.propNames
+			if namesMarkedForCopy(names) {
+				newNames := make([]unistring.String, len(names), cap(names))
+				copy(newNames, names)
+				names = newNames
+				e.propNames = names
+			}
+			copy(names[k+1:], names[k:len(names)-1])
+			names[k] = propNameStack
 		}
 		e.stackPropAdded = true
 		return res
@@ -88,8 +96,8 @@ func (e *errorObject) hasOwnPropertyStr(name unistring.String) bool {
 }
 
 func (e *errorObject) stringKeys(all bool, accum []Value) []Value {
-	if all && !e.stackPropAdded {
-		accum = append(accum, asciiString(propNameStack))
+	if all {
+		e.addStackProp()
 	}
 	return e.baseObject.stringKeys(all, accum)
 }
@@ -120,11 +128,22 @@ func (r *Runtime) newErrorObject(proto *Object, class string) *errorObject {
 	return o
 }
 
+// installErrorCause implements InstallErrorCause(): if options is an object which has a 'cause' property,
+// its value is set as a non-enumerable own property of the error.
+func (r *Runtime) installErrorCause(obj *errorObject, options Value) {
+	if opts, ok := options.(*Object); ok && opts.self.hasPropertyStr("cause") {
+		obj._putProp("cause", opts.self.getStr("cause", nil), true, false, true)
+	}
+}
+
 func (r *Runtime) builtin_Error(args []Value, proto *Object) *Object {
 	obj := r.newErrorObject(proto, classError)
 	if len(args) > 0 && args[0] != _undefined {
 		obj._putProp("message", args[0], true, false, true)
 	}
+	if len(args) > 1 {
+		r.installErrorCause(obj, args[1])
+	}
 	return obj.val
 }
 
@@ -133,6 +152,9 @@ func (r *Runtime) builtin_AggregateError(args []Value, proto *Object) *Object {
 	if len(args) > 1 && args[1] != nil && args[1] != _undefined {
 		obj._putProp("message", args[1].toString(), true, false, true)
 	}
+	if len(args) > 2 {
+		r.installErrorCause(obj, args[2])
+	}
 	var errors []Value
 	if len(args) > 0 {
 		errors = r.iterableToList(args[0], nil)
@@ -6,8 +6,14 @@ import (
 )
 
 func (r *Runtime) builtin_Function(args []Value, proto *Object) *Object {
+	return r.createDynamicFunction("function", args, proto)
+}
+
+// createDynamicFunction creates a function from the arguments of a Function-like constructor, kind is
+// the keyword the source starts with.
+func (r *Runtime) createDynamicFunction(kind string, args []Value, proto *Object) *Object {
 	var sb valueStringBuilder
-	sb.WriteString(asciiString("(function anonymous("))
+	sb.WriteString(asciiString("(" + kind + " anonymous("))
 	if len(args) > 1 {
 		ar := args[:len(args)-1]
 		for i, arg := range ar {
@@ -38,8 +44,14 @@ repeat:
 		return newStringValue(f.src)
 	case *methodFuncObject:
 		return newStringValue(f.src)
+	case *generatorFuncObject:
+		return newStringValue(f.src)
+	case *asyncFuncObject:
+		return newStringValue(f.src)
 	case *arrowFuncObject:
 		return newStringValue(f.src)
+	case *asyncArrowFuncObject:
+		return newStringValue(f.src)
 	case *nativeFuncObject:
 		return newStringValue(fmt.Sprintf("function %s() { [native code] }", nilSafe(f.getStr("name", nil)).toString()))
 	case *boundFuncObject:
@@ -50,7 +62,7 @@ repeat:
 	case *proxyObject:
 	repeat2:
 		switch c := f.target.self.(type) {
-		case *classFuncObject, *methodFuncObject, *funcObject, *arrowFuncObject, *nativeFuncObject, *boundFuncObject:
+		case *classFuncObject, *methodFuncObject, *generatorFuncObject, *asyncFuncObject, *funcObject, *arrowFuncObject, *asyncArrowFuncObject, *nativeFuncObject, *boundFuncObject:
 			return asciiString("function () { [native code] }")
 		case *lazyObject:
 			f.target.self = c.create(obj)
@@ -193,6 +205,11 @@ lenNotInt:
 	bf := &boundFuncObject{
 		nativeFuncObject: *ff,
 		wrapped:          obj,
+		boundThis:        call.Argument(0),
+	}
+	if len(call.Arguments) > 1 {
+		bf.boundArgs = make([]Value, len(call.Arguments)-1)
+		copy(bf.boundArgs, call.Arguments[1:])
 	}
 	bf.prototype = obj.self.proto()
 	v.self = bf
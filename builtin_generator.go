@@ -0,0 +1,117 @@
+package goja
+
+type generatorObject struct {
+	baseObject
+	gen generator
+}
+
+func (r *Runtime) newGeneratorObject(proto *Object) *generatorObject {
+	v := &Object{runtime: r}
+	o := &generatorObject{}
+	o.class = classGenerator
+	o.val = v
+	o.extensible = true
+	v.self = o
+	o.prototype = proto
+	o.init()
+	return o
+}
+
+func (r *Runtime) thisGeneratorObject(v Value, method string) *generatorObject {
+	if o, ok := v.(*Object); ok {
+		if g, ok := o.self.(*generatorObject); ok {
+			if g.gen.state == genStateExecuting {
+				panic(r.NewTypeError("Generator is already running"))
+			}
+			return g
+		}
+	}
+	panic(r.NewTypeError("Method [Generator].prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (g *generatorObject) resume(mode resumeMode, v Value) Value {
+	r := g.val.runtime
+	res, done := g.gen.resume(r.vm, mode, v)
+	if g.gen.delegating {
+		// the result of the inner iterator is passed as is
+		return res
+	}
+	return r.createIterResultObject(res, done)
+}
+
+func (r *Runtime) generatorproto_next(call FunctionCall) Value {
+	g := r.thisGeneratorObject(call.This, "next")
+	if g.gen.state == genStateCompleted {
+		return r.createIterResultObject(_undefined, true)
+	}
+	return g.resume(resumeNext, call.Argument(0))
+}
+
+func (r *Runtime) generatorproto_return(call FunctionCall) Value {
+	g := r.thisGeneratorObject(call.This, "return")
+	arg := call.Argument(0)
+	switch g.gen.state {
+	case genStateSuspendedStart:
+		g.gen.state = genStateCompleted
+		g.gen.clearSaved()
+		fallthrough
+	case genStateCompleted:
+		return r.createIterResultObject(arg, true)
+	}
+	return g.resume(resumeReturn, arg)
+}
+
+func (r *Runtime) generatorproto_throw(call FunctionCall) Value {
+	g := r.thisGeneratorObject(call.This, "throw")
+	arg := call.Argument(0)
+	switch g.gen.state {
+	case genStateSuspendedStart:
+		g.gen.state = genStateCompleted
+		g.gen.clearSaved()
+		fallthrough
+	case genStateCompleted:
+		panic(arg)
+	}
+	return g.resume(resumeThrow, arg)
+}
+
+func (r *Runtime) builtin_GeneratorFunction(args []Value, proto *Object) *Object {
+	return r.createDynamicFunction("function*", args, proto)
+}
+
+func (r *Runtime) createGeneratorProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.IteratorPrototype, classObject)
+	o._putProp("constructor", r.global.GeneratorFunctionPrototype, false, false, true)
+
+	o._putProp("next", r.newNativeFunc(r.generatorproto_next, nil, "next", nil, 1), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.generatorproto_return, nil, "return", nil, 1), true, false, true)
+	o._putProp("throw", r.newNativeFunc(r.generatorproto_throw, nil, "throw", nil, 1), true, false, true)
+
+	o._putSym(SymToStringTag, valueProp(asciiString(classGenerator), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createGeneratorFunctionProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.FunctionPrototype, classObject)
+	o._putProp("constructor", r.global.GeneratorFunction, false, false, true)
+	o._putProp("prototype", r.global.GeneratorPrototype, false, false, true)
+
+	o._putSym(SymToStringTag, valueProp(asciiString(classGeneratorFunction), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createGeneratorFunction(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.constructToCall(r.builtin_GeneratorFunction, r.global.GeneratorFunctionPrototype),
+		r.builtin_GeneratorFunction, "GeneratorFunction", r.global.GeneratorFunctionPrototype, intToValue(1))
+	o.prototype = r.global.Function
+
+	return o
+}
+
+func (r *Runtime) initGenerators() {
+	r.global.GeneratorPrototype = r.newLazyObject(r.createGeneratorProto)
+	r.global.GeneratorFunctionPrototype = r.newLazyObject(r.createGeneratorFunctionProto)
+	r.global.GeneratorFunction = r.newLazyObject(r.createGeneratorFunction)
+}
@@ -0,0 +1,333 @@
+package goja
+
+import "testing"
+
+func TestGeneratorBasic(t *testing.T) {
+	const SCRIPT = `
+	function* g() { yield 1; yield 2; }
+	var it = g();
+	var r1 = it.next(), r2 = it.next(), r3 = it.next(), r4 = it.next();
+	assert.sameValue(r1.value, 1, "r1.value");
+	assert.sameValue(r1.done, false, "r1.done");
+	assert.sameValue(r2.value, 2, "r2.value");
+	assert.sameValue(r3.value, undefined, "r3.value");
+	assert.sameValue(r3.done, true, "r3.done");
+	assert.sameValue(r4.done, true, "r4.done");
+	assert(compareArray([...g()], [1, 2]), "spread");
+
+	var res = [];
+	for (var v of g()) {
+		res.push(v);
+	}
+	assert(compareArray(res, [1, 2]), "for-of");
+
+	function* echo() {
+		var x = yield "a";
+		var y = yield x + 1;
+		return x + y;
+	}
+	var e = echo();
+	assert.sameValue(e.next(100).value, "a", "first next() argument is ignored");
+	assert.sameValue(e.next(10).value, 11, "sent value");
+	var last = e.next(5);
+	assert.sameValue(last.value, 15, "return value");
+	assert.sameValue(last.done, true, "done after return");
+
+	var x = 0;
+	function* noArg() { x = yield; }
+	var na = noArg();
+	assert.sameValue(na.next().value, undefined, "yield without an argument");
+	na.next(3);
+	assert.sameValue(x, 3, "yield without an argument result");
+
+	function* letLoop() { for (let i = 0; i < 3; i++) { yield () => i; } }
+	var fs = [...letLoop()];
+	assert.sameValue(fs[0]() + fs[1]() + fs[2](), 3, "per-iteration bindings");
+
+	function* withArgs(a, b = 2, {c} = {c: 3}) { yield arguments.length; yield a + b + c; }
+	assert(compareArray([...withArgs(1)], [1, 6]), "parameters");
+
+	function* withEval() { var q = 1; yield eval("q + 1"); q = 5; yield eval("q"); }
+	assert(compareArray([...withEval()], [2, 5]), "eval");
+
+	var o = {x: 0};
+	function* withStmt() { with (o) { x = yield 1; } }
+	var wi = withStmt();
+	wi.next();
+	wi.next(5);
+	assert.sameValue(o.x, 5, "reference across yield");
+
+	function* counter() { var i = 0; while (true) yield i++; }
+	function* tens() { var c = counter(); for (var k = 0; k < 3; k++) yield c.next().value * 10; }
+	assert(compareArray([...tens()], [0, 10, 20]), "nested generators");
+
+	var gen = function*() { yield this; };
+	var thisObj = {};
+	assert.sameValue(gen.call(thisObj).next().value, thisObj, "this");
+
+	function* reenter() { it2.next(); yield 1; }
+	var it2 = reenter();
+	assert.throws(TypeError, function() { it2.next(); }, "already running");
+	assert.sameValue(it2.next().done, true, "completed after an exception");
+
+	function* thrower() { yield 1; throw new Error("boom"); }
+	var ti = thrower();
+	ti.next();
+	assert.throws(Error, function() { ti.next(); }, "exception");
+	assert.sameValue(ti.next().done, true, "completed after an exception");
+
+	var yield_ = function() { var yield = 5; return yield; };
+	assert.sameValue(yield_(), 5, "yield as an identifier");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorReturn(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function* fin() { try { yield 1; yield 2; } finally { log.push("fin"); } }
+	for (var v of fin()) {
+		log.push(v);
+		break;
+	}
+	assert(compareArray(log, [1, "fin"]), "break: " + log);
+
+	log = [];
+	function* nested() {
+		for (var x of [1, 2, 3]) {
+			try {
+				yield x;
+			} finally {
+				log.push("f" + x);
+			}
+		}
+	}
+	var ni = nested();
+	ni.next();
+	ni.next();
+	var r = ni.return(9);
+	assert.sameValue(r.value, 9, "value");
+	assert.sameValue(r.done, true, "done");
+	assert(compareArray(log, ["f1", "f2"]), "finally: " + log);
+	assert.sameValue(ni.next().done, true, "completed");
+
+	var closed = false;
+	var iterable = {
+		[Symbol.iterator]() {
+			var i = 0;
+			return {
+				next() { return {value: i++, done: false}; },
+				return() { closed = true; return {}; }
+			};
+		}
+	};
+	function* forOf() { for (var x of iterable) yield x; }
+	var fo = forOf();
+	fo.next();
+	fo.next();
+	fo.return();
+	assert(closed, "the iterator of a for-of loop is closed");
+
+	function* overridden() { try { yield 1; } finally { return 5; } }
+	var ov = overridden();
+	ov.next();
+	assert.sameValue(ov.return(2).value, 5, "return in finally");
+
+	function* yieldInFinally() { try { yield 1; } finally { yield 2; } }
+	var yf = yieldInFinally();
+	yf.next();
+	var y2 = yf.return(3);
+	assert.sameValue(y2.value, 2, "yield in finally");
+	assert.sameValue(y2.done, false, "yield in finally done");
+	var y3 = yf.next();
+	assert.sameValue(y3.value, 3, "the return is resumed");
+	assert.sameValue(y3.done, true, "the return is resumed done");
+
+	function* notStarted() { try { yield 1; } finally { throw new Error("must not run"); } }
+	var ns = notStarted();
+	r = ns.return(4);
+	assert.sameValue(r.value, 4, "return before start");
+	assert.sameValue(ns.next().done, true, "completed after return before start");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorThrow(t *testing.T) {
+	const SCRIPT = `
+	function* c() { try { yield 1; } catch (e) { yield "caught " + e; } }
+	var ci = c();
+	ci.next();
+	assert.sameValue(ci.throw("x").value, "caught x", "caught");
+	assert.sameValue(ci.next().done, true, "done");
+
+	function* cr() { try { yield 1; } catch (e) { return "c" + e; } }
+	var cri = cr();
+	cri.next();
+	var r = cri.throw(1);
+	assert.sameValue(r.value, "c1", "return from catch");
+	assert.sameValue(r.done, true, "return from catch done");
+
+	function* s() { yield 1; }
+	var si = s();
+	assert.throws(RangeError, function() { si.throw(new RangeError()); }, "throw before start");
+	assert.sameValue(si.next().done, true, "completed after throw before start");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorDelegate(t *testing.T) {
+	const SCRIPT = `
+	function* inner() { var x = yield 1; yield x; return "r"; }
+	function* outer() { var r = yield* inner(); yield r; }
+	var o = outer();
+	assert.sameValue(o.next().value, 1, "1");
+	assert.sameValue(o.next(7).value, 7, "sent value");
+	assert.sameValue(o.next().value, "r", "return value of the inner generator");
+	assert.sameValue(o.next().done, true, "done");
+
+	function* arr() { yield* [1, 2]; }
+	assert(compareArray([...arr()], [1, 2]), "array");
+
+	function* deep(n) { if (n > 0) { yield n; yield* deep(n - 1); } }
+	assert(compareArray([...deep(3)], [3, 2, 1]), "recursive");
+
+	var log = [];
+	function* fin() { try { yield 1; yield 2; } finally { log.push("inner"); } }
+	function* retOuter() { try { yield* fin(); } finally { log.push("outer"); } }
+	var ro = retOuter();
+	ro.next();
+	ro.return();
+	assert(compareArray(log, ["inner", "outer"]), "return: " + log);
+
+	log = [];
+	function* throwOuter() { try { yield* fin(); } catch (e) { yield "caught " + e; } }
+	var to = throwOuter();
+	to.next();
+	assert.sameValue(to.throw("E").value, "caught E", "throw");
+	assert(compareArray(log, ["inner"]), "throw: " + log);
+
+	var res = {value: 42, done: false};
+	var iterable = {
+		[Symbol.iterator]() {
+			return {next() { return res; }};
+		}
+	};
+	function* raw() { yield* iterable; }
+	assert.sameValue(raw().next(), res, "the inner result is passed as is");
+	var noThrow = raw();
+	noThrow.next();
+	assert.throws(TypeError, function() { noThrow.throw(1); }, "no throw() method");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorMethods(t *testing.T) {
+	const SCRIPT = `
+	var obj = { *m() { yield this.x; }, x: 42 };
+	assert.sameValue(obj.m().next().value, 42, "object literal");
+
+	class C {
+		*[Symbol.iterator]() { yield 1; yield 2; }
+		static *s() { yield 3; }
+	}
+	assert(compareArray([...new C()], [1, 2]), "class method");
+	assert.sameValue(C.s().next().value, 3, "static method");
+
+	class B { m() { return "B"; } }
+	class D extends B { *g() { yield super.m(); } }
+	assert.sameValue(new D().g().next().value, "B", "super");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorObjects(t *testing.T) {
+	const SCRIPT = `
+	function* g() { yield 1; }
+	var GeneratorFunction = Object.getPrototypeOf(g).constructor;
+	assert.sameValue(GeneratorFunction.name, "GeneratorFunction", "name");
+	assert.sameValue(Object.getPrototypeOf(GeneratorFunction), Function, "GeneratorFunction proto");
+	assert.sameValue(Object.getPrototypeOf(g), GeneratorFunction.prototype, "generator function proto");
+	assert.sameValue(Object.getPrototypeOf(g()), g.prototype, "generator object proto");
+	assert.sameValue(Object.getPrototypeOf(g.prototype), GeneratorFunction.prototype.prototype, "generator prototype proto");
+	assert.sameValue(Object.getOwnPropertyNames(g.prototype).length, 0, "no constructor");
+	assert.sameValue(Object.prototype.toString.call(g()), "[object Generator]", "toStringTag");
+	assert.sameValue(typeof g, "function", "typeof");
+	assert.sameValue(g.toString(), "function* g() { yield 1; }", "toString");
+	assert.throws(TypeError, function() { new g(); }, "not a constructor");
+	assert.throws(TypeError, function() { g().next.call({}); }, "incompatible receiver");
+
+	var dyn = new GeneratorFunction("a", "yield a; yield a * 2;");
+	assert(compareArray([...dyn(3)], [3, 6]), "GeneratorFunction()");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGeneratorFromGo(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`function* g() { try { yield 1; } finally { throw new Error("in finally"); } } g`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := AssertFunction(v)
+	it, err := f(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itObj := it.ToObject(vm)
+	next, _ := AssertFunction(itObj.Get("next"))
+	res, err := next(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := res.ToObject(vm).Get("value"); !v.SameAs(valueInt(1)) {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+	ret, _ := AssertFunction(itObj.Get("return"))
+	_, err = ret(it)
+	if ex, ok := err.(*Exception); !ok || ex.Value().String() != "Error: in finally" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err = next(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done := res.ToObject(vm).Get("done"); !done.ToBoolean() {
+		t.Fatal("not done")
+	}
+}
+
+func TestGeneratorConformance(t *testing.T) {
+	const SCRIPT = `
+	var GeneratorFunction = Object.getPrototypeOf(function*() {}).constructor;
+	class SubGeneratorFunction extends GeneratorFunction {}
+	var sub = new SubGeneratorFunction("yield 1");
+	assert(sub instanceof SubGeneratorFunction, "subclass instance");
+	assert.sameValue(sub().next().value, 1, "subclass call");
+	assert.sameValue(Object.getPrototypeOf(sub.prototype), GeneratorFunction.prototype.prototype, "subclass prototype");
+	assert.throws(SyntaxError, function() { GeneratorFunction("a = yield", ""); }, "yield in parameters");
+	assert.throws(SyntaxError, function() { GeneratorFunction("yield", ""); }, "yield as a parameter");
+	assert.throws(SyntaxError, function() { GeneratorFunction("", "var o = {yield};"); }, "yield as a shorthand property");
+
+	assert.sameValue(({ *  m ( ) { } }).m.toString(), "*  m ( ) { }", "method toString");
+	class C {
+		static * #m(v) { yield v; }
+		static get m() { return this.#m; }
+	}
+	assert.sameValue(C.m(5).next().value, 5, "private static generator method");
+
+	function* g(a = eval("var x = 1"), b = function() { return x; }) { var x = 2; yield b(); }
+	assert.sameValue(g().next().value, 1, "eval in parameters");
+	assert.throws(SyntaxError, function() {
+		(function*(a = eval("var arguments")) {})().next();
+	}, "arguments declared by eval in parameters");
+
+	function* fin() { try { yield 1; } finally { yield 2; } }
+	var it = fin();
+	it.next();
+	var res = it.return(9);
+	assert(res.value === 2 && !res.done, "return() runs finally");
+	res = it.next();
+	assert(res.value === 9 && res.done, "return() completes after finally");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
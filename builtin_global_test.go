@@ -19,3 +19,40 @@ func TestDecodeURI(t *testing.T) {
 
 	testScript(SCRIPT, newStringValue("http://ru.wikipedia.org/wiki/Юникод"), t)
 }
+
+func TestGlobalThis(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(globalThis, this, "value");
+	assert.sameValue(globalThis.globalThis, globalThis, "self-reference");
+	// the attributes are defined by the spec (ECMAScript 19.1.1)
+	const desc = Object.getOwnPropertyDescriptor(globalThis, "globalThis");
+	assert(desc.writable && !desc.enumerable && desc.configurable, "attributes");
+	assert(!Object.keys(globalThis).includes("globalThis"), "keys");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	prg, err := CompileWithOptions("test.js", `
+	let globalThis = 1;
+	globalThis;
+	`, CompileOptions{SandboxGlobals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		v, err := vm.RunProgram(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.ToInteger() != 1 {
+			t.Fatalf("shadowed globalThis: %v", v)
+		}
+	}
+	v, err := vm.RunString("globalThis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != vm.GlobalObject() {
+		t.Fatalf("globalThis after sandboxed runs: %v", v)
+	}
+}
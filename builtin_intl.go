@@ -0,0 +1,630 @@
+package goja
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja/unistring"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// The Intl implementation is minimal: the requested locales are validated, but the formatting always
+// follows the "en-US" conventions, which is reflected in resolvedOptions().locale.
+const intlLocale = "en-US"
+
+var intlPrinter = message.NewPrinter(language.AmericanEnglish)
+
+type intlNumberFormatObject struct {
+	baseObject
+
+	style            string
+	currency         string
+	currencyDisplay  string
+	minFrac, maxFrac int
+	useGrouping      bool
+
+	boundFormat *Object
+}
+
+type intlDateTimeFormatObject struct {
+	baseObject
+
+	loc      *time.Location
+	timeZone string
+
+	weekday, year, month, day string
+	hour, minute, second      string
+	hour12                    bool
+
+	boundFormat *Object
+}
+
+var intlMonthNames = [...]string{"January", "February", "March", "April", "May", "June", "July", "August",
+	"September", "October", "November", "December"}
+
+func (r *Runtime) intlCheckLocales(locales Value) {
+	check := func(v Value) {
+		if _, err := language.Parse(v.String()); err != nil {
+			panic(r.newError(r.global.RangeError, "Incorrect locale information provided"))
+		}
+	}
+	switch locales := locales.(type) {
+	case valueUndefined:
+	case valueString:
+		check(locales)
+	default:
+		obj := r.toObject(locales)
+		l := toLength(obj.self.getStr("length", nil))
+		for i := int64(0); i < l; i++ {
+			if v := nilSafe(obj.self.getIdx(valueInt(i), nil)); v != _undefined {
+				check(v)
+			}
+		}
+	}
+}
+
+func (r *Runtime) intlOptions(options Value) *Object {
+	if options == nil || options == _undefined {
+		return nil
+	}
+	return r.toObject(options)
+}
+
+func (r *Runtime) intlGetOption(options *Object, ctor, name string, allowed []string, def string) string {
+	if options == nil {
+		return def
+	}
+	v := options.self.getStr(unistring.NewFromString(name), nil)
+	if v == nil || v == _undefined {
+		return def
+	}
+	s := v.String()
+	if allowed != nil {
+		for _, a := range allowed {
+			if s == a {
+				return s
+			}
+		}
+		panic(r.newError(r.global.RangeError, "Value %s out of range for %s options property %s", s, ctor, name))
+	}
+	return s
+}
+
+func (r *Runtime) intlGetNumberOption(options *Object, name string, min, max, def int) int {
+	if options == nil {
+		return def
+	}
+	v := options.self.getStr(unistring.NewFromString(name), nil)
+	if v == nil || v == _undefined {
+		return def
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || f < float64(min) || f > float64(max) {
+		panic(r.newError(r.global.RangeError, "%s value is out of range.", name))
+	}
+	return int(math.Floor(f))
+}
+
+func (r *Runtime) intlGetBoolOption(options *Object, name string, def bool) bool {
+	if options == nil {
+		return def
+	}
+	v := options.self.getStr(unistring.NewFromString(name), nil)
+	if v == nil || v == _undefined {
+		return def
+	}
+	return v.ToBoolean()
+}
+
+// intlFormatFixed returns the integer and the fractional digits of the absolute value of f rounded half away
+// from zero to maxFrac fractional digits, with the trailing zeros removed down to minFrac. Like in the other
+// implementations the rounding is done on the shortest decimal representation of f, so that 1.005 is rounded
+// to 1.01.
+func intlFormatFixed(f float64, minFrac, maxFrac int) (intPart, fracPart string) {
+	s := strconv.FormatFloat(math.Abs(f), 'e', -1, 64)
+	p := strings.IndexByte(s, 'e')
+	exp, _ := strconv.Atoi(s[p+1:])
+	digits := strings.Replace(s[:p], ".", "", 1)
+	point := exp + 1
+	if point <= 0 {
+		digits = strings.Repeat("0", 1-point) + digits
+		point = 1
+	}
+	if point > len(digits) {
+		digits += strings.Repeat("0", point-len(digits))
+	}
+	if keep := point + maxFrac; keep < len(digits) {
+		b := []byte(digits[:keep])
+		if digits[keep] >= '5' {
+			i := len(b) - 1
+			for ; i >= 0; i-- {
+				if b[i] == '9' {
+					b[i] = '0'
+				} else {
+					b[i]++
+					break
+				}
+			}
+			if i < 0 {
+				b = append([]byte{'1'}, b...)
+				point++
+			}
+		}
+		digits = string(b)
+	}
+	intPart = strings.TrimLeft(digits[:point], "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = digits[point:]
+	if len(fracPart) < minFrac {
+		fracPart += strings.Repeat("0", minFrac-len(fracPart))
+	}
+	for len(fracPart) > minFrac && fracPart[len(fracPart)-1] == '0' {
+		fracPart = fracPart[:len(fracPart)-1]
+	}
+	return
+}
+
+func intlGroupDigits(s string) string {
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	first := len(s) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(s[:first])
+	for i := first; i < len(s); i += 3 {
+		b.WriteByte(',')
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+func (nf *intlNumberFormatObject) currencySymbol() string {
+	if nf.currencyDisplay == "code" {
+		return nf.currency
+	}
+	unit, err := currency.ParseISO(nf.currency)
+	if err != nil {
+		return nf.currency
+	}
+	if nf.currencyDisplay == "narrowSymbol" {
+		return intlPrinter.Sprint(currency.NarrowSymbol(unit))
+	}
+	return intlPrinter.Sprint(currency.Symbol(unit))
+}
+
+func (nf *intlNumberFormatObject) format(f float64) string {
+	var b strings.Builder
+	if math.Signbit(f) && !math.IsNaN(f) {
+		b.WriteByte('-')
+	}
+	if nf.style == "currency" {
+		sym := nf.currencySymbol()
+		b.WriteString(sym)
+		if last := sym[len(sym)-1]; last >= 'A' && last <= 'Z' || last >= 'a' && last <= 'z' {
+			b.WriteString(" ")
+		}
+	}
+	switch {
+	case math.IsNaN(f):
+		b.WriteString("NaN")
+	case math.IsInf(f, 0):
+		b.WriteString("∞")
+	default:
+		if nf.style == "percent" {
+			f *= 100
+		}
+		intPart, fracPart := intlFormatFixed(f, nf.minFrac, nf.maxFrac)
+		if nf.useGrouping {
+			intPart = intlGroupDigits(intPart)
+		}
+		b.WriteString(intPart)
+		if fracPart != "" {
+			b.WriteByte('.')
+			b.WriteString(fracPart)
+		}
+	}
+	if nf.style == "percent" {
+		b.WriteByte('%')
+	}
+	return b.String()
+}
+
+func (r *Runtime) toIntlNumberFormat(v Value, method string) *intlNumberFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if nf, ok := obj.self.(*intlNumberFormatObject); ok {
+			return nf
+		}
+	}
+	panic(r.NewTypeError("Method Intl.NumberFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) intlNumberFormatProto_getFormat(call FunctionCall) Value {
+	nf := r.toIntlNumberFormat(call.This, "format")
+	if nf.boundFormat == nil {
+		nf.boundFormat = r.newNativeFunc(func(call FunctionCall) Value {
+			return newStringValue(nf.format(call.Argument(0).ToFloat()))
+		}, nil, "", nil, 1)
+	}
+	return nf.boundFormat
+}
+
+func (r *Runtime) intlNumberFormatProto_resolvedOptions(call FunctionCall) Value {
+	nf := r.toIntlNumberFormat(call.This, "resolvedOptions")
+	res := r.NewObject()
+	res.self._putProp("locale", asciiString(intlLocale), true, true, true)
+	res.self._putProp("numberingSystem", asciiString("latn"), true, true, true)
+	res.self._putProp("style", asciiString(nf.style), true, true, true)
+	if nf.style == "currency" {
+		res.self._putProp("currency", asciiString(nf.currency), true, true, true)
+		res.self._putProp("currencyDisplay", asciiString(nf.currencyDisplay), true, true, true)
+	}
+	res.self._putProp("minimumIntegerDigits", intToValue(1), true, true, true)
+	res.self._putProp("minimumFractionDigits", intToValue(int64(nf.minFrac)), true, true, true)
+	res.self._putProp("maximumFractionDigits", intToValue(int64(nf.maxFrac)), true, true, true)
+	res.self._putProp("useGrouping", r.toBoolean(nf.useGrouping), true, true, true)
+	return res
+}
+
+func (r *Runtime) builtin_newIntlNumberFormat(args []Value, newTarget *Object) *Object {
+	proto := r.global.IntlNumberFormatPrototype
+	if newTarget != nil {
+		proto = r.getPrototypeFromCtor(newTarget, r.global.IntlNumberFormat, proto)
+	}
+	call := FunctionCall{Arguments: args}
+	nf := &intlNumberFormatObject{}
+	r.initIntlNumberFormat(nf, call.Argument(0), call.Argument(1))
+
+	o := &Object{runtime: r}
+	nf.class = classObject
+	nf.val = o
+	nf.extensible = true
+	nf.prototype = proto
+	o.self = nf
+	nf.init()
+	return o
+}
+
+// initIntlNumberFormat validates the locales and sets the formatting parameters of nf from the options.
+func (r *Runtime) initIntlNumberFormat(nf *intlNumberFormatObject, locales, opts Value) {
+	const ctorName = "Intl.NumberFormat"
+	r.intlCheckLocales(locales)
+	options := r.intlOptions(opts)
+
+	nf.style = r.intlGetOption(options, ctorName, "style", []string{"decimal", "percent", "currency"}, "decimal")
+	cur := r.intlGetOption(options, ctorName, "currency", nil, "")
+	if cur != "" {
+		if len(cur) != 3 || strings.IndexFunc(cur, func(c rune) bool {
+			return !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z')
+		}) != -1 {
+			panic(r.newError(r.global.RangeError, "Invalid currency code : %s", cur))
+		}
+		cur = strings.ToUpper(cur)
+	}
+	nf.currencyDisplay = r.intlGetOption(options, ctorName, "currencyDisplay", []string{"code", "symbol", "narrowSymbol"}, "symbol")
+
+	minDef, maxDef := 0, 3
+	switch nf.style {
+	case "currency":
+		if cur == "" {
+			panic(r.NewTypeError("Currency code is required with currency style."))
+		}
+		nf.currency = cur
+		minDef = 2
+		if unit, err := currency.ParseISO(cur); err == nil {
+			minDef, _ = currency.Standard.Rounding(unit)
+		}
+		maxDef = minDef
+	case "percent":
+		maxDef = 0
+	}
+	nf.minFrac = r.intlGetNumberOption(options, "minimumFractionDigits", 0, 20, minDef)
+	if nf.minFrac > maxDef {
+		maxDef = nf.minFrac
+	}
+	nf.maxFrac = r.intlGetNumberOption(options, "maximumFractionDigits", nf.minFrac, 20, maxDef)
+	nf.useGrouping = r.intlGetBoolOption(options, "useGrouping", true)
+}
+
+func (r *Runtime) createIntlNumberFormatProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("constructor", r.global.IntlNumberFormat, true, false, true)
+	o.setOwnStr("format", &valueProperty{
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.intlNumberFormatProto_getFormat, nil, "get format", nil, 0),
+		accessor:     true,
+	}, false)
+	o._putProp("resolvedOptions", r.newNativeFunc(r.intlNumberFormatProto_resolvedOptions, nil, "resolvedOptions", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Intl.NumberFormat"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createIntlNumberFormat(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newIntlNumberFormat, r.global.IntlNumberFormatPrototype, "NumberFormat", 0)
+}
+
+func intlPad2(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+func (df *intlDateTimeFormatObject) formatNumeric(n int, style string) string {
+	if style == "2-digit" {
+		return intlPad2(n % 100)
+	}
+	return strconv.Itoa(n)
+}
+
+func (df *intlDateTimeFormatObject) formatDate(t time.Time) string {
+	var parts []string
+	switch df.month {
+	case "", "numeric", "2-digit":
+		if df.month != "" {
+			parts = append(parts, df.formatNumeric(int(t.Month()), df.month))
+		}
+		if df.day != "" {
+			parts = append(parts, df.formatNumeric(t.Day(), df.day))
+		}
+		if df.year != "" {
+			parts = append(parts, df.formatNumeric(t.Year(), df.year))
+		}
+		if df.month == "" {
+			return strings.Join(parts, " ")
+		}
+		return strings.Join(parts, "/")
+	}
+	name := intlMonthNames[t.Month()-1]
+	switch df.month {
+	case "short":
+		name = name[:3]
+	case "narrow":
+		name = name[:1]
+	}
+	s := name
+	if df.day != "" {
+		s += " " + df.formatNumeric(t.Day(), df.day)
+		if df.year != "" {
+			s += ","
+		}
+	}
+	if df.year != "" {
+		s += " " + df.formatNumeric(t.Year(), df.year)
+	}
+	return s
+}
+
+func (df *intlDateTimeFormatObject) formatTime(t time.Time) string {
+	var b strings.Builder
+	if df.hour != "" {
+		h := t.Hour()
+		if df.hour12 {
+			h %= 12
+			if h == 0 {
+				h = 12
+			}
+			b.WriteString(df.formatNumeric(h, df.hour))
+		} else {
+			b.WriteString(intlPad2(h))
+		}
+	}
+	if df.minute != "" {
+		if b.Len() > 0 {
+			b.WriteByte(':')
+			b.WriteString(intlPad2(t.Minute()))
+		} else {
+			b.WriteString(df.formatNumeric(t.Minute(), df.minute))
+		}
+	}
+	if df.second != "" {
+		if b.Len() > 0 {
+			b.WriteByte(':')
+			b.WriteString(intlPad2(t.Second()))
+		} else {
+			b.WriteString(df.formatNumeric(t.Second(), df.second))
+		}
+	}
+	if df.hour != "" && df.hour12 {
+		if t.Hour() < 12 {
+			b.WriteString(" AM")
+		} else {
+			b.WriteString(" PM")
+		}
+	}
+	return b.String()
+}
+
+func (df *intlDateTimeFormatObject) format(t time.Time) string {
+	t = t.In(df.loc)
+	var parts []string
+	date := ""
+	if df.year != "" || df.month != "" || df.day != "" {
+		date = df.formatDate(t)
+	}
+	if df.weekday != "" {
+		name := t.Weekday().String()
+		switch df.weekday {
+		case "short":
+			name = name[:3]
+		case "narrow":
+			name = name[:1]
+		}
+		if date != "" {
+			date = name + ", " + date
+		} else {
+			date = name
+		}
+	}
+	if date != "" {
+		parts = append(parts, date)
+	}
+	if df.hour != "" || df.minute != "" || df.second != "" {
+		parts = append(parts, df.formatTime(t))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *Runtime) toIntlDateTimeFormat(v Value, method string) *intlDateTimeFormatObject {
+	if obj, ok := v.(*Object); ok {
+		if df, ok := obj.self.(*intlDateTimeFormatObject); ok {
+			return df
+		}
+	}
+	panic(r.NewTypeError("Method Intl.DateTimeFormat.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: v})))
+}
+
+func (r *Runtime) intlDateTimeFormatProto_getFormat(call FunctionCall) Value {
+	df := r.toIntlDateTimeFormat(call.This, "format")
+	if df.boundFormat == nil {
+		df.boundFormat = r.newNativeFunc(func(call FunctionCall) Value {
+			var t time.Time
+			if arg := call.Argument(0); arg == _undefined {
+				t = r.now()
+			} else {
+				f := arg.ToFloat()
+				if math.IsNaN(f) || math.Abs(f) > maxTime {
+					panic(r.newError(r.global.RangeError, "Invalid time value"))
+				}
+				t = timeFromMsec(int64(f))
+			}
+			return asciiString(df.format(t))
+		}, nil, "", nil, 1)
+	}
+	return df.boundFormat
+}
+
+func (r *Runtime) intlDateTimeFormatProto_resolvedOptions(call FunctionCall) Value {
+	df := r.toIntlDateTimeFormat(call.This, "resolvedOptions")
+	res := r.NewObject()
+	res.self._putProp("locale", asciiString(intlLocale), true, true, true)
+	res.self._putProp("calendar", asciiString("gregory"), true, true, true)
+	res.self._putProp("numberingSystem", asciiString("latn"), true, true, true)
+	res.self._putProp("timeZone", newStringValue(df.timeZone), true, true, true)
+	if df.hour != "" {
+		if df.hour12 {
+			res.self._putProp("hourCycle", asciiString("h12"), true, true, true)
+		} else {
+			res.self._putProp("hourCycle", asciiString("h23"), true, true, true)
+		}
+		res.self._putProp("hour12", r.toBoolean(df.hour12), true, true, true)
+	}
+	for _, p := range []struct {
+		name  unistring.String
+		value string
+	}{
+		{"weekday", df.weekday},
+		{"year", df.year},
+		{"month", df.month},
+		{"day", df.day},
+		{"hour", df.hour},
+		{"minute", df.minute},
+		{"second", df.second},
+	} {
+		if p.value != "" {
+			res.self._putProp(p.name, asciiString(p.value), true, true, true)
+		}
+	}
+	return res
+}
+
+func (r *Runtime) builtin_newIntlDateTimeFormat(args []Value, newTarget *Object) *Object {
+	const ctorName = "Intl.DateTimeFormat"
+	proto := r.global.IntlDateTimeFormatPrototype
+	if newTarget != nil {
+		proto = r.getPrototypeFromCtor(newTarget, r.global.IntlDateTimeFormat, proto)
+	}
+	call := FunctionCall{Arguments: args}
+	r.intlCheckLocales(call.Argument(0))
+	options := r.intlOptions(call.Argument(1))
+
+	df := &intlDateTimeFormatObject{}
+	textual := []string{"long", "short", "narrow"}
+	numeric := []string{"numeric", "2-digit"}
+	df.weekday = r.intlGetOption(options, ctorName, "weekday", textual, "")
+	df.year = r.intlGetOption(options, ctorName, "year", numeric, "")
+	df.month = r.intlGetOption(options, ctorName, "month", append(numeric, textual...), "")
+	df.day = r.intlGetOption(options, ctorName, "day", numeric, "")
+	df.hour = r.intlGetOption(options, ctorName, "hour", numeric, "")
+	df.minute = r.intlGetOption(options, ctorName, "minute", numeric, "")
+	df.second = r.intlGetOption(options, ctorName, "second", numeric, "")
+	df.hour12 = r.intlGetBoolOption(options, "hour12", true)
+	if df.weekday == "" && df.year == "" && df.month == "" && df.day == "" &&
+		df.hour == "" && df.minute == "" && df.second == "" {
+		df.year, df.month, df.day = "numeric", "numeric", "numeric"
+	}
+
+	tz := r.intlGetOption(options, ctorName, "timeZone", nil, "")
+	switch {
+	case tz == "":
+		df.loc = time.Local
+		df.timeZone = time.Local.String()
+	case strings.EqualFold(tz, "UTC"):
+		df.loc = time.UTC
+		df.timeZone = "UTC"
+	default:
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			panic(r.newError(r.global.RangeError, "Invalid time zone specified: %s", tz))
+		}
+		df.loc = loc
+		df.timeZone = loc.String()
+	}
+
+	o := &Object{runtime: r}
+	df.class = classObject
+	df.val = o
+	df.extensible = true
+	df.prototype = proto
+	o.self = df
+	df.init()
+	return o
+}
+
+func (r *Runtime) createIntlDateTimeFormatProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("constructor", r.global.IntlDateTimeFormat, true, false, true)
+	o.setOwnStr("format", &valueProperty{
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.intlDateTimeFormatProto_getFormat, nil, "get format", nil, 0),
+		accessor:     true,
+	}, false)
+	o._putProp("resolvedOptions", r.newNativeFunc(r.intlDateTimeFormatProto_resolvedOptions, nil, "resolvedOptions", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Intl.DateTimeFormat"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) createIntlDateTimeFormat(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newIntlDateTimeFormat, r.global.IntlDateTimeFormatPrototype, "DateTimeFormat", 0)
+}
+
+func (r *Runtime) createIntl(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+
+	o._putProp("NumberFormat", r.global.IntlNumberFormat, true, false, true)
+	o._putProp("DateTimeFormat", r.global.IntlDateTimeFormat, true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString("Intl"), false, false, true))
+
+	return o
+}
+
+func (r *Runtime) initIntl() {
+	r.global.IntlNumberFormatPrototype = r.newLazyObject(r.createIntlNumberFormatProto)
+	r.global.IntlNumberFormat = r.newLazyObject(r.createIntlNumberFormat)
+	r.global.IntlDateTimeFormatPrototype = r.newLazyObject(r.createIntlDateTimeFormatProto)
+	r.global.IntlDateTimeFormat = r.newLazyObject(r.createIntlDateTimeFormat)
+
+	r.addToGlobal("Intl", r.newLazyObject(r.createIntl))
+}
@@ -0,0 +1,90 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestIntlNumberFormat(t *testing.T) {
+	const SCRIPT = `
+	var nf = new Intl.NumberFormat("en-US");
+	assert.sameValue(nf.format(1234567.891), "1,234,567.891");
+	assert.sameValue(nf.format(1.0005), "1.001");
+	assert.sameValue(nf.format(-0), "-0");
+	assert.sameValue(nf.format(NaN), "NaN");
+	assert.sameValue(nf.format(-Infinity), "-∞");
+	assert.sameValue(nf.format(0.000001), "0");
+	assert.sameValue(nf.format(1e21), "1,000,000,000,000,000,000,000");
+	assert.sameValue([1000, 2.5].map(nf.format).join(" "), "1,000 2.5");
+	assert.sameValue(nf.format, nf.format);
+
+	var cur = new Intl.NumberFormat("en-US", {style: "currency", currency: "usd"});
+	assert.sameValue(cur.format(-1234.5), "-$1,234.50");
+	assert.sameValue(cur.format(1.005), "$1.01");
+	assert.sameValue(cur.format(9.999), "$10.00");
+	assert.sameValue(new Intl.NumberFormat("en-US", {style: "currency", currency: "JPY"}).format(1234.5), "¥1,235");
+	assert.sameValue(new Intl.NumberFormat("en-US", {style: "currency", currency: "EUR"}).format(1), "€1.00");
+	assert.sameValue(new Intl.NumberFormat("en-US", {style: "currency", currency: "CHF"}).format(1), "CHF 1.00");
+	assert.sameValue(new Intl.NumberFormat("en-US", {style: "currency", currency: "USD", currencyDisplay: "code"}).format(1), "USD 1.00");
+
+	var pct = Intl.NumberFormat(undefined, {style: "percent", minimumFractionDigits: 1});
+	assert.sameValue(pct.format(0.1234), "12.3%");
+	assert.sameValue(new Intl.NumberFormat([], {useGrouping: false, maximumFractionDigits: 1}).format(12345.67), "12345.7");
+
+	var opts = cur.resolvedOptions();
+	assert.sameValue(opts.locale, "en-US");
+	assert.sameValue(opts.style, "currency");
+	assert.sameValue(opts.currency, "USD");
+	assert.sameValue(opts.minimumFractionDigits, 2);
+	assert.sameValue(opts.maximumFractionDigits, 2);
+	assert.sameValue(opts.useGrouping, true);
+	assert.sameValue(Object.prototype.toString.call(cur), "[object Intl.NumberFormat]");
+
+	assert.throws(TypeError, function() { new Intl.NumberFormat("en-US", {style: "currency"}) });
+	assert.throws(RangeError, function() { new Intl.NumberFormat("en-US", {style: "currency", currency: "US"}) });
+	assert.throws(RangeError, function() { new Intl.NumberFormat("en-US", {style: "bogus"}) });
+	assert.throws(RangeError, function() { new Intl.NumberFormat("en-US", {minimumFractionDigits: 3, maximumFractionDigits: 2}) });
+	assert.throws(RangeError, function() { new Intl.NumberFormat("not a locale!") });
+	assert.throws(TypeError, function() { Intl.NumberFormat.prototype.resolvedOptions.call({}) });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestNumberToLocaleString(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue((1234567.891).toLocaleString(), "1,234,567.891");
+	assert.sameValue((-1234).toLocaleString("en-US"), "-1,234");
+	assert.sameValue((1234.5).toLocaleString(undefined, {minimumFractionDigits: 2}), "1,234.50");
+	assert.sameValue((0.5).toLocaleString("en-US", {style: "percent"}), "50%");
+	assert.sameValue((12).toLocaleString("en-US", {style: "currency", currency: "USD"}), "$12.00");
+	assert.sameValue((12345).toLocaleString([], {useGrouping: false}), "12345");
+	assert.sameValue(new Number(1000).toLocaleString(), "1,000");
+	assert.sameValue(NaN.toLocaleString(), "NaN");
+	assert.sameValue(Number.prototype.toLocaleString.length, 0);
+	assert.throws(RangeError, function() { (1).toLocaleString("not a locale!") });
+	assert.throws(RangeError, function() { (1).toLocaleString(undefined, {minimumFractionDigits: 21}) });
+	assert.throws(TypeError, function() { Number.prototype.toLocaleString.call("1") });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestIntlDateTimeFormat(t *testing.T) {
+	const SCRIPT = `
+	var d = Date.UTC(2021, 0, 2, 15, 4, 5);
+	var utc = {timeZone: "UTC"};
+	assert.sameValue(new Intl.DateTimeFormat("en-US", utc).format(d), "1/2/2021");
+	assert.sameValue(new Intl.DateTimeFormat("en-US", {timeZone: "UTC", year: "numeric", month: "long", day: "numeric", weekday: "long"}).format(d), "Saturday, January 2, 2021");
+	assert.sameValue(new Intl.DateTimeFormat("en-US", {timeZone: "UTC", month: "short", year: "numeric"}).format(d), "Jan 2021");
+	assert.sameValue(new Intl.DateTimeFormat("en-US", {timeZone: "UTC", year: "2-digit", month: "2-digit", day: "2-digit", hour: "numeric", minute: "2-digit", second: "2-digit"}).format(d), "01/02/21, 3:04:05 PM");
+	assert.sameValue(new Intl.DateTimeFormat("en-US", {timeZone: "UTC", hour: "numeric", minute: "numeric", hour12: false}).format(new Date(d)), "15:04");
+	assert.sameValue(new Intl.DateTimeFormat("en-US", {timeZone: "America/New_York", hour: "numeric"}).format(d), "10 AM");
+	var dopts = new Intl.DateTimeFormat("en-US", {timeZone: "utc", hour: "numeric"}).resolvedOptions();
+	assert.sameValue(dopts.timeZone, "UTC");
+	assert.sameValue(dopts.hour, "numeric");
+	assert.sameValue(dopts.hour12, true);
+	assert.sameValue(dopts.year, undefined);
+	assert.sameValue(typeof new Intl.DateTimeFormat().format(), "string");
+	assert.throws(RangeError, function() { new Intl.DateTimeFormat("en-US", {timeZone: "Nowhere/Special"}) });
+	assert.throws(RangeError, function() { new Intl.DateTimeFormat().format(NaN) });
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
@@ -43,6 +43,50 @@ func (r *Runtime) builtinJSON_parse(call FunctionCall) Value {
 	return value
 }
 
+type jsonErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (j *jsonErrReader) Read(p []byte) (n int, err error) {
+	n, err = j.r.Read(p)
+	if err != nil && err != io.EOF {
+		j.err = err
+	}
+	return
+}
+
+// DecodeJSON reads a JSON text from the supplied io.Reader and converts it into a value, the same way
+// JSON.parse() (called without a reviver) would do for the equivalent string. Unlike JSON.parse() the input is
+// consumed incrementally, so there is no need to load it into a string first which makes it suitable for
+// large inputs.
+// If the input is not a valid JSON text an *Exception containing a SyntaxError is returned. Errors returned
+// by the reader are returned as is.
+func (r *Runtime) DecodeJSON(rd io.Reader) (Value, error) {
+	er := &jsonErrReader{r: rd}
+	d := json.NewDecoder(er)
+
+	value, err := r.builtinJSON_decodeValue(d)
+	if err == nil {
+		if tok, err1 := d.Token(); err1 != io.EOF {
+			if er.err == nil && err1 == nil {
+				err = fmt.Errorf("Unexpected token at the end: %v", tok)
+			} else {
+				err = err1
+			}
+		}
+	}
+	if er.err != nil {
+		return nil, er.err
+	}
+	if err != nil {
+		return nil, &Exception{
+			val: r.newError(r.global.SyntaxError, err.Error()),
+		}
+	}
+	return value, nil
+}
+
 func (r *Runtime) builtinJSON_decodeToken(d *json.Decoder, tok json.Token) (Value, error) {
 	switch tok := tok.(type) {
 	case json.Delim:
@@ -274,6 +318,15 @@ func (ctx *_builtinJSON_stringifyContext) do(v Value) bool {
 	return ctx.str(stringEmpty, holder)
 }
 
+func isHostObject(o objectImpl) bool {
+	switch o.(type) {
+	case *objectGoReflect, *objectGoMapSimple, *objectGoMapReflect, *objectGoSlice, *objectGoSliceReflect,
+		*objectGoArrayReflect, *dynamicObject, *dynamicArray:
+		return true
+	}
+	return false
+}
+
 func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 	value := nilSafe(holder.get(key, nil))
 
@@ -295,6 +348,14 @@ func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 		})
 	}
 
+	if marshaler := ctx.r.hostJSONMarshaler; marshaler != nil {
+		if o, ok := value.(*Object); ok && isHostObject(o.self) {
+			if v, ok := marshaler(o); ok {
+				value = nilSafe(v)
+			}
+		}
+	}
+
 	if o, ok := value.(*Object); ok {
 		switch o1 := o.self.(type) {
 		case *primitiveValueObject:
@@ -353,6 +414,8 @@ func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 		}
 	case valueNull:
 		ctx.buf.WriteString("null")
+	case *valueBigInt:
+		ctx.r.typeErrorResult(true, "Do not know how to serialize a BigInt")
 	case *Object:
 		for _, object := range ctx.stack {
 			if value1 == object {
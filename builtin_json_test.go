@@ -2,8 +2,11 @@ package goja
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -47,6 +50,57 @@ func TestJSONMarshalObjectCircular(t *testing.T) {
 	}
 }
 
+func TestHostJSONMarshaler(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	vm := New()
+	var calls int
+	vm.SetHostJSONMarshaler(func(v Value) (Value, bool) {
+		calls++
+		switch e := v.Export().(type) {
+		case time.Time:
+			return vm.ToValue(e.Format("2006-01-02")), true
+		case point:
+			return vm.ToValue([]int{e.X, e.Y}), true
+		}
+		return nil, false
+	})
+	vm.Set("d", time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC))
+	vm.Set("p", point{X: 1, Y: 2})
+	vm.Set("m", map[string]interface{}{"n": 42})
+
+	v, err := vm.RunString(`JSON.stringify({
+		d: d,
+		p: p,
+		m: m,
+		plain: {a: 1},
+		viaToJSON: {toJSON() { return p; }},
+		replaced: 0
+	}, (k, v) => k === "replaced" ? d : v)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"d":"2020-05-17","p":[1,2],"m":{"n":42},"plain":{"a":1},"viaToJSON":[1,2],"replaced":"2020-05-17"}`
+	if s := v.String(); s != expected {
+		t.Fatalf("Unexpected value: %s", s)
+	}
+	// d, p, m, the result of viaToJSON.toJSON() and the result of the replacer. The values returned by
+	// the marshaler are serialized by default.
+	if calls != 5 {
+		t.Fatalf("Unexpected number of calls: %d", calls)
+	}
+
+	vm.SetHostJSONMarshaler(nil)
+	v, err = vm.RunString(`JSON.stringify(p)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != `{"X":1,"Y":2}` {
+		t.Fatalf("Unexpected value after removing the marshaler: %s", s)
+	}
+}
+
 func TestJSONParseReviver(t *testing.T) {
 	// example from
 	// https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/JSON/parse
@@ -61,6 +115,73 @@ func TestJSONParseReviver(t *testing.T) {
 	testScript(SCRIPT, intToValue(10), t)
 }
 
+func TestJSONStringifySymbolKeys(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("s");
+	var o = {a: 1, [s]: 2, b: s, c: [s]};
+	Object.defineProperty(o, Symbol("e"), {value: 3, enumerable: true});
+	var expected = '{"a":1,"c":[null]}';
+	assert.sameValue(JSON.stringify(o), expected);
+	assert.sameValue(JSON.stringify(new Proxy(o, {})), expected, "Proxy");
+	assert.sameValue(JSON.stringify(o, [s, "a"]), '{"a":1}', "property list");
+
+	var keys = [];
+	JSON.stringify(o, function(k, v) {
+		keys.push(typeof k === "symbol" ? "symbol" : k);
+		return v;
+	});
+	assert(compareArray(keys, ["", "a", "b", "c", "0"]), keys.join());
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	o := vm.NewObject()
+	_ = o.Set("a", 1)
+	_ = o.SetSymbol(SymIterator, 2)
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"a":1}` {
+		t.Fatalf("Unexpected value: %s", b)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	const SRC = `{"a": [1, 2.5, -0, "str\u00e9", true, false, null, {}], "b": {"c": {"d": []}}, "": 1e300}`
+	vm := New()
+	v, err := vm.DecodeJSON(iotest.OneByteReader(strings.NewReader(SRC)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.Set("v", v)
+	vm.Set("src", SRC)
+	vm.testScriptWithTestLibX(`deepEqual(v, JSON.parse(src)) && Array.isArray(v.a) && 1/v.a[2] === -Infinity`, valueTrue, t)
+}
+
+func TestDecodeJSONSyntaxError(t *testing.T) {
+	vm := New()
+	for _, src := range []string{`{"a": }`, `[1, 2`, `1 2`, ``} {
+		_, err := vm.DecodeJSON(strings.NewReader(src))
+		if ex, ok := err.(*Exception); ok {
+			if obj, ok := ex.Value().(*Object); !ok || obj.Get("name").String() != "SyntaxError" {
+				t.Fatalf("%q: unexpected exception value: %v", src, ex.Value())
+			}
+		} else {
+			t.Fatalf("%q: unexpected error: %v", src, err)
+		}
+	}
+}
+
+func TestDecodeJSONReaderError(t *testing.T) {
+	vm := New()
+	readErr := errors.New("read failed")
+	_, err := vm.DecodeJSON(io.MultiReader(strings.NewReader(`[1, 2, `), iotest.ErrReader(readErr)))
+	if err != readErr {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
 func TestQuoteMalformedSurrogatePair(t *testing.T) {
 	testScript(`JSON.stringify("\uD800")`, asciiString(`"\ud800"`), t)
 }
@@ -62,6 +62,37 @@ func TestMapEvilIterator(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestMapInsertionOrder(t *testing.T) {
+	const SCRIPT = `
+	var m = new Map([["a", 1], ["b", 2], ["c", 3]]);
+	m.delete("a");
+	m.set("a", 4);
+	m.set("b", 5);
+	assert(compareArray(Array.from(m.keys()), ["b", "c", "a"]), "keys");
+	assert(compareArray(Array.from(m.values()), [5, 3, 4]), "values");
+	assert(compareArray(Array.from(m, function(e) { return e.join("="); }), ["b=5", "c=3", "a=4"]), "entries");
+
+	var thisArg = {};
+	var log = [];
+	m.forEach(function(value, key, map) {
+		assert.sameValue(this, thisArg, "thisArg");
+		assert.sameValue(map, m, "map");
+		log.push(key + "=" + value);
+		if (key === "b") {
+			m.delete("c");
+			m.set("c", 6);
+		}
+	}, thisArg);
+	assert(compareArray(log, ["b=5", "a=4", "c=6"]), "forEach visits the re-added key at the end: " + log);
+
+	m.forEach(function() {
+		"use strict";
+		assert.sameValue(this, undefined, "no thisArg");
+	});
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestMapExportToNilMap(t *testing.T) {
 	vm := New()
 	var m map[int]interface{}
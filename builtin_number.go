@@ -72,6 +72,18 @@ func (r *Runtime) numberproto_toString(call FunctionCall) Value {
 	return asciiString(ftoa.FToBaseStr(num, radix))
 }
 
+// numberproto_toLocaleString implements Number.prototype.toLocaleString() as defined by ECMA-402, i.e. the arguments
+// are handled in the same way as by the Intl.NumberFormat constructor.
+func (r *Runtime) numberproto_toLocaleString(call FunctionCall) Value {
+	if !isNumber(call.This) {
+		r.typeErrorResult(true, "Value is not a number")
+	}
+	num := call.This.ToFloat()
+	nf := &intlNumberFormatObject{}
+	r.initIntlNumberFormat(nf, call.Argument(0), call.Argument(1))
+	return newStringValue(nf.format(num))
+}
+
 func (r *Runtime) numberproto_toFixed(call FunctionCall) Value {
 	num := r.toNumber(call.This).ToFloat()
 	prec := call.Argument(0).ToInteger()
@@ -184,7 +196,7 @@ func (r *Runtime) initNumber() {
 	o := r.global.NumberPrototype.self
 	o._putProp("toExponential", r.newNativeFunc(r.numberproto_toExponential, nil, "toExponential", nil, 1), true, false, true)
 	o._putProp("toFixed", r.newNativeFunc(r.numberproto_toFixed, nil, "toFixed", nil, 1), true, false, true)
-	o._putProp("toLocaleString", r.newNativeFunc(r.numberproto_toString, nil, "toLocaleString", nil, 0), true, false, true)
+	o._putProp("toLocaleString", r.newNativeFunc(r.numberproto_toLocaleString, nil, "toLocaleString", nil, 0), true, false, true)
 	o._putProp("toPrecision", r.newNativeFunc(r.numberproto_toPrecision, nil, "toPrecision", nil, 1), true, false, true)
 	o._putProp("toString", r.newNativeFunc(r.numberproto_toString, nil, "toString", nil, 1), true, false, true)
 	o._putProp("valueOf", r.newNativeFunc(r.numberproto_valueOf, nil, "valueOf", nil, 0), true, false, true)
@@ -58,6 +58,9 @@ type Promise struct {
 	fulfillReactions []*promiseReaction
 	rejectReactions  []*promiseReaction
 	handled          bool
+
+	// the async function suspended in an await expression on this Promise, if any
+	awaiter *asyncFrame
 }
 
 func (p *Promise) State() PromiseState {
@@ -287,6 +290,10 @@ func (r *Runtime) performPromiseThen(p *Promise, onFulfilled, onRejected Value,
 	if f, ok := assertCallable(onRejected); ok {
 		onRejectedJobCallback = &jobCallback{callback: f}
 	}
+	return r.performPromiseThenJobs(p, onFulfilledJobCallback, onRejectedJobCallback, resultCapability)
+}
+
+func (r *Runtime) performPromiseThenJobs(p *Promise, onFulfilledJobCallback, onRejectedJobCallback *jobCallback, resultCapability *promiseCapability) Value {
 	fulfillReaction := &promiseReaction{
 		capability: resultCapability,
 		typ:        promiseReactionFulfill,
@@ -1273,3 +1273,108 @@ func TestProxyEnumerableSymbols(t *testing.T) {
 
 	testScriptWithTestLib(SCRIPT, valueTrue, t)
 }
+
+func TestProxyTrapsFromOpcodes(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var target = {x: 1, f: function() { return this; }};
+	var p;
+	function key(k) {
+		return typeof k === "symbol" ? k.toString() : k;
+	}
+	p = new Proxy(target, {
+		get: function(t, k, r) {
+			assert.sameValue(t, target, "get target");
+			log.push("get " + key(k) + (r === p ? "" : " wrong receiver"));
+			return Reflect.get(t, k, r);
+		},
+		set: function(t, k, v, r) {
+			log.push("set " + key(k) + (r === p ? "" : " wrong receiver"));
+			return Reflect.set(t, k, v, r);
+		},
+		has: function(t, k) {
+			log.push("has " + key(k));
+			return Reflect.has(t, k);
+		},
+		deleteProperty: function(t, k) {
+			log.push("delete " + key(k));
+			return Reflect.deleteProperty(t, k);
+		},
+	});
+	var k = "y", sym = Symbol("s");
+
+	p.x; p[k]; p[0]; p[sym];
+	assert(compareArray(log.splice(0), ["get x", "get y", "get 0", "get Symbol(s)"]), "get");
+
+	p.x = 2; p[k] = 3; p[0] = 4; p[sym] = 5;
+	(function() { "use strict"; p.z = 6; })();
+	assert(compareArray(log.splice(0), ["set x", "set y", "set 0", "set Symbol(s)", "set z"]), "set");
+
+	"x" in p; k in p; 0 in p; sym in p;
+	assert(compareArray(log.splice(0), ["has x", "has y", "has 0", "has Symbol(s)"]), "has");
+
+	delete p.x; delete p[k]; delete p[0]; delete p[sym];
+	(function() { "use strict"; delete p.z; })();
+	assert(compareArray(log.splice(0), ["delete x", "delete y", "delete 0", "delete Symbol(s)", "delete z"]), "delete");
+
+	p.x = 1; log.length = 0;
+	p.x++; p[k] += 1;
+	assert(compareArray(log.splice(0), ["get x", "set x", "get y", "set y"]), "compound assignment");
+
+	assert.sameValue(p.f(), p, "method call this");
+	assert.sameValue(p[k = "f"](), p, "computed method call this");
+	var {x} = p;
+	assert.sameValue(x, 2, "destructuring");
+	log.length = 0;
+
+	with (p) {
+		x;
+	}
+	assert(compareArray(log.splice(0), ["has x", "get Symbol(Symbol.unscopables)", "get x"]), "with");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestProxyTrapsInheritedReceiver(t *testing.T) {
+	const SCRIPT = `
+	var receivers = [];
+	var target = {
+		get g() { return this; },
+		set s(v) { this.sv = this; },
+	};
+	var p = new Proxy(target, {
+		get: function(t, k, r) {
+			receivers.push(r);
+			return Reflect.get(t, k, r);
+		},
+		set: function(t, k, v, r) {
+			receivers.push(r);
+			return Reflect.set(t, k, v, r);
+		},
+	});
+
+	var child = Object.create(p);
+	assert.sameValue(child.g, child, "getter this via the prototype chain");
+	child.s = 1;
+	assert.sameValue(child.sv, child, "setter this via the prototype chain");
+	assert.sameValue(target.hasOwnProperty("sv"), false, "target is not modified");
+	child.n = 1;
+	assert.sameValue(child.hasOwnProperty("n"), true, "a data property is created on the receiver");
+	assert(receivers.every(function(r) { return r === child; }), "receivers");
+	receivers.length = 0;
+
+	class A {
+		get() { return super.g; }
+		set() { super.s = 1; }
+	}
+	Object.setPrototypeOf(A.prototype, p);
+	var a = new A();
+	assert.sameValue(a.get(), a, "super property get");
+	a.set();
+	assert.sameValue(a.sv, a, "super property set");
+	assert(receivers.length > 0 && receivers.every(function(r) { return r === a; }), "super receivers");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
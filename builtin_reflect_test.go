@@ -0,0 +1,126 @@
+package goja
+
+import "testing"
+
+func TestReflectConstructNewTarget(t *testing.T) {
+	const SCRIPT = `
+	var newTargets = [];
+	function F() {
+		newTargets.push(new.target);
+		this.a = 1;
+	}
+	function G() {}
+	var o = Reflect.construct(F, [], G);
+	assert.sameValue(newTargets[0], G, "new.target");
+	assert.sameValue(Object.getPrototypeOf(o), G.prototype, "prototype is taken from newTarget");
+	assert.sameValue(o.a, 1, "the target is called");
+
+	class A {
+		constructor() {
+			newTargets.push(new.target);
+		}
+	}
+	class B {}
+	assert(Reflect.construct(A, [], B) instanceof B, "class");
+	assert.sameValue(newTargets[1], B, "class new.target");
+
+	o = Reflect.construct(F, []);
+	assert.sameValue(newTargets[2], F, "newTarget defaults to the target");
+	assert.sameValue(Object.getPrototypeOf(o), F.prototype, "default prototype");
+
+	assert.throws(TypeError, function() {
+		Reflect.construct(F, [], Math.max);
+	}, "newTarget is not a constructor");
+	assert.throws(TypeError, function() {
+		Reflect.construct(function() {}.bind(), [], () => {});
+	}, "arrow function as newTarget");
+	assert.throws(TypeError, function() {
+		Reflect.construct(() => {}, []);
+	}, "target is not a constructor");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestReflectSetReceiver(t *testing.T) {
+	const SCRIPT = `
+	var receiver = {};
+	var target = {
+		set x(v) {
+			this.y = v;
+		},
+		z: 1
+	};
+	assert(Reflect.set(target, "x", 5, receiver), "setter");
+	assert.sameValue(receiver.y, 5, "the setter is called with the receiver");
+	assert(!target.hasOwnProperty("y"), "target is not modified by the setter");
+
+	assert(Reflect.set(target, "z", 2, receiver), "data property");
+	assert.sameValue(receiver.z, 2, "the property is created on the receiver");
+	assert.sameValue(target.z, 1, "target is not modified");
+
+	assert(!Reflect.set(target, "z", 2, 1), "primitive receiver");
+
+	var readOnly = Object.defineProperty({}, "z", {value: 1, writable: false});
+	assert(!Reflect.set({z: 1}, "z", 2, readOnly), "non-writable property on the receiver");
+	assert.sameValue(readOnly.z, 1, "non-writable property value");
+
+	var accessor = Object.defineProperty({}, "z", {get: function() { return 1; }, configurable: true});
+	assert(!Reflect.set({z: 1}, "z", 2, accessor), "accessor property on the receiver");
+
+	var frozen = Object.freeze({z: 1});
+	assert(!Reflect.set(frozen, "z", 2), "frozen target");
+	assert(!Reflect.set(frozen, "z", 2, receiver), "frozen target with a receiver");
+
+	var arr = [1, 2, 3];
+	assert(Reflect.set(arr, 1, 9), "array element");
+	assert.sameValue(arr[1], 9, "array element value");
+	assert(Reflect.set([], "length", 2, receiver), "array length with a receiver");
+	assert.sameValue(receiver.length, 2, "length is created on the receiver");
+
+	var p = new Proxy({}, {
+		set: function(t, key, value, r) {
+			return r === receiver;
+		}
+	});
+	assert(Reflect.set(p, "x", 1, receiver), "the receiver is passed to the Proxy trap");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestReflectApply(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Reflect.apply(Math.max, null, [1, 3, 2]), 3, "native");
+	assert.sameValue(Reflect.apply(function() { return this; }, 1, []) instanceof Number, true, "sloppy this");
+	assert.sameValue(Reflect.apply(function() { "use strict"; return this; }, 1, []), 1, "strict this");
+	assert.sameValue(Reflect.apply(function(a, b) { return a + b; }, null, {length: 2, 0: 1, 1: 2}), 3, "array-like");
+	assert.throws(TypeError, function() {
+		Reflect.apply(Math.max, null);
+	}, "no arguments list");
+	assert.throws(TypeError, function() {
+		Reflect.apply({}, null, []);
+	}, "not callable");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestReflectObjectOps(t *testing.T) {
+	const SCRIPT = `
+	var sym = Symbol("s");
+	var o = {b: 1, a: 2, 1: 0};
+	o[sym] = 3;
+	assert(compareArray(Reflect.ownKeys(o), ["1", "b", "a", sym]), "ownKeys");
+	assert(Reflect.has(Object.create(o), "a"), "has inherited");
+	assert(!Reflect.has(o, "c"), "has missing");
+	assert.sameValue(Reflect.get(o, "a"), 2, "get");
+	assert.sameValue(Reflect.get({get x() { return this; }}, "x", o), o, "get with a receiver");
+	assert(Reflect.deleteProperty(o, "a"), "deleteProperty");
+	assert(!Reflect.deleteProperty(Object.freeze({x: 1}), "x"), "deleteProperty non-configurable");
+	assert(Reflect.defineProperty(o, "c", {value: 1}), "defineProperty");
+	assert(!Reflect.defineProperty(o, "c", {value: 2}), "defineProperty non-configurable");
+	assert.sameValue(Reflect.getPrototypeOf(Object.create(null)), null, "getPrototypeOf");
+	assert.throws(TypeError, function() {
+		Reflect.getPrototypeOf(1);
+	}, "getPrototypeOf primitive");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
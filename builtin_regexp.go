@@ -280,7 +280,7 @@ func compileRegexp(patternStr, flags string) (p *regexpPattern, err error) {
 }
 
 func (r *Runtime) _newRegExp(patternStr valueString, flags string, proto *Object) *regexpObject {
-	pattern, err := compileRegexpFromValueString(patternStr, flags)
+	pattern, err := r.compileRegexp(patternStr, flags)
 	if err != nil {
 		panic(r.newSyntaxError(err.Error(), -1))
 	}
@@ -378,7 +378,7 @@ func (r *Runtime) regexpproto_compile(call FunctionCall) Value {
 		if flagsVal != _undefined {
 			flags = flagsVal.toString().String()
 		}
-		pattern, err = compileRegexpFromValueString(source, flags)
+		pattern, err = r.compileRegexp(source, flags)
 		if err != nil {
 			panic(r.newSyntaxError(err.Error(), -1))
 		}
@@ -1199,6 +1199,10 @@ func (r *Runtime) regexpproto_stdReplacer(call FunctionCall) Value {
 	} else {
 		rx.updateLastIndex(index, nil, nil)
 	}
+	if rx.pattern.global {
+		// the last (failed) match attempt resets lastIndex
+		rx.setOwnStr("lastIndex", intToValue(0), true)
+	}
 
 	return stringReplace(s, found, replaceStr, rcall)
 }
@@ -26,6 +26,37 @@ func TestSetEvilIterator(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestSetInsertionOrder(t *testing.T) {
+	const SCRIPT = `
+	var s = new Set(["a", "b", "c"]);
+	s.delete("a");
+	s.add("a");
+	s.add("b");
+	assert(compareArray(Array.from(s), ["b", "c", "a"]), "values");
+	assert(compareArray(Array.from(s.keys()), ["b", "c", "a"]), "keys");
+
+	var thisArg = {};
+	var log = [];
+	s.forEach(function(value, key, set) {
+		assert.sameValue(this, thisArg, "thisArg");
+		assert.sameValue(key, value, "key");
+		assert.sameValue(set, s, "set");
+		log.push(value);
+		if (value === "b") {
+			s.delete("c");
+			s.add("c");
+		}
+	}, thisArg);
+	assert(compareArray(log, ["b", "a", "c"]), "forEach visits the re-added value at the end: " + log);
+
+	s.forEach(function() {
+		"use strict";
+		assert.sameValue(this, undefined, "no thisArg");
+	});
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func ExampleRuntime_ExportTo_setToMap() {
 	vm := New()
 	s, err := vm.RunString(`
@@ -127,6 +127,151 @@ var prefix2 = new Prefix("def");
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestStringReplaceAll(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue("a.b.c".replaceAll(".", "-"), "a-b-c", "string");
+	assert.sameValue("aaa".replaceAll("aa", "b"), "ba", "non-overlapping");
+	assert.sameValue("abc".replaceAll("", "_"), "_a_b_c_", "empty search string");
+	assert.sameValue("".replaceAll("", "_"), "_", "empty string");
+	assert.sameValue("xax".replaceAll("x", "[$&$$]"), "[x$]a[x$]", "substitutions");
+	assert.sameValue("ЖaЖ".replaceAll("Ж", "ж"), "жaж", "unicode");
+	var calls = [];
+	assert.sameValue("a1a2".replaceAll("a", function(m, pos, s) { calls.push(pos); return m.toUpperCase(); }), "A1A2", "function");
+	assert(compareArray(calls, [0, 2]), "positions: " + calls);
+
+	assert.sameValue("a1b22c".replaceAll(/\d+/g, "#"), "a#b#c", "global RegExp");
+	assert.sameValue("a-b".replaceAll(/(\w)/g, "<$1>"), "<a>-<b>", "RegExp groups");
+	assert.throws(TypeError, function() { "abc".replaceAll(/b/, "x"); }, "non-global RegExp");
+	assert.sameValue("a+b+".replaceAll("+", "$'"), "ab+b", "no RegExp conversion");
+	assert.sameValue(String.prototype.replaceAll.length, 2, "length");
+
+	var re = /a/g;
+	re.lastIndex = 2;
+	assert.sameValue("aaa".replaceAll(re, "b"), "bbb", "lastIndex is reset");
+	assert.sameValue(re.lastIndex, 0, "lastIndex after");
+
+	var re = /(\d)(\d)?/g;
+	var iter = "a1b22".matchAll(re);
+	assert.sameValue(iter[Symbol.iterator](), iter, "matchAll iterator");
+	var matches = Array.from(iter);
+	assert.sameValue(matches.length, 2, "matchAll count");
+	assert(compareArray(matches[0], ["1", "1", undefined]), "matchAll match 0");
+	assert(compareArray(matches[1], ["22", "2", "2"]), "matchAll match 1");
+	assert.sameValue(matches[1].index, 3, "matchAll index");
+	assert.sameValue(matches[1].input, "a1b22", "matchAll input");
+	assert(matches[1].hasOwnProperty("groups"), "matchAll groups");
+	assert.sameValue(matches[1].groups, undefined, "matchAll groups value");
+	assert.sameValue(re.lastIndex, 0, "matchAll does not change lastIndex");
+	assert.throws(TypeError, function() { "abc".matchAll(/b/); }, "matchAll with a non-global RegExp");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+// The cases below follow test/built-ins/String/prototype/replaceAll of test262.
+func TestStringReplaceAllConformance(t *testing.T) {
+	const SCRIPT = `
+	var desc = Object.getOwnPropertyDescriptor(String.prototype, "replaceAll");
+	assert(desc.writable && !desc.enumerable && desc.configurable, "property descriptor");
+	assert.sameValue(String.prototype.replaceAll.name, "replaceAll", "name");
+	assert.throws(TypeError, function() { new String.prototype.replaceAll("a", "b"); }, "not a constructor");
+
+	assert.throws(TypeError, function() { String.prototype.replaceAll.call(undefined, "a", "b"); }, "this undefined");
+	assert.throws(TypeError, function() { String.prototype.replaceAll.call(null, "a", "b"); }, "this null");
+	assert.sameValue(String.prototype.replaceAll.call(1001, "0", "_"), "1__1", "this is converted to string");
+
+	// searchValue is not converted to string before its Symbol.replace method is looked up
+	var log = [];
+	var searchValue = {
+		get [Symbol.match]() { log.push("isRegExp"); return false; },
+		get [Symbol.replace]() {
+			log.push("replace");
+			return function(str, repl) {
+				log.push("call");
+				assert.sameValue(this, searchValue, "this of Symbol.replace");
+				assert.sameValue(str, "abc", "string argument");
+				assert.sameValue(repl, replaceValue, "replaceValue argument");
+				return "result";
+			};
+		},
+		toString: function() { log.push("toString"); return "b"; }
+	};
+	var replaceValue = {};
+	assert.sameValue("abc".replaceAll(searchValue, replaceValue), "result", "Symbol.replace result");
+	assert(compareArray(log, ["isRegExp", "replace", "call"]), "order: " + log);
+
+	// the flags are only checked for the values that are regexps according to IsRegExp
+	var fakeRe = {
+		[Symbol.match]: true,
+		flags: "",
+		[Symbol.replace]: function() { return "not reached"; }
+	};
+	assert.throws(TypeError, function() { "a".replaceAll(fakeRe, "b"); }, "IsRegExp object without g flag");
+	fakeRe.flags = "g";
+	assert.sameValue("a".replaceAll(fakeRe, "b"), "not reached", "IsRegExp object with g flag");
+	var nullFlags = {[Symbol.match]: true, flags: null};
+	assert.throws(TypeError, function() { "a".replaceAll(nullFlags, "b"); }, "null flags");
+	var re = /a/;
+	Object.defineProperty(re, "flags", {value: "g"});
+	assert.sameValue("aa".replaceAll(re, "b"), "ba", "flags property is checked, not the internal flags");
+
+	// a Symbol.replace method that is undefined or null is ignored
+	var noReplace = {[Symbol.replace]: undefined, toString: function() { return "b"; }};
+	assert.sameValue("abcb".replaceAll(noReplace, "x"), "axcx", "undefined Symbol.replace");
+	noReplace[Symbol.replace] = null;
+	assert.sameValue("abcb".replaceAll(noReplace, "x"), "axcx", "null Symbol.replace");
+	assert.throws(TypeError, function() { "a".replaceAll({[Symbol.replace]: 1}, "b"); }, "non-callable Symbol.replace");
+
+	assert.sameValue("null".replaceAll(null, "x"), "x", "null searchValue");
+	assert.sameValue("undefined".replaceAll(undefined, "x"), "x", "undefined searchValue");
+	assert.sameValue("a1b1".replaceAll(1, 2), "a2b2", "number arguments");
+
+	// the searchValue is converted before the replaceValue
+	log = [];
+	"ab".replaceAll({toString: function() { log.push("search"); return "a"; }},
+		{toString: function() { log.push("replace"); return "x"; }});
+	assert(compareArray(log, ["search", "replace"]), "conversion order: " + log);
+	log = [];
+	"ab".replaceAll({toString: function() { log.push("search"); return "a"; }}, function() { log.push("fn"); return "x"; });
+	assert(compareArray(log, ["search", "fn"]), "function replaceValue is not converted: " + log);
+
+	// replacement patterns
+	assert.sameValue("aba".replaceAll("b", "[$$]"), "a[$]a", "$$");
+	assert.sameValue("aba".replaceAll("b", "[$&]"), "a[b]a", "$&");
+	assert.sameValue("aba".replaceAll("b", "[$` + "`" + `]"), "a[a]a", "$` + "`" + `");
+	assert.sameValue("aba".replaceAll("b", "[$']"), "a[a]a", "$'");
+	assert.sameValue("aba".replaceAll("b", "[$1]"), "a[$1]a", "$1 without captures");
+	assert.sameValue("aba".replaceAll("b", "[$<x>]"), "a[$<x>]a", "$< without named groups");
+	assert.sameValue("aba".replaceAll("b", "[$]"), "a[$]a", "lone $");
+	assert.sameValue("xyxy".replaceAll("x", "$` + "`" + `"), "yxyy", "$` + "`" + ` for every match");
+
+	// the replace function arguments
+	var args = [];
+	"a.b.".replaceAll(".", function() { args.push(Array.prototype.slice.call(arguments)); return ""; });
+	assert.sameValue(args.length, 2, "number of calls");
+	assert(compareArray(args[0], [".", 1, "a.b."]), "arguments 0: " + args[0]);
+	assert(compareArray(args[1], [".", 3, "a.b."]), "arguments 1: " + args[1]);
+	var thisValue;
+	"a".replaceAll("a", function() { "use strict"; thisValue = this; return ""; });
+	assert.sameValue(thisValue, undefined, "this of the replace function");
+	assert.sameValue("aa".replaceAll("a", function() { return 1; }), "11", "result is converted to string");
+
+	// the matches are found before any replacement is done
+	var str = "aaa";
+	var positions = [];
+	assert.sameValue(str.replaceAll("a", function(m, p) { positions.push(p); return "aa"; }), "aaaaaa", "expanding replacement");
+	assert(compareArray(positions, [0, 1, 2]), "positions: " + positions);
+
+	// empty searchValue matches between the UTF-16 code units
+	assert.sameValue("\ud834\udf06".replaceAll("", "-"), "-\ud834-\udf06-", "surrogate pair");
+	assert.sameValue("ab".replaceAll("", "$&|"), "|a|b|", "empty match substitution");
+
+	// global RegExp searchValue
+	assert.sameValue("aaa".replaceAll(/a*?/g, "-"), "-a-a-a-", "empty RegExp matches");
+	assert.sameValue("\ud834\udf06".replaceAll(/(?:)/gu, "-"), "-\ud834\udf06-", "unicode RegExp");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestGenericSplitter(t *testing.T) {
 	const SCRIPT = `
 function MyRegexp(pattern, flags) {
@@ -165,6 +310,52 @@ res.length === 3 && res[0] === "a" && res[1] === "b" && res[2] === "c";
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestStringNormalize(t *testing.T) {
+	const SCRIPT = `
+	var composed = "\u00C5ngstr\u00F6m";
+	var decomposed = "A\u030Angstro\u0308m";
+	assert.sameValue(decomposed.normalize(), composed, "default");
+	assert.sameValue(decomposed.normalize("NFC"), composed, "NFC");
+	assert.sameValue(composed.normalize("NFD"), decomposed, "NFD");
+	assert.sameValue("\uFB01".normalize("NFKC"), "fi", "NFKC");
+	assert.sameValue("\u1E9B\u0323".normalize("NFKD"), "s\u0323\u0307", "NFKD");
+	assert.sameValue("\uFB01".normalize("NFC"), "\uFB01", "NFC keeps compatibility characters");
+	assert.sameValue("ascii".normalize("NFD"), "ascii", "ascii");
+	assert.throws(RangeError, function() {
+		"a".normalize("nfc");
+	});
+	assert.throws(TypeError, function() {
+		String.prototype.normalize.call(null);
+	});
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	s := asciiString("ascii")
+	if res := vm.stringproto_normalize(FunctionCall{This: s}); res != s {
+		t.Fatalf("ASCII string has been copied: %v", res)
+	}
+	u := newStringValue("\u00C5")
+	if res := vm.stringproto_normalize(FunctionCall{This: u}); !res.SameAs(u) {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+}
+
+func TestStringAt(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue("abc".at(0), "a", "0");
+	assert.sameValue("abc".at(-1), "c", "-1");
+	assert.sameValue("abc".at(3), undefined, "out of range");
+	assert.sameValue("abc".at(-4), undefined, "negative out of range");
+	assert.sameValue("abc".at(1.5), "b", "truncated");
+	assert.sameValue("abc".at(), "a", "undefined");
+	assert.sameValue("я😀".at(-1), "\uDE00", "code units");
+	assert.sameValue(String.prototype.at.call(123, -1), "3", "number this");
+	assert.throws(TypeError, () => String.prototype.at.call(null, 0), "null this");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestStringIterSurrPair(t *testing.T) {
 	const SCRIPT = `
 var lo = '\uD834';
@@ -625,6 +625,15 @@ func (r *Runtime) typedArrayProto_includes(call FunctionCall) Value {
 			return valueFalse
 		}
 		if ta.typedArray.typeMatch(searchElement) {
+			if f := searchElement.ToFloat(); f == 0 || math.IsNaN(f) {
+				// zeros and NaNs have more than one raw representation in the float arrays
+				for k := startIdx; k < ta.length; k++ {
+					if searchElement.SameValueZero(ta.typedArray.get(ta.offset + k)) {
+						return valueTrue
+					}
+				}
+				return valueFalse
+			}
 			se := ta.typedArray.toRaw(searchElement)
 			for k := startIdx; k < ta.length; k++ {
 				if ta.typedArray.getRaw(ta.offset+k) == se {
@@ -324,3 +324,48 @@ func TestTypedArrayDeleteUnconfigurable(t *testing.T) {
 
 	testScript(SCRIPT, _undefined, t)
 }
+
+func TestDataViewByteOrder(t *testing.T) {
+	const SCRIPT = `
+	var buf = new ArrayBuffer(8);
+	var bytes = new Uint8Array(buf);
+	var dv = new DataView(buf, 1, 6);
+
+	dv.setInt32(0, -2, true);
+	assert(compareArray(bytes, [0, 0xfe, 0xff, 0xff, 0xff, 0, 0, 0]), "setInt32 little endian");
+	assert.sameValue(dv.getInt32(0, true), -2, "getInt32 little endian");
+	assert.sameValue(dv.getInt32(0), -16777217, "getInt32 big endian");
+	assert.sameValue(dv.getInt32(0, false), -16777217, "getInt32 explicit big endian");
+
+	dv.setUint16(4, 0x1234);
+	assert.sameValue(bytes[5], 0x12, "setUint16 big endian is the default");
+	assert.sameValue(dv.getUint16(4, true), 0x3412, "getUint16 little endian");
+	assert.sameValue(dv.getInt32("2", 1), 0x3412ffff, "offset and flag conversion");
+
+	assert.throws(RangeError, function() { dv.getInt32(3); }, "past the end of the view");
+	assert.throws(RangeError, function() { dv.getInt32(-1); }, "negative offset");
+	assert.throws(RangeError, function() { dv.setFloat64(0, 1); }, "larger than the view");
+	assert.throws(RangeError, function() { new DataView(buf, 9); }, "view offset");
+	assert.throws(RangeError, function() { new DataView(buf, 4, 5); }, "view length");
+	assert.sameValue(bytes[7], 0, "the bytes outside of the view are not modified");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkTypedArrayIdx(b *testing.B) {
+	vm := New()
+	prg := MustCompile("test.js", `
+	(function() {
+		var a = new Int32Array(1024);
+		for (var i = 0; i < a.length; i++) {
+			a[i] = a[(i + 1) & 1023] + i;
+		}
+	})();
+	`, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vm.RunProgram(prg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
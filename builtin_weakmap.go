@@ -1,5 +1,10 @@
 package goja
 
+// weakMap is the identity of a WeakMap or a WeakSet. The entries are not stored in the collection itself, instead
+// each key object holds the values in its weakRefs map, keyed by the weakMap. This way an entry becomes unreachable
+// together with its key, without the need for finalizers (which cannot be used reliably anyway because an *Object
+// is a part of a reference cycle with its implementation). The downside is that the entries outlive
+// the collection, until the key is collected or the entry is deleted.
 type weakMap uint64
 
 type weakMapObject struct {
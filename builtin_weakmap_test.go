@@ -1,7 +1,9 @@
 package goja
 
 import (
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestWeakMap(t *testing.T) {
@@ -36,3 +38,65 @@ func TestWeakMap(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type testWeakMapPayload struct {
+	data [64]byte
+}
+
+func TestWeakMapCollectsEntries(t *testing.T) {
+	// The finalizers cannot be set on the key objects directly because an *Object is a part of a reference cycle,
+	// so the values are Go objects which can only be reached through the WeakMap and WeakSet entries.
+	vm := New()
+	collected := make(chan string, 2)
+	vm.Set("payload", func(name string) *testWeakMapPayload {
+		p := &testWeakMapPayload{}
+		runtime.SetFinalizer(p, func(*testWeakMapPayload) {
+			collected <- name
+		})
+		return p
+	})
+	_, err := vm.RunString(`
+	var m = new WeakMap();
+	var s = new WeakSet();
+	var liveKey = {};
+	m.set(liveKey, payload("live"));
+	(function() {
+		var key = {};
+		m.set(key, payload("value"));
+		s.add(key);
+		if (!m.has(key) || !s.has(key)) {
+			throw new Error("has");
+		}
+	})();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// overwrite the stack slots that may still hold the objects
+	if _, err := vm.RunString("(function(a, b, c, d, e, f, g, h) {})(1, 2, 3, 4, 5, 6, 7, 8)"); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	deadline := time.Now().Add(5 * time.Second)
+	for !seen["value"] && time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case name := <-collected:
+			seen[name] = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !seen["value"] {
+		t.Fatal("Expected the entry to be collected")
+	}
+	if seen["live"] {
+		t.Fatal("The entry for a reachable key has been collected")
+	}
+	v, err := vm.RunString(`m.get(liveKey)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.Export().(*testWeakMapPayload); !ok {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+}
@@ -0,0 +1,169 @@
+package goja
+
+import (
+	"math"
+	"time"
+)
+
+type cloneCtx struct {
+	src, dst *Runtime
+	seen     map[*Object]*Object
+}
+
+// StructuredClone creates a deep copy of the value in another Runtime (which may also be this Runtime) using
+// an algorithm similar to the HTML structured clone. Plain objects, arrays, Maps, Sets, Dates, RegExps,
+// ArrayBuffers, typed arrays and DataViews are copied recursively, the primitive values are copied as is.
+// Only the own enumerable string-keyed properties of objects and arrays are copied (using their getters if any),
+// the prototypes of the copies are the corresponding intrinsic prototypes of the target Runtime.
+// References to the same object (including cycles) are preserved in the copy.
+//
+// If the value contains a function, a Symbol or any other value that cannot be cloned (e.g. a Proxy or a
+// wrapped Go value), a DataCloneError (an Error of this Runtime with the 'name' property set to
+// "DataCloneError") is thrown, i.e. the method panics with it. When called from a native function this results
+// in a JavaScript exception.
+//
+// Both Runtimes must not be running in other goroutines while the value is being cloned.
+func (r *Runtime) StructuredClone(v Value, into *Runtime) Value {
+	c := &cloneCtx{
+		src:  r,
+		dst:  into,
+		seen: make(map[*Object]*Object),
+	}
+	return c.clone(v)
+}
+
+func (c *cloneCtx) clone(v Value) Value {
+	switch v := v.(type) {
+	case *Object:
+		return c.cloneObject(v)
+	case *Symbol:
+		panic(c.dataCloneError("%s could not be cloned", v.descriptiveString()))
+	}
+	return v
+}
+
+func (c *cloneCtx) dataCloneError(format string, args ...interface{}) *Object {
+	e := c.src.newError(c.src.global.Error, format, args...).(*Object)
+	e.self._putProp("name", asciiString("DataCloneError"), true, false, true)
+	return e
+}
+
+func (c *cloneCtx) cloneObject(o *Object) *Object {
+	if dst, exists := c.seen[o]; exists {
+		return dst
+	}
+	dst := c.dst
+	var res *Object
+	switch s := o.self.(type) {
+	case *arrayObject:
+		if s.propValueCount == 0 && s.objCount == len(s.values) && uint32(len(s.values)) == s.length {
+			values := make([]Value, len(s.values))
+			res = dst.newArrayValues(values)
+			c.seen[o] = res
+			for i, v := range s.values {
+				values[i] = c.clone(v)
+			}
+			c.copyProps(o, res, true)
+			return res
+		}
+		res = dst.newArrayLength(int64(s.length))
+	case *sparseArrayObject:
+		res = dst.newArrayLength(int64(s.length))
+	case *dateObject:
+		res = dst.newDateObject(time.Time{}, false, dst.global.DatePrototype)
+		res.self.(*dateObject).msec = s.msec
+		c.seen[o] = res
+		return res
+	case *regexpObject:
+		res = dst._newRegExp(s.source, regexpFlags(s.pattern), dst.global.RegExpPrototype).val
+		c.seen[o] = res
+		return res
+	case *mapObject:
+		res = dst.builtin_newMap(nil, dst.global.Map)
+		c.seen[o] = res
+		m := res.self.(*mapObject).m
+		iter := s.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			m.set(c.clone(entry.key), c.clone(entry.value))
+		}
+		return res
+	case *setObject:
+		res = dst.builtin_newSet(nil, dst.global.Set)
+		c.seen[o] = res
+		m := res.self.(*setObject).m
+		iter := s.m.newIter()
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			m.set(c.clone(entry.key), nil)
+		}
+		return res
+	case *arrayBufferObject:
+		if s.detached {
+			panic(c.dataCloneError("A detached ArrayBuffer could not be cloned"))
+		}
+		buf := dst._newArrayBuffer(dst.global.ArrayBufferPrototype, nil)
+		buf.data = append([]byte(nil), s.data...)
+		res = buf.val
+		c.seen[o] = res
+		return res
+	case *typedArrayObject:
+		buf := c.cloneObject(s.viewedArrayBuf.val)
+		res = dst.typedArrayCreate(c.typedArrayCtor(s), buf, intToValue(int64(s.offset*s.elemSize)), intToValue(int64(s.length))).val
+		c.seen[o] = res
+		return res
+	case *dataViewObject:
+		buf := c.cloneObject(s.viewedArrayBuf.val)
+		res = dst.newDataView([]Value{buf, intToValue(int64(s.byteOffset)), intToValue(int64(s.byteLen))}, dst.global.DataView)
+		c.seen[o] = res
+		return res
+	case *baseObject:
+		if s.class != classObject {
+			panic(c.dataCloneError("#<%s> could not be cloned", s.class))
+		}
+		res = dst.NewObject()
+	default:
+		if _, ok := o.self.assertCallable(); ok {
+			panic(c.dataCloneError("%s could not be cloned", o.String()))
+		}
+		panic(c.dataCloneError("#<%s> could not be cloned", o.self.className()))
+	}
+	c.seen[o] = res
+	c.copyProps(o, res, false)
+	return res
+}
+
+// copyProps copies the own enumerable string-keyed properties. If skipIndexes is true the array index properties
+// are skipped.
+func (c *cloneCtx) copyProps(src, dst *Object, skipIndexes bool) {
+	for _, key := range src.self.stringKeys(false, nil) {
+		name := key.string()
+		if skipIndexes && strToArrayIdx(name) != math.MaxUint32 {
+			continue
+		}
+		createDataPropertyOrThrow(dst, key, c.clone(nilSafe(src.self.getStr(name, nil))))
+	}
+}
+
+func (c *cloneCtx) typedArrayCtor(ta *typedArrayObject) *Object {
+	g := &c.dst.global
+	switch ta.typedArray.(type) {
+	case *uint8Array:
+		return g.Uint8Array
+	case *uint8ClampedArray:
+		return g.Uint8ClampedArray
+	case *int8Array:
+		return g.Int8Array
+	case *uint16Array:
+		return g.Uint16Array
+	case *int16Array:
+		return g.Int16Array
+	case *uint32Array:
+		return g.Uint32Array
+	case *int32Array:
+		return g.Int32Array
+	case *float32Array:
+		return g.Float32Array
+	case *float64Array:
+		return g.Float64Array
+	}
+	panic(c.dataCloneError("#<%s> could not be cloned", ta.className()))
+}
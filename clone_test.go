@@ -0,0 +1,114 @@
+package goja
+
+import "testing"
+
+func TestStructuredClone(t *testing.T) {
+	src := New()
+	v, err := src.RunString(`
+	var buf = new ArrayBuffer(8);
+	var o = {
+		a: [1, "str", true, null, undefined, 2.5, 10n],
+		holes: [1, , 3],
+		date: new Date(1234567890),
+		re: /a+b/gi,
+		map: new Map([["k", {x: 1}], [1, 2]]),
+		set: new Set([1, "a"]),
+		u8: new Uint8Array(buf, 2, 4),
+		f64: new Float64Array([0.5]),
+		dv: new DataView(buf, 1),
+		get getter() { return "got"; },
+	};
+	Object.defineProperty(o, "hidden", {value: 1, enumerable: false});
+	o.a.extra = "extra";
+	o.self = o;
+	o.shared = o.map.get("k");
+	new Uint8Array(buf).set([1, 2, 3, 4, 5, 6, 7, 8]);
+	o;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := New()
+	dst.Set("o", src.StructuredClone(v, dst))
+	_, err = dst.RunProgram(testLib())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dst.RunString(`
+	assert.sameValue(Object.getPrototypeOf(o), Object.prototype, "proto");
+	assert.sameValue(o.self, o, "cycle");
+	assert.sameValue(o.shared, o.map.get("k"), "shared reference");
+	assert.sameValue(o.getter, "got", "getter value");
+	assert.sameValue(Object.getOwnPropertyDescriptor(o, "getter").value, "got", "getter is copied as a value");
+	assert.sameValue(o.hasOwnProperty("hidden"), false, "non-enumerable");
+
+	assert(Array.isArray(o.a), "array");
+	assert(compareArray(o.a, [1, "str", true, null, undefined, 2.5, 10n]), "array values");
+	assert.sameValue(o.a.extra, "extra", "array extra property");
+	assert.sameValue(o.holes.length, 3, "holes length");
+	assert.sameValue(1 in o.holes, false, "hole");
+	assert.sameValue(o.holes[2], 3, "holes value");
+
+	assert(o.date instanceof Date, "Date");
+	assert.sameValue(o.date.getTime(), 1234567890, "Date value");
+	assert(o.re instanceof RegExp, "RegExp");
+	assert.sameValue(o.re.toString(), "/a+b/gi", "RegExp value");
+	assert(o.map instanceof Map, "Map");
+	assert(compareArray(Array.from(o.map.keys()), ["k", 1]), "Map keys");
+	assert.sameValue(o.map.get("k").x, 1, "Map value");
+	assert(o.set instanceof Set, "Set");
+	assert(compareArray(Array.from(o.set), [1, "a"]), "Set values");
+
+	assert(o.u8 instanceof Uint8Array, "Uint8Array");
+	assert(compareArray(Array.from(o.u8), [3, 4, 5, 6]), "Uint8Array values");
+	assert.sameValue(o.u8.buffer, o.dv.buffer, "shared ArrayBuffer");
+	assert(o.u8.buffer instanceof ArrayBuffer, "ArrayBuffer");
+	assert.sameValue(o.u8.buffer.byteLength, 8, "ArrayBuffer length");
+	assert(o.dv instanceof DataView, "DataView");
+	assert.sameValue(o.dv.byteOffset, 1, "DataView offset");
+	assert.sameValue(o.dv.getUint8(0), 2, "DataView value");
+	assert.sameValue(o.f64[0], 0.5, "Float64Array");
+
+	o.u8[0] = 100;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := v.(*Object).Get("u8").(*Object).Get("0"); v.ToInteger() != 3 {
+		t.Fatalf("The buffer has not been copied: %v", v)
+	}
+}
+
+func TestStructuredCloneErrors(t *testing.T) {
+	src := New()
+	dst := New()
+	src.Set("clone", func(call FunctionCall) Value {
+		return src.StructuredClone(call.Argument(0), dst)
+	})
+	_, err := src.RunProgram(testLib())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = src.RunString(`
+	[
+		function f() {},
+		{nested: [() => 1]},
+		Symbol("s"),
+		new Proxy({}, {}),
+		new WeakMap(),
+		Promise.resolve(),
+	].forEach(function(v, i) {
+		try {
+			clone(v);
+			throw new Test262Error("not thrown: " + i);
+		} catch (e) {
+			assert(e instanceof Error, "Error " + i);
+			assert.sameValue(e.name, "DataCloneError", "name " + i);
+		}
+	});
+	assert.sameValue(clone(42), 42, "primitive");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -70,6 +70,8 @@ type Program struct {
 	funcName unistring.String
 	src      *file.File
 	srcMap   []srcMapItem
+
+	sandboxGlobals bool
 }
 
 type compiler struct {
@@ -85,6 +87,10 @@ type compiler struct {
 	ctxVM  *vm // VM in which an eval() code is compiled
 
 	codeScratchpad []instruction
+
+	// compiling for a debugger: every statement is preceded by a debugStmt and the bindings visible from it
+	// are kept in stashes by name (as if there was a direct eval()), so that they can be inspected.
+	debug bool
 }
 
 type binding struct {
@@ -287,6 +293,8 @@ type scope struct {
 
 	// function type. If not funcNone, this is a function or a top-level lexical environment
 	funcType funcType
+	// the function is a generator, i.e. its frame can be suspended
+	generator bool
 
 	// in strict mode
 	strict bool
@@ -316,6 +324,7 @@ type block struct {
 	outer      *block
 	breaking   *block // set when the 'finally' block is an empty break statement sequence
 	needResult bool
+	async      bool // set for a for-await-of loop, its iterator is closed by emitEnumPopClose()
 }
 
 func (c *compiler) leaveScopeBlock(enter *enterBlock) {
@@ -488,6 +497,9 @@ func (s *scope) lookupName(name unistring.String) (binding *binding, noDynamics
 			}
 			curScope.argsNeeded = true
 			binding, _ = curScope.bindName(name)
+			if toStash && !binding.inStash {
+				binding.moveToStash()
+			}
 			return
 		}
 		if curScope.isFunction() {
@@ -804,6 +816,16 @@ func (s *scope) finaliseVarAlloc(stackOffset int) (stashSize, stackSize int) {
 	return stashIdx, stackIdx
 }
 
+// stashPoolable returns true if the stash of the scope may be returned to the pool when the scope is left,
+// i.e. there is no direct eval() which can reach it and it is not aliased by a mapped arguments object.
+// Whether it's captured by a closure is only known at run time.
+func (s *scope) stashPoolable() bool {
+	if s.isDynamic() {
+		return false
+	}
+	return !(s.argsInStash && s.argsNeeded && !s.strict)
+}
+
 func (s *scope) moveArgsToStash() {
 	for _, b := range s.bindings {
 		if !b.isArg {
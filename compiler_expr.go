@@ -1,6 +1,8 @@
 package goja
 
 import (
+	"math/big"
+
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/token"
@@ -28,6 +30,8 @@ type compiledCallExpr struct {
 	callee compiledExpr
 
 	isVariadic bool
+	// the call is in a tail position, see compiler.isTailPosition()
+	tail bool
 }
 
 type compiledNewExpr struct {
@@ -137,6 +141,8 @@ type compiledFunctionLiteral struct {
 	homeObjOffset   uint32
 	typ             funcType
 	isExpr          bool
+	isGenerator     bool
+	isAsync         bool
 }
 
 type compiledBracketExpr struct {
@@ -213,6 +219,22 @@ type compiledOptionalChain struct {
 	expr compiledExpr
 }
 
+type deleteOptChainExpr struct {
+	baseCompiledExpr
+	expr compiledExpr
+}
+
+type compiledYieldExpr struct {
+	baseCompiledExpr
+	arg      compiledExpr
+	delegate bool
+}
+
+type compiledAwaitExpr struct {
+	baseCompiledExpr
+	arg compiledExpr
+}
+
 type compiledOptional struct {
 	baseCompiledExpr
 	expr compiledExpr
@@ -303,6 +325,19 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		}
 		r.init(c, v.Idx0())
 		return r
+	case *ast.YieldExpression:
+		r := &compiledYieldExpr{
+			arg:      c.compileExpression(v.Argument),
+			delegate: v.Delegate,
+		}
+		r.init(c, v.Idx0())
+		return r
+	case *ast.AwaitExpression:
+		r := &compiledAwaitExpr{
+			arg: c.compileExpression(v.Argument),
+		}
+		r.init(c, v.Idx0())
+		return r
 	default:
 		c.assert(false, int(v.Idx0())-1, "Unknown expression type: %T", v)
 		panic("unreachable")
@@ -811,7 +846,7 @@ func (e *compiledSuperBracketExpr) emitUnary(prepare, body func(), postfix, putO
 	if !putOnStack {
 		e.c.emitLoadThis()
 		e.member.emitGetter(true)
-		e.c.emit(loadSuper, dupLast(3), getElemRecv)
+		e.c.emit(_toPropertyKey{}, loadSuper, dupLast(3), getElemRecv)
 		body()
 		e.addSrcMap()
 		if e.c.scope.strict {
@@ -823,7 +858,7 @@ func (e *compiledSuperBracketExpr) emitUnary(prepare, body func(), postfix, putO
 		if !postfix {
 			e.c.emitLoadThis()
 			e.member.emitGetter(true)
-			e.c.emit(loadSuper, dupLast(3), getElemRecv)
+			e.c.emit(_toPropertyKey{}, loadSuper, dupLast(3), getElemRecv)
 			if prepare != nil {
 				prepare()
 			}
@@ -838,7 +873,7 @@ func (e *compiledSuperBracketExpr) emitUnary(prepare, body func(), postfix, putO
 			e.c.emit(loadUndef)
 			e.c.emitLoadThis()
 			e.member.emitGetter(true)
-			e.c.emit(loadSuper, dupLast(3), getElemRecv)
+			e.c.emit(_toPropertyKey{}, loadSuper, dupLast(3), getElemRecv)
 			if prepare != nil {
 				prepare()
 			}
@@ -1060,7 +1095,7 @@ func (e *compiledBracketExpr) emitUnary(prepare, body func(), postfix, putOnStac
 	if !putOnStack {
 		e.left.emitGetter(true)
 		e.member.emitGetter(true)
-		e.c.emit(dupLast(2), getElem)
+		e.c.emit(_toPropertyKey{}, dupLast(2), getElem)
 		body()
 		e.addSrcMap()
 		if e.c.scope.strict {
@@ -1072,7 +1107,7 @@ func (e *compiledBracketExpr) emitUnary(prepare, body func(), postfix, putOnStac
 		if !postfix {
 			e.left.emitGetter(true)
 			e.member.emitGetter(true)
-			e.c.emit(dupLast(2), getElem)
+			e.c.emit(_toPropertyKey{}, dupLast(2), getElem)
 			if prepare != nil {
 				prepare()
 			}
@@ -1087,7 +1122,7 @@ func (e *compiledBracketExpr) emitUnary(prepare, body func(), postfix, putOnStac
 			e.c.emit(loadUndef)
 			e.left.emitGetter(true)
 			e.member.emitGetter(true)
-			e.c.emit(dupLast(2), getElem)
+			e.c.emit(_toPropertyKey{}, dupLast(2), getElem)
 			if prepare != nil {
 				prepare()
 			}
@@ -1226,12 +1261,61 @@ func (e *compiledAssignExpr) emitGetter(putOnStack bool) {
 			e.right.emitGetter(true)
 			e.c.emit(shr)
 		}, false, putOnStack)
+	case token.LOGICAL_AND:
+		e.emitLogicalAssign(func(offset int) instruction { return jneq1(offset) }, putOnStack)
+	case token.LOGICAL_OR:
+		e.emitLogicalAssign(func(offset int) instruction { return jeq1(offset) }, putOnStack)
+	case token.COALESCE:
+		e.emitLogicalAssign(func(offset int) instruction { return jcoalesc(offset) }, putOnStack)
 	default:
 		e.c.assert(false, e.offset, "Unknown assign operator: %s", e.operator.String())
 		panic("unreachable")
 	}
 }
 
+// emitLogicalAssign emits &&=, ||= or ??=. The reference is resolved once, the right-hand side is only evaluated
+// and the value is only stored if the current value does not short-circuit the operator. shortCircuit creates
+// the jump which keeps the current value on the stack and jumps if it short-circuits, or pops it otherwise.
+func (e *compiledAssignExpr) emitLogicalAssign(shortCircuit func(offset int) instruction, putOnStack bool) {
+	var j int
+	e.left.emitUnary(nil, func() {
+		j = len(e.c.p.code)
+		e.c.emit(nil)
+		if id, ok := e.left.(*compiledIdentifierExpr); ok {
+			e.c.emitNamedOrConst(e.right, id.name)
+		} else {
+			e.c.emitExpr(e.right, true)
+		}
+	}, false, true)
+	end := len(e.c.p.code)
+	e.c.emit(nil)
+	e.c.p.code[j] = shortCircuit(len(e.c.p.code) - j)
+
+	// The current value is on top of the values emitUnary() keeps below it for the store, drop those.
+	var n int
+	switch left := e.left.(type) {
+	case *compiledIdentifierExpr:
+		n = 1
+		if _, noDynamics := e.c.scope.lookupName(left.name); !noDynamics {
+			e.c.emit(popRef)
+		}
+	case *compiledDotExpr, *compiledPrivateDotExpr:
+		n = 1
+	case *compiledBracketExpr, *compiledSuperDotExpr:
+		n = 2
+	case *compiledSuperBracketExpr:
+		n = 3
+	}
+	e.c.emit(rdupN(n))
+	for i := 0; i < n; i++ {
+		e.c.emit(pop)
+	}
+	e.c.p.code[end] = jump(len(e.c.p.code) - end)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
 func (e *compiledLiteral) emitGetter(putOnStack bool) {
 	if putOnStack {
 		e.c.emit(loadVal(e.c.p.defineLiteralValue(e.val)))
@@ -1242,6 +1326,18 @@ func (e *compiledLiteral) constant() bool {
 	return true
 }
 
+func (e *compiledTemplateLiteral) constant() bool {
+	if e.tag != nil {
+		return false
+	}
+	for _, expr := range e.expressions {
+		if !expr.constant() {
+			return false
+		}
+	}
+	return true
+}
+
 func (e *compiledTemplateLiteral) emitGetter(putOnStack bool) {
 	if e.tag == nil {
 		if len(e.elements) == 0 {
@@ -1256,7 +1352,7 @@ func (e *compiledTemplateLiteral) emitGetter(putOnStack bool) {
 					e.c.emit(loadVal(e.c.p.defineLiteralValue(stringValueFromRaw(head))))
 					stringCount++
 				}
-				e.expressions[0].emitGetter(true)
+				e.c.emitExpr(e.expressions[0], true)
 				e.c.emit(_toString{})
 				stringCount++
 				for i := 1; i < len(e.elements)-1; i++ {
@@ -1264,7 +1360,7 @@ func (e *compiledTemplateLiteral) emitGetter(putOnStack bool) {
 						e.c.emit(loadVal(e.c.p.defineLiteralValue(stringValueFromRaw(elt))))
 						stringCount++
 					}
-					e.expressions[i].emitGetter(true)
+					e.c.emitExpr(e.expressions[i], true)
 					e.c.emit(_toString{})
 					stringCount++
 				}
@@ -1352,6 +1448,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 	e.c.newScope()
 	s := e.c.scope
 	s.funcType = e.typ
+	s.generator = e.isGenerator || e.isAsync
 
 	if e.name != nil {
 		name = e.name.Name
@@ -1482,11 +1579,14 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				}
 				if firstForwardRef == -1 {
 					s.bindings[i].emitGetAt(markGet)
+					s.bindings[i].emitInitP()
+					e.c.p.code[mark] = jdefP(len(e.c.p.code) - mark)
 				} else {
+					// the argument is not in its place yet, it needs to be initialised even if it's defined
 					e.c.p.code[markGet] = loadStackLex(-i - 1)
+					e.c.p.code[mark] = jdef(len(e.c.p.code) - mark)
+					s.bindings[i].emitInitP()
 				}
-				s.bindings[i].emitInitP()
-				e.c.p.code[mark] = jdefP(len(e.c.p.code) - mark)
 			} else {
 				if firstForwardRef == -1 && s.bindings[i].useCount() > 0 {
 					firstForwardRef = i
@@ -1553,6 +1653,9 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 	}
 
 	e.c.compileFunctions(funcs)
+	if e.isGenerator || e.isAsync {
+		e.c.emit(initGenerator)
+	}
 	e.c.compileStatements(body, false)
 
 	var last ast.Statement
@@ -1581,7 +1684,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 			e.c.throwSyntaxError(e.offset, "'arguments' is not allowed in class field initializer or static initialization block")
 		}
 		b, created := s.bindNameLexical("arguments", false, 0)
-		if created || b.isVar {
+		if created || b.isVar && !b.isArg {
 			if !s.argsInStash {
 				s.moveArgsToStash()
 			}
@@ -1649,6 +1752,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				stackSize:   uint32(stackSize),
 				extensible:  s.dynamic,
 				funcType:    e.typ,
+				pooled:      s.stashPoolable(),
 			}
 			if s.isDynamic() {
 				enter1.names = s.makeNamesMap()
@@ -1657,6 +1761,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 			if enterFunc2Mark != -1 {
 				ef2 := &enterFuncBody{
 					extensible: e.c.scope.dynamic,
+					dynamic:    e.c.scope.isDynamic(),
 					funcType:   e.typ,
 				}
 				e.c.updateEnterBlock(&ef2.enterBlock)
@@ -1669,6 +1774,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				argsToCopy: uint32(firstForwardRef),
 				extensible: s.dynamic,
 				funcType:   e.typ,
+				pooled:     s.stashPoolable(),
 			}
 			if s.isDynamic() {
 				enter1.names = s.makeNamesMap()
@@ -1678,6 +1784,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 				ef2 := &enterFuncBody{
 					adjustStack: true,
 					extensible:  e.c.scope.dynamic,
+					dynamic:     e.c.scope.isDynamic(),
 					funcType:    e.typ,
 				}
 				e.c.updateEnterBlock(&ef2.enterBlock)
@@ -1695,6 +1802,7 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 		if enterFunc2Mark != -1 {
 			ef2 := &enterFuncBody{
 				extensible: e.c.scope.dynamic,
+				dynamic:    e.c.scope.isDynamic(),
 				funcType:   e.typ,
 			}
 			e.c.updateEnterBlock(&ef2.enterBlock)
@@ -1720,10 +1828,30 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 	p, name, length, strict := e.compile()
 	switch e.typ {
 	case funcArrow:
+		if e.isAsync {
+			e.c.emit(&newAsyncArrowFunc{newArrowFunc: newArrowFunc{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}}})
+			break
+		}
 		e.c.emit(&newArrowFunc{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}})
 	case funcMethod, funcClsInit:
+		if e.isAsync {
+			e.c.emit(&newAsyncFunc{newMethod: newMethod{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}, homeObjOffset: e.homeObjOffset}})
+			break
+		}
+		if e.isGenerator {
+			e.c.emit(&newGeneratorFunc{newMethod: newMethod{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}, homeObjOffset: e.homeObjOffset}})
+			break
+		}
 		e.c.emit(&newMethod{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}, homeObjOffset: e.homeObjOffset})
 	case funcRegular:
+		if e.isAsync {
+			e.c.emit(&newAsyncFunc{newMethod: newMethod{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}}})
+			break
+		}
+		if e.isGenerator {
+			e.c.emit(&newGeneratorFunc{newMethod: newMethod{newFunc: newFunc{prg: p, length: length, name: name, source: e.source, strict: strict}}})
+			break
+		}
 		e.c.emit(&newFunc{prg: p, length: length, name: name, source: e.source, strict: strict})
 	default:
 		e.c.throwSyntaxError(e.offset, "Unsupported func type: %v", e.typ)
@@ -1745,6 +1873,8 @@ func (c *compiler) compileFunctionLiteral(v *ast.FunctionLiteral, isExpr bool) *
 		source:          v.Source,
 		declarationList: v.DeclarationList,
 		isExpr:          isExpr,
+		isGenerator:     v.Generator,
+		isAsync:         v.Async,
 		typ:             funcRegular,
 		strict:          strictBody,
 	}
@@ -2107,7 +2237,7 @@ func (e *compiledClassLiteral) emitGetter(putOnStack bool) {
 			ise.privateMethods = env.methods
 		}
 	} else {
-		e.c.emit(endVariadic) // re-using as semantics match
+		e.c.emit(rdupN(1), pop)
 	}
 
 	if !putOnStack {
@@ -2259,6 +2389,7 @@ func (c *compiler) compileArrowFunctionLiteral(v *ast.ArrowFunctionLiteral) *com
 		source:          v.Source,
 		declarationList: v.DeclarationList,
 		isExpr:          true,
+		isAsync:         v.Async,
 		typ:             funcArrow,
 		strict:          strictBody,
 	}
@@ -2287,6 +2418,37 @@ func (e *compiledThisExpr) emitGetter(putOnStack bool) {
 	}
 }
 
+// An await suspends the frame of the async function in the same way as yield does, the value is then awaited
+// by the caller which resumes the frame once it has settled (see Runtime.asyncStep()).
+func (e *compiledAwaitExpr) emitGetter(putOnStack bool) {
+	if s := e.c.scope.nearestFunction(); s == nil || !s.generator {
+		e.c.throwSyntaxError(e.offset, "await is only valid in async functions")
+	}
+	e.arg.emitGetter(true)
+	e.addSrcMap()
+	e.c.emit(yield)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledYieldExpr) emitGetter(putOnStack bool) {
+	if e.arg != nil {
+		e.arg.emitGetter(true)
+	} else {
+		e.c.emit(loadUndef)
+	}
+	e.addSrcMap()
+	if e.delegate {
+		e.c.emit(yieldDelegateStart, yieldDelegate)
+	} else {
+		e.c.emit(yield)
+	}
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
 func (e *compiledSuperExpr) emitGetter(putOnStack bool) {
 	if putOnStack {
 		e.c.emit(loadSuper)
@@ -2299,7 +2461,7 @@ func (e *compiledNewExpr) emitGetter(putOnStack bool) {
 	}
 	e.callee.emitGetter(true)
 	for _, expr := range e.args {
-		expr.emitGetter(true)
+		e.c.emitExpr(expr, true)
 	}
 	e.addSrcMap()
 	if e.isVariadic {
@@ -2387,7 +2549,7 @@ func (c *compiler) emitThrow(v Value) {
 	if o, ok := v.(*Object); ok {
 		t := nilSafe(o.self.getStr("name", nil)).toString().String()
 		switch t {
-		case "TypeError":
+		case "TypeError", "RangeError":
 			c.emit(loadDynamic(t))
 			msg := o.self.getStr("message", nil)
 			if msg != nil {
@@ -2530,14 +2692,14 @@ func (c *compiler) compileUnaryExpression(v *ast.UnaryExpression) compiledExpr {
 }
 
 func (e *compiledConditionalExpr) emitGetter(putOnStack bool) {
-	e.test.emitGetter(true)
+	e.c.emitExpr(e.test, true)
 	j := len(e.c.p.code)
 	e.c.emit(nil)
-	e.consequent.emitGetter(putOnStack)
+	e.c.emitExpr(e.consequent, putOnStack)
 	j1 := len(e.c.p.code)
 	e.c.emit(nil)
 	e.c.p.code[j] = jne(len(e.c.p.code) - j)
-	e.alternate.emitGetter(putOnStack)
+	e.c.emitExpr(e.alternate, putOnStack)
 	e.c.p.code[j1] = jump(len(e.c.p.code) - j1)
 }
 
@@ -3035,7 +3197,7 @@ func (e *compiledCallExpr) emitGetter(putOnStack bool) {
 	calleeName := e.c.emitCallee(e.callee)
 
 	for _, expr := range e.args {
-		expr.emitGetter(true)
+		e.c.emitExpr(expr, true)
 	}
 
 	e.addSrcMap()
@@ -3081,6 +3243,8 @@ func (e *compiledCallExpr) emitGetter(putOnStack bool) {
 	} else {
 		if e.isVariadic {
 			e.c.emit(callVariadic)
+		} else if e.tail {
+			e.c.emit(tailCall(len(e.args)))
 		} else {
 			e.c.emit(call(len(e.args)))
 		}
@@ -3168,6 +3332,8 @@ func (c *compiler) compileNumberLiteral(v *ast.NumberLiteral) compiledExpr {
 		val = intToValue(num)
 	case float64:
 		val = floatToValue(num)
+	case *big.Int:
+		val = newBigInt(num)
 	default:
 		c.assert(false, int(v.Idx)-1, "Unsupported number literal type: %T", v.Value)
 		panic("unreachable")
@@ -3511,6 +3677,28 @@ func (e *compiledOptionalChain) emitGetter(putOnStack bool) {
 	}
 }
 
+func (e *compiledOptionalChain) deleteExpr() compiledExpr {
+	r := &deleteOptChainExpr{
+		expr: e.expr.deleteExpr(),
+	}
+	r.init(e.c, file.Idx(e.offset+1))
+	return r
+}
+
+func (e *deleteOptChainExpr) emitGetter(putOnStack bool) {
+	e.c.startOptChain()
+	e.expr.emitGetter(true)
+	j := len(e.c.p.code)
+	e.c.emit(nil)
+	e.c.endOptChain()
+	// short-circuited, the result is true
+	e.c.emit(pop, loadVal(e.c.p.defineLiteralValue(valueTrue)))
+	e.c.p.code[j] = jump(len(e.c.p.code) - j)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
 func (e *compiledOptional) emitGetter(putOnStack bool) {
 	e.expr.emitGetter(putOnStack)
 	if putOnStack {
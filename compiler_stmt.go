@@ -8,6 +8,17 @@ import (
 )
 
 func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
+	if c.debug {
+		switch v.(type) {
+		case *ast.BlockStatement, *ast.EmptyStatement, *ast.FunctionDeclaration, *ast.DebuggerStatement:
+		default:
+			for sc := c.scope; sc != nil; sc = sc.outer {
+				sc.dynLookup = true
+			}
+			c.p.addSrcMap(int(v.Idx0()) - 1)
+			c.emit(debugStmt)
+		}
+	}
 
 	switch v := v.(type) {
 	case *ast.BlockStatement:
@@ -52,6 +63,8 @@ func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
 	case *ast.WithStatement:
 		c.compileWithStatement(v, needResult)
 	case *ast.DebuggerStatement:
+		c.p.addSrcMap(int(v.Idx0()) - 1)
+		c.emit(debugger)
 	default:
 		c.assert(false, int(v.Idx0())-1, "Unknown statement type: %T", v)
 		panic("unreachable")
@@ -100,6 +113,7 @@ func (c *compiler) updateEnterBlock(enter *enterBlock) {
 		}
 	}
 	enter.stashSize, enter.stackSize = uint32(stashSize), uint32(stackSize)
+	enter.pooled = scope.stashPoolable()
 }
 
 func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
@@ -127,11 +141,10 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 		c.emit(clearResult)
 	}
 	c.compileBlockStatement(v.Body, bodyNeedResult)
-	c.emit(halt)
-	lbl2 := len(c.p.code)
-	c.emit(nil)
 	var catchOffset int
 	if v.Catch != nil {
+		lbl2 := len(c.p.code) // jump over the catch block
+		c.emit(nil)
 		catchOffset = len(c.p.code) - lbl
 		if v.Catch.Parameter != nil {
 			c.block = &block{
@@ -174,6 +187,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 					names:     enter.names,
 					stashSize: enter.stashSize,
 					stackSize: enter.stackSize,
+					pooled:    enter.pooled,
 				}
 			} else {
 				enter.stackSize--
@@ -183,23 +197,21 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement, needResult bool) {
 			c.emit(pop)
 			c.compileBlockStatement(v.Catch.Body, bodyNeedResult)
 		}
-		c.emit(halt)
+		c.p.code[lbl2] = jump(len(c.p.code) - lbl2)
 	}
 	var finallyOffset int
 	if v.Finally != nil {
-		lbl1 := len(c.p.code)
-		c.emit(nil)
 		finallyOffset = len(c.p.code) - lbl
+		c.emit(enterFinally)
 		if bodyNeedResult && finallyBreaking != nil && lp == -1 {
 			c.emit(clearResult)
 		}
 		c.compileBlockStatement(v.Finally, false)
-		c.emit(halt, retFinally)
-
-		c.p.code[lbl1] = jump(len(c.p.code) - lbl1)
+		c.emit(leaveFinally)
+	} else {
+		c.emit(leaveTry)
 	}
 	c.p.code[lbl] = try{catchOffset: int32(catchOffset), finallyOffset: int32(finallyOffset)}
-	c.p.code[lbl2] = jump(len(c.p.code) - lbl2)
 	c.leaveBlock()
 }
 
@@ -208,7 +220,7 @@ func (c *compiler) addSrcMap(node ast.Node) {
 }
 
 func (c *compiler) compileThrowStatement(v *ast.ThrowStatement) {
-	c.compileExpression(v.Argument).emitGetter(true)
+	c.emitExpr(c.compileExpression(v.Argument), true)
 	c.addSrcMap(v)
 	c.emit(throw)
 }
@@ -375,9 +387,10 @@ func (c *compiler) compileForInto(into ast.ForInto, needResult bool) (enter *ent
 			c.throwSyntaxError(int(target.Idx0()-1), "unsupported for-in var target: %T", target)
 		}
 	case *ast.ForDeclaration:
-
+		// Unlike the one of a for(let...) loop, this scope is entered anew for each iteration, so it has to be
+		// left by continue as well.
 		c.block = &block{
-			typ:        blockIterScope,
+			typ:        blockScope,
 			outer:      c.block,
 			needResult: needResult,
 		}
@@ -407,12 +420,13 @@ func (c *compiler) compileForInto(into ast.ForInto, needResult bool) (enter *ent
 	return
 }
 
-func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.Expression, body ast.Statement, iter, needResult bool, label unistring.String) {
+func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.Expression, body ast.Statement, iter, async, needResult bool, label unistring.String) {
 	c.block = &block{
 		typ:        blockLoopEnum,
 		outer:      c.block,
 		label:      label,
 		needResult: needResult,
+		async:      async,
 	}
 	enterPos := -1
 	if forDecl, ok := into.(*ast.ForDeclaration); ok {
@@ -452,7 +466,9 @@ func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.E
 		}
 		c.popScope()
 	}
-	if iter {
+	if async {
+		c.emit(iterateAsyncP)
+	} else if iter {
 		c.emit(iterateP)
 	} else {
 		c.emit(enumerate)
@@ -463,6 +479,10 @@ func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.E
 	start := len(c.p.code)
 	c.block.cont = start
 	c.emit(nil)
+	if async {
+		// await the result of next()
+		c.emit(yield, nil)
+	}
 	enterIterBlock := c.compileForInto(into, needResult)
 	if needResult {
 		c.emit(clearResult)
@@ -473,18 +493,35 @@ func (c *compiler) compileLabeledForInOfStatement(into ast.ForInto, source ast.E
 		c.popScope()
 	}
 	c.emit(jump(start - len(c.p.code)))
-	if iter {
-		c.p.code[start] = iterNext(len(c.p.code) - start)
+	if async {
+		c.p.code[start] = asyncIterNext(len(c.p.code) - start)
+		c.p.code[start+2] = asyncIterResult(len(c.p.code) - start - 2)
+		c.emit(enumPop, jump(4))
 	} else {
-		c.p.code[start] = enumNext(len(c.p.code) - start)
+		if iter {
+			c.p.code[start] = iterNext(len(c.p.code) - start)
+		} else {
+			c.p.code[start] = enumNext(len(c.p.code) - start)
+		}
+		c.emit(enumPop, jump(2))
 	}
-	c.emit(enumPop, jump(2))
+	b := c.block
 	c.leaveBlock()
-	c.emit(enumPopClose)
+	c.emitEnumPopClose(b)
+}
+
+// emitEnumPopClose emits the code that leaves the for-in/of loop block b, closing its iterator. For a for-await-of
+// loop the result of the iterator's return() is awaited.
+func (c *compiler) emitEnumPopClose(b *block) {
+	if b.async {
+		c.emit(asyncIterClose(3), yield, asyncIterCloseResult)
+	} else {
+		c.emit(enumPopClose)
+	}
 }
 
 func (c *compiler) compileLabeledForInStatement(v *ast.ForInStatement, needResult bool, label unistring.String) {
-	c.compileLabeledForInOfStatement(v.Into, v.Source, v.Body, false, needResult, label)
+	c.compileLabeledForInOfStatement(v.Into, v.Source, v.Body, false, false, needResult, label)
 }
 
 func (c *compiler) compileForOfStatement(v *ast.ForOfStatement, needResult bool) {
@@ -492,7 +529,7 @@ func (c *compiler) compileForOfStatement(v *ast.ForOfStatement, needResult bool)
 }
 
 func (c *compiler) compileLabeledForOfStatement(v *ast.ForOfStatement, needResult bool, label unistring.String) {
-	c.compileLabeledForInOfStatement(v.Into, v.Source, v.Body, true, needResult, label)
+	c.compileLabeledForInOfStatement(v.Into, v.Source, v.Body, true, v.Await, needResult, label)
 }
 
 func (c *compiler) compileWhileStatement(v *ast.WhileStatement, needResult bool) {
@@ -636,11 +673,11 @@ L:
 			b.breaks = append(b.breaks, len(c.p.code))
 			c.emit(nil)
 		case blockTry:
-			c.emit(halt)
+			c.emit(leaveTry)
 		case blockWith:
 			c.emit(leaveWith)
 		case blockLoopEnum:
-			c.emit(enumPopClose)
+			c.emitEnumPopClose(b)
 		}
 	}
 	return block
@@ -732,16 +769,20 @@ func (c *compiler) compileReturnStatement(v *ast.ReturnStatement) {
 		c.throwSyntaxError(int(v.Return)-1, "Illegal return statement")
 	}
 	if v.Argument != nil {
-		c.emitExpr(c.compileExpression(v.Argument), true)
+		expr := c.compileExpression(v.Argument)
+		if c.isTailPosition() {
+			markTailCalls(expr)
+		}
+		c.emitExpr(expr, true)
 	} else {
 		c.emit(loadUndef)
 	}
 	for b := c.block; b != nil; b = b.outer {
 		switch b.typ {
 		case blockTry:
-			c.emit(halt)
+			c.emit(leaveTry)
 		case blockLoopEnum:
-			c.emit(enumPopClose)
+			c.emitEnumPopClose(b)
 		}
 	}
 	if s := c.scope.nearestFunction(); s != nil && s.funcType == funcDerivedCtor {
@@ -752,6 +793,52 @@ func (c *compiler) compileReturnStatement(v *ast.ReturnStatement) {
 	c.emit(ret)
 }
 
+// isTailPosition returns true if a call made by a return statement at the current position can replace
+// the current frame. This is only done in strict mode functions which cannot be suspended, and only if
+// there is nothing to do after the call returns, i.e. outside of try statements and for-in/of loops.
+func (c *compiler) isTailPosition() bool {
+	if !c.scope.strict {
+		return false
+	}
+	s := c.scope.nearestFunction()
+	if s == nil || s.generator {
+		return false
+	}
+	switch s.funcType {
+	case funcRegular, funcArrow, funcMethod:
+	default:
+		return false
+	}
+	for b := c.block; b != nil; b = b.outer {
+		switch b.typ {
+		case blockTry, blockLoopEnum:
+			return false
+		}
+	}
+	return true
+}
+
+// markTailCalls marks the calls whose result is the value of expr as tail calls.
+func markTailCalls(expr compiledExpr) {
+	switch expr := expr.(type) {
+	case *compiledCallExpr:
+		expr.tail = true
+	case *compiledConditionalExpr:
+		markTailCalls(expr.consequent)
+		markTailCalls(expr.alternate)
+	case *compiledLogicalOr:
+		markTailCalls(expr.right)
+	case *compiledLogicalAnd:
+		markTailCalls(expr.right)
+	case *compiledCoalesce:
+		markTailCalls(expr.right)
+	case *compiledSequenceExpr:
+		if l := len(expr.sequence); l > 0 {
+			markTailCalls(expr.sequence[l-1])
+		}
+	}
+}
+
 func (c *compiler) checkVarConflict(name unistring.String, offset int) {
 	for sc := c.scope; sc != nil; sc = sc.outer {
 		if b, exists := sc.boundNames[name]; exists && !b.isVar && !(b.isArg && sc != c.scope) {
@@ -1037,6 +1124,7 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 
 	var enter *enterBlock
 	var db *binding
+	var initDb int
 	if scopeDeclared {
 		c.block = &block{
 			typ:        blockScope,
@@ -1045,6 +1133,9 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 		}
 		enter = &enterBlock{}
 		c.emit(enter)
+		// placeholder for moving the discriminant into the stash in case all bindings are placed there
+		initDb = len(c.p.code)
+		c.emit(nil)
 		// create anonymous variable for the discriminant
 		bindings := c.scope.bindings
 		var bb []*binding
@@ -1116,7 +1207,12 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 	}
 	if enter != nil {
 		c.leaveScopeBlock(enter)
-		enter.stackSize--
+		if c.scope.dynLookup {
+			db.emitInitPAtScope(c.scope, initDb)
+		} else {
+			c.p.code[initDb] = jump(1)
+			enter.stackSize--
+		}
 		c.popScope()
 	}
 	c.leaveBlock()
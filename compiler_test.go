@@ -2,6 +2,7 @@ package goja
 
 import (
 	"os"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -318,6 +319,59 @@ func TestThisNoStrict(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestTopLevelThis(t *testing.T) {
+	const SCRIPT = `
+	var self = this;
+	assert.sameValue(self, globalThis, "sloppy script");
+	assert.sameValue((() => this)(), globalThis, "arrow");
+	assert.sameValue(eval("this"), globalThis, "direct eval");
+	assert.sameValue((0, eval)("this"), globalThis, "indirect eval");
+	assert.sameValue(new Function("return this")(), globalThis, "Function constructor");
+	{
+		let x;
+		assert.sameValue(this, globalThis, "block scope");
+	}
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	for _, src := range []string{"this", "'use strict'; this", "(() => this)()"} {
+		v, err := vm.RunString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != vm.GlobalObject() {
+			t.Fatalf("%s: unexpected value: %v", src, v)
+		}
+	}
+}
+
+func TestDirectCallThis(t *testing.T) {
+	const SCRIPT = `
+	function sloppy() {
+		return this;
+	}
+	function strict() {
+		"use strict";
+		return this;
+	}
+	function sloppyNested() {
+		return (function() {
+			return this;
+		})();
+	}
+	assert.sameValue(sloppy(), globalThis, "sloppy");
+	assert.sameValue(strict(), undefined, "strict");
+	assert.sameValue(sloppyNested(), globalThis, "sloppy nested");
+	assert.sameValue(sloppy.call(undefined), globalThis, "sloppy call(undefined)");
+	assert.sameValue(sloppy.call(null), globalThis, "sloppy call(null)");
+	assert.sameValue(strict.call(null), null, "strict call(null)");
+	assert.sameValue(typeof sloppy.call(1), "object", "sloppy boxes primitives");
+	assert.sameValue(strict.call(1), 1, "strict does not box primitives");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestNestedFuncVarResolution(t *testing.T) {
 	const SCRIPT = `
 	(function outer() {
@@ -1210,6 +1264,100 @@ func TestNew(t *testing.T) {
 	testScript(SCRIPT, intToValue(42), t)
 }
 
+func TestNewReturnOverride(t *testing.T) {
+	const SCRIPT = `
+	var obj = {};
+
+	function RetObj() {
+		this.x = 1;
+		return obj;
+	}
+	function RetFunc() {
+		this.x = 1;
+		return RetObj;
+	}
+	function RetPrim() {
+		this.x = 1;
+		return 42;
+	}
+	function RetNull() {
+		this.x = 1;
+		return null;
+	}
+	function RetNothing() {
+		this.x = 1;
+		return;
+	}
+	function Other() {}
+
+	assert.sameValue(new RetObj(), obj, "object");
+	assert.sameValue(new RetFunc(), RetObj, "function");
+	assert.sameValue(new RetPrim().x, 1, "primitive");
+	assert.sameValue(new RetNull().x, 1, "null");
+	assert.sameValue(new RetNothing().x, 1, "nothing");
+
+	assert.sameValue(Reflect.construct(RetObj, []), obj, "Reflect.construct object");
+	var r = Reflect.construct(RetPrim, [], Other);
+	assert.sameValue(r.x, 1, "Reflect.construct primitive");
+	assert.sameValue(Object.getPrototypeOf(r), Other.prototype, "Reflect.construct newTarget");
+	assert.sameValue(new (RetObj.bind(null))(), obj, "bound");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestClassNewReturnOverride(t *testing.T) {
+	const SCRIPT = `
+	var obj = {};
+
+	class Base {
+		constructor(v) {
+			this.x = 1;
+			return v;
+		}
+	}
+	assert.sameValue(new Base(obj), obj, "base object");
+	assert.sameValue(new Base(42).x, 1, "base primitive");
+	assert.sameValue(new Base().x, 1, "base nothing");
+
+	class Derived extends Base {
+		constructor(v, ret) {
+			super(v);
+			this.y = 2;
+			return ret;
+		}
+	}
+	var d = new Derived(obj);
+	assert.sameValue(d, obj, "this is the object returned by super()");
+	assert.sameValue(obj.y, 2);
+	assert.sameValue(new Derived(42).y, 2, "nothing");
+	var other = {};
+	assert.sameValue(new Derived(42, other), other, "derived object");
+	assert.throws(TypeError, function() {
+		new Derived(42, 42);
+	}, "derived primitive");
+	assert.throws(TypeError, function() {
+		new Derived(42, null);
+	}, "derived null");
+	assert.throws(TypeError, function() {
+		Reflect.construct(Derived, [42, 42]);
+	}, "Reflect.construct derived primitive");
+
+	class NoSuper extends Base {
+		constructor(ret) {
+			return ret;
+		}
+	}
+	assert.sameValue(new NoSuper(other), other, "no super() object");
+	assert.throws(ReferenceError, function() {
+		new NoSuper();
+	}, "no super() nothing");
+	assert.throws(TypeError, function() {
+		new NoSuper(42);
+	}, "no super() primitive");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestStringConstructor(t *testing.T) {
 	const SCRIPT = `
 	function F() {
@@ -1286,6 +1434,105 @@ func TestEvalOrder(t *testing.T) {
 	testScript(SCRIPT, asciiString("42First!Second!Third!"), t)
 }
 
+func TestBracketAssignEvalOrder(t *testing.T) {
+	const SCRIPT = `
+	var trace = [];
+	var o = {x: 1};
+
+	function a() {
+		trace.push("a");
+		return o;
+	}
+
+	function b() {
+		trace.push("b");
+		return {
+			toString: function() {
+				trace.push("key");
+				return "x";
+			}
+		};
+	}
+
+	function c() {
+		trace.push("c");
+		return 2;
+	}
+
+	function check(expected, msg) {
+		assert(compareArray(trace, expected), msg + ": " + trace.join());
+		trace = [];
+	}
+
+	a()[b()] = c();
+	check(["a", "b", "c", "key"], "=");
+	assert.sameValue(o.x, 2, "= result");
+
+	a()[b()] += c();
+	check(["a", "b", "key", "c"], "+=");
+	assert.sameValue(o.x, 4, "+= result");
+
+	var res = (a()[b()] *= c());
+	check(["a", "b", "key", "c"], "*= on stack");
+	assert.sameValue(res, 8, "*= result");
+
+	a()[b()]++;
+	check(["a", "b", "key"], "postfix ++");
+	res = a()[b()]--;
+	check(["a", "b", "key"], "postfix -- on stack");
+	assert.sameValue(res, 9, "postfix -- result");
+	res = ++a()[b()];
+	check(["a", "b", "key"], "prefix ++ on stack");
+	assert.sameValue(res, 9, "prefix ++ result");
+
+	(function() {
+		"use strict";
+		a()[b()] = c();
+		check(["a", "b", "c", "key"], "strict =");
+		a()[b()] -= c();
+		check(["a", "b", "key", "c"], "strict -=");
+	})();
+
+	var n = null;
+	assert.throws(TypeError, function() {
+		n[b()] = c();
+	});
+	check(["b", "c"], "= on null");
+	assert.throws(TypeError, function() {
+		n[b()] += c();
+	});
+	assert.sameValue(trace.indexOf("c"), -1, "+= on null must not evaluate rhs");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestSuperBracketAssignEvalOrder(t *testing.T) {
+	const SCRIPT = `
+	var trace = [];
+	var key = {
+		toString: function() {
+			trace.push("key");
+			return "x";
+		}
+	};
+
+	class A {}
+	class B extends A {
+		m() {
+			super[key] += (trace.push("rhs"), 1);
+			super[key]++;
+		}
+	}
+	var b = new B();
+	b.m();
+	assert(compareArray(trace, ["key", "rhs", "key"]), trace.join());
+	assert.sameValue(b.x, NaN);
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestPostfixIncBracket(t *testing.T) {
 	const SCRIPT = `
 	var o = {x: 42};
@@ -1513,6 +1760,20 @@ func TestEvalDirectCreateBinding3(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestEvalDirectCreateBindingAfterDelete(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		eval("var a = 1; var b = 2");
+		delete a;
+		eval("var c = 3");
+		return "" + b + c;
+	}
+	f();
+	`
+
+	testScript(SCRIPT, asciiString("23"), t)
+}
+
 func TestEvalGlobalStrict(t *testing.T) {
 	const SCRIPT = `
 	'use strict';
@@ -1812,6 +2073,23 @@ func TestArgumentsExist(t *testing.T) {
 	testScript(SCRIPT, intToValue(42), t)
 }
 
+func TestArgumentsExistDynamic(t *testing.T) {
+	const SCRIPT = `
+	function F(x, arguments) {
+		eval("");
+		return arguments;
+	}
+	function G() {
+		var arguments;
+		eval("");
+		return arguments.length;
+	}
+	"" + F(1, 42) + G(1, 2);
+	`
+
+	testScript(SCRIPT, asciiString("422"), t)
+}
+
 func TestArgumentsDelete(t *testing.T) {
 	const SCRIPT = `
 	function f(x) {
@@ -1924,6 +2202,76 @@ func TestWithInFunc(t *testing.T) {
 	testScript(SCRIPT, intToValue(42), t)
 }
 
+func TestWithEvalVar(t *testing.T) {
+	const SCRIPT = `
+	var obj = {};
+	with (obj) {
+		eval("var x = 1; function g() { return x; }");
+		with ({}) {
+			eval("var deep = 2");
+		}
+	}
+	assert.sameValue(x, 1, "x");
+	assert.sameValue(g(), 1, "g()");
+	assert.sameValue(deep, 2, "deep");
+	assert(!obj.hasOwnProperty("x") && !obj.hasOwnProperty("g"), "obj");
+
+	var obj1 = {y: 5};
+	with (obj1) {
+		eval("var y = 1");
+	}
+	assert.sameValue(y, undefined, "y is declared in the global scope");
+	assert.sameValue(obj1.y, 1, "but the initialiser assigns to the object property");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestWithEvalVarInFunc(t *testing.T) {
+	const SCRIPT = `
+	function f(a) {
+		var obj = {};
+		with (obj) {
+			eval("var x = 1; var a = 2");
+			(function() {
+				eval("var inner = 3");
+			})();
+			try {
+				throw 0;
+			} catch (e) {
+				eval("var inCatch = 4");
+			}
+		}
+		assert.sameValue(x, 1, "x");
+		assert.sameValue(a, 2, "a");
+		assert.sameValue(typeof inner, "undefined", "inner");
+		assert.sameValue(inCatch, 4, "inCatch");
+		assert.sameValue(Object.keys(obj).length, 0, "obj");
+		assert(delete x, "x is deletable");
+
+		var obj1 = {y: 5};
+		with (obj1) {
+			eval("var y = 1");
+		}
+		assert.sameValue(y, undefined, "y");
+		assert.sameValue(obj1.y, 1, "obj1.y");
+	}
+	f(0);
+
+	function f1() {
+		{
+			let z;
+			with ({}) {
+				eval("var z");
+			}
+		}
+	}
+	assert.throws(SyntaxError, f1, "conflict with a lexical declaration outside of with");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestAssignNonExtendable(t *testing.T) {
 	const SCRIPT = `
 	'use strict';
@@ -2303,6 +2651,20 @@ func TestSwitchResult2(t *testing.T) {
 	testScript(SCRIPT, valueInt(7), t)
 }
 
+func TestSwitchLexicalEval(t *testing.T) {
+	const SCRIPT = `
+	function f(x) {
+		switch (x) {
+		case 1:
+			let y = 2;
+			return eval("y") + x;
+		}
+	}
+	f(1);
+	`
+	testScript(SCRIPT, valueInt(3), t)
+}
+
 func TestSwitchResultJumpIntoEmptyEval(t *testing.T) {
 	const SCRIPT = `
 	function t(x) {
@@ -2682,6 +3044,39 @@ func TestLargeNumberLiteral(t *testing.T) {
 	testScript(SCRIPT, asciiString("9.671406556917033e+24"), t)
 }
 
+func TestNumericSeparators(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(1_000_000, 1000000, "decimal");
+	assert.sameValue(1_0.2_5e1_0, 1.025e11, "float");
+	assert.sameValue(.0_1, 0.01, "leading decimal point");
+	assert.sameValue(0xf_f, 255, "hex");
+	assert.sameValue(0o1_7, 15, "octal");
+	assert.sameValue(0b1_0_1, 5, "binary");
+	assert.sameValue(1_000n, 1000n, "BigInt");
+	assert.sameValue({1_0: true}[10], true, "property key");
+	assert.throws(SyntaxError, function() {
+		eval("1__0");
+	}, "double separator");
+
+	class C {
+		[1_2_3_4_5_6_7_8] = 1;
+		[1_0]() { return 1_0; }
+	}
+	assert.sameValue(new C()["12345678"], 1, "computed field name");
+	assert.sameValue(new C()["10"](), 10, "computed method name");
+
+	assert.sameValue(Number("1_0"), NaN, "string to number");
+	assert.sameValue(Number("1_0.5e1"), NaN, "string to number with a fraction");
+	assert.sameValue(+"0x1_0", NaN, "hex string to number");
+	assert.sameValue(parseFloat("1_0"), 1, "parseFloat");
+	assert.sameValue(parseInt("1_0"), 1, "parseInt");
+	assert.throws(SyntaxError, function() {
+		BigInt("1_0");
+	}, "string to BigInt");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestIncDelete(t *testing.T) {
 	const SCRIPT = `
 	var o = {x: 1};
@@ -3155,6 +3550,58 @@ func TestDummyCompileForUpdate(t *testing.T) {
 	}
 }
 
+func TestRedeclarationSyntaxError(t *testing.T) {
+	for _, tc := range []struct {
+		src, pos string
+	}{
+		{"let x; let x;", "1:12"},
+		{"const x = 1; let x;", "1:18"},
+		{"var x; let x;", "1:12"},
+		{"let x; var x;", "1:5"},
+		{"let x; { var x; }", "1:5"},
+		{"{ let x; let x; }", "1:14"},
+		{"{ let x; function x() {} }", "1:7"},
+		{"class A {}; class A {}", "1:19"},
+		{"switch (1) { case 1: let x; break; default: let x; }", "1:49"},
+		{"function f(a) { let a; }", "1:21"},
+		{"function f(a) { const a = 1; }", "1:23"},
+		{"(a) => { let a; }", "1:14"},
+		{"try {} catch (e) { let e; }", "1:24"},
+		{"for (let x of []) { var x; }", "1:25"},
+		{"'use strict'; function f(a, a) {}", "1:29"},
+		{"function f(a, a) { 'use strict'; }", "1:15"},
+		{"(a, a) => 1", "1:5"},
+		{"function f(a, [a]) {}", "1:16"},
+		{"function f(a = 1, a) {}", "1:19"},
+		{"class C { m(a, a) {} }", "1:16"},
+		{"L: L: ;", "1:4"},
+		{"L: { L: ; }", "1:6"},
+		{"L: for (;;) { M: { L: break L; } }", "1:20"},
+	} {
+		_, err := Compile("", tc.src, false)
+		if err, ok := err.(*CompilerSyntaxError); !ok {
+			t.Errorf("%s: expected syntax error, got %v", tc.src, err)
+		} else if !strings.Contains(err.Error(), tc.pos) {
+			t.Errorf("%s: unexpected position: %v", tc.src, err)
+		}
+	}
+
+	for _, src := range []string{
+		"function f(a) { { let a; } }",
+		"function f(a) { var a; }",
+		"function f(a) { function a() {} }",
+		"function f(a, a) {}",
+		"{ function a() {} function a() {} }",
+		"L: ; L: ;",
+		"L: { (function() { L: ; }); }",
+		"L: { () => { L: ; }; }",
+	} {
+		if _, err := Compile("", src, false); err != nil {
+			t.Errorf("%s: %v", src, err)
+		}
+	}
+}
+
 func TestObjectLiteralWithNumericKeys(t *testing.T) {
 	const SCRIPT = `
 	var o = {1e3: true};
@@ -3306,6 +3753,83 @@ func TestLexicalDeclFunction(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestTypeofUnresolved(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(typeof undeclaredVar, "undefined", "global");
+	assert.sameValue(typeof (undeclaredVar), "undefined", "parenthesised");
+	assert.throws(ReferenceError, function() {
+		undeclaredVar;
+	}, "plain reference");
+	assert.throws(ReferenceError, function() {
+		typeof undeclaredVar.prop;
+	}, "member of unresolved");
+
+	var declared;
+	assert.sameValue(typeof declared, "undefined", "declared global");
+
+	(function() {
+		var local;
+		assert.sameValue(typeof local, "undefined", "declared local");
+		assert.sameValue(typeof undeclaredVar, "undefined", "from function");
+	})();
+
+	(function() {
+		"use strict";
+		assert.sameValue(typeof undeclaredVar, "undefined", "from strict function");
+	})();
+
+	(function() {
+		eval("");
+		assert.sameValue(typeof undeclaredVar, "undefined", "from function with eval");
+	})();
+
+	with ({}) {
+		assert.sameValue(typeof undeclaredVar, "undefined", "from with");
+	}
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestTypeofTDZ(t *testing.T) {
+	const SCRIPT = `
+	assert.throws(ReferenceError, function() {
+		typeof l;
+		let l;
+	}, "let");
+	assert.throws(ReferenceError, function() {
+		typeof c;
+		const c = 1;
+	}, "const");
+	assert.throws(ReferenceError, function() {
+		{
+			typeof b;
+			let b;
+		}
+	}, "block");
+	assert.throws(ReferenceError, function() {
+		function inner() {
+			return typeof l;
+		}
+		inner();
+		let l;
+	}, "closure");
+	assert.throws(ReferenceError, function() {
+		eval("");
+		typeof l;
+		let l;
+	}, "function with eval");
+	assert.throws(ReferenceError, function() {
+		typeof C;
+		class C {}
+	}, "class");
+	assert.throws(ReferenceError, function() {
+		typeof g;
+	}, "global");
+	let g;
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestLexicalDynamicScope(t *testing.T) {
 	const SCRIPT = `
 	const global = 1;
@@ -3557,6 +4081,123 @@ func TestLexicalForOfLoopContBreak(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestLabeledForOfContinueOuter(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var closed = 0;
+	function* g(a) { try { yield* a; } finally { closed++; } }
+	outer: for (const x of g([1, 2, 3])) {
+		let fx = () => x;
+		for (const y of g(["a", "b", "c"])) {
+			let fy = () => y;
+			if (y === "b") continue outer;
+			try {
+				if (x === 3) break outer;
+			} finally {
+				log.push("f" + x);
+			}
+			log.push(fx() + fy());
+		}
+	}
+	assert.sameValue(log.join(), "f1,1a,f2,2a,f3", log.join());
+	assert.sameValue(closed, 4, "closed");
+	var r = [];
+	lbl: {
+		for (var i in {a: 1, b: 2}) {
+			for (var j in {c: 1, d: 2}) {
+				try { if (j === "d") break lbl; } finally { r.push("fin"); }
+			}
+		}
+	}
+	assert.sameValue(r.join(), "fin,fin", "block label");
+
+	var out = [];
+	a: for (let i = 0; i < 3; i++) {
+		b: for (let j = 0; j < 3; j++) {
+			switch (j) {
+			case 1: continue a;
+			}
+			out.push(() => i + "" + j);
+		}
+	}
+	assert.sameValue(out.map(f => f()).join(), "00,10,20", "let closures");
+	function f() {
+		x: for (var k of [1, 2]) {
+			try {
+				return k;
+			} finally {
+				continue x;
+			}
+		}
+		return "done";
+	}
+	assert.sameValue(f(), "done", "continue out of finally overrides return");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestLabeledJumpsUnwindStacks(t *testing.T) {
+	vm := New()
+	vm.Set("check", func() int {
+		return len(vm.vm.iterStack) + len(vm.vm.tryStack)
+	})
+	res, err := vm.RunString(`
+	function f() {
+		outer: for (const x of [1, 2, 3]) {
+			for (const y of [1, 2, 3]) {
+				try {
+					if (y === 2) continue outer;
+				} finally {}
+				for (const z in {a: 1}) {
+					break outer;
+				}
+			}
+		}
+	}
+	for (var i = 0; i < 100; i++) {
+		f();
+	}
+	check();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToInteger() != 0 {
+		t.Fatalf("Unexpected stack depth: %v", res)
+	}
+}
+
+func TestForOfContinueLeavesIterScope(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		for (const b of [1, 2]) {
+			if (b === 1) continue;
+		}
+		outer: for (const a of ["a", "b"]) {
+			for (const b of [1, 2]) {
+				if (b === 2) continue outer;
+			}
+		}
+		outer1: for (const a in {x: 1, y: 2}) {
+			for (let [b] of [[1], [2]]) {
+				setTimeout(() => a + b);
+				if (b === 1) continue outer1;
+			}
+		}
+		try {
+			throw new TypeError("x");
+		} catch (e) {
+			assert(e instanceof TypeError, "catch binding: " + e);
+		}
+	}
+	function setTimeout(f) {
+		f();
+	}
+	f();
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestVarBlockConflict(t *testing.T) {
 	const SCRIPT = `
 	let x;
@@ -3773,6 +4414,62 @@ func TestArrayLiteralSpread(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestArrayLiteralSpreadOrder(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function iter(name, items, throwAt) {
+		var it = {};
+		it[Symbol.iterator] = function() {
+			log.push(name + ":start");
+			var i = 0;
+			return {
+				next: function() {
+					if (i === throwAt) {
+						throw new Error(name + ":throw");
+					}
+					if (i < items.length) {
+						log.push(name + ":" + items[i]);
+						return {value: items[i++], done: false};
+					}
+					return {value: undefined, done: true};
+				}
+			};
+		};
+		return it;
+	}
+	function item(v) {
+		log.push("item:" + v);
+		return v;
+	}
+
+	var a = [item(1), ...iter("a", [2, 3]), item(4), ...iter("empty", []), ...[], item(5), ...iter("b", [6]), , ...new Set([7, 8]), ..."9"];
+	assert(compareArray(a, [1, 2, 3, 4, 5, 6, undefined, 7, 8, "9"]), "values");
+	assert.sameValue(a.length, 10, "length");
+	assert(!(6 in a), "hole");
+	assert(compareArray(log, ["item:1", "a:start", "a:2", "a:3", "item:4", "empty:start", "item:5", "b:start", "b:6"]), "order: " + log.join());
+
+	log = [];
+	var res = "not assigned";
+	try {
+		res = [item(1), ...iter("a", [2, 3, 4], 2), item(5)];
+	} catch (e) {
+		assert.sameValue(e.message, "a:throw", "error");
+	}
+	assert.sameValue(res, "not assigned", "partial result");
+	assert(compareArray(log, ["item:1", "a:start", "a:2", "a:3"]), "order when throwing: " + log.join());
+
+	var big = [];
+	for (var i = 0; i < 50; i++) {
+		big.push(i);
+	}
+	var b = [-1, ...big, 50, ...big, ...big.slice(0, 0), 51];
+	assert.sameValue(b.length, 103, "long length");
+	assert.sameValue(b[51], 50, "long middle");
+	assert.sameValue(b[102], 51, "long last");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestObjectAssignmentPattern(t *testing.T) {
 	const SCRIPT = `
 	let a, b, c;
@@ -4130,6 +4827,18 @@ func TestFuncParamInitializerSimple(t *testing.T) {
 	testScript(SCRIPT, asciiString("12"), t)
 }
 
+func TestFuncParamInitializerStrictEval(t *testing.T) {
+	const SCRIPT = `
+	"use strict";
+	function f(a = 1) {
+		eval("");
+		return a;
+	}
+	""+f()+f(2);
+	`
+	testScript(SCRIPT, asciiString("12"), t)
+}
+
 func TestFuncParamObjectPatternSimple(t *testing.T) {
 	const SCRIPT = `
 	function f({a, b} = {a: 1, b: 2}) {
@@ -4308,6 +5017,97 @@ func TestDefParamsStackPtr(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestFuncParamBackwardRef(t *testing.T) {
+	const SCRIPT = `
+	var order = [];
+	function log(v) {
+		order.push(v);
+		return v;
+	}
+	function f(a, b = log(a + 1), c = log(b * 2)) {
+		return [a, b, c].join();
+	}
+	assert.sameValue(f(1), "1,2,4");
+	assert.sameValue(f(1, 5), "1,5,10");
+	assert.sameValue(f(1, undefined, 0), "1,2,0");
+	assert.sameValue(order.join(), "2,4,10,2");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestFuncParamSelfRef(t *testing.T) {
+	const SCRIPT = `
+	function f(a = a) {
+		return a;
+	}
+	function g(a = () => b, b = a()) {
+		return b;
+	}
+	function h(a = 0) {
+		eval("");
+		return a;
+	}
+	assert.sameValue(f(1), 1);
+	assert.sameValue(h(1), 1, "dynamic");
+	assert.sameValue(h(), 0, "dynamic default");
+	assert.throws(ReferenceError, function() {
+		f();
+	});
+	assert.throws(ReferenceError, function() {
+		g();
+	});
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestFuncParamClosureScope(t *testing.T) {
+	const SCRIPT = `
+	var x = "outer";
+	function f(a, getA = () => a, setA = v => { a = v; }, getX = () => x) {
+		var a, x = "body";
+		assert.sameValue(a, 1, "body var is initialised with the parameter value");
+		a = 2;
+		assert.sameValue(getA(), 1, "body var does not clobber the parameter");
+		setA(3);
+		assert.sameValue(a, 2, "parameter does not clobber the body var");
+		assert.sameValue(getA(), 3);
+		assert.sameValue(getX(), "outer", "body var is not visible to the parameter scope");
+	}
+	f(1);
+
+	function g(a, getA = () => a) {
+		a = 2;
+		return getA();
+	}
+	assert.sameValue(g(1), 2, "no body var, the parameter is shared");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestArrowCaptureArguments(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		return (() => arguments)();
+	}
+	function g(a) {
+		var h = () => () => arguments[1];
+		return h()();
+	}
+	function p(a = () => arguments) {
+		return a();
+	}
+	function q(a = () => arguments) {
+		var arguments = "local";
+		return [a().length, arguments].join();
+	}
+	assert.sameValue(f(1, 2).length, 2);
+	assert.sameValue(g(1, 2, 3), 2);
+	assert.sameValue(p(undefined, 2, 3).length, 3);
+	assert.sameValue(q(undefined, 2), "2,local");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestNestedVariadicCalls(t *testing.T) {
 	const SCRIPT = `
 	function f() {
@@ -4329,6 +5129,40 @@ func TestVariadicNew(t *testing.T) {
 	testScript(SCRIPT, asciiString("1,a,2"), t)
 }
 
+func TestVariadicNewNative(t *testing.T) {
+	const SCRIPT = `
+	var args = [1, "a", true];
+	var o = new Native(...args);
+	assert.sameValue(o.args, "1,a,true", "spread");
+	assert.sameValue(new Native(0, ...args, ...[], 2).args, "0,1,a,true,2", "mixed");
+	assert.sameValue(new Native(...[new Native(...[3, 4]).args], 5).args, "3,4,5", "nested");
+	assert.sameValue(new Native(...[]).args, "", "empty");
+
+	class Derived extends Native {
+		constructor(...a) {
+			super(...a, "d");
+		}
+	}
+	var d = new Derived(...args);
+	assert.sameValue(d.args, "1,a,true,d", "derived");
+	assert(d instanceof Derived, "instanceof");
+
+	assert.sameValue(new Date(...[2020, 0, 2]).getDate(), 2, "Date");
+	assert(compareArray(new Uint8Array(...[[1, 2]]), [1, 2]), "Uint8Array");
+	assert.sameValue(new Map(...[[[1, 2]]]).get(1), 2, "Map");
+	`
+	vm := New()
+	vm.Set("Native", func(call ConstructorCall) *Object {
+		strs := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			strs[i] = arg.String()
+		}
+		call.This.Set("args", strings.Join(strs, ","))
+		return nil
+	})
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestVariadicUseStackVars(t *testing.T) {
 	const SCRIPT = `
 	function A(message) { return message; }
@@ -4340,6 +5174,45 @@ func TestVariadicUseStackVars(t *testing.T) {
 	testScript(SCRIPT, asciiString("C"), t)
 }
 
+func TestVariadicCallsAcrossFrames(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		return Array.prototype.join.call(arguments, ",");
+	}
+	function* g() {
+		return f(...[1, 2], yield "a", f(...[3], yield "b"));
+	}
+	const it = g();
+	assert.sameValue(f(...[0], it.next().value, f(...[9], it.next("x").value)), "0,a,9,b", "suspended in the arguments");
+	assert.sameValue(it.next("y").value, "1,2,x,3,y", "resumed");
+
+	function thrower() {
+		return f(...[1], (() => { throw new Error("inner") })());
+	}
+	const res = f(...[1, 2], tryCall(thrower), f(...[3, 4]), ...[5]);
+	assert.sameValue(res, "1,2,caught,3,4,5", "exception from a nested variadic call");
+
+	async function a() {
+		return f(...[1], await Promise.resolve(2), ...[3]);
+	}
+	`
+	vm := New()
+	vm.Set("tryCall", func(call FunctionCall) Value {
+		fn, _ := AssertFunction(call.Argument(0))
+		if _, err := fn(nil); err != nil {
+			return vm.ToValue("caught")
+		}
+		return _undefined
+	})
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+	if _, err := vm.RunString("var asyncRes; a().then(v => { asyncRes = v; })"); err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("asyncRes"); res == nil || res.String() != "1,2,3" {
+		t.Fatalf("async: %v", res)
+	}
+}
+
 func TestCatchParamPattern(t *testing.T) {
 	const SCRIPT = `
 	function f() {
@@ -4453,6 +5326,44 @@ func TestTaggedTemplate(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestTaggedTemplateCache(t *testing.T) {
+	const SCRIPT = `
+	function tag(s) {
+		return s;
+	}
+	function get() {
+		return tag` + "`a${1}b\\n`" + `;
+	}
+	const s = get();
+	assert.sameValue(get(), s, "same call site");
+	assert(tag` + "`a${1}b\\n`" + ` !== s, "different call site");
+	assert(Object.isFrozen(s) && Object.isFrozen(s.raw), "frozen");
+	assert(compareArray(s, ["a", "b\n"]), "cooked");
+	assert(compareArray(s.raw, ["a", "b\\n"]), "raw");
+	assert.sameValue(String.raw(s, 1), "a1b\\n", "String.raw");
+	assert.sameValue(String.raw` + "`x${2}\\t${3}`" + `, "x2\\t3", "String.raw tag");
+	`
+	vm := New()
+	vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	// the template objects stay equal after they have been evicted from the cache
+	v, err := vm.RunString("get()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.taggedTemplates = nil
+	v1, err := vm.RunString("get()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.(*Object) == v1.(*Object) {
+		t.Fatal("Expected a new object")
+	}
+	if !v.StrictEquals(v1) {
+		t.Fatal("Expected the objects to be equal")
+	}
+}
+
 func TestDuplicateGlobalFunc(t *testing.T) {
 	const SCRIPT = `
 	function a(){}
@@ -4657,6 +5568,62 @@ func TestConstantFolding(t *testing.T) {
 	})
 }
 
+func TestConstantFoldingSemantics(t *testing.T) {
+	// Each constant expression is compared with the same operation performed at run time
+	// (the operands are passed through v() which prevents folding).
+	cases := []struct {
+		folded, runtime string
+	}{
+		{"60 * 60 * 24", "v(60) * v(60) * v(24)"},
+		{"1 << 31", "v(1) << v(31)"},
+		{"0x7fffffff + 1", "v(0x7fffffff) + v(1)"},
+		{"-2147483648 - 1", "-v(2147483648) - v(1)"},
+		{"2 ** 53 + 1", "v(2) ** v(53) + v(1)"},
+		{"0.1 + 0.2", "v(0.1) + v(0.2)"},
+		{"1 / 0", "v(1) / v(0)"},
+		{"0 * -1", "v(0) * -v(1)"},
+		{"-5 % 5", "-v(5) % v(5)"},
+		{"'a' + 1 + 2", "v('a') + v(1) + v(2)"},
+		{"1 + 2 + 'a'", "v(1) + v(2) + v('a')"},
+		{"`t${1 + 1}`", "`t${v(1) + v(1)}`"},
+		{"true ? 1 + 1 : 0", "v(true) ? v(1) + v(1) : v(0)"},
+	}
+	for _, tc := range cases {
+		prg := MustCompile("test.js", "f("+tc.folded+")", false)
+		var b strings.Builder
+		if err := prg.Disassemble(&b); err != nil {
+			t.Fatal(err)
+		}
+		before := MustCompile("test.js", "f("+tc.runtime+")", false)
+		var bb strings.Builder
+		if err := before.Disassemble(&bb); err != nil {
+			t.Fatal(err)
+		}
+		for _, op := range []string{" add\n", " sub\n", " mul\n", " div\n", " mod\n", " exp\n", " sal\n", " neg\n", " concatStrings "} {
+			if strings.Contains(b.String(), op) {
+				t.Fatalf("%s: %q was not folded:\n%s", tc.folded, op, b.String())
+			}
+		}
+		if len(prg.code) >= len(before.code) {
+			t.Fatalf("%s: expected less code than\n%s\ngot:\n%s", tc.folded, bb.String(), b.String())
+		}
+
+		vm := New()
+		var res []Value
+		vm.Set("v", func(x Value) Value { return x })
+		vm.Set("f", func(x Value) { res = append(res, x) })
+		if _, err := vm.RunProgram(prg); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vm.RunProgram(before); err != nil {
+			t.Fatal(err)
+		}
+		if !res[0].SameAs(res[1]) || res[0].ExportType() != res[1].ExportType() {
+			t.Fatalf("%s: %v (%T) != %v (%T)", tc.folded, res[0], res[0], res[1], res[1])
+		}
+	}
+}
+
 func TestAssignBeforeInit(t *testing.T) {
 	const SCRIPT = `
 	assert.throws(ReferenceError, () => {
@@ -4729,6 +5696,236 @@ func TestOptChainCallee(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestOptChainEvalOrder(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function traced(name, props) {
+		var o = {};
+		Object.keys(props).forEach(function(key) {
+			var v = props[key];
+			Object.defineProperty(o, key, {
+				get: function() {
+					log.push(name + "." + key);
+					if (typeof v !== "function") {
+						return v;
+					}
+					return function() {
+						log.push(name + "." + key + "()" + (this === o ? "" : " wrong this"));
+						return v.apply(this, arguments);
+					};
+				}
+			});
+		});
+		return o;
+	}
+	function arg(v) {
+		log.push("arg");
+		return v;
+	}
+	function check(expected, actual, expectedLog) {
+		assert.sameValue(actual, expected);
+		assert.sameValue(log.join(), expectedLog);
+		log = [];
+	}
+
+	var c = traced("c", {d: function() { return 42; }});
+	var b = traced("b", {c: function() { return c; }});
+	var a = traced("a", {
+		b: function() { return b; },
+		nil: function() { return null; },
+		undef: undefined
+	});
+
+	check(42, a?.b()?.c()?.d(), "a.b,a.b(),b.c,b.c(),c.d,c.d()");
+	check(undefined, a?.nil()?.c(arg()), "a.nil,a.nil()");
+	check(undefined, a.nil()?.c.d.e(arg()), "a.nil,a.nil()");
+	check(undefined, a?.undef?.(arg()), "a.undef");
+	check(undefined, a.undef?.x.y, "a.undef");
+	check(42, a?.b?.()?.c?.().d(), "a.b,a.b(),b.c,b.c(),c.d,c.d()");
+	check(42, a?.["b"]().c?.()["d"](), "a.b,a.b(),b.c,b.c(),c.d,c.d()");
+
+	var n = null;
+	check(undefined, n?.b().c.d(arg()), "");
+	check(undefined, n?.[arg("b")].c, "");
+
+	assert.throws(TypeError, function() {
+		a?.undef.c;
+	}, "non-optional link after a nullish value");
+	check(undefined, undefined, "a.undef");
+	assert.throws(TypeError, function() {
+		a?.nil().c();
+	}, "non-optional call after a nullish value");
+	check(undefined, undefined, "a.nil,a.nil()");
+	assert.throws(TypeError, function() {
+		(n?.b).c;
+	}, "parentheses stop short-circuiting");
+	check(undefined, undefined, "");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestOptChainMisc(t *testing.T) {
+	const SCRIPT = `
+	var a = {b: {c: function() { return this; }}, nil: null};
+	var n;
+	assert.sameValue(a?.b.c(), a.b, "this of a call after an optional link");
+	assert.sameValue(a.b?.c?.(), a.b, "this of an optional call");
+	assert.sameValue((a?.b).c(), a.b, "this of a call on a parenthesized chain");
+	assert.sameValue(a?.b?.["c"](), a.b, "this of a computed member call");
+	assert.sameValue(a.b.c?.call(1) instanceof Number, true, "call() on an optional member");
+
+	assert.sameValue(delete a?.x, true, "delete of a missing property");
+	assert.sameValue(delete n?.x, true, "delete short-circuited");
+	var o = {x: 1};
+	assert.sameValue(delete o?.x, true, "delete");
+	assert(!o.hasOwnProperty("x"), "property deleted");
+
+	class C {
+		#p = 1;
+		static get(o) {
+			return o?.#p;
+		}
+		static call(o) {
+			return o?.c.#m();
+		}
+		#m() {
+			return 2;
+		}
+	}
+	assert.sameValue(C.get(new C()), 1, "private name");
+	assert.sameValue(C.get(null), undefined, "private name short-circuited");
+	assert.sameValue(C.call({c: new C()}), 2, "private method");
+	assert.sameValue(C.call(undefined), undefined, "private method short-circuited");
+
+	assert.sameValue(0?.toString(), "0", "number base");
+	assert.sameValue(""?.length, 0, "empty string base");
+	assert.sameValue(false?.valueOf(), false, "false base");
+	assert.sameValue(eval("a?.b"), a.b, "eval");
+	assert.sameValue(a.nil?.b.c.d, undefined, "long chain");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestOptChainSyntaxErrors(t *testing.T) {
+	for _, src := range []string{
+		"a?.b = 1",
+		"a?.b++",
+		"a?.b += 1",
+		"new a?.b()",
+		"new a?.b",
+		"a?.b`tmpl`",
+		"for (a?.b of []);",
+		"[a?.b] = [1]",
+	} {
+		_, err := Compile("", src, false)
+		if _, ok := err.(*CompilerSyntaxError); !ok {
+			t.Errorf("%s: unexpected error: %v", src, err)
+		}
+	}
+}
+
+func TestLogicalAssignment(t *testing.T) {
+	const SCRIPT = `
+	var calls = 0;
+	function rhs(v) {
+		calls++;
+		return v;
+	}
+
+	var a = 1, b = 0, c = null;
+	assert.sameValue(a &&= rhs(2), 2, "&&= result");
+	assert.sameValue(a, 2, "&&= stored");
+	assert.sameValue(b &&= rhs(3), 0, "&&= short-circuited");
+	assert.sameValue(a ||= rhs(3), 2, "||= short-circuited");
+	assert.sameValue(b ||= rhs(3), 3, "||= result");
+	assert.sameValue(c ??= rhs(4), 4, "??= result");
+	assert.sameValue(c ??= rhs(5), 4, "??= short-circuited");
+	assert.sameValue(calls, 3, "rhs calls");
+
+	function locals() {
+		let x = 0, y = "";
+		const k = 1;
+		x ||= 5;
+		y &&= 6;
+		k ||= 7;
+		let z;
+		z ??= function() {};
+		return [x, y, k, z.name];
+	}
+	assert.sameValue(locals().join(), "5,,1,z", "locals");
+	assert.throws(TypeError, function() {
+		const k = 0;
+		k ||= 1;
+	}, "assignment to a const");
+
+	var o = {p: 0, get g() { calls++; return 1; }, set g(v) { throw new Error("setter called"); }};
+	var keys = 0;
+	function key() {
+		keys++;
+		return "p";
+	}
+	assert.sameValue(o[key()] ||= 10, 10, "bracket");
+	assert.sameValue(o[key()] ||= 20, 10, "bracket short-circuited");
+	assert.sameValue(keys, 2, "key evaluated once per expression");
+	assert.sameValue(o.g ||= 2, 1, "setter not called");
+	o.q ??= 3;
+	assert.sameValue(o.q, 3, "dot");
+
+	with ({w: null}) {
+		w ??= 1;
+		assert.sameValue(w, 1, "with");
+	}
+	eval("var e;");
+	e ||= 2;
+	assert.sameValue(e, 2, "dynamic");
+	assert.throws(ReferenceError, function() {
+		undeclared ??= 5;
+	}, "unresolvable reference");
+
+	class B {
+		get s() { return this._s; }
+		set s(v) { this._s = v; }
+	}
+	class C extends B {
+		#p;
+		m() {
+			this.#p ??= 1;
+			this.#p &&= this.#p + 1;
+			super.s ||= 3;
+			super["s"] &&= 4;
+			return [this.#p, this.s];
+		}
+	}
+	assert.sameValue(new C().m().join(), "2,4", "class");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestLogicalAssignmentSemantics(t *testing.T) {
+	const SCRIPT = `
+	var f; f ||= function() {};
+	assert.sameValue(f.name, "f", "fn name");
+	var g = 0; g ||= () => {};
+	assert.sameValue(g.name, "g", "arrow name");
+	var h; h ??= class {};
+	assert.sameValue(h.name, "h", "class name");
+	var o = {}; o.p ??= function() {};
+	assert.sameValue(o.p.name, "", "no name for properties");
+	var ro = Object.freeze({a: 0});
+	assert.throws(TypeError, function() { "use strict"; ro.a ||= 1; });
+	ro.a &&= 1;
+	assert.throws(ReferenceError, function() { "use strict"; und ||= 1; });
+	var n = null;
+	assert.sameValue(n ??= n ??= 5, 5);
+	var calls = [];
+	var p = new Proxy({}, { get(t, k) { calls.push("get " + k); return 1; }, set(t, k, v) { calls.push("set " + k); return true; } });
+	p.x &&= 2;
+	p.y ||= 2;
+	assert.sameValue(calls.join(), "get x,set x,get y");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestObjectLiteralSuper(t *testing.T) {
 	const SCRIPT = `
 	const proto = {
@@ -5317,6 +6514,44 @@ func TestClassReferToBindingFromHeritage(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestClassComputedNamesAndStaticBlocks(t *testing.T) {
+	const SCRIPT = `
+	var sym = Symbol("m");
+	var order = [];
+	var key = {
+		toString() {
+			order.push("key");
+			return "k";
+		}
+	};
+	class C {
+		static a = (order.push("a"), 1);
+		[sym]() {
+			return 42;
+		}
+		static [key]() {
+			return "k";
+		}
+		static {
+			var local = C.a + 1;
+			order.push("block");
+			this.b = local;
+		}
+		static c = (order.push("c"), C.b + 1);
+	}
+	assert.sameValue(new C()[sym](), 42, "symbol method");
+	assert.sameValue(C.k(), "k", "computed static method");
+	assert.sameValue(C.b, 2, "C.b");
+	assert.sameValue(C.c, 3, "C.c");
+	// the computed keys are evaluated once, at definition time, before the static elements are initialised
+	// which happens in the order of appearance
+	assert.sameValue(order.join(), "key,a,block,c", "order");
+	assert.sameValue(typeof local, "undefined", "static block scope");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestClassCaptureSuperCallInArrowFunc(t *testing.T) {
 	const SCRIPT = `
 	let f;
@@ -5470,6 +6705,50 @@ func TestPrivateIn(t *testing.T) {
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestPrivateInBrandCheck(t *testing.T) {
+	const SCRIPT = `
+	class C {
+		#x;
+		#m() {}
+		static #s = 1;
+		has(arg) {
+			return #x in arg;
+		}
+		hasMethod(arg) {
+			return #m in arg;
+		}
+		static hasStatic(arg) {
+			return #s in arg;
+		}
+	}
+	class D {
+		#x;
+	}
+	const c = new C();
+	assert(c.has(c), "own instance");
+	assert(c.hasMethod(c), "private method");
+	assert(C.hasStatic(C), "static");
+	assert(!C.hasStatic(c), "static on an instance");
+	assert(!c.has(new D()), "same name in another class");
+	assert(!c.has({}), "plain object");
+	assert(!c.has(Object.create(c)), "inherited");
+
+	// a brand check does not trigger any traps or getters
+	const log = [];
+	const p = new Proxy(c, {
+		has(t, k) { log.push("has"); return true; },
+		get(t, k) { log.push("get"); return undefined; },
+		getOwnPropertyDescriptor(t, k) { log.push("gopd"); return undefined; },
+	});
+	assert(!c.has(p), "proxy");
+	assert.sameValue(log.length, 0, "traps");
+
+	assert.throws(TypeError, () => c.has(1), "primitive");
+	assert.throws(TypeError, () => c.has(undefined), "undefined");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func TestDeletePropOfNonObject(t *testing.T) {
 	const SCRIPT = `
 	delete 'Test262'[100] && delete 'Test262'.a && delete 'Test262'['@'];
@@ -0,0 +1,8 @@
+//go:build !goja_debug
+// +build !goja_debug
+
+package goja
+
+// debugChecks enables the internal consistency checks which are too expensive to be done in production.
+// Build with the goja_debug tag to turn them on.
+const debugChecks = false
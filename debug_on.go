@@ -0,0 +1,6 @@
+//go:build goja_debug
+// +build goja_debug
+
+package goja
+
+const debugChecks = true
@@ -0,0 +1,133 @@
+package goja
+
+import (
+	"sync/atomic"
+
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/unistring"
+)
+
+// DebugAction is returned by a DebugHandler to tell the vm how to proceed.
+type DebugAction int
+
+const (
+	// DebugStep resumes the execution and stops again before the next statement.
+	DebugStep DebugAction = iota
+	// DebugContinue resumes the execution until a debugger statement is reached or Runtime.DebugBreak() is called.
+	DebugContinue
+)
+
+// DebugHandler is called by the vm when it stops before a statement, see Runtime.SetDebugHandler().
+type DebugHandler func(ctx *DebugContext) DebugAction
+
+// DebugContext describes the state of the vm when it has stopped before a statement. It is only valid
+// during the DebugHandler call and must not be retained.
+type DebugContext struct {
+	vm *vm
+}
+
+// Program returns the Program being executed.
+func (c *DebugContext) Program() *Program {
+	return c.vm.prg
+}
+
+// PC returns the index of the current instruction within the Program.
+func (c *DebugContext) PC() int {
+	return c.vm.pc
+}
+
+// Position returns the source position of the statement that is about to be executed.
+func (c *DebugContext) Position() file.Position {
+	prg := c.vm.prg
+	if prg == nil || prg.src == nil {
+		return file.Position{}
+	}
+	return prg.src.Position(prg.sourceOffset(c.vm.pc))
+}
+
+// Locals returns a snapshot of the bindings that are visible from the current statement by name,
+// excluding the global ones. Uninitialised lexical bindings (i.e. the ones in the temporal dead zone)
+// and the bindings of 'with' statement objects are omitted.
+func (c *DebugContext) Locals() map[string]Value {
+	return c.vm.locals()
+}
+
+// locals returns the bindings that are visible from the current position by name, see DebugContext.Locals().
+func (vm *vm) locals() map[string]Value {
+	res := make(map[string]Value)
+	seen := make(map[unistring.String]struct{})
+	global := &vm.r.global.stash
+	for s := vm.stash; s != nil && s != global; s = s.outer {
+		if s.obj != nil {
+			continue
+		}
+		for name, idx := range s.names {
+			if name == thisBindingName {
+				continue
+			}
+			if _, exists := seen[name]; exists {
+				continue
+			}
+			seen[name] = struct{}{}
+			if v := s.values[idx&^maskTyp]; v != nil {
+				res[name.String()] = v
+			} else if idx&maskVar != 0 {
+				res[name.String()] = _undefined
+			}
+		}
+	}
+	return res
+}
+
+type _debugStmt struct{}
+
+// debugStmt precedes every statement of a Program compiled for a debugger.
+var debugStmt _debugStmt
+
+func (_debugStmt) exec(vm *vm) {
+	if vm.debugHandler != nil && atomic.LoadUint32(&vm.debugBreak) != 0 {
+		vm.callDebugHandler()
+	}
+	vm.pc++
+}
+
+type _debugger struct{}
+
+// debugger is emitted for a debugger statement.
+var debugger _debugger
+
+func (_debugger) exec(vm *vm) {
+	if vm.debugHandler != nil {
+		vm.callDebugHandler()
+	}
+	vm.pc++
+}
+
+func (vm *vm) callDebugHandler() {
+	if vm.debugHandler(&DebugContext{vm: vm}) == DebugContinue {
+		atomic.StoreUint32(&vm.debugBreak, 0)
+	} else {
+		atomic.StoreUint32(&vm.debugBreak, 1)
+	}
+}
+
+// SetDebugHandler sets a function which is called before a statement is executed, allowing to step through
+// the code. The handler is called before every statement while stepping (which is the initial state), returning
+// DebugContinue stops stepping until a debugger statement is reached or DebugBreak() is called.
+// Only the code compiled by this Runtime while a handler is set (i.e. by RunString(), RunScript(), eval() or
+// the Function constructor) stops before statements, such code keeps all local variables by name so that they
+// are available from DebugContext.Locals(), which makes it slower. Programs compiled using Compile() do not
+// have any overhead, they only stop at debugger statements.
+// The handler must not run any code in this Runtime. Passing nil removes the handler.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetDebugHandler(handler DebugHandler) {
+	r.vm.debugHandler = handler
+	atomic.StoreUint32(&r.vm.debugBreak, 1)
+}
+
+// DebugBreak makes the vm stop before the next statement as if DebugStep was returned by the DebugHandler.
+// Unlike SetDebugHandler() this method is safe to call concurrently.
+func (r *Runtime) DebugBreak() {
+	atomic.StoreUint32(&r.vm.debugBreak, 1)
+}
@@ -0,0 +1,145 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestDebugHandlerStep(t *testing.T) {
+	vm := New()
+	var lines []int
+	var locals []map[string]Value
+	vm.SetDebugHandler(func(ctx *DebugContext) DebugAction {
+		if ctx.Program() == nil {
+			t.Fatal("Program is nil")
+		}
+		lines = append(lines, ctx.Position().Line)
+		locals = append(locals, ctx.Locals())
+		return DebugStep
+	})
+	_, err := vm.RunString(`
+	function f(a) {
+		let b = a + 1;
+		return b * 2;
+	}
+	var x = f(1);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expLines := []int{6, 3, 4}
+	if len(lines) != len(expLines) {
+		t.Fatalf("Unexpected stops: %v", lines)
+	}
+	for i, l := range expLines {
+		if lines[i] != l {
+			t.Fatalf("Unexpected stops: %v", lines)
+		}
+	}
+	if len(locals[0]) != 0 {
+		t.Fatalf("Unexpected top-level locals: %v", locals[0])
+	}
+	if a := locals[1]["a"]; a == nil || a.ToInteger() != 1 {
+		t.Fatalf("a: %v", a)
+	}
+	if _, exists := locals[1]["b"]; exists {
+		t.Fatal("b is in TDZ and must not be in the locals")
+	}
+	if b := locals[2]["b"]; b == nil || b.ToInteger() != 2 {
+		t.Fatalf("b: %v", b)
+	}
+	if args := locals[2]["arguments"]; args == nil {
+		t.Fatal("arguments is missing")
+	}
+}
+
+func TestDebugHandlerContinue(t *testing.T) {
+	vm := New()
+	var stops []int
+	vm.SetDebugHandler(func(ctx *DebugContext) DebugAction {
+		stops = append(stops, ctx.Position().Line)
+		if len(stops) == 1 {
+			return DebugContinue
+		}
+		return DebugStep
+	})
+	_, err := vm.RunString(`
+	var x = 1;
+	x++;
+	debugger;
+	x++;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) != 3 || stops[0] != 2 || stops[1] != 4 || stops[2] != 5 {
+		t.Fatalf("Unexpected stops: %v", stops)
+	}
+}
+
+func TestDebugHandlerShadowing(t *testing.T) {
+	vm := New()
+	var locals map[string]Value
+	vm.SetDebugHandler(func(ctx *DebugContext) DebugAction {
+		locals = ctx.Locals()
+		return DebugStep
+	})
+	_, err := vm.RunString(`
+	(function() {
+		var x = 1, y = 2;
+		{
+			let x = "inner";
+			x;
+		}
+	})();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := locals["x"]; x == nil || x.String() != "inner" {
+		t.Fatalf("x: %v", x)
+	}
+	if y := locals["y"]; y == nil || y.ToInteger() != 2 {
+		t.Fatalf("y: %v", y)
+	}
+}
+
+func TestDebugHandlerPrecompiled(t *testing.T) {
+	prg := MustCompile("test.js", `
+	var x = 1;
+	debugger;
+	x++;
+	`, false)
+	vm := New()
+	var stops []int
+	vm.SetDebugHandler(func(ctx *DebugContext) DebugAction {
+		stops = append(stops, ctx.Position().Line)
+		return DebugStep
+	})
+	_, err := vm.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) != 1 || stops[0] != 3 {
+		t.Fatalf("Unexpected stops: %v", stops)
+	}
+}
+
+func TestDebugHandlerRemoved(t *testing.T) {
+	vm := New()
+	calls := 0
+	vm.SetDebugHandler(func(ctx *DebugContext) DebugAction {
+		calls++
+		return DebugStep
+	})
+	prg, err := vm.compile("test.js", "var x = 1; debugger; x++;", false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.SetDebugHandler(nil)
+	if _, err := vm.RunProgram(prg); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatalf("calls: %d", calls)
+	}
+}
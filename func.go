@@ -43,12 +43,28 @@ type methodFuncObject struct {
 	homeObject *Object
 }
 
+// generatorFuncObject is a generator function or method. Calling it returns a generator object
+// (see initGenerator), it is not a constructor.
+type generatorFuncObject struct {
+	methodFuncObject
+}
+
 type arrowFuncObject struct {
 	baseJsFuncObject
 	funcObj   *Object
 	newTarget Value
 }
 
+// asyncFuncObject is an async function or method. Calling it returns a Promise, see Runtime.runAsync().
+type asyncFuncObject struct {
+	methodFuncObject
+}
+
+// asyncArrowFuncObject is an async arrow function.
+type asyncArrowFuncObject struct {
+	arrowFuncObject
+}
+
 type nativeFuncObject struct {
 	baseFuncObject
 
@@ -59,6 +75,9 @@ type nativeFuncObject struct {
 type boundFuncObject struct {
 	nativeFuncObject
 	wrapped *Object
+
+	boundThis Value
+	boundArgs []Value
 }
 
 func (f *nativeFuncObject) export(*objectExportCtx) interface{} {
@@ -67,7 +86,7 @@ func (f *nativeFuncObject) export(*objectExportCtx) interface{} {
 
 func (f *funcObject) _addProto(n unistring.String) Value {
 	if n == "prototype" {
-		if _, exists := f.values[n]; !exists {
+		if _, exists := f.slotIdx[n]; !exists {
 			return f.addPrototype()
 		}
 	}
@@ -123,16 +142,18 @@ func (f *funcObject) hasOwnPropertyStr(name unistring.String) bool {
 }
 
 func (f *funcObject) stringKeys(all bool, accum []Value) []Value {
+	accum = f.baseFuncObject.stringKeys(all, accum)
 	if all {
-		if _, exists := f.values["prototype"]; !exists {
+		// the same position it gets when created by iterateStringKeys()
+		if _, exists := f.slotIdx["prototype"]; !exists {
 			accum = append(accum, asciiString("prototype"))
 		}
 	}
-	return f.baseFuncObject.stringKeys(all, accum)
+	return accum
 }
 
 func (f *funcObject) iterateStringKeys() iterNextFunc {
-	if _, exists := f.values["prototype"]; !exists {
+	if _, exists := f.slotIdx["prototype"]; !exists {
 		f.addPrototype()
 	}
 	return f.baseFuncObject.iterateStringKeys()
@@ -274,7 +295,7 @@ func (f *arrowFuncObject) Call(call FunctionCall) Value {
 func (f *baseJsFuncObject) _call(args []Value, newTarget, this Value) Value {
 	vm := f.val.runtime.vm
 
-	vm.stack.expand(vm.sp + len(args) + 1)
+	vm.expandStack(vm.sp + len(args) + 1)
 	vm.stack[vm.sp] = f.val
 	vm.sp++
 	vm.stack[vm.sp] = this
@@ -339,6 +360,34 @@ func (f *arrowFuncObject) export(*objectExportCtx) interface{} {
 	return f.Call
 }
 
+func (f *asyncFuncObject) Call(call FunctionCall) Value {
+	return f.val.runtime.runAsync(func() Value {
+		return f.methodFuncObject.Call(call)
+	})
+}
+
+func (f *asyncFuncObject) assertCallable() (func(FunctionCall) Value, bool) {
+	return f.Call, true
+}
+
+func (f *asyncFuncObject) export(*objectExportCtx) interface{} {
+	return f.Call
+}
+
+func (f *asyncArrowFuncObject) Call(call FunctionCall) Value {
+	return f.val.runtime.runAsync(func() Value {
+		return f.arrowFuncObject.Call(call)
+	})
+}
+
+func (f *asyncArrowFuncObject) assertCallable() (func(FunctionCall) Value, bool) {
+	return f.Call, true
+}
+
+func (f *asyncArrowFuncObject) export(*objectExportCtx) interface{} {
+	return f.Call
+}
+
 func (f *baseFuncObject) init(name unistring.String, length Value) {
 	f.baseObject.init()
 
@@ -429,3 +478,18 @@ func (f *boundFuncObject) setForeignStr(name unistring.String, val, receiver Val
 func (f *boundFuncObject) hasInstance(v Value) bool {
 	return instanceOfOperator(v, f.wrapped)
 }
+
+// isScriptTarget returns true if the bound function eventually wraps a script function, in which case the call
+// can be made by splicing the bound this and arguments into the stack rather than going through the native wrapper.
+func (f *boundFuncObject) isScriptTarget() bool {
+	for {
+		switch w := f.wrapped.self.(type) {
+		case *funcObject, *methodFuncObject, *arrowFuncObject, *generatorFuncObject:
+			return true
+		case *boundFuncObject:
+			f = w
+		default:
+			return false
+		}
+	}
+}
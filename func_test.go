@@ -81,6 +81,92 @@ func TestFuncExport(t *testing.T) {
 	})
 }
 
+func TestBoundFuncCall(t *testing.T) {
+	const SCRIPT = `
+	function f(a, b, c) {
+		"use strict";
+		return [this, a, b, c, arguments.length, new.target];
+	}
+	var o = {};
+	var r = f.bind(o, 1)(2, 3);
+	assert.sameValue(r[0], o, "this");
+	assert(compareArray(r.slice(1, 5), [1, 2, 3, 3]), "args");
+	assert.sameValue(r[5], undefined, "new.target");
+
+	r = f.bind(o)();
+	assert(compareArray(r.slice(1, 5), [undefined, undefined, undefined, 0]), "no args");
+
+	r = f.bind(o, 1).bind(null, 2).bind(undefined, 3, 4)(5);
+	assert.sameValue(r[0], o, "nested this");
+	assert(compareArray(r.slice(1, 5), [1, 2, 3, 5]), "nested args");
+
+	function sloppy() {
+		return this;
+	}
+	assert.sameValue(sloppy.bind(undefined)(), this, "sloppy this");
+	assert.sameValue(typeof sloppy.bind(1)(), "object", "sloppy this boxed");
+
+	var obj = {
+		m(x) { return [this, x]; },
+		*g(x) { yield this; yield x; }
+	};
+	r = obj.m.bind(o, "x")();
+	assert.sameValue(r[0], o, "method this");
+	assert.sameValue(r[1], "x", "method arg");
+	assert(compareArray([...obj.g.bind(o, "y")()], [o, "y"]), "generator");
+
+	var arrow = (function() {
+		return (a, b) => [this, a, b];
+	}).call(o);
+	r = arrow.bind({}, 1)(2);
+	assert.sameValue(r[0], o, "arrow this");
+	assert(compareArray(r.slice(1), [1, 2]), "arrow args");
+
+	var bf = f.bind(o, 1);
+	var c = new bf(2);
+	assert.sameValue(c[0] instanceof f, true, "construct this");
+	assert.sameValue(c[5], f, "construct new.target");
+
+	var native = Math.max.bind(null, 5);
+	assert.sameValue(native(1, 10), 10, "native target");
+
+	function deep(n) {
+		return n === 0 ? new Error().stack : deepBound(n - 1);
+	}
+	var deepBound = deep.bind(null);
+	var stack = deepBound(2);
+	assert.sameValue(stack.split("at deep").length - 1, 3, stack);
+
+	function rec(n) {
+		return n === 0 ? 0 : recBound(n - 1) + 1;
+	}
+	var recBound = rec.bind(null);
+	assert.sameValue(recBound(1000), 1000, "recursion");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func BenchmarkBoundFuncCall(b *testing.B) {
+	vm := New()
+	prg := MustCompile("test.js", `
+	function f(a, b) {
+		return a + b;
+	}
+	var bf = f.bind(null, 1);
+	for (var i = 0; i < 1000; i++) {
+		bf(i);
+	}
+	`, false)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := vm.RunProgram(prg)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func ExampleAssertConstructor() {
 	vm := New()
 	res, err := vm.RunString(`
@@ -0,0 +1,317 @@
+package goja
+
+type generatorState uint8
+
+const (
+	genStateSuspendedStart generatorState = iota
+	genStateSuspendedYield
+	genStateExecuting
+	genStateCompleted
+)
+
+type resumeMode uint8
+
+const (
+	resumeNext resumeMode = iota
+	resumeThrow
+	resumeReturn
+)
+
+// generatorReturn is thrown at the suspension point when return() is called on a suspended generator. It is not
+// caught by the script, but it runs the 'finally' blocks (see vm.handleThrow()).
+type generatorReturn struct {
+	val Value
+}
+
+// generator holds a function frame which can be suspended and resumed later. While suspended, the part of
+// the vm state that belongs to the frame (the stack values starting with the callee, the entered try statements,
+// the iterators and the references) is moved out of the vm and is copied back when the frame is resumed,
+// possibly at a different stack position.
+type generator struct {
+	state generatorState
+
+	ctx       context
+	stack     []Value
+	tryStack  []tryFrame
+	iterStack []iterStackItem
+	refStack  []ref
+
+	// the lengths of the vm stacks when the frame was resumed
+	tryBase, iterBase, refBase int
+
+	// the iterator of a yield* expression the frame is suspended in, if any
+	delegate   *iteratorRecord
+	delegating bool
+	resumeMode resumeMode
+}
+
+// storeFrame moves the current frame out of the vm. The frame is expected to be on top of everything.
+func (g *generator) storeFrame(vm *vm) {
+	vm.saveCtx(&g.ctx)
+	vm.captureStash()
+	base := vm.sb - 1
+	g.stack = append(g.stack[:0], vm.stack[base:vm.sp]...)
+
+	g.tryStack = append(g.tryStack[:0], vm.tryStack[g.tryBase:]...)
+	for i := range g.tryStack {
+		tf := &g.tryStack[i]
+		tf.sp -= int32(base)
+		tf.iterLen -= uint32(g.iterBase)
+		tf.refLen -= uint32(g.refBase)
+	}
+	vm.truncateTryStack(g.tryBase)
+
+	g.iterStack = append(g.iterStack[:0], vm.iterStack[g.iterBase:]...)
+	iterTail := vm.iterStack[g.iterBase:]
+	for i := range iterTail {
+		iterTail[i] = iterStackItem{}
+	}
+	vm.iterStack = vm.iterStack[:g.iterBase]
+
+	g.refStack = append(g.refStack[:0], vm.refStack[g.refBase:]...)
+	refTail := vm.refStack[g.refBase:]
+	for i := range refTail {
+		refTail[i] = nil
+	}
+	vm.refStack = vm.refStack[:g.refBase]
+}
+
+// restoreFrame copies the frame back into the vm on top of the stack.
+func (g *generator) restoreFrame(vm *vm) {
+	g.tryBase, g.iterBase, g.refBase = len(vm.tryStack), len(vm.iterStack), len(vm.refStack)
+	base := vm.sp
+	vm.expandStack(base + len(g.stack))
+	copy(vm.stack[base:], g.stack)
+	vm.sp = base + len(g.stack)
+	vm.restoreCtx(&g.ctx)
+	vm.sb = base + 1
+
+	callStackLen := uint32(len(vm.callStack))
+	for _, tf := range g.tryStack {
+		tf.sp += int32(base)
+		tf.iterLen += uint32(g.iterBase)
+		tf.refLen += uint32(g.refBase)
+		tf.callStackLen = callStackLen
+		vm.tryStack = append(vm.tryStack, tf)
+	}
+	vm.iterStack = append(vm.iterStack, g.iterStack...)
+	vm.refStack = append(vm.refStack, g.refStack...)
+
+	g.ctx = context{}
+	g.clearSaved()
+}
+
+func (g *generator) clearSaved() {
+	for i := range g.stack {
+		g.stack[i] = nil
+	}
+	g.stack = g.stack[:0]
+	for i := range g.tryStack {
+		g.tryStack[i] = tryFrame{}
+	}
+	g.tryStack = g.tryStack[:0]
+	for i := range g.iterStack {
+		g.iterStack[i] = iterStackItem{}
+	}
+	g.iterStack = g.iterStack[:0]
+	for i := range g.refStack {
+		g.refStack[i] = nil
+	}
+	g.refStack = g.refStack[:0]
+}
+
+// suspend stores the frame and returns v to the caller of the frame as if by a return statement.
+// The execution resumes at pc.
+func (g *generator) suspend(vm *vm, v Value, pc int, state generatorState) {
+	vm.pc = pc
+	g.storeFrame(vm)
+	g.state = state
+	vm.stack[vm.sb-1] = v
+	vm.sp = vm.sb
+	vm.popCtx()
+	if vm.pc < 0 {
+		vm.halt = true
+	}
+}
+
+// resume continues the execution of a suspended frame until it yields, returns or throws. The returned bool
+// is true if the frame has returned (or a return() has completed).
+func (g *generator) resume(vm *vm, mode resumeMode, v Value) (res Value, done bool) {
+	pc := vm.pc
+	callStackLen := len(vm.callStack)
+	sp := vm.sp
+	if pc != -1 {
+		vm.pc++ // fake "return address" so that captureStack() records the correct call location
+		vm.pushCtx()
+		vm.callStack = append(vm.callStack, context{pc: -1}) // extra frame so that run() halts after ret
+	} else {
+		vm.pushCtx()
+	}
+
+	savedGen := vm.curGenerator
+	vm.curGenerator = g
+	defer func() {
+		vm.curGenerator = savedGen
+		if g.state == genStateExecuting {
+			g.state = genStateCompleted
+			g.delegate = nil
+		}
+		if x := recover(); x != nil {
+			ret, ok := x.(*generatorReturn)
+			if !ok {
+				panic(x)
+			}
+			// all the try statements of the frame have been left by now
+			vm.closeIters(vm.iterStack[g.iterBase:])
+			vm.iterStack = vm.iterStack[:g.iterBase]
+			refTail := vm.refStack[g.refBase:]
+			for i := range refTail {
+				refTail[i] = nil
+			}
+			vm.refStack = vm.refStack[:g.refBase]
+			vm.callStack = vm.callStack[:callStackLen+1]
+			vm.popCtx()
+			vm.pc = pc
+			vm.sp = sp
+			vm.halt = false
+			res, done = ret.val, true
+		}
+	}()
+
+	started := g.state == genStateSuspendedYield
+	g.state = genStateExecuting
+	g.restoreFrame(vm)
+
+	var f func()
+	if started {
+		if g.delegating {
+			g.delegating = false
+			g.resumeMode = mode
+			vm.push(v)
+		} else {
+			switch mode {
+			case resumeNext:
+				vm.push(v)
+			case resumeThrow:
+				f = func() {
+					panic(v)
+				}
+			case resumeReturn:
+				f = func() {
+					panic(&generatorReturn{val: v})
+				}
+			}
+		}
+	}
+	vm.runFrom(g.tryBase, f)
+	if pc != -1 {
+		vm.popCtx()
+	}
+	vm.pc = pc
+	vm.halt = false
+	res = vm.pop()
+	done = g.state != genStateSuspendedYield
+	return
+}
+
+type _initGenerator struct{}
+
+// initGenerator is emitted at the beginning of a generator function body. It creates the generator object and
+// suspends the frame returning the object to the caller.
+var initGenerator _initGenerator
+
+func (_initGenerator) exec(vm *vm) {
+	r := vm.r
+	var proto *Object
+	if callee, ok := vm.stack[vm.sb-1].(*Object); ok {
+		proto, _ = callee.self.getStr("prototype", nil).(*Object)
+	}
+	if proto == nil {
+		proto = r.global.GeneratorPrototype
+	}
+	o := r.newGeneratorObject(proto)
+	g := &o.gen
+	g.tryBase, g.iterBase, g.refBase = len(vm.tryStack), len(vm.iterStack), len(vm.refStack)
+	g.suspend(vm, o.val, vm.pc+1, genStateSuspendedStart)
+}
+
+type _yield struct{}
+
+var yield _yield
+
+func (_yield) exec(vm *vm) {
+	v := vm.pop()
+	vm.curGenerator.suspend(vm, v, vm.pc+1, genStateSuspendedYield)
+}
+
+type _yieldDelegateStart struct{}
+
+// yieldDelegateStart gets the iterator for a yield* expression and pushes the value to send to it.
+var yieldDelegateStart _yieldDelegateStart
+
+func (_yieldDelegateStart) exec(vm *vm) {
+	g := vm.curGenerator
+	g.delegate = vm.r.getIterator(vm.stack[vm.sp-1], nil)
+	g.resumeMode = resumeNext
+	vm.stack[vm.sp-1] = _undefined
+	vm.pc++
+}
+
+type _yieldDelegate struct{}
+
+// yieldDelegate forwards the value the generator was resumed with to the iterator of a yield* expression,
+// yields the result as is if the iterator is not done, otherwise replaces the value with the iterator's
+// return value and continues.
+var yieldDelegate _yieldDelegate
+
+func (_yieldDelegate) exec(vm *vm) {
+	r := vm.r
+	g := vm.curGenerator
+	iter := g.delegate
+	received := vm.stack[vm.sp-1]
+	mode := g.resumeMode
+	g.resumeMode = resumeNext
+	var res *Object
+	switch mode {
+	case resumeNext:
+		if iter.next == nil {
+			panic(r.NewTypeError("Iterator does not have a 'next' method"))
+		}
+		res = r.toIterResult(iter.next(FunctionCall{This: iter.iterator, Arguments: []Value{received}}))
+	case resumeThrow:
+		throwMethod := toMethod(iter.iterator.self.getStr("throw", nil))
+		if throwMethod == nil {
+			g.delegate = nil
+			iter.returnIter()
+			panic(r.NewTypeError("The iterator does not provide a 'throw' method"))
+		}
+		res = r.toIterResult(throwMethod(FunctionCall{This: iter.iterator, Arguments: []Value{received}}))
+	case resumeReturn:
+		retMethod := toMethod(iter.iterator.self.getStr("return", nil))
+		if retMethod == nil {
+			g.delegate = nil
+			panic(&generatorReturn{val: received})
+		}
+		res = r.toIterResult(retMethod(FunctionCall{This: iter.iterator, Arguments: []Value{received}}))
+		if nilSafe(res.self.getStr("done", nil)).ToBoolean() {
+			g.delegate = nil
+			panic(&generatorReturn{val: nilSafe(res.self.getStr("value", nil))})
+		}
+	}
+	if nilSafe(res.self.getStr("done", nil)).ToBoolean() {
+		g.delegate = nil
+		vm.stack[vm.sp-1] = nilSafe(res.self.getStr("value", nil))
+		vm.pc++
+		return
+	}
+	vm.sp--
+	g.delegating = true
+	g.suspend(vm, res, vm.pc, genStateSuspendedYield)
+}
+
+func (r *Runtime) toIterResult(v Value) *Object {
+	if o, ok := v.(*Object); ok {
+		return o
+	}
+	panic(r.NewTypeError("Iterator result %s is not an object", v.String()))
+}
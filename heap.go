@@ -0,0 +1,367 @@
+package goja
+
+import (
+	"reflect"
+)
+
+// HeapStats describes the composition of the part of the heap that is reachable from a Runtime.
+// See Runtime.HeapSnapshot().
+type HeapStats struct {
+	// Objects contains the number of reachable objects by class (e.g. "Object", "Array", "Function").
+	// Built-in objects that have not been used yet are not counted.
+	Objects map[string]int
+
+	// ObjectBytes contains the estimated size of the reachable objects by class. The size of an object includes
+	// its properties and the strings and ArrayBuffer data it holds, but not the other objects it refers to.
+	// The estimate is the same as the one used by SetMemoryLimit().
+	ObjectBytes map[string]int
+
+	// Properties is the total number of own properties of the reachable objects, including array elements,
+	// Map and Set entries and private elements.
+	Properties int
+
+	// Strings is the number of string values stored in properties, variables and collections.
+	// The same string stored in several places is counted several times.
+	Strings int
+
+	// StringChars is the total length of the strings counted in Strings, in UTF-16 code units.
+	StringChars int
+
+	// ArrayBufferBytes is the total size of the reachable ArrayBuffers.
+	ArrayBufferBytes int
+
+	// Types contains the number of reachable objects and their estimated size (see ObjectBytes) by the type of
+	// the internal implementation (e.g. "arrayObject", "funcObject"), which is more detailed than the class.
+	Types map[string]HeapTypeStats
+}
+
+// HeapTypeStats contains the number of the objects of a type and their estimated total size in bytes.
+type HeapTypeStats struct {
+	Count int
+	Bytes int
+}
+
+// TotalObjects returns the total number of objects in all classes.
+func (s *HeapStats) TotalObjects() int {
+	n := 0
+	for _, c := range s.Objects {
+		n += c
+	}
+	return n
+}
+
+// Diff returns the difference between s and prev (a snapshot taken earlier), i.e. what was allocated
+// (positive values) or released (negative values) in between. Classes with no change are omitted.
+func (s *HeapStats) Diff(prev *HeapStats) *HeapStats {
+	d := &HeapStats{
+		Objects:          make(map[string]int),
+		ObjectBytes:      make(map[string]int),
+		Properties:       s.Properties - prev.Properties,
+		Strings:          s.Strings - prev.Strings,
+		StringChars:      s.StringChars - prev.StringChars,
+		ArrayBufferBytes: s.ArrayBufferBytes - prev.ArrayBufferBytes,
+	}
+	for class, n := range s.Objects {
+		if delta := n - prev.Objects[class]; delta != 0 {
+			d.Objects[class] = delta
+		}
+	}
+	for class, n := range prev.Objects {
+		if _, exists := s.Objects[class]; !exists {
+			d.Objects[class] = -n
+		}
+	}
+	for class, n := range s.ObjectBytes {
+		if delta := n - prev.ObjectBytes[class]; delta != 0 {
+			d.ObjectBytes[class] = delta
+		}
+	}
+	for class, n := range prev.ObjectBytes {
+		if _, exists := s.ObjectBytes[class]; !exists {
+			d.ObjectBytes[class] = -n
+		}
+	}
+	if s.Types != nil || prev.Types != nil {
+		d.Types = make(map[string]HeapTypeStats)
+		for typ, st := range s.Types {
+			p := prev.Types[typ]
+			if st != p {
+				d.Types[typ] = HeapTypeStats{Count: st.Count - p.Count, Bytes: st.Bytes - p.Bytes}
+			}
+		}
+		for typ, p := range prev.Types {
+			if _, exists := s.Types[typ]; !exists {
+				d.Types[typ] = HeapTypeStats{Count: -p.Count, Bytes: -p.Bytes}
+			}
+		}
+	}
+	return d
+}
+
+// HeapSnapshot traverses everything that is reachable from the global object, the global lexical scope
+// and the current call stack (including the variables captured by closures) and returns the composition
+// of the heap at this point. Values held by Go code (e.g. inside wrapped Go objects) are not traversed.
+// The traversal does not invoke any JavaScript code, i.e. getters and Proxy traps are not called.
+//
+// Taking a snapshot before and after an operation (e.g. in a Go function called by the script) and
+// computing the HeapStats.Diff() shows what the operation has allocated and left reachable.
+//
+// This method is not safe for concurrent use and should only be called by a Go function that is
+// called from a running script or when the Runtime is not running.
+func (r *Runtime) HeapSnapshot() *HeapStats {
+	w := newHeapWalker()
+	w.stats.Types = make(map[string]HeapTypeStats)
+	r.walkHeap(w)
+	return &w.stats
+}
+
+// Rough sizes used to estimate the memory held by the reachable part of the heap (see SetMemoryLimit()).
+const (
+	heapObjectSize   = 128
+	heapPropertySize = 32
+)
+
+type heapWalker struct {
+	stats     HeapStats
+	seen      map[*Object]struct{}
+	seenStash map[*stash]struct{}
+	queue     []*Object
+
+	// the estimated size of what has been visited so far, in bytes
+	bytes uint64
+	// if not 0, the walk stops as soon as bytes exceeds this value
+	maxBytes uint64
+}
+
+func newHeapWalker() *heapWalker {
+	return &heapWalker{
+		stats: HeapStats{
+			Objects:     make(map[string]int),
+			ObjectBytes: make(map[string]int),
+		},
+		seen:      make(map[*Object]struct{}),
+		seenStash: make(map[*stash]struct{}),
+	}
+}
+
+func (r *Runtime) walkHeap(w *heapWalker) {
+	w.visitObj(r.globalObject)
+	w.visitStash(&r.global.stash)
+	vm := r.vm
+	for _, v := range vm.stack[:vm.sp] {
+		w.visitValue(v)
+	}
+	w.visitStash(vm.stash)
+	for i := range vm.callStack {
+		w.visitStash(vm.callStack[i].stash)
+	}
+	for len(w.queue) > 0 {
+		if w.maxBytes != 0 && w.bytes > w.maxBytes {
+			return
+		}
+		o := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		before := w.bytes
+		if class := w.walkObject(o); class != "" {
+			w.stats.ObjectBytes[class] += int(w.bytes - before)
+			if w.stats.Types != nil {
+				w.countType(o, w.bytes-before)
+			}
+		}
+	}
+}
+
+// countType adds the object to HeapStats.Types.
+func (w *heapWalker) countType(o *Object, bytes uint64) {
+	if _, ok := o.self.(*lazyObject); ok {
+		return
+	}
+	t := reflect.TypeOf(o.self)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	st := w.stats.Types[t.Name()]
+	st.Count++
+	st.Bytes += int(bytes)
+	w.stats.Types[t.Name()] = st
+}
+
+type heapBaseHolder interface {
+	heapBase() *baseObject
+}
+
+func (o *baseObject) heapBase() *baseObject {
+	return o
+}
+
+func (w *heapWalker) visitObj(o *Object) {
+	if o == nil {
+		return
+	}
+	if _, exists := w.seen[o]; exists {
+		return
+	}
+	w.seen[o] = struct{}{}
+	w.queue = append(w.queue, o)
+}
+
+func (w *heapWalker) visitValue(v Value) {
+	switch v := v.(type) {
+	case *Object:
+		w.visitObj(v)
+	case valueString:
+		w.stats.Strings++
+		l := v.length()
+		w.stats.StringChars += l
+		if _, ok := v.(asciiString); ok {
+			w.bytes += uint64(l)
+		} else {
+			w.bytes += uint64(l) * 2
+		}
+	case *mappedProperty:
+		w.visitValue(*v.v)
+	case *valueProperty:
+		if v.accessor {
+			w.visitObj(v.getterFunc)
+			w.visitObj(v.setterFunc)
+		} else {
+			w.visitValue(v.value)
+		}
+	}
+}
+
+func (w *heapWalker) visitValues(values []Value) {
+	for _, v := range values {
+		if v != nil {
+			w.stats.Properties++
+			w.bytes += heapPropertySize
+			w.visitValue(v)
+		}
+	}
+}
+
+func (w *heapWalker) visitStash(s *stash) {
+	for ; s != nil; s = s.outer {
+		if _, exists := w.seenStash[s]; exists {
+			return
+		}
+		w.seenStash[s] = struct{}{}
+		for _, v := range s.values {
+			w.visitValue(v)
+		}
+		for _, v := range s.extraArgs {
+			w.visitValue(v)
+		}
+		w.visitObj(s.obj)
+	}
+}
+
+func (w *heapWalker) visitOrderedMap(m *orderedMap) {
+	for e := m.iterFirst; e != nil; e = e.iterNext {
+		w.stats.Properties++
+		w.bytes += heapPropertySize
+		w.visitValue(e.key)
+		w.visitValue(e.value)
+	}
+}
+
+// walkObject counts the object and queues the values it refers to. Returns the class the object has been
+// counted in, or "" if it has not been counted.
+func (w *heapWalker) walkObject(o *Object) string {
+	if _, ok := o.self.(*lazyObject); ok {
+		// not instantiated yet
+		return ""
+	}
+	if p, ok := o.self.(*proxyObject); ok {
+		// className() of a Proxy is the one of its target and it panics if the Proxy is revoked
+		w.stats.Objects["Proxy"]++
+		w.bytes += heapObjectSize
+		w.visitObj(p.target)
+		if h, ok := p.handler.(*jsProxyHandler); ok {
+			w.visitObj(h.handler)
+		}
+		return "Proxy"
+	}
+	class := o.self.className()
+	w.stats.Objects[class]++
+	w.bytes += heapObjectSize
+	if b, ok := o.self.(heapBaseHolder); ok {
+		b := b.heapBase()
+		w.visitObj(b.prototype)
+		for _, slot := range b.slots {
+			w.stats.Properties++
+			w.bytes += heapPropertySize
+			w.visitValue(slot.value)
+		}
+		if b.symValues != nil {
+			w.visitOrderedMap(b.symValues)
+		}
+		for _, elements := range b.privateElements {
+			w.visitValues(elements.fields)
+			w.visitValues(elements.methods)
+		}
+	}
+
+	switch obj := o.self.(type) {
+	case *arrayObject:
+		w.visitValues(obj.values)
+	case *sparseArrayObject:
+		for _, item := range obj.items {
+			w.stats.Properties++
+			w.bytes += heapPropertySize
+			w.visitValue(item.value)
+		}
+	case *funcObject:
+		w.visitStash(obj.stash)
+	case *methodFuncObject:
+		w.visitStash(obj.stash)
+		w.visitObj(obj.homeObject)
+	case *generatorFuncObject:
+		w.visitStash(obj.stash)
+		w.visitObj(obj.homeObject)
+	case *generatorObject:
+		w.visitValues(obj.gen.stack)
+		w.visitStash(obj.gen.ctx.stash)
+	case *asyncFuncObject:
+		w.visitStash(obj.stash)
+		w.visitObj(obj.homeObject)
+	case *arrowFuncObject:
+		w.visitStash(obj.stash)
+		w.visitObj(obj.funcObj)
+		w.visitValue(obj.newTarget)
+	case *asyncArrowFuncObject:
+		w.visitStash(obj.stash)
+		w.visitObj(obj.funcObj)
+		w.visitValue(obj.newTarget)
+	case *classFuncObject:
+		w.visitStash(obj.stash)
+		for _, v := range obj.computedKeys {
+			w.visitValue(v)
+		}
+		for _, v := range obj.privateMethods {
+			w.visitValue(v)
+		}
+	case *boundFuncObject:
+		w.visitObj(obj.wrapped)
+	case *mapObject:
+		w.visitOrderedMap(obj.m)
+	case *setObject:
+		w.visitOrderedMap(obj.m)
+	case *arrayBufferObject:
+		w.stats.ArrayBufferBytes += len(obj.data)
+		w.bytes += uint64(len(obj.data))
+	case *typedArrayObject:
+		w.visitObj(obj.viewedArrayBuf.val)
+	case *dataViewObject:
+		w.visitObj(obj.viewedArrayBuf.val)
+	case *Promise:
+		w.visitValue(obj.result)
+	case *primitiveValueObject:
+		w.visitValue(obj.pValue)
+	case *stringObject:
+		w.visitValue(obj.value)
+	case *arrayIterObject:
+		w.visitObj(obj.obj)
+	}
+	return class
+}
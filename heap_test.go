@@ -0,0 +1,142 @@
+package goja
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeapSnapshotDiff(t *testing.T) {
+	vm := New()
+	var before, after *HeapStats
+	vm.Set("snapshot", func() {
+		if before == nil {
+			before = vm.HeapSnapshot()
+		} else {
+			after = vm.HeapSnapshot()
+		}
+	})
+	const SCRIPT = `
+	var retained = [];
+	function suspect(report) {
+		var local = {};
+		if (report) snapshot();
+		for (var i = 0; i < 10; i++) {
+			retained.push({id: i, name: "item" + i});
+		}
+		retained.push(new Map([[1, "one"]]), new Uint8Array(16), new Proxy({}, {}));
+		var garbage = [1, 2, 3];
+		if (report) snapshot();
+	}
+	// the first call instantiates the built-ins it uses so that they are not included in the diff
+	suspect(false);
+	retained = [];
+	suspect(true);
+	`
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	d := after.Diff(before)
+	if n := d.Objects["Object"]; n != 14 { // 10 items, the Uint8Array and its buffer, the Proxy target and handler
+		t.Errorf("Objects: %d", n)
+	}
+	if n := d.Objects["Array"]; n != 1 { // garbage is still referenced by a local variable
+		t.Errorf("Arrays: %d", n)
+	}
+	if n := d.Objects["Proxy"]; n != 1 {
+		t.Errorf("Proxies: %d", n)
+	}
+	if n := d.Objects["Map"]; n != 1 {
+		t.Errorf("Maps: %d", n)
+	}
+	if n := d.ArrayBufferBytes; n != 16 {
+		t.Errorf("ArrayBufferBytes: %d", n)
+	}
+	if n := d.Strings; n != 11 { // 10 names and "one"
+		t.Errorf("Strings: %d", n)
+	}
+	if n := d.TotalObjects(); n != 17 {
+		t.Errorf("TotalObjects: %d (%v)", n, d.Objects)
+	}
+
+	d = vm.HeapSnapshot().Diff(after)
+	if n := d.Objects["Array"]; n != -1 {
+		t.Errorf("Arrays after return: %d", n)
+	}
+	if n := d.Objects["Object"]; n != -1 { // local
+		t.Errorf("Objects after return: %d", n)
+	}
+}
+
+func TestHeapSnapshotClosures(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var f = (function() {
+		var captured = {a: "x"};
+		return function() {
+			return captured;
+		}
+	})();
+	const lex = [{}, {}];
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := vm.HeapSnapshot()
+	_, err = vm.RunString(`f = null; lex.length = 0;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := vm.HeapSnapshot().Diff(s)
+	if n := d.Objects["Object"]; n != -3 {
+		t.Errorf("Objects: %d", n)
+	}
+	if n := d.Objects["Function"]; n != -1 {
+		t.Errorf("Functions: %d", n)
+	}
+	if n := d.TotalObjects(); n != -4 {
+		t.Errorf("TotalObjects: %d %v", n, d.Objects)
+	}
+}
+
+func TestHeapSnapshotTypes(t *testing.T) {
+	vm := New()
+	if _, err := vm.RunString("var arrays = []; arrays.push(0); arrays.length = 0"); err != nil {
+		t.Fatal(err)
+	}
+	s := vm.HeapSnapshot()
+	_, err := vm.RunString(`
+	for (var i = 0; i < 100; i++) {
+		arrays.push([i, "` + strings.Repeat("x", 100) + `"]);
+	}
+	var buf = new ArrayBuffer(1000);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1 := vm.HeapSnapshot()
+	d := s1.Diff(s)
+	arr := d.Types["arrayObject"]
+	if arr.Count != 100 {
+		t.Errorf("arrayObject count: %d", arr.Count)
+	}
+	if arr.Bytes < 100*(heapObjectSize+2*heapPropertySize+100) {
+		t.Errorf("arrayObject bytes: %d", arr.Bytes)
+	}
+	if b := d.Types["arrayBufferObject"]; b.Count != 1 || b.Bytes < 1000 {
+		t.Errorf("arrayBufferObject: %+v", b)
+	}
+	if b := d.ObjectBytes["Array"]; b != arr.Bytes {
+		t.Errorf("Array bytes: %d, arrayObject bytes: %d", b, arr.Bytes)
+	}
+	// the class of an ArrayBuffer is "Object"
+	if b := d.ObjectBytes["Object"]; b < 1000 {
+		t.Errorf("Object bytes: %d", b)
+	}
+	total := 0
+	for _, st := range s1.Types {
+		total += st.Count
+	}
+	if total != s1.TotalObjects() {
+		t.Errorf("Types total: %d, TotalObjects: %d", total, s1.TotalObjects())
+	}
+}
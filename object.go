@@ -19,6 +19,7 @@ const (
 	classSet      = "Set"
 	classFunction = "Function"
 	classNumber   = "Number"
+	classBigInt   = "BigInt"
 	classString   = "String"
 	classBoolean  = "Boolean"
 	classError    = "Error"
@@ -29,6 +30,10 @@ const (
 	classGlobal   = "global"
 	classPromise  = "Promise"
 
+	classGenerator         = "Generator"
+	classGeneratorFunction = "GeneratorFunction"
+	classAsyncFunction     = "AsyncFunction"
+
 	classArrayIterator        = "Array Iterator"
 	classMapIterator          = "Map Iterator"
 	classSetIterator          = "Set Iterator"
@@ -216,8 +221,13 @@ type baseObject struct {
 	prototype  *Object
 	extensible bool
 
-	values    map[unistring.String]Value
+	// the own string-keyed properties: slotIdx maps the names to their slots, propNames keeps them in order
+	slotIdx   map[unistring.String]int
+	slots     []propSlot
 	propNames []unistring.String
+	// identifies the object along with the layout of its slots in the property cache, 0 if not assigned yet
+	// (see propCacheEntry)
+	shape uint64
 
 	lastSortedPropLen, idxPropCount int
 
@@ -270,7 +280,44 @@ func (f ConstructorCall) Argument(idx int) Value {
 }
 
 func (o *baseObject) init() {
-	o.values = make(map[unistring.String]Value)
+	o.slotIdx = make(map[unistring.String]int)
+}
+
+// getOwnVal returns the value of the own string-keyed property or nil if it does not exist.
+func (o *baseObject) getOwnVal(name unistring.String) Value {
+	if slot, exists := o.slotIdx[name]; exists {
+		return o.slots[slot].value
+	}
+	return nil
+}
+
+// putOwnVal sets the value of the own string-keyed property, allocating a new slot if it does not exist.
+// The name is not added to propNames.
+func (o *baseObject) putOwnVal(name unistring.String, v Value) {
+	if slot, exists := o.slotIdx[name]; exists {
+		o.slots[slot].value = v
+		return
+	}
+	o.slotIdx[name] = len(o.slots)
+	o.slots = append(o.slots, propSlot{name: name, value: v})
+}
+
+// deleteOwnVal frees the slot of the own string-keyed property. The last slot is moved in its place,
+// so the object gets a new shape.
+func (o *baseObject) deleteOwnVal(name unistring.String) {
+	slot, exists := o.slotIdx[name]
+	if !exists {
+		return
+	}
+	delete(o.slotIdx, name)
+	last := len(o.slots) - 1
+	if slot != last {
+		o.slots[slot] = o.slots[last]
+		o.slotIdx[o.slots[slot].name] = slot
+	}
+	o.slots[last] = propSlot{}
+	o.slots = o.slots[:last]
+	o.shape = 0
 }
 
 func (o *baseObject) className() string {
@@ -342,7 +389,7 @@ func (o *baseObject) getSym(s *Symbol, receiver Value) Value {
 }
 
 func (o *baseObject) getStr(name unistring.String, receiver Value) Value {
-	prop := o.values[name]
+	prop := o.getOwnVal(name)
 	if prop == nil {
 		if o.prototype != nil {
 			if receiver == nil {
@@ -372,7 +419,7 @@ func (o *baseObject) getOwnPropSym(s *Symbol) Value {
 }
 
 func (o *baseObject) getOwnPropStr(name unistring.String) Value {
-	return o.values[name]
+	return o.getOwnVal(name)
 }
 
 func (o *baseObject) checkDeleteProp(name unistring.String, prop *valueProperty, throw bool) bool {
@@ -394,7 +441,7 @@ func (o *baseObject) checkDelete(name unistring.String, val Value, throw bool) b
 }
 
 func (o *baseObject) _delete(name unistring.String) {
-	delete(o.values, name)
+	o.deleteOwnVal(name)
 	for i, n := range o.propNames {
 		if n == name {
 			names := o.propNames
@@ -436,7 +483,7 @@ func (o *baseObject) deleteSym(s *Symbol, throw bool) bool {
 }
 
 func (o *baseObject) deleteStr(name unistring.String, throw bool) bool {
-	if val, exists := o.values[name]; exists {
+	if val := o.getOwnVal(name); val != nil {
 		if !o.checkDelete(name, val, throw) {
 			return false
 		}
@@ -468,7 +515,7 @@ func (o *baseObject) setProto(proto *Object, throw bool) bool {
 }
 
 func (o *baseObject) setOwnStr(name unistring.String, val Value, throw bool) bool {
-	ownDesc := o.values[name]
+	ownDesc := o.getOwnVal(name)
 	if ownDesc == nil {
 		if proto := o.prototype; proto != nil {
 			// we know it's foreign because prototype loops are not allowed
@@ -481,9 +528,12 @@ func (o *baseObject) setOwnStr(name unistring.String, val Value, throw bool) boo
 			o.val.runtime.typeErrorResult(throw, "Cannot add property %s, object is not extensible", name)
 			return false
 		} else {
-			o.values[name] = val
+			o.putOwnVal(name, val)
 			names := copyNamesIfNeeded(o.propNames, 1)
 			o.propNames = append(names, name)
+			if r := o.val.runtime; r.dictThreshold > 0 {
+				r.traceDynamicProp(o.val)
+			}
 		}
 		return true
 	}
@@ -495,7 +545,7 @@ func (o *baseObject) setOwnStr(name unistring.String, val Value, throw bool) boo
 			prop.set(o.val, val)
 		}
 	} else {
-		o.values[name] = val
+		o.putOwnVal(name, val)
 	}
 	return true
 }
@@ -525,6 +575,9 @@ func (o *baseObject) setOwnSym(name *Symbol, val Value, throw bool) bool {
 				o.symValues = newOrderedMap(nil)
 			}
 			o.symValues.set(name, val)
+			if r := o.val.runtime; r.dictThreshold > 0 {
+				r.traceDynamicProp(o.val)
+			}
 		}
 		return true
 	}
@@ -588,7 +641,7 @@ func (o *baseObject) _setForeignIdx(idx valueInt, prop, val, receiver Value, thr
 }
 
 func (o *baseObject) setForeignStr(name unistring.String, val, receiver Value, throw bool) (bool, bool) {
-	return o._setForeignStr(name, o.values[name], val, receiver, throw)
+	return o._setForeignStr(name, o.getOwnVal(name), val, receiver, throw)
 }
 
 func (o *baseObject) setForeignIdx(name valueInt, val, receiver Value, throw bool) (bool, bool) {
@@ -636,7 +689,7 @@ func (o *baseObject) hasOwnPropertySym(s *Symbol) bool {
 }
 
 func (o *baseObject) hasOwnPropertyStr(name unistring.String) bool {
-	_, exists := o.values[name]
+	_, exists := o.slotIdx[name]
 	return exists
 }
 
@@ -748,9 +801,9 @@ Reject:
 }
 
 func (o *baseObject) defineOwnPropertyStr(name unistring.String, descr PropertyDescriptor, throw bool) bool {
-	existingVal := o.values[name]
+	existingVal := o.getOwnVal(name)
 	if v, ok := o._defineOwnProperty(name, existingVal, descr, throw); ok {
-		o.values[name] = v
+		o.putOwnVal(name, v)
 		if existingVal == nil {
 			names := copyNamesIfNeeded(o.propNames, 1)
 			o.propNames = append(names, name)
@@ -780,12 +833,12 @@ func (o *baseObject) defineOwnPropertySym(s *Symbol, descr PropertyDescriptor, t
 }
 
 func (o *baseObject) _put(name unistring.String, v Value) {
-	if _, exists := o.values[name]; !exists {
+	if _, exists := o.slotIdx[name]; !exists {
 		names := copyNamesIfNeeded(o.propNames, 1)
 		o.propNames = append(names, name)
 	}
 
-	o.values[name] = v
+	o.putOwnVal(name, v)
 }
 
 func valueProp(value Value, writable, enumerable, configurable bool) Value {
@@ -1188,7 +1241,7 @@ func (i *objectPropIter) next() (propIterItem, iterNextFunc) {
 	for i.idx < len(i.propNames) {
 		name := i.propNames[i.idx]
 		i.idx++
-		prop := i.o.values[name]
+		prop := i.o.getOwnVal(name)
 		if prop != nil {
 			return propIterItem{name: stringValueFromRaw(name), value: prop}, i.next
 		}
@@ -1364,7 +1417,7 @@ func (o *baseObject) stringKeys(all bool, keys []Value) []Value {
 		}
 	} else {
 		for _, k := range o.propNames {
-			prop := o.values[k]
+			prop := o.getOwnVal(k)
 			if prop, ok := prop.(*valueProperty); ok && !prop.enumerable {
 				continue
 			}
@@ -60,9 +60,28 @@ type DynamicArray interface {
 	SetLen(int) bool
 }
 
+/*
+DynamicObjectSymbols is an optional interface that can be implemented by a DynamicObject or a DynamicArray
+handler to provide Symbol properties, such as Symbol.iterator, Symbol.toPrimitive or Symbol.toStringTag.
+This allows a dynamic object to take part in the language protocols (i.e. to be iterable, to have a custom
+conversion to a primitive value or a custom Object.prototype.toString() tag) without a JavaScript shim.
+
+The Symbol properties are read-only, non-enumerable and non-configurable data properties. They cannot be
+assigned, defined or deleted. If GetSymbol returns nil, the property is looked up in the prototype.
+
+See TestDynamicObjectSymbols for an example.
+*/
+type DynamicObjectSymbols interface {
+	// GetSymbol returns a property value for the Symbol key. May return nil if the property does not exist.
+	GetSymbol(sym *Symbol) Value
+	// SymbolKeys returns a list of all existing Symbol property keys.
+	SymbolKeys() []*Symbol
+}
+
 type baseDynamicObject struct {
 	val       *Object
 	prototype *Object
+	sym       DynamicObjectSymbols
 }
 
 type dynamicObject struct {
@@ -86,8 +105,9 @@ The Object is always extensible and cannot be made non-extensible. Object.preven
 The Object's prototype is initially set to Object.prototype, but can be changed using regular mechanisms
 (Object.SetPrototype() in Go or Object.setPrototypeOf() in JS).
 
-The Object cannot have own Symbol properties, however its prototype can. If you need an iterator support for
-example, you could create a regular object, set Symbol.iterator on that object and then use it as a
+The Object cannot have own Symbol properties unless the DynamicObject also implements DynamicObjectSymbols,
+however its prototype can. If you need an iterator support for example, you could either implement
+DynamicObjectSymbols, or create a regular object, set Symbol.iterator on that object and then use it as a
 prototype. See TestDynamicObjectCustomProto for more details.
 
 Export() returns the original DynamicObject.
@@ -105,6 +125,7 @@ func (r *Runtime) NewDynamicObject(d DynamicObject) *Object {
 			prototype: r.global.ObjectPrototype,
 		},
 	}
+	o.sym, _ = d.(DynamicObjectSymbols)
 	v.self = o
 	return v
 }
@@ -121,6 +142,7 @@ func NewSharedDynamicObject(d DynamicObject) *Object {
 			val: v,
 		},
 	}
+	o.sym, _ = d.(DynamicObjectSymbols)
 	v.self = o
 	return v
 }
@@ -144,6 +166,7 @@ func (r *Runtime) NewDynamicArray(a DynamicArray) *Object {
 			prototype: r.global.ArrayPrototype,
 		},
 	}
+	o.sym, _ = a.(DynamicObjectSymbols)
 	v.self = o
 	return v
 }
@@ -161,6 +184,7 @@ func NewSharedDynamicArray(a DynamicArray) *Object {
 			val: v,
 		},
 	}
+	o.sym, _ = a.(DynamicObjectSymbols)
 	v.self = o
 	return v
 }
@@ -216,7 +240,17 @@ func (o *dynamicObject) getIdx(p valueInt, receiver Value) Value {
 	return prop
 }
 
+func (o *baseDynamicObject) getOwnSym(p *Symbol) Value {
+	if o.sym != nil {
+		return o.sym.GetSymbol(p)
+	}
+	return nil
+}
+
 func (o *baseDynamicObject) getSym(p *Symbol, receiver Value) Value {
+	if prop := o.getOwnSym(p); prop != nil {
+		return prop
+	}
 	if proto := o.prototype; proto != nil {
 		if receiver == nil {
 			return proto.self.getSym(p, o.val)
@@ -234,7 +268,12 @@ func (o *dynamicObject) getOwnPropIdx(v valueInt) Value {
 	return o.d.Get(v.String())
 }
 
-func (*baseDynamicObject) getOwnPropSym(*Symbol) Value {
+func (o *baseDynamicObject) getOwnPropSym(p *Symbol) Value {
+	if prop := o.getOwnSym(p); prop != nil {
+		return &valueProperty{
+			value: prop,
+		}
+	}
 	return nil
 }
 
@@ -277,6 +316,10 @@ func (o *dynamicObject) setOwnIdx(p valueInt, v Value, throw bool) bool {
 }
 
 func (o *baseDynamicObject) setOwnSym(s *Symbol, v Value, throw bool) bool {
+	if o.getOwnSym(s) != nil {
+		typeErrorResult(throw, "Cannot assign to read only property '%s'", s)
+		return false
+	}
 	if proto := o.prototype; proto != nil {
 		// we know it's foreign because prototype loops are not allowed
 		if res, handled := proto.self.setForeignSym(s, v, o.val, throw); handled {
@@ -324,6 +367,10 @@ func (o *dynamicObject) setForeignIdx(p valueInt, v, receiver Value, throw bool)
 }
 
 func (o *baseDynamicObject) setForeignSym(p *Symbol, v, receiver Value, throw bool) (res bool, handled bool) {
+	if o.getOwnSym(p) != nil {
+		typeErrorResult(throw, "Cannot assign to read only property '%s'", p)
+		return false, true
+	}
 	if proto := o.prototype; proto != nil {
 		if receiver != proto {
 			return proto.self.setForeignSym(p, v, receiver, throw)
@@ -354,6 +401,9 @@ func (o *dynamicObject) hasPropertyIdx(idx valueInt) bool {
 }
 
 func (o *baseDynamicObject) hasPropertySym(s *Symbol) bool {
+	if o.hasOwnPropertySym(s) {
+		return true
+	}
 	if proto := o.prototype; proto != nil {
 		return proto.self.hasPropertySym(s)
 	}
@@ -368,8 +418,8 @@ func (o *dynamicObject) hasOwnPropertyIdx(v valueInt) bool {
 	return o.d.Has(v.String())
 }
 
-func (*baseDynamicObject) hasOwnPropertySym(_ *Symbol) bool {
-	return false
+func (o *baseDynamicObject) hasOwnPropertySym(s *Symbol) bool {
+	return o.getOwnSym(s) != nil
 }
 
 func (o *baseDynamicObject) checkDynamicObjectPropertyDescr(name fmt.Stringer, descr PropertyDescriptor, throw bool) bool {
@@ -427,7 +477,11 @@ func (o *dynamicObject) deleteIdx(idx valueInt, throw bool) bool {
 	return o._delete(idx.String(), throw)
 }
 
-func (*baseDynamicObject) deleteSym(_ *Symbol, _ bool) bool {
+func (o *baseDynamicObject) deleteSym(s *Symbol, throw bool) bool {
+	if o.getOwnSym(s) != nil {
+		typeErrorResult(throw, "Could not delete property %s of a dynamic object", s.descriptiveString())
+		return false
+	}
 	return true
 }
 
@@ -498,14 +552,40 @@ func (o *dynamicObject) iterateStringKeys() iterNextFunc {
 	}).next
 }
 
+type dynamicObjectSymbolIter struct {
+	o    *baseDynamicObject
+	syms []*Symbol
+	idx  int
+}
+
+func (i *dynamicObjectSymbolIter) next() (propIterItem, iterNextFunc) {
+	for i.idx < len(i.syms) {
+		sym := i.syms[i.idx]
+		i.idx++
+		if i.o.hasOwnPropertySym(sym) {
+			return propIterItem{name: sym, enumerable: _ENUM_FALSE}, i.next
+		}
+	}
+	return propIterItem{}, nil
+}
+
 func (o *baseDynamicObject) iterateSymbols() iterNextFunc {
+	if o.sym != nil {
+		return (&dynamicObjectSymbolIter{
+			o:    o,
+			syms: o.sym.SymbolKeys(),
+		}).next
+	}
 	return func() (propIterItem, iterNextFunc) {
 		return propIterItem{}, nil
 	}
 }
 
 func (o *dynamicObject) iterateKeys() iterNextFunc {
-	return o.iterateStringKeys()
+	return (&objectAllPropIter{
+		o:      o.val,
+		curStr: o.iterateStringKeys(),
+	}).next
 }
 
 func (o *dynamicObject) export(ctx *objectExportCtx) interface{} {
@@ -544,12 +624,18 @@ func (o *dynamicObject) stringKeys(all bool, accum []Value) []Value {
 	return accum
 }
 
-func (*baseDynamicObject) symbols(all bool, accum []Value) []Value {
+func (o *baseDynamicObject) symbols(all bool, accum []Value) []Value {
+	// all Symbol properties are non-enumerable
+	if all && o.sym != nil {
+		for _, sym := range o.sym.SymbolKeys() {
+			accum = append(accum, sym)
+		}
+	}
 	return accum
 }
 
 func (o *dynamicObject) keys(all bool, accum []Value) []Value {
-	return o.stringKeys(all, accum)
+	return o.symbols(all, o.stringKeys(all, accum))
 }
 
 func (*baseDynamicObject) _putProp(name unistring.String, value Value, writable, enumerable, configurable bool) Value {
@@ -755,7 +841,10 @@ func (a *dynamicArray) iterateStringKeys() iterNextFunc {
 }
 
 func (a *dynamicArray) iterateKeys() iterNextFunc {
-	return a.iterateStringKeys()
+	return (&objectAllPropIter{
+		o:      a.val,
+		curStr: a.iterateStringKeys(),
+	}).next
 }
 
 func (a *dynamicArray) export(ctx *objectExportCtx) interface{} {
@@ -794,5 +883,5 @@ func (a *dynamicArray) stringKeys(all bool, accum []Value) []Value {
 }
 
 func (a *dynamicArray) keys(all bool, accum []Value) []Value {
-	return a.stringKeys(all, accum)
+	return a.symbols(all, a.stringKeys(all, accum))
 }
@@ -164,6 +164,123 @@ func TestDynamicObjectCustomProto(t *testing.T) {
 	}
 }
 
+type testDynObjectSymbols struct {
+	testDynObject
+	syms map[*Symbol]Value
+}
+
+func (t *testDynObjectSymbols) GetSymbol(sym *Symbol) Value {
+	return t.syms[sym]
+}
+
+func (t *testDynObjectSymbols) SymbolKeys() []*Symbol {
+	keys := make([]*Symbol, 0, len(t.syms))
+	for k := range t.syms {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestDynamicObjectSymbols(t *testing.T) {
+	vm := New()
+	dynObj := &testDynObjectSymbols{
+		testDynObject: testDynObject{
+			r: vm,
+			m: make(map[string]Value),
+		},
+		syms: map[*Symbol]Value{
+			SymToStringTag: vm.ToValue("GoObject"),
+			SymToPrimitive: vm.ToValue(func(call FunctionCall) Value {
+				if call.Argument(0).String() == "number" {
+					return vm.ToValue(42)
+				}
+				return vm.ToValue("str")
+			}),
+			SymIterator: vm.ToValue(func(call FunctionCall) Value {
+				i := 0
+				iter := vm.NewObject()
+				iter.Set("next", func(FunctionCall) Value {
+					res := vm.NewObject()
+					if i < 3 {
+						i++
+						res.Set("value", i)
+						res.Set("done", false)
+					} else {
+						res.Set("done", true)
+					}
+					return res
+				})
+				return iter
+			}),
+		},
+	}
+	o := vm.NewDynamicObject(dynObj)
+	vm.Set("o", o)
+	vm.testScriptWithTestLibX(`
+	assert.sameValue(Object.prototype.toString.call(o), "[object GoObject]", "toStringTag");
+	assert.sameValue(+o, 42, "toPrimitive number");
+	assert.sameValue(`+"`${o}`"+`, "str", "toPrimitive string");
+	assert(compareArray([...o], [1, 2, 3]), "iterator");
+
+	assert(Symbol.iterator in o, "in");
+	assert(o.hasOwnProperty(Symbol.iterator), "hasOwnProperty");
+	assert(!o.hasOwnProperty(Symbol.asyncIterator), "hasOwnProperty (missing)");
+	var desc = Object.getOwnPropertyDescriptor(o, Symbol.toStringTag);
+	assert(deepEqual(desc, {value: "GoObject", writable: false, enumerable: false, configurable: false}), "prop desc");
+	assert.sameValue(Object.getOwnPropertySymbols(o).length, 3, "getOwnPropertySymbols");
+	assert.sameValue(Reflect.ownKeys(o).length, 3, "ownKeys");
+	assert.sameValue(Object.keys(o).length, 0, "keys");
+
+	assert.throws(TypeError, function() {
+		"use strict";
+		o[Symbol.toStringTag] = "x";
+	}, "assign");
+	assert.throws(TypeError, function() {
+		"use strict";
+		delete o[Symbol.toStringTag];
+	}, "delete");
+	assert.throws(TypeError, function() {
+		"use strict";
+		o[Symbol.unscopables] = {};
+	}, "assign new");
+
+	var child = Object.create(o);
+	assert.sameValue(Object.prototype.toString.call(child), "[object GoObject]", "inherited");
+	assert.throws(TypeError, function() {
+		"use strict";
+		child[Symbol.toStringTag] = "x";
+	}, "assign inherited");
+	`, _undefined, t)
+}
+
+func TestDynamicObjectSymbolsNonConfigurable(t *testing.T) {
+	vm := New()
+	o := vm.NewDynamicObject(&testDynObjectSymbols{
+		testDynObject: testDynObject{
+			r: vm,
+			m: make(map[string]Value),
+		},
+		syms: map[*Symbol]Value{
+			SymToStringTag: vm.ToValue("GoObject"),
+		},
+	})
+	vm.Set("o", o)
+	vm.testScriptWithTestLibX(`
+	var desc = Object.getOwnPropertyDescriptor(o, Symbol.toStringTag);
+	assert.sameValue(desc.configurable, false, "configurable");
+	assert.sameValue(delete o[Symbol.toStringTag], false, "delete");
+	assert.throws(TypeError, function() {
+		"use strict";
+		delete o[Symbol.toStringTag];
+	}, "delete (strict)");
+	assert.throws(TypeError, function() {
+		Object.defineProperty(o, Symbol.toStringTag, {value: "x"});
+	}, "defineProperty");
+	assert.sameValue(Reflect.deleteProperty(o, Symbol.toStringTag), false, "Reflect.deleteProperty");
+	assert.sameValue(o[Symbol.toStringTag], "GoObject", "value");
+	`, _undefined, t)
+}
+
 func TestDynamicArray(t *testing.T) {
 	vm := New()
 	dynObj := &testDynArray{
@@ -93,6 +93,64 @@ func TestPropertyOrder(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestPropertyOrderEnumeration(t *testing.T) {
+	const ENUMERATE = `
+	function forIn(o) {
+		var keys = [];
+		for (var k in o) {
+			keys.push(k);
+		}
+		return keys.join();
+	}
+	function rest(o) {
+		var {...r} = o;
+		return Object.keys(r).join();
+	}
+	function check(o) {
+		var keys = Object.keys(o).join();
+		var all = [forIn(o), Object.keys({...o}).join(), rest(o), Object.keys(Object.assign({}, o)).join()];
+		for (var i = 0; i < all.length; i++) {
+			if (all[i] !== keys) {
+				throw new Error("Mismatch #" + i + ": " + all[i] + " vs " + keys);
+			}
+		}
+		return Reflect.ownKeys(o).map(String).join() + "|" + keys;
+	}
+	`
+	for _, tc := range []struct {
+		name, src, expected string
+	}{
+		{"mixed", `var o = {b: 1, 10: 1, a: 1, 2: 1}; o[Symbol("s")] = 1; o[1] = 1;`, "1,2,10,b,a,Symbol(s)|1,2,10,b,a"},
+		{"negative", `var o = {"-1": 1, 1: 1, "-0": 1, 0: 1};`, "0,1,-1,-0|0,1,-1,-0"},
+		{"leading zeros", `var o = {"08": 1, 8: 1, "00": 1, 0: 1};`, "0,8,08,00|0,8,08,00"},
+		{"large", `var o = {4294967295: 1, 4294967294: 1, 1e21: 1, 1.5: 1, 3: 1};`, "3,4294967294,4294967295,1e+21,1.5|3,4294967294,4294967295,1e+21,1.5"},
+		{"added after enumeration", `var o = {5: 1, z: 1}; Object.keys(o); o[7] = 1; o[1] = 1; o.y = 1;`, "1,5,7,z,y|1,5,7,z,y"},
+		{"deleted and re-added", `var o = {a: 1, 5: 1, 3: 1}; Object.keys(o); delete o[3]; o[1] = 1; o[3] = 1; o[0] = 1;`, "0,1,3,5,a|0,1,3,5,a"},
+		{"non-enumerable", `var o = {2: 1, a: 1}; Object.defineProperty(o, 1, {value: 1}); Object.defineProperty(o, "b", {value: 1});`, "1,2,a,b|2,a"},
+		{"function", `var o = function f() {}; o.x = 1; o[3] = 1; o[1] = 1;
+			var lazy = Reflect.ownKeys(o).join();
+			if (lazy !== "1,3,length,name,x,prototype") throw new Error("lazy: " + lazy);`, "1,3,length,name,x,prototype|1,3,x"},
+		{"error", `var o = new Error("m"); o.q = 1; o[2] = 1; o[0] = 1;
+			var lazy = Reflect.ownKeys(o).join();
+			if (lazy !== "0,2,stack,message,q") throw new Error("lazy: " + lazy);`, "0,2,stack,message,q|0,2,q"},
+		{"error with stack", `var o = new Error("m"); o[2] = 1; o.stack; o[0] = 1;`, "0,2,stack,message|0,2"},
+		{"array", `var o = [1, 2]; o.x = 1; o[5] = 1; o[3] = 1;`, "0,1,3,5,length,x|0,1,3,5,x"},
+		{"arguments", `var o = (function() { arguments.x = 1; arguments[5] = 1; arguments[3] = 1; return arguments; })(1, 2);`, "0,1,3,5,length,callee,x,Symbol(Symbol.iterator)|0,1,3,5,x"},
+		{"string", `var o = new String("ab"); o.x = 1; o[5] = 1; o[3] = 1;`, "0,1,3,5,length,x|0,1,3,5,x"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := New()
+			v, err := vm.RunString(ENUMERATE + tc.src + "check(o);")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := v.String(); s != tc.expected {
+				t.Fatalf("Unexpected order: %s", s)
+			}
+		})
+	}
+}
+
 func TestDefinePropertiesSymbol(t *testing.T) {
 	const SCRIPT = `
 	var desc = {};
@@ -484,6 +542,333 @@ Object.defineProperties({}, proxy);
 	testScript(SCRIPT, valueTrue, t)
 }
 
+func TestInheritedAccessorReceiver(t *testing.T) {
+	const SCRIPT = `
+	var sym = Symbol("s");
+	var receivers = [];
+	function acc() {
+		return {
+			get: function() {
+				receivers.push(this);
+				return this.v;
+			},
+			set: function(v) {
+				receivers.push(this);
+				this.v = v;
+			},
+			configurable: true
+		};
+	}
+	var grandparent = {};
+	Object.defineProperty(grandparent, "x", acc());
+	Object.defineProperty(grandparent, 0, acc());
+	Object.defineProperty(grandparent, sym, acc());
+	var parent = Object.create(grandparent);
+	var inst = Object.create(parent);
+
+	inst.x = 1;
+	assert.sameValue(inst.x, 1, "x");
+	inst[0] = 2;
+	assert.sameValue(inst[0], 2, "0");
+	inst[sym] = 3;
+	assert.sameValue(inst[sym], 3, "sym");
+
+	assert.sameValue(receivers.length, 6, "receivers.length");
+	for (var i = 0; i < receivers.length; i++) {
+		assert.sameValue(receivers[i], inst, "receiver #" + i);
+	}
+	assert(inst.hasOwnProperty("v"), "inst.v");
+	assert(!parent.hasOwnProperty("v") && !grandparent.hasOwnProperty("v"), "prototypes must not be modified");
+	assert(!inst.hasOwnProperty("x"), "accessor must not be shadowed");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestInheritedAccessorReceiverClass(t *testing.T) {
+	const SCRIPT = `
+	class A {
+		get who() {
+			return this.name;
+		}
+		set who(v) {
+			this.name = v;
+		}
+	}
+	class B extends A {}
+	class C extends B {
+		constructor() {
+			super();
+			this.name = "c";
+		}
+	}
+	var c = new C();
+	assert.sameValue(c.who, "c", "get");
+	c.who = "d";
+	assert.sameValue(c.name, "d", "set");
+	assert(!A.prototype.hasOwnProperty("name"), "A.prototype.name");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestInheritedAccessorReceiverProxy(t *testing.T) {
+	const SCRIPT = `
+	var receivers = [];
+	var grandparent = {
+		get x() {
+			receivers.push(this);
+			return 42;
+		},
+		set x(v) {
+			receivers.push(this);
+		}
+	};
+
+	// transparent proxy in the middle of the chain
+	var parent = new Proxy(Object.create(grandparent), {});
+	var inst = Object.create(parent);
+	assert.sameValue(inst.x, 42, "get via transparent proxy");
+	inst.x = 1;
+
+	// proxy with traps: the trap must receive the original receiver
+	var trapReceivers = [];
+	var parent1 = new Proxy(Object.create(grandparent), {
+		get: function(target, key, receiver) {
+			trapReceivers.push(receiver);
+			return Reflect.get(target, key, receiver);
+		},
+		set: function(target, key, value, receiver) {
+			trapReceivers.push(receiver);
+			return Reflect.set(target, key, value, receiver);
+		}
+	});
+	var inst1 = Object.create(parent1);
+	assert.sameValue(inst1.x, 42, "get via proxy with traps");
+	inst1.x = 1;
+
+	assert.sameValue(receivers.length, 4, "receivers.length");
+	assert.sameValue(receivers[0], inst, "receivers[0]");
+	assert.sameValue(receivers[1], inst, "receivers[1]");
+	assert.sameValue(receivers[2], inst1, "receivers[2]");
+	assert.sameValue(receivers[3], inst1, "receivers[3]");
+	assert.sameValue(trapReceivers.length, 2, "trapReceivers.length");
+	assert.sameValue(trapReceivers[0], inst1, "trapReceivers[0]");
+	assert.sameValue(trapReceivers[1], inst1, "trapReceivers[1]");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestInheritedAccessorReceiverGo(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var proto = {
+		get x() {
+			return this;
+		}
+	};
+	var inst = Object.create(Object.create(proto));
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := vm.Get("inst").(*Object)
+	if res := inst.Get("x"); res != inst {
+		t.Fatalf("Unexpected receiver: %v", res)
+	}
+}
+
+func TestSymbolKeysEnumeration(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("s");
+	var proto = {[Symbol("proto")]: 1, inherited: 1};
+	var o = Object.create(proto);
+	o.a = 1;
+	o[s] = 2;
+	Object.defineProperty(o, Symbol("e"), {value: 3, enumerable: true});
+
+	var forIn = [];
+	for (var k in o) {
+		forIn.push(k);
+	}
+	assert(compareArray(forIn, ["a", "inherited"]), "for-in: " + forIn.join());
+	forIn = [];
+	for (var k in new Proxy(o, {})) {
+		forIn.push(k);
+	}
+	assert(compareArray(forIn, ["a", "inherited"]), "for-in proxy: " + forIn.join());
+
+	assert(compareArray(Object.keys(o), ["a"]), "keys");
+	assert(compareArray(Object.values(o), [1]), "values");
+	assert.sameValue(Object.entries(o).length, 1, "entries");
+	assert(compareArray(Object.getOwnPropertyNames(o), ["a"]), "getOwnPropertyNames");
+
+	var symbols = Object.getOwnPropertySymbols(o);
+	assert.sameValue(symbols.length, 2, "getOwnPropertySymbols");
+	assert.sameValue(symbols[0], s);
+	var ownKeys = Reflect.ownKeys(o);
+	assert.sameValue(ownKeys.length, 3, "ownKeys");
+	assert.sameValue(ownKeys[0], "a");
+	assert.sameValue(ownKeys[1], s);
+	assert.sameValue(Object.assign({}, o)[s], 2, "assign");
+	assert.sameValue(({...o})[s], 2, "spread");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	o := vm.NewObject()
+	_ = o.Set("a", 1)
+	_ = o.SetSymbol(SymIterator, 2)
+	if keys := o.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("Keys: %v", keys)
+	}
+	if syms := o.Symbols(); len(syms) != 1 || syms[0] != SymIterator {
+		t.Fatalf("Symbols: %v", syms)
+	}
+}
+
+func TestGetterOnlyAssignSloppy(t *testing.T) {
+	const SCRIPT = `
+	var proto = {
+		get x() {
+			return 1;
+		}
+	};
+	Object.defineProperty(proto, 0, {
+		get: function() {
+			return 2;
+		}
+	});
+	var inst = Object.create(proto);
+
+	proto.x = 42;
+	proto[0] = 42;
+	assert.sameValue(proto.x, 1, "own");
+	assert.sameValue(proto[0], 2, "own index");
+
+	inst.x = 42;
+	inst["x"] = 42;
+	inst[0] = 42;
+	inst.x += 1;
+	inst.x++;
+	assert.sameValue(inst.x, 1, "inherited");
+	assert.sameValue(inst[0], 2, "inherited index");
+	assert.sameValue(Object.getOwnPropertyNames(inst).length, 0, "own properties");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestGetterOnlyAssignStrict(t *testing.T) {
+	const SCRIPT = `
+	'use strict';
+	var sym = Symbol();
+	var proto = {
+		get x() {
+			return 1;
+		},
+		get [sym]() {
+			return 3;
+		}
+	};
+	Object.defineProperty(proto, 0, {
+		get: function() {
+			return 2;
+		}
+	});
+	var inst = Object.create(proto);
+	var key = "x";
+
+	assert.throws(TypeError, function() { proto.x = 42; }, "own");
+	assert.throws(TypeError, function() { proto[0] = 42; }, "own index");
+
+	assert.throws(TypeError, function() { inst.x = 42; }, "inherited");
+	assert.throws(TypeError, function() { inst[key] = 42; }, "inherited computed");
+	assert.throws(TypeError, function() { inst[0] = 42; }, "inherited index");
+	assert.throws(TypeError, function() { inst[sym] = 42; }, "inherited symbol");
+	assert.throws(TypeError, function() { inst.x += 1; }, "inherited compound");
+	assert.throws(TypeError, function() { inst.x++; }, "inherited update");
+	assert.throws(TypeError, function() { [inst.x] = [42]; }, "inherited destructuring");
+
+	assert.sameValue(inst.x, 1);
+	assert.sameValue(inst[0], 2);
+	assert.sameValue(inst[sym], 3);
+	assert.sameValue(Reflect.ownKeys(inst).length, 0, "own properties");
+	assert.sameValue(Reflect.set(inst, "x", 42), false, "Reflect.set");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestExistenceCheckNoGetters(t *testing.T) {
+	const SCRIPT = `
+	var calls = 0;
+	var sym = Symbol();
+	var obj = {
+		get k() {
+			calls++;
+			return 1;
+		},
+		get [sym]() {
+			calls++;
+			return 2;
+		}
+	};
+	Object.defineProperty(obj, 0, {
+		get: function() {
+			calls++;
+			return 3;
+		},
+		enumerable: true
+	});
+	var inst = Object.create(obj);
+
+	assert("k" in obj, "in");
+	assert(sym in obj, "in (symbol)");
+	assert(0 in obj, "in (index)");
+	assert(obj.hasOwnProperty("k"), "hasOwnProperty");
+	assert(obj.hasOwnProperty(sym), "hasOwnProperty (symbol)");
+	assert(obj.hasOwnProperty(0), "hasOwnProperty (index)");
+	assert(Reflect.has(obj, "k"), "Reflect.has");
+	assert("k" in inst, "in (inherited)");
+	assert(0 in inst, "in (inherited index)");
+	assert(!inst.hasOwnProperty("k"), "hasOwnProperty (inherited)");
+	assert(Reflect.has(inst, sym), "Reflect.has (inherited)");
+	assert(obj.propertyIsEnumerable(0), "propertyIsEnumerable");
+	assert.sameValue(typeof Object.getOwnPropertyDescriptor(obj, "k").get, "function", "getOwnPropertyDescriptor");
+	assert.sameValue(Object.keys(obj).length, 2, "keys");
+	assert.sameValue(calls, 0, "getters called by existence checks");
+
+	assert.sameValue(obj.k, 1, "get");
+	assert.sameValue(inst[sym], 2, "get (inherited symbol)");
+	assert.sameValue(inst[0], 3, "get (inherited index)");
+	assert.sameValue(calls, 3, "getters called by reads");
+
+	var traps = [];
+	var p = new Proxy(obj, {
+		has: function(target, key) {
+			traps.push("has");
+			return Reflect.has(target, key);
+		},
+		get: function(target, key, receiver) {
+			traps.push("get");
+			return Reflect.get(target, key, receiver);
+		},
+		getOwnPropertyDescriptor: function(target, key) {
+			traps.push("getOwnPropertyDescriptor");
+			return Reflect.getOwnPropertyDescriptor(target, key);
+		}
+	});
+	calls = 0;
+	assert("k" in p, "in (proxy)");
+	assert(Reflect.has(p, sym), "Reflect.has (proxy)");
+	assert("k" in Object.create(p), "in (proxy in prototype chain)");
+	assert(Object.prototype.hasOwnProperty.call(p, "k"), "hasOwnProperty (proxy)");
+	assert.sameValue(traps.join(), "has,has,has,getOwnPropertyDescriptor", "traps");
+	assert.sameValue(calls, 0, "getters called by existence checks (proxy)");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func ExampleObject_Delete() {
 	vm := New()
 	obj := vm.NewObject()
@@ -495,6 +880,72 @@ func ExampleObject_Delete() {
 	// Output: before: true, after: <nil>
 }
 
+func TestObjectIsKind(t *testing.T) {
+	vm := New()
+	vm.Set("goSlice", []int{1, 2})
+	vm.Set("goMap", map[string]int{})
+	vm.Set("goFunc", func(FunctionCall) Value { return nil })
+	v, err := vm.RunString(`
+	var revocable = Proxy.revocable([], {});
+	revocable.revoke();
+	({
+		array: [],
+		goSlice: goSlice,
+		proxyArray: new Proxy([], {}),
+		revokedProxy: revocable.proxy,
+		date: new Date(),
+		regexp: /x/,
+		map: new Map(),
+		set: new Set(),
+		promise: Promise.resolve(),
+		typedArray: new Uint8Array(1),
+		func: function() {},
+		arrow: () => {},
+		method: {m() {}}.m,
+		class: class {},
+		bound: function() {}.bind(null),
+		goFunc: goFunc,
+		proxyFunc: new Proxy(function() {}, {}),
+		object: {},
+		goMap: goMap,
+		fakeRegExp: {[Symbol.match]: true},
+		fakeDate: Object.create(Date.prototype),
+	})
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := v.(*Object)
+
+	kinds := []struct {
+		name string
+		is   func(*Object) bool
+		objs []string
+	}{
+		{"IsArray", (*Object).IsArray, []string{"array", "goSlice", "proxyArray"}},
+		{"IsDate", (*Object).IsDate, []string{"date"}},
+		{"IsRegExp", (*Object).IsRegExp, []string{"regexp"}},
+		{"IsMap", (*Object).IsMap, []string{"map"}},
+		{"IsSet", (*Object).IsSet, []string{"set"}},
+		{"IsPromise", (*Object).IsPromise, []string{"promise"}},
+		{"IsTypedArray", (*Object).IsTypedArray, []string{"typedArray"}},
+		{"IsCallable", (*Object).IsCallable, []string{"func", "arrow", "method", "class", "bound", "goFunc", "proxyFunc"}},
+		{"IsConstructor", (*Object).IsConstructor, []string{"func", "class", "bound", "proxyFunc"}},
+	}
+
+	for _, kind := range kinds {
+		expected := make(map[string]bool)
+		for _, name := range kind.objs {
+			expected[name] = true
+		}
+		for _, name := range o.Keys() {
+			if res := kind.is(o.Get(name).(*Object)); res != expected[name] {
+				t.Errorf("%s(%s): expected %v, got %v", kind.name, name, expected[name], res)
+			}
+		}
+	}
+}
+
 func BenchmarkPut(b *testing.B) {
 	v := &Object{}
 
@@ -22,6 +22,10 @@ func (self *_parser) parseIdentifier() *ast.Identifier {
 func (self *_parser) parsePrimaryExpression() ast.Expression {
 	literal, parsedLiteral := self.literal, self.parsedLiteral
 	idx := self.idx
+	if self.isAsyncFunction() {
+		self.next()
+		return self.parseFunction(false, true, idx)
+	}
 	switch self.token {
 	case token.IDENTIFIER:
 		self.next()
@@ -88,12 +92,12 @@ func (self *_parser) parsePrimaryExpression() ast.Expression {
 	case token.SUPER:
 		return self.parseSuperProperty()
 	case token.FUNCTION:
-		return self.parseFunction(false)
+		return self.parseFunction(false, false, idx)
 	case token.CLASS:
 		return self.parseClass(false)
 	}
 
-	if isBindingId(self.token, parsedLiteral) {
+	if isBindingId(self.token, parsedLiteral) && !self.isYield() && !self.isAwait() {
 		self.next()
 		return &ast.Identifier{
 			Name: parsedLiteral,
@@ -270,6 +274,9 @@ func isBindingId(tok token.Token, parsedLiteral unistring.String) bool {
 }
 
 func (self *_parser) tokenToBindingId() {
+	if self.isYield() || self.isAwait() {
+		return
+	}
 	if isBindingId(self.token, self.parsedLiteral) {
 		self.token = token.IDENTIFIER
 	}
@@ -394,6 +401,18 @@ func (self *_parser) parseObjectProperty() ast.Property {
 		}
 	}
 	keyStartIdx := self.idx
+	if self.token == token.MULTIPLY {
+		self.next()
+		_, _, value, _ := self.parseObjectPropertyKey()
+		if value == nil {
+			return nil
+		}
+		return &ast.PropertyKeyed{
+			Key:   value,
+			Kind:  ast.PropertyKindMethod,
+			Value: self.parseMethodDefinition(keyStartIdx, ast.PropertyKindMethod, false, true),
+		}
+	}
 	literal, parsedLiteral, value, tkn := self.parseObjectPropertyKey()
 	if value == nil {
 		return nil
@@ -401,13 +420,13 @@ func (self *_parser) parseObjectProperty() ast.Property {
 	if token.IsId(tkn) || tkn == token.STRING || tkn == token.ILLEGAL {
 		switch {
 		case self.token == token.LEFT_PARENTHESIS:
-			parameterList := self.parseFunctionParameterList()
+			parameterList := self.parseFunctionParameterList(false, false)
 
 			node := &ast.FunctionLiteral{
 				Function:      keyStartIdx,
 				ParameterList: parameterList,
 			}
-			node.Body, node.DeclarationList = self.parseFunctionBlock()
+			node.Body, node.DeclarationList = self.parseFunctionBlock(false, false)
 			node.Source = self.slice(keyStartIdx, node.Body.Idx1())
 
 			return &ast.PropertyKeyed{
@@ -416,6 +435,9 @@ func (self *_parser) parseObjectProperty() ast.Property {
 				Value: node,
 			}
 		case self.token == token.COMMA || self.token == token.RIGHT_BRACE || self.token == token.ASSIGN: // shorthand property
+			if parsedLiteral == "yield" && self.scope.allowYield || parsedLiteral == "await" && self.scope.allowAwait {
+				self.error(value.Idx0(), "Unexpected reserved word")
+			}
 			if isBindingId(tkn, parsedLiteral) {
 				var initializer ast.Expression
 				if self.token == token.ASSIGN {
@@ -450,7 +472,21 @@ func (self *_parser) parseObjectProperty() ast.Property {
 			return &ast.PropertyKeyed{
 				Key:   keyValue,
 				Kind:  kind,
-				Value: self.parseMethodDefinition(keyStartIdx, kind),
+				Value: self.parseMethodDefinition(keyStartIdx, kind, false, false),
+			}
+		case literal == "async" && self.token != token.COLON && !self.implicitSemicolon:
+			if self.token == token.MULTIPLY {
+				self.error(self.idx, "Async generators are not supported")
+				self.next()
+			}
+			_, _, keyValue, _ := self.parseObjectPropertyKey()
+			if keyValue == nil {
+				return nil
+			}
+			return &ast.PropertyKeyed{
+				Key:   keyValue,
+				Kind:  ast.PropertyKindMethod,
+				Value: self.parseMethodDefinition(keyStartIdx, ast.PropertyKindMethod, true, false),
 			}
 		}
 	}
@@ -464,9 +500,9 @@ func (self *_parser) parseObjectProperty() ast.Property {
 	}
 }
 
-func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.PropertyKind) *ast.FunctionLiteral {
+func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.PropertyKind, async, generator bool) *ast.FunctionLiteral {
 	idx1 := self.idx
-	parameterList := self.parseFunctionParameterList()
+	parameterList := self.parseFunctionParameterList(async, generator)
 	switch kind {
 	case ast.PropertyKindGet:
 		if len(parameterList.List) > 0 || parameterList.Rest != nil {
@@ -480,8 +516,10 @@ func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.Proper
 	node := &ast.FunctionLiteral{
 		Function:      keyStartIdx,
 		ParameterList: parameterList,
+		Generator:     generator,
+		Async:         async,
 	}
-	node.Body, node.DeclarationList = self.parseFunctionBlock()
+	node.Body, node.DeclarationList = self.parseFunctionBlock(async, generator)
 	node.Source = self.slice(keyStartIdx, node.Body.Idx1())
 	return node
 }
@@ -681,6 +719,11 @@ func (self *_parser) parseNewExpression() ast.Expression {
 		bad.From = idx
 		return bad
 	}
+	if self.token == token.QUESTION_DOT {
+		self.error(self.idx, "Invalid optional chain from new expression")
+		self.nextStatement()
+		return &ast.BadExpression{From: idx, To: self.idx}
+	}
 	node := &ast.NewExpression{
 		New:    idx,
 		Callee: callee,
@@ -817,6 +860,16 @@ func (self *_parser) parseUnaryExpression() ast.Expression {
 			Idx:      idx,
 			Operand:  self.parseUnaryExpression(),
 		}
+	case token.KEYWORD:
+		if self.isAwait() {
+			idx := self.idx
+			self.scope.awaitIdx = idx
+			self.next()
+			return &ast.AwaitExpression{
+				Await:    idx,
+				Argument: self.parseUnaryExpression(),
+			}
+		}
 	case token.INCREMENT, token.DECREMENT:
 		tkn := self.token
 		idx := self.idx
@@ -847,9 +900,12 @@ func isUpdateExpression(expr ast.Expression) bool {
 }
 
 func (self *_parser) parseExponentiationExpression() ast.Expression {
+	// A unary expression can only start with a parenthesis if it is parenthesized itself, e.g. (-2) ** 2,
+	// which is allowed as the base.
+	parenthesized := self.token == token.LEFT_PARENTHESIS
 	left := self.parseUnaryExpression()
 
-	for self.token == token.EXPONENT && isUpdateExpression(left) {
+	for self.token == token.EXPONENT && (parenthesized || isUpdateExpression(left)) {
 		self.next()
 		left = &ast.BinaryExpression{
 			Operator: token.EXPONENT,
@@ -1132,13 +1188,67 @@ func (self *_parser) parseConditionalExpression() ast.Expression {
 	return left
 }
 
+// isYield returns true if the current token is a yield keyword within a generator function body.
+func (self *_parser) isYield() bool {
+	return self.scope.allowYield && self.token == token.KEYWORD && self.parsedLiteral == "yield"
+}
+
+// isAwait returns true if the current token is an await keyword within an async function body.
+func (self *_parser) isAwait() bool {
+	return self.scope.allowAwait && self.token == token.KEYWORD && self.parsedLiteral == "await"
+}
+
+func (self *_parser) parseYieldExpression() ast.Expression {
+	node := &ast.YieldExpression{
+		Yield: self.idx,
+	}
+	self.scope.yieldIdx = node.Yield
+	self.next()
+	if self.implicitSemicolon {
+		return node
+	}
+	if self.token == token.MULTIPLY {
+		node.Delegate = true
+		self.next()
+	} else {
+		switch self.token {
+		case token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET, token.RIGHT_BRACE, token.COMMA,
+			token.SEMICOLON, token.COLON, token.EOF:
+			return node
+		}
+	}
+	node.Argument = self.parseAssignmentExpression()
+	return node
+}
+
 func (self *_parser) parseAssignmentExpression() ast.Expression {
+	if self.isYield() {
+		return self.parseYieldExpression()
+	}
 	start := self.idx
-	parenthesis := false
+	parenthesis, async := false, false
 	var state parserState
 	if self.token == token.LEFT_PARENTHESIS {
 		self.mark(&state)
 		parenthesis = true
+	} else if self.token == token.IDENTIFIER && self.literal == "async" {
+		switch tok, sameLine := self.peekSameLine(); {
+		case tok == token.IDENTIFIER && sameLine:
+			// async x => ...
+			self.next()
+			id := self.parseIdentifier()
+			return self.parseArrowFunction(start, &ast.ParameterList{
+				Opening: id.Idx,
+				Closing: id.Idx1(),
+				List: []*ast.Binding{{
+					Target: id,
+				}},
+			}, true)
+		case tok == token.LEFT_PARENTHESIS && sameLine:
+			// async (...) => ..., or a call of a function named async
+			self.mark(&state)
+			async = true
+		}
 	} else {
 		self.tokenToBindingId()
 	}
@@ -1171,8 +1281,23 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 		operator = token.SHIFT_RIGHT
 	case token.UNSIGNED_SHIFT_RIGHT_ASSIGN:
 		operator = token.UNSIGNED_SHIFT_RIGHT
+	case token.LOGICAL_AND_ASSIGN:
+		operator = token.LOGICAL_AND
+	case token.LOGICAL_OR_ASSIGN:
+		operator = token.LOGICAL_OR
+	case token.COALESCE_ASSIGN:
+		operator = token.COALESCE
 	case token.ARROW:
 		var paramList *ast.ParameterList
+		if call, ok := left.(*ast.CallExpression); ok && async {
+			if _, ok := call.Callee.(*ast.Identifier); !ok {
+				self.error(left.Idx0(), "Malformed arrow function parameter list")
+				return &ast.BadExpression{From: left.Idx0(), To: left.Idx1()}
+			}
+			self.restore(&state)
+			self.next()
+			return self.parseArrowFunction(start, self.parseFunctionParameterList(true, self.scope.allowYield), true)
+		}
 		if id, ok := left.(*ast.Identifier); ok {
 			paramList = &ast.ParameterList{
 				Opening: id.Idx,
@@ -1184,22 +1309,16 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 		} else if parenthesis {
 			if seq, ok := left.(*ast.SequenceExpression); ok && len(self.errors) == 0 {
 				paramList = self.reinterpretSequenceAsArrowFuncParams(seq)
+				self.checkParameterList(start)
 			} else {
 				self.restore(&state)
-				paramList = self.parseFunctionParameterList()
+				paramList = self.parseFunctionParameterList(self.scope.allowAwait, self.scope.allowYield)
 			}
 		} else {
 			self.error(left.Idx0(), "Malformed arrow function parameter list")
 			return &ast.BadExpression{From: left.Idx0(), To: left.Idx1()}
 		}
-		self.expect(token.ARROW)
-		node := &ast.ArrowFunctionLiteral{
-			Start:         start,
-			ParameterList: paramList,
-		}
-		node.Body, node.DeclarationList = self.parseArrowFunctionBody()
-		node.Source = self.slice(node.Start, node.Body.Idx1())
-		return node
+		return self.parseArrowFunction(start, paramList, false)
 	}
 
 	if operator != 0 {
@@ -1235,6 +1354,18 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 	return left
 }
 
+func (self *_parser) parseArrowFunction(start file.Idx, paramList *ast.ParameterList, async bool) ast.Expression {
+	self.expect(token.ARROW)
+	node := &ast.ArrowFunctionLiteral{
+		Start:         start,
+		ParameterList: paramList,
+		Async:         async,
+	}
+	node.Body, node.DeclarationList = self.parseArrowFunctionBody(async)
+	node.Source = self.slice(node.Start, node.Body.Idx1())
+	return node
+}
+
 func (self *_parser) parseExpression() ast.Expression {
 	left := self.parseAssignmentExpression()
 
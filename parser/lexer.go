@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode"
@@ -187,6 +188,7 @@ func isLineTerminator(chr rune) bool {
 }
 
 type parserState struct {
+	idx                                file.Idx
 	tok                                token.Token
 	literal                            string
 	parsedLiteral                      unistring.String
@@ -200,16 +202,16 @@ func (self *_parser) mark(state *parserState) *parserState {
 	if state == nil {
 		state = &parserState{}
 	}
-	state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset =
-		self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset
+	state.idx, state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset =
+		self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset
 
 	state.errorCount = len(self.errors)
 	return state
 }
 
 func (self *_parser) restore(state *parserState) {
-	self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset =
-		state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset
+	self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset =
+		state.idx, state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset
 	self.errors = self.errors[:state.errorCount]
 }
 
@@ -220,6 +222,15 @@ func (self *_parser) peek() token.Token {
 	return tok
 }
 
+// peekSameLine is like peek, but also reports whether there is no line terminator before the next token.
+func (self *_parser) peekSameLine() (token.Token, bool) {
+	implicitSemicolon, insertSemicolon, chr, chrOffset, offset := self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset
+	tok, _, _, _ := self.scan()
+	sameLine := !self.implicitSemicolon
+	self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset = implicitSemicolon, insertSemicolon, chr, chrOffset, offset
+	return tok, sameLine
+}
+
 func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unistring.String, idx file.Idx) {
 
 	self.implicitSemicolon = false
@@ -260,6 +271,7 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 						// TODO If strict and in strict mode, then this is not a break
 						break
 					}
+					self.insertSemicolon = true
 					return
 
 				case
@@ -399,9 +411,9 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 					tkn = token.STRICT_NOT_EQUAL
 				}
 			case '&':
-				tkn = self.switch3(token.AND, token.AND_ASSIGN, '&', token.LOGICAL_AND)
+				tkn = self.switch4(token.AND, token.AND_ASSIGN, '&', token.LOGICAL_AND, token.LOGICAL_AND_ASSIGN)
 			case '|':
-				tkn = self.switch3(token.OR, token.OR_ASSIGN, '|', token.LOGICAL_OR)
+				tkn = self.switch4(token.OR, token.OR_ASSIGN, '|', token.LOGICAL_OR, token.LOGICAL_OR_ASSIGN)
 			case '~':
 				tkn = token.BITWISE_NOT
 			case '?':
@@ -410,7 +422,12 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 					tkn = token.QUESTION_DOT
 				} else if self.chr == '?' {
 					self.read()
-					tkn = token.COALESCE
+					if self.chr == '=' {
+						self.read()
+						tkn = token.COALESCE_ASSIGN
+					} else {
+						tkn = token.COALESCE
+					}
 				} else {
 					tkn = token.QUESTION_MARK
 				}
@@ -593,9 +610,28 @@ func (self *_parser) skipWhiteSpace() {
 	}
 }
 
-func (self *_parser) scanMantissa(base int) {
-	for digitValue(self.chr) < base {
-		self.read()
+// scanMantissa reads the digits of the specified base. If allowSeparator is true, the digits may be separated
+// by single underscores (numeric separators). Returns false if a separator is misplaced, i.e. it's not
+// between two digits.
+func (self *_parser) scanMantissa(base int, allowSeparator bool) bool {
+	digit := false
+	for {
+		if digitValue(self.chr) < base {
+			digit = true
+			self.read()
+			continue
+		}
+		if allowSeparator && self.chr == '_' {
+			if !digit {
+				return false
+			}
+			self.read()
+			if digitValue(self.chr) >= base {
+				return false
+			}
+			continue
+		}
+		return true
 	}
 }
 
@@ -853,6 +889,15 @@ func hex2decimal(chr byte) (value rune, ok bool) {
 }
 
 func parseNumberLiteral(literal string) (value interface{}, err error) {
+	// the placement of the numeric separators has been checked by the scanner
+	literal = strings.ReplaceAll(literal, "_", "")
+	if l := len(literal); l > 1 && literal[l-1] == 'n' {
+		if b, ok := new(big.Int).SetString(literal[:l-1], 0); ok {
+			return b, nil
+		}
+		return nil, errors.New("Illegal numeric literal")
+	}
+
 	// TODO Is Uint okay? What about -MAX_UINT
 	value, err = strconv.ParseInt(literal, 0, 64)
 	if err == nil {
@@ -1080,10 +1125,14 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 
 	offset := self.chrOffset
 	tkn := token.NUMBER
+	// whether the literal may have the BigInt suffix, i.e. it's an integer which is not a legacy octal
+	bigInt := !decimalPoint
 
 	if decimalPoint {
 		offset--
-		self.scanMantissa(10)
+		if !self.scanMantissa(10, true) {
+			return token.ILLEGAL, self.str[offset:self.chrOffset]
+		}
 	} else {
 		if self.chr == '0' {
 			self.read()
@@ -1099,7 +1148,8 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 				// no-op
 			default:
 				// legacy octal
-				self.scanMantissa(8)
+				bigInt = self.chr == 'n'
+				self.scanMantissa(8, false)
 				goto end
 			}
 			if base > 0 {
@@ -1107,31 +1157,37 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 				if !isDigit(self.chr, base) {
 					return token.ILLEGAL, self.str[offset:self.chrOffset]
 				}
-				self.scanMantissa(base)
+				if !self.scanMantissa(base, true) {
+					return token.ILLEGAL, self.str[offset:self.chrOffset]
+				}
 				goto end
 			}
-		} else {
-			self.scanMantissa(10)
+		} else if !self.scanMantissa(10, true) {
+			return token.ILLEGAL, self.str[offset:self.chrOffset]
 		}
 		if self.chr == '.' {
+			bigInt = false
 			self.read()
-			self.scanMantissa(10)
+			if !self.scanMantissa(10, true) {
+				return token.ILLEGAL, self.str[offset:self.chrOffset]
+			}
 		}
 	}
 
 	if self.chr == 'e' || self.chr == 'E' {
+		bigInt = false
 		self.read()
 		if self.chr == '-' || self.chr == '+' {
 			self.read()
 		}
-		if isDecimalDigit(self.chr) {
-			self.read()
-			self.scanMantissa(10)
-		} else {
+		if !isDecimalDigit(self.chr) || !self.scanMantissa(10, true) {
 			return token.ILLEGAL, self.str[offset:self.chrOffset]
 		}
 	}
 end:
+	if bigInt && self.chr == 'n' {
+		self.read()
+	}
 	if isIdentifierStart(self.chr) || isDecimalDigit(self.chr) {
 		return token.ILLEGAL, self.str[offset:self.chrOffset]
 	}
@@ -296,6 +296,14 @@ Second line \
 			token.UNSIGNED_SHIFT_RIGHT_ASSIGN, "", 1,
 		)
 
+		test("&&= ||= ??= ?? ?.",
+			token.LOGICAL_AND_ASSIGN, "", 1,
+			token.LOGICAL_OR_ASSIGN, "", 5,
+			token.COALESCE_ASSIGN, "", 9,
+			token.COALESCE, "", 13,
+			token.QUESTION_DOT, "", 16,
+		)
+
 		test("1 \"abc\"",
 			token.NUMBER, "1", 1,
 			token.STRING, "\"abc\"", 3,
@@ -54,10 +54,11 @@ const (
 type options struct {
 	disableSourceMaps bool
 	sourceMapLoader   func(path string) ([]byte, error)
+	sourceMap         []byte
 }
 
 // Option represents one of the options for the parser to use in the Parse methods. Currently supported are:
-// WithDisableSourceMaps and WithSourceMapLoader.
+// WithDisableSourceMaps, WithSourceMapLoader and WithSourceMap.
 type Option func(*options)
 
 // WithDisableSourceMaps is an option to disable source maps support. May save a bit of time when source maps
@@ -77,6 +78,15 @@ func WithSourceMapLoader(loader func(path string) ([]byte, error)) Option {
 	}
 }
 
+// WithSourceMap is an option to supply the source map of the file being parsed, e.g. one produced along with
+// a bundle by a transpiler. It takes precedence over the sourceMappingURL comment (which is not loaded in this
+// case) and is used even if WithDisableSourceMaps is set. An invalid source map fails the parsing.
+func WithSourceMap(data []byte) Option {
+	return func(opts *options) {
+		opts.sourceMap = data
+	}
+}
+
 type _parser struct {
 	str    string
 	length int
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"math/big"
 	"regexp"
 	"strings"
 	"testing"
@@ -123,6 +124,34 @@ func TestParserErr(t *testing.T) {
 
 		test("3x", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
 
+		test("1.5n", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1e3n", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("07n", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1nn", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1_", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1__0", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1_.5", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1._5", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1e_5", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1_e5", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("0_1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("0x_1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("07_1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
+		test("1_n", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
+
 		test("3x0", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
 
 		test("0x", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
@@ -163,6 +192,16 @@ func TestParserErr(t *testing.T) {
 
 		test("(1 + 1) = 2", "(anonymous): Line 1:2 Invalid left-hand side in assignment")
 
+		test("func() &&= 1", "(anonymous): Line 1:1 Invalid left-hand side in assignment")
+
+		test("[a] ||= 1", "(anonymous): Line 1:1 Invalid left-hand side in assignment")
+
+		test("({a}) ??= 1", "(anonymous): Line 1:2 Invalid left-hand side in assignment")
+
+		test("a?.b ||= 1", "(anonymous): Line 1:1 Invalid left-hand side in assignment")
+
+		test("a ?? b ||= 1", "(anonymous): Line 1:1 Invalid left-hand side in assignment")
+
 		test("1++", "(anonymous): Line 1:2 Invalid left-hand side in assignment")
 
 		test("1--", "(anonymous): Line 1:2 Invalid left-hand side in assignment")
@@ -199,6 +238,7 @@ func TestParserErr(t *testing.T) {
 
 		test("+1 ** 2", "(anonymous): Line 1:4 Unexpected token **")
 		test("typeof 1 ** 2", "(anonymous): Line 1:10 Unexpected token **")
+		test("-(1) ** 2", "(anonymous): Line 1:6 Unexpected token **")
 
 		// TODO
 		//{ set 1 }
@@ -495,6 +535,34 @@ func TestParserErr(t *testing.T) {
 			test(`abc.yield = 1`, nil)
 			test(`var yield;`, nil)
 		}
+		test("function* g() { yield; yield 1, yield* [2]; var x = yield\n1; }", nil)
+		test(`function* g() { var yield; }`, "(anonymous): Line 1:21 Unexpected reserved word")
+		test(`function* g() { function f() { var yield; } }`, nil)
+		test(`0, { *g() { yield 1; } }; class C { *g() { yield; } static *h() {} }`, nil)
+		test("async function f() { await 1; var g = async x => await x; }; async\nfunction h() {}", nil)
+		test(`0, { async m() { await 1; } }; class C { async m() {} static async n() {} async() {} }`, nil)
+		test(`var async; async(1); async = 2; async => 1`, nil)
+		test(`function f() { await 1; }`, "(anonymous): Line 1:22 Unexpected number")
+		test(`async function* g() {}`, "(anonymous): Line 1:15 Async generators are not supported")
+		test(`class C { async constructor() {} }`, "(anonymous): Line 1:17 Class constructor may not be an async method")
+		test(`function* g() { var h = async function(a = yield 5) {}; }`, "(anonymous): Line 1:50 Unexpected number")
+		test(`function* g() { var h = function(yield) {}; }`, nil)
+		test(`function* g() { var h = (a = yield 5) => a; }`, "(anonymous): Line 1:30 Yield expression not allowed in formal parameter")
+		test(`function* g() { var h = (b, a = yield) => a; }`, "(anonymous): Line 1:33 Yield expression not allowed in formal parameter")
+		test(`function* g() { var h = async (a = yield) => a; }`, "(anonymous): Line 1:36 Yield expression not allowed in formal parameter")
+		test(`function* g(a = yield) {}`, "(anonymous): Line 1:17 Yield expression not allowed in formal parameter")
+		test(`function* g(a = function*() { yield; }) { yield; }`, nil)
+		test(`0, { *g(a = yield) {} }`, "(anonymous): Line 1:13 Yield expression not allowed in formal parameter")
+		test(`class C { *g(a = yield) {} }`, "(anonymous): Line 1:18 Yield expression not allowed in formal parameter")
+		test(`async function f(a = await 1) {}`, "(anonymous): Line 1:22 Illegal await-expression in formal parameters")
+		test(`async function f() { var h = (a = await 1) => a; }`, "(anonymous): Line 1:35 Illegal await-expression in formal parameters")
+		test(`async function f() { var h = async (a = await 1) => a; }`, "(anonymous): Line 1:41 Illegal await-expression in formal parameters")
+		test(`async function f(a = async () => await 1) { await 1; (await 1, await 2); }`, nil)
+		test(`0, { async m(a = await 1) {} }`, "(anonymous): Line 1:18 Illegal await-expression in formal parameters")
+		test(`function* g() { var o = {yield}; }`, "(anonymous): Line 1:26 Unexpected reserved word")
+		test(`function* g() { var {yield} = {}; }`, "(anonymous): Line 1:22 Unexpected reserved word")
+		test(`async function f() { ({await} = {}); }`, "(anonymous): Line 1:24 Unexpected reserved word")
+		test(`function* g() { var o = {yield: 1, yield() {}}; } var o = {yield, await};`, nil)
 		test(`0, { get a(param = null) {} };`, "(anonymous): Line 1:11 Getter must not have any formal parameters.")
 		test(`let{f(`, "(anonymous): Line 1:7 Unexpected end of input")
 		test("`", "(anonymous): Line 1:2 Unexpected end of input")
@@ -911,6 +979,10 @@ func TestParser(t *testing.T) {
 		};`, nil)
 		test(`(a,) => {}`, nil)
 
+		test(`(-2) ** 2`, nil)
+		test(`(typeof a) ** 2 ** (-1)`, nil)
+		test(`(-a) ** 2`, nil)
+
 		test(`2 ?? (2 && 3) + 3`, nil)
 		test(`(2 ?? 2) && 3 + 3`, nil)
 		program = test(`a ?? b ?? c`, nil)
@@ -1027,6 +1099,23 @@ func Test_parseNumberLiteral(t *testing.T) {
 		test("0", 0)
 
 		test("0x8000000000000000", float64(9.223372036854776e+18))
+
+		test("1_000_000", 1000000)
+		test("0xf_f", 255)
+		test("1_0.2_5e1_0", float64(1.025e+11))
+
+		for input, expect := range map[string]string{
+			"0n":                      "0",
+			"123456789012345678901n":  "123456789012345678901",
+			"0xffffffffffffffffffffn": "1208925819614629174706175",
+			"0o17n":                   "15",
+			"0b101n":                  "5",
+			"1_000_000_000_000_000n":  "1000000000000000",
+		} {
+			result, err := parseNumberLiteral(input)
+			is(err, nil)
+			is(result.(*big.Int).String(), expect)
+		}
 	})
 }
 
@@ -1131,6 +1220,15 @@ var x = {};
 		is(err, nil)
 		is(count, 1)
 		is(requestedPath, "https://site.com/delme.js.map")
+
+		count = 0
+		sm := []byte(`{"version":3,"sources":["orig.js"],"names":[],"mappings":"AAAA"}`)
+		_, err = ParseFile(nil, "delme.js", src, 0, WithSourceMapLoader(loader), WithSourceMap(sm))
+		is(err, nil)
+		is(count, 0)
+
+		_, err = ParseFile(nil, "delme.js", src, 0, WithDisableSourceMaps, WithSourceMap([]byte("{")))
+		is(err != nil, true)
 	})
 }
 
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/unistring"
 )
 
@@ -12,9 +13,14 @@ type _scope struct {
 	inIteration     bool
 	inSwitch        bool
 	inFunction      bool
+	allowYield      bool
+	allowAwait      bool
 	declarationList []*ast.VariableDeclaration
 
 	labels []unistring.String
+
+	// the positions of the last yield and await expressions, used to reject them in formal parameters
+	yieldIdx, awaitIdx file.Idx
 }
 
 func (self *_parser) openScope() {
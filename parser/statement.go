@@ -75,7 +75,15 @@ func (self *_parser) parseStatement() ast.Statement {
 		return self.parseLexicalDeclaration(self.token)
 	case token.FUNCTION:
 		return &ast.FunctionDeclaration{
-			Function: self.parseFunction(true),
+			Function: self.parseFunction(true, false, self.idx),
+		}
+	case token.IDENTIFIER:
+		if self.isAsyncFunction() {
+			start := self.idx
+			self.next()
+			return &ast.FunctionDeclaration{
+				Function: self.parseFunction(true, true, start),
+			}
 		}
 	case token.CLASS:
 		return &ast.ClassDeclaration{
@@ -157,7 +165,15 @@ func (self *_parser) parseTryStatement() ast.Statement {
 	return node
 }
 
-func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
+// parseFunctionParameterList parses the formal parameters of a function. If yield (await) is true, the function
+// is a generator (async) or an arrow function within one, so yield (await) is parsed as an expression, which is
+// then rejected. Otherwise it is an identifier.
+func (self *_parser) parseFunctionParameterList(await, yield bool) *ast.ParameterList {
+	allowYield, allowAwait := self.scope.allowYield, self.scope.allowAwait
+	self.scope.allowYield, self.scope.allowAwait = yield, await
+	defer func() {
+		self.scope.allowYield, self.scope.allowAwait = allowYield, allowAwait
+	}()
 	opening := self.expect(token.LEFT_PARENTHESIS)
 	var list []*ast.Binding
 	var rest ast.Expression
@@ -173,6 +189,7 @@ func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 		}
 	}
 	closing := self.expect(token.RIGHT_PARENTHESIS)
+	self.checkParameterList(opening)
 
 	return &ast.ParameterList{
 		Opening: opening,
@@ -182,10 +199,41 @@ func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 	}
 }
 
-func (self *_parser) parseFunction(declaration bool) *ast.FunctionLiteral {
+// checkParameterList reports the yield and await expressions found in the formal parameters that start at
+// the specified position.
+func (self *_parser) checkParameterList(start file.Idx) {
+	if self.scope.yieldIdx >= start {
+		self.error(self.scope.yieldIdx, "Yield expression not allowed in formal parameter")
+	}
+	if self.scope.awaitIdx >= start {
+		self.error(self.scope.awaitIdx, "Illegal await-expression in formal parameters")
+	}
+}
 
+// isAsyncFunction returns true if the current token starts an async function declaration or expression.
+func (self *_parser) isAsyncFunction() bool {
+	if self.token == token.IDENTIFIER && self.literal == "async" {
+		tok, sameLine := self.peekSameLine()
+		return tok == token.FUNCTION && sameLine
+	}
+	return false
+}
+
+// parseFunction parses a function declaration or expression, start is the position of the 'function'
+// keyword or, for async functions, of the 'async' keyword which has already been consumed.
+func (self *_parser) parseFunction(declaration, async bool, start file.Idx) *ast.FunctionLiteral {
+	self.expect(token.FUNCTION)
 	node := &ast.FunctionLiteral{
-		Function: self.expect(token.FUNCTION),
+		Function: start,
+		Async:    async,
+	}
+
+	if self.token == token.MULTIPLY {
+		if async {
+			self.error(self.idx, "Async generators are not supported")
+		}
+		node.Generator = true
+		self.next()
 	}
 
 	self.tokenToBindingId()
@@ -197,17 +245,19 @@ func (self *_parser) parseFunction(declaration bool) *ast.FunctionLiteral {
 		self.expect(token.IDENTIFIER)
 	}
 	node.Name = name
-	node.ParameterList = self.parseFunctionParameterList()
-	node.Body, node.DeclarationList = self.parseFunctionBlock()
+	node.ParameterList = self.parseFunctionParameterList(node.Async, node.Generator)
+	node.Body, node.DeclarationList = self.parseFunctionBlock(node.Async, node.Generator)
 	node.Source = self.slice(node.Idx0(), node.Idx1())
 
 	return node
 }
 
-func (self *_parser) parseFunctionBlock() (body *ast.BlockStatement, declarationList []*ast.VariableDeclaration) {
+func (self *_parser) parseFunctionBlock(async, generator bool) (body *ast.BlockStatement, declarationList []*ast.VariableDeclaration) {
 	self.openScope()
 	inFunction := self.scope.inFunction
 	self.scope.inFunction = true
+	self.scope.allowYield = generator
+	self.scope.allowAwait = async
 	defer func() {
 		self.scope.inFunction = inFunction
 		self.closeScope()
@@ -217,10 +267,18 @@ func (self *_parser) parseFunctionBlock() (body *ast.BlockStatement, declaration
 	return
 }
 
-func (self *_parser) parseArrowFunctionBody() (ast.ConciseBody, []*ast.VariableDeclaration) {
+func (self *_parser) parseArrowFunctionBody(async bool) (ast.ConciseBody, []*ast.VariableDeclaration) {
 	if self.token == token.LEFT_BRACE {
-		return self.parseFunctionBlock()
+		return self.parseFunctionBlock(async, false)
 	}
+	allowYield, allowAwait := self.scope.allowYield, self.scope.allowAwait
+	yieldIdx, awaitIdx := self.scope.yieldIdx, self.scope.awaitIdx
+	self.scope.allowYield, self.scope.allowAwait = false, async
+	defer func() {
+		self.scope.allowYield, self.scope.allowAwait = allowYield, allowAwait
+		// the expressions belong to the arrow function
+		self.scope.yieldIdx, self.scope.awaitIdx = yieldIdx, awaitIdx
+	}()
 	return &ast.ExpressionBody{
 		Expression: self.parseAssignmentExpression(),
 	}, nil
@@ -270,7 +328,7 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 					b := &ast.ClassStaticBlock{
 						Static: start,
 					}
-					b.Block, b.DeclarationList = self.parseFunctionBlock()
+					b.Block, b.DeclarationList = self.parseFunctionBlock(false, false)
 					b.Source = self.slice(b.Block.LeftBrace, b.Block.Idx1())
 					node.Body = append(node.Body, b)
 					continue
@@ -281,7 +339,27 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 
 		var kind ast.PropertyKind
 		methodBodyStart := self.idx
-		if self.literal == "get" || self.literal == "set" {
+		async, generator := false, false
+		if self.token == token.IDENTIFIER && self.literal == "async" {
+			switch tok, sameLine := self.peekSameLine(); tok {
+			case token.LEFT_PARENTHESIS, token.ASSIGN, token.SEMICOLON, token.RIGHT_BRACE:
+				// treat as identifier
+			default:
+				if sameLine {
+					async = true
+					kind = ast.PropertyKindMethod
+					self.next()
+				}
+			}
+		}
+		if self.token == token.MULTIPLY {
+			if async {
+				self.error(self.idx, "Async generators are not supported")
+			}
+			generator = true
+			kind = ast.PropertyKindMethod
+			self.next()
+		} else if !async && (self.literal == "get" || self.literal == "set") {
 			if self.peek() != token.LEFT_PARENTHESIS {
 				if self.literal == "get" {
 					kind = ast.PropertyKindGet
@@ -312,6 +390,8 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 			if keyName == "constructor" {
 				if !computed && !static && kind != ast.PropertyKindMethod {
 					self.error(value.Idx0(), "Class constructor may not be an accessor")
+				} else if !computed && !static && async {
+					self.error(value.Idx0(), "Class constructor may not be an async method")
 				} else if private {
 					self.error(value.Idx0(), "Class constructor may not be a private method")
 				}
@@ -320,7 +400,7 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 				Idx:      start,
 				Key:      value,
 				Kind:     kind,
-				Body:     self.parseMethodDefinition(methodBodyStart, kind),
+				Body:     self.parseMethodDefinition(methodBodyStart, kind, async, generator),
 				Static:   static,
 				Computed: computed,
 			}
@@ -562,6 +642,11 @@ func (self *_parser) parseFor(idx file.Idx, initializer ast.ForLoopInitializer)
 
 func (self *_parser) parseForOrForInStatement() ast.Statement {
 	idx := self.expect(token.FOR)
+	await := false
+	if self.isAwait() {
+		await = true
+		self.next()
+	}
 	self.expect(token.LEFT_PARENTHESIS)
 
 	var initializer ast.ForLoopInitializer
@@ -664,11 +749,18 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 		self.scope.allowIn = allowIn
 	}
 
+	if await && !forOf {
+		self.error(idx, "for await can only be used with for-of")
+		self.nextStatement()
+		return &ast.BadStatement{From: idx, To: self.idx}
+	}
 	if forIn {
 		return self.parseForIn(idx, into)
 	}
 	if forOf {
-		return self.parseForOf(idx, into)
+		stmt := self.parseForOf(idx, into)
+		stmt.Await = await
+		return stmt
 	}
 
 	self.expect(token.SEMICOLON)
@@ -821,6 +913,9 @@ func extractSourceMapLine(str string) string {
 }
 
 func (self *_parser) parseSourceMap() *sourcemap.Consumer {
+	if self.opts.sourceMap != nil {
+		return self.loadSourceMap(self.opts.sourceMap)
+	}
 	if self.opts.disableSourceMaps {
 		return nil
 	}
@@ -856,15 +951,20 @@ func (self *_parser) parseSourceMap() *sourcemap.Consumer {
 			return nil
 		}
 
-		if sm, err := sourcemap.Parse(self.file.Name(), data); err == nil {
-			return sm
-		} else {
-			self.error(file.Idx(0), "Could not parse source map: %v", err)
-		}
+		return self.loadSourceMap(data)
 	}
 	return nil
 }
 
+func (self *_parser) loadSourceMap(data []byte) *sourcemap.Consumer {
+	sm, err := sourcemap.Parse(self.file.Name(), data)
+	if err != nil {
+		self.error(file.Idx(0), "Could not parse source map: %v", err)
+		return nil
+	}
+	return sm
+}
+
 func (self *_parser) parseBreakStatement() ast.Statement {
 	idx := self.expect(token.BREAK)
 	semicolon := self.implicitSemicolon
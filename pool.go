@@ -0,0 +1,269 @@
+package goja
+
+import (
+	gocontext "context"
+	"sync"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// RuntimePool keeps a set of initialised Runtimes which can be borrowed by concurrently running goroutines, e.g.
+// to serve requests in a web server. A compiled Program is not linked to a Runtime, so the same Program can be
+// run by all the Runtimes of the pool at the same time.
+//
+// Note that it's not possible to share a single global object (or any other JavaScript value) between the
+// goroutines: every object belongs to a Runtime and may only be accessed by the goroutine that is using that
+// Runtime, even for reading (reading a property may run a getter or initialise a lazily created object). Therefore
+// each Runtime of the pool has its own realm, i.e. the global object, the built-in prototypes and everything
+// created by the initialisation function. The pool only saves the cost of creating and initialising them for
+// every request.
+//
+// When a Runtime is returned to the pool, its global bindings are reset to the state they were in after the
+// initialisation: the string-keyed properties of the global object (i.e. the global variables and functions) and
+// the top-level lexical declarations created by the scripts are removed, and the ones that have been modified or
+// deleted are restored. Note that only the bindings are reset, not the objects they refer to, so the changes
+// made to the built-in objects and prototypes (e.g. Array.prototype.foo = ...) or to the objects created by the
+// initialisation function are still seen by the next borrower of that Runtime.
+//
+// The settings that apply to a run are reset as well: the context (see Runtime.SetContext()), the instruction,
+// memory and stack size limits and Runtime.SetInterruptibleReads() are set back to the values they had after the
+// initialisation (so the instruction budget is replenished), the opcode counters are reset (the counting stays
+// enabled only if it was enabled by the initialisation function), the profiling is stopped and the promise jobs
+// that have not been performed are discarded.
+type RuntimePool struct {
+	init    func(*Runtime) error
+	maxIdle int
+
+	mu        sync.Mutex
+	idle      []*Runtime
+	snapshots map[*Runtime]*runtimeSnapshot
+}
+
+// runtimeSnapshot is the state of the per-run settings and of the global bindings of a Runtime taken after
+// its initialisation.
+type runtimeSnapshot struct {
+	goCtx                          gocontext.Context
+	maxCallStackSize, maxStackSize int
+	memLimit, instrLimit           uint64
+	opcodeStats                    bool
+	interruptibleReads             bool
+
+	globals *globalsSnapshot
+}
+
+// globalsSnapshot is the state of the global bindings.
+type globalsSnapshot struct {
+	extensible bool
+	prototype  *Object
+
+	propNames                       []unistring.String
+	values                          []Value
+	lastSortedPropLen, idxPropCount int
+
+	stashNames  map[unistring.String]uint32
+	stashValues []Value
+	varNames    map[unistring.String]struct{}
+}
+
+// copyPropValue returns a copy of v if it is a *valueProperty, because those are modified in place.
+func copyPropValue(v Value) Value {
+	if prop, ok := v.(*valueProperty); ok {
+		cp := *prop
+		return &cp
+	}
+	return v
+}
+
+func (r *Runtime) snapshot() *runtimeSnapshot {
+	vm := r.vm
+	return &runtimeSnapshot{
+		goCtx:              vm.goCtx,
+		maxCallStackSize:   vm.maxCallStackSize,
+		maxStackSize:       vm.maxStackSize,
+		memLimit:           vm.memLimit,
+		instrLimit:         vm.instrLimit,
+		opcodeStats:        vm.opcodeStats != nil,
+		interruptibleReads: vm.interruptibleReads,
+
+		globals: r.snapshotGlobals(),
+	}
+}
+
+func (r *Runtime) snapshotGlobals() *globalsSnapshot {
+	o, ok := r.globalObject.self.(*baseObject)
+	if !ok {
+		return nil
+	}
+	s := &globalsSnapshot{
+		extensible:        o.extensible,
+		prototype:         o.prototype,
+		propNames:         append([]unistring.String(nil), o.propNames...),
+		values:            make([]Value, len(o.propNames)),
+		lastSortedPropLen: o.lastSortedPropLen,
+		idxPropCount:      o.idxPropCount,
+		stashNames:        make(map[unistring.String]uint32, len(r.global.stash.names)),
+		stashValues:       append([]Value(nil), r.global.stash.values...),
+	}
+	for i, name := range s.propNames {
+		s.values[i] = copyPropValue(o.getOwnVal(name))
+	}
+	for name, idx := range r.global.stash.names {
+		s.stashNames[name] = idx
+	}
+	if r.global.varNames != nil {
+		s.varNames = make(map[unistring.String]struct{}, len(r.global.varNames))
+		for name := range r.global.varNames {
+			s.varNames[name] = struct{}{}
+		}
+	}
+	return s
+}
+
+func (r *Runtime) restoreSnapshot(s *runtimeSnapshot) {
+	r.StopProfile()
+	if s.opcodeStats {
+		r.EnableOpcodeStats()
+	} else {
+		r.DisableOpcodeStats()
+	}
+	r.jobQueue = nil
+	vm := r.vm
+	vm.stepping, vm.stepTop, vm.paused = false, false, false
+	r.SetContext(s.goCtx)
+	r.SetMaxCallStackSize(s.maxCallStackSize)
+	r.SetMaxStackSize(s.maxStackSize)
+	r.SetMemoryLimit(s.memLimit)
+	r.SetInstructionLimit(s.instrLimit)
+	r.SetInterruptibleReads(s.interruptibleReads)
+	if s.globals != nil {
+		r.restoreGlobals(s.globals)
+	}
+}
+
+func (r *Runtime) restoreGlobals(s *globalsSnapshot) {
+	o, ok := r.globalObject.self.(*baseObject)
+	if !ok {
+		return
+	}
+	o.extensible = s.extensible
+	o.prototype = s.prototype
+	o.slotIdx = make(map[unistring.String]int, len(s.propNames))
+	o.slots = nil
+	o.shape = 0
+	o.propNames = append([]unistring.String(nil), s.propNames...)
+	o.lastSortedPropLen, o.idxPropCount = s.lastSortedPropLen, s.idxPropCount
+	for i, name := range s.propNames {
+		o.putOwnVal(name, copyPropValue(s.values[i]))
+	}
+
+	stash := &r.global.stash
+	stash.names = make(map[unistring.String]uint32, len(s.stashNames))
+	for name, idx := range s.stashNames {
+		stash.names[name] = idx
+	}
+	stash.values = append(stash.values[:0], s.stashValues...)
+	if s.varNames != nil {
+		r.global.varNames = make(map[unistring.String]struct{}, len(s.varNames))
+		for name := range s.varNames {
+			r.global.varNames[name] = struct{}{}
+		}
+	} else {
+		r.global.varNames = nil
+	}
+}
+
+// NewRuntimePool creates a RuntimePool. If init is not nil, it is called for every new Runtime before it's
+// handed out, e.g. to set up the global functions or to run a library script. If it returns an error, the Runtime
+// is discarded and the error is returned by Get(). maxIdle limits the number of Runtimes kept by the pool
+// when they are not used, a value of 0 or less means no limit.
+func NewRuntimePool(init func(*Runtime) error, maxIdle int) *RuntimePool {
+	return &RuntimePool{
+		init:    init,
+		maxIdle: maxIdle,
+	}
+}
+
+// Get returns an idle Runtime of the pool or creates a new one. The Runtime must be returned to the pool with
+// Put() once it's no longer used. It is safe to call this method concurrently.
+func (p *RuntimePool) Get() (*Runtime, error) {
+	p.mu.Lock()
+	if l := len(p.idle); l > 0 {
+		r := p.idle[l-1]
+		p.idle[l-1] = nil
+		p.idle = p.idle[:l-1]
+		p.mu.Unlock()
+		return r, nil
+	}
+	p.mu.Unlock()
+	r := New()
+	if p.init != nil {
+		if err := p.init(r); err != nil {
+			return nil, err
+		}
+	}
+	s := r.snapshot()
+	p.mu.Lock()
+	if p.snapshots == nil {
+		p.snapshots = make(map[*Runtime]*runtimeSnapshot)
+	}
+	p.snapshots[r] = s
+	p.mu.Unlock()
+	return r, nil
+}
+
+// Put returns a Runtime obtained with Get() to the pool. Neither the Runtime nor any values that belong to it
+// may be used after that. A pending interrupt is cleared, the global bindings and the per-run settings are reset
+// (see RuntimePool).
+// It is safe to call this method concurrently.
+func (p *RuntimePool) Put(r *Runtime) {
+	r.ClearInterrupt()
+	p.mu.Lock()
+	s := p.snapshots[r]
+	p.mu.Unlock()
+	if s != nil {
+		r.restoreSnapshot(s)
+	}
+	p.mu.Lock()
+	if p.maxIdle <= 0 || len(p.idle) < p.maxIdle {
+		p.idle = append(p.idle, r)
+	} else {
+		delete(p.snapshots, r)
+	}
+	p.mu.Unlock()
+}
+
+// Run borrows a Runtime from the pool, calls f with it and returns it to the pool. It returns the error returned
+// by f or by the initialisation of a new Runtime. The values that belong to the Runtime must not be retained
+// after f returns, export them if needed. This includes the errors: an *Exception holds the thrown value,
+// so f should not return it as is (see RunProgram()).
+// It is safe to call this method concurrently.
+func (p *RuntimePool) Run(f func(r *Runtime) error) error {
+	r, err := p.Get()
+	if err != nil {
+		return err
+	}
+	defer p.Put(r)
+	return f(r)
+}
+
+// RunProgram runs the Program in a Runtime borrowed from the pool and returns the exported result
+// (see Value.Export()). If the script throws, the returned *Exception holds the string representation
+// of the thrown value rather than the value itself.
+// It is safe to call this method concurrently.
+func (p *RuntimePool) RunProgram(prg *Program) (result interface{}, err error) {
+	err = p.Run(func(r *Runtime) error {
+		v, err := r.RunProgram(prg)
+		if err != nil {
+			if ex, ok := err.(*Exception); ok {
+				return &Exception{
+					val:   newStringValue(ex.valString()),
+					stack: ex.stack,
+				}
+			}
+			return err
+		}
+		result = v.Export()
+		return nil
+	})
+	return
+}
@@ -0,0 +1,222 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestRuntimePool(t *testing.T) {
+	var created int32
+	var mu sync.Mutex
+	pool := NewRuntimePool(func(r *Runtime) error {
+		mu.Lock()
+		created++
+		mu.Unlock()
+		_, err := r.RunString(`function square(x) { return x * x; }`)
+		return err
+	}, 2)
+	prg := MustCompile("test.js", `
+	var count = (typeof count === "undefined" ? 0 : count) + 1;
+	square(input);
+	`, false)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := pool.Run(func(r *Runtime) error {
+					r.Set("input", i)
+					res, err := r.RunProgram(prg)
+					if err != nil {
+						return err
+					}
+					if res.ToInteger() != int64(i*i) {
+						return errors.New("unexpected result: " + res.String())
+					}
+					return nil
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if len(pool.idle) > 2 {
+		t.Fatalf("Too many idle Runtimes: %d", len(pool.idle))
+	}
+	if created > 10 {
+		t.Fatalf("Too many Runtimes created: %d", created)
+	}
+
+	r, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := r.Get("count"); count != nil {
+		t.Fatalf("The global variable has not been reset: %v", count)
+	}
+	pool.Put(r)
+
+	_, err = pool.RunProgram(MustCompile("test.js", `throw {toString() { return "custom"; }}`, false))
+	if ex, ok := err.(*Exception); !ok || ex.Error() != "custom at test.js:1:1(3)" {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if _, ok := ex.Value().(valueString); !ok {
+		t.Fatalf("The thrown value has not been detached: %v", ex.Value())
+	}
+
+	res, err := pool.RunProgram(MustCompile("test.js", `({a: [1, 2]})`, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := res.(map[string]interface{}); !ok || len(m["a"].([]interface{})) != 2 {
+		t.Fatalf("Unexpected result: %#v", res)
+	}
+
+	initErr := errors.New("init failed")
+	_, err = NewRuntimePool(func(*Runtime) error {
+		return initErr
+	}, 0).RunProgram(prg)
+	if err != initErr {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRuntimePoolResetsGlobals(t *testing.T) {
+	pool := NewRuntimePool(func(r *Runtime) error {
+		_, err := r.RunString(`
+		var counter = 0;
+		let lex = "init";
+		function helper() { return counter; }
+		`)
+		return err
+	}, 1)
+	r, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RunString(`
+	counter = 10;
+	lex = "changed";
+	var leaked = 1;
+	let leakedLex = 2;
+	globalThis.prop = 3;
+	delete globalThis.helper;
+	Array = null;
+	Object.preventExtensions(globalThis);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(r)
+
+	r1, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 != r {
+		t.Fatal("The Runtime has not been reused")
+	}
+	_, err = r1.RunString(TESTLIB + `
+	assert.sameValue(counter, 0, "counter");
+	assert.sameValue(lex, "init", "lex");
+	assert.sameValue(typeof leaked, "undefined", "leaked");
+	assert.sameValue(typeof leakedLex, "undefined", "leakedLex");
+	assert.sameValue(typeof prop, "undefined", "prop");
+	assert.sameValue(helper(), 0, "helper");
+	assert(Array.isArray(new Array(1)), "Array");
+	assert(Object.isExtensible(globalThis), "extensible");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the declarations can be made again
+	if _, err = r1.RunString(`let leakedLex = "again"; var leaked = "again";`); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(r1)
+}
+
+func TestRuntimePoolResetsSettings(t *testing.T) {
+	pool := NewRuntimePool(func(r *Runtime) error {
+		r.SetMaxCallStackSize(100)
+		return nil
+	}, 1)
+	r, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	r.SetContext(ctx)
+	r.SetInstructionLimit(10)
+	r.SetMemoryLimit(1)
+	r.SetMaxCallStackSize(5)
+	r.SetMaxStackSize(10)
+	r.EnableOpcodeStats()
+	r.StartProfile(1000)
+	r.SetInterruptibleReads(true)
+	jobRan := false
+	r.enqueuePromiseJob(func() {
+		jobRan = true
+	})
+	pool.Put(r)
+
+	r1, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 != r {
+		t.Fatal("The Runtime has not been reused")
+	}
+	vm := r1.vm
+	if vm.goCtx != nil {
+		t.Fatal("context")
+	}
+	if vm.instrLimit != 0 {
+		t.Fatalf("instruction limit: %d", vm.instrLimit)
+	}
+	if vm.memLimit != 0 {
+		t.Fatalf("memory limit: %d", vm.memLimit)
+	}
+	if vm.maxCallStackSize != 100 {
+		t.Fatalf("call stack size: %d", vm.maxCallStackSize)
+	}
+	if vm.maxStackSize != math.MaxInt32 {
+		t.Fatalf("stack size: %d", vm.maxStackSize)
+	}
+	if r1.OpcodeStats() != nil {
+		t.Fatal("opcode stats")
+	}
+	if r1.StopProfile() != nil {
+		t.Fatal("profile")
+	}
+	if vm.interruptibleReads {
+		t.Fatal("interruptible reads")
+	}
+	res, err := r1.RunString(`
+	function f(n) { return n > 0 ? f(n - 1) + 1 : 0; }
+	f(50);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToInteger() != 50 {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+	if jobRan {
+		t.Fatal("The pending job has been performed")
+	}
+	pool.Put(r1)
+}
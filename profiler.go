@@ -0,0 +1,295 @@
+package goja
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+const defaultProfileHz = 100
+
+// Profile is a sampled CPU profile of the script code collected between Runtime.StartProfile() and
+// Runtime.StopProfile(). Only the time spent running the scripts is accounted for, i.e. the samples are taken
+// while the vm is executing instructions. The time spent in a native function is attributed to the instruction
+// following its call.
+type Profile struct {
+	// Samples contains the distinct call stacks that have been sampled.
+	Samples []ProfileSample
+
+	// Period is the time between two consecutive samples.
+	Period time.Duration
+
+	Start    time.Time
+	Duration time.Duration
+}
+
+// ProfileSample is a call stack along with the number of times it has been sampled.
+type ProfileSample struct {
+	// Frames contains the call stack, the innermost frame first.
+	Frames []StackFrame
+	Count  int
+}
+
+type profiler struct {
+	// set by the sampling goroutine, the sample is taken by the vm goroutine before the next instruction
+	req uint32
+	// points to vm.interrupted, which is set after req so that the vm loop notices the request
+	interrupted *uint32
+
+	period  time.Duration
+	start   time.Time
+	stop    chan struct{}
+	stopped sync.WaitGroup
+
+	samples []ProfileSample
+	index   map[string]int
+	buf     []StackFrame
+	key     []byte
+}
+
+func (p *profiler) run() {
+	defer p.stopped.Done()
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			atomic.StoreUint32(&p.req, 1)
+			atomic.StoreUint32(p.interrupted, 1)
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (vm *vm) takeProfileSample(p *profiler) {
+	atomic.StoreUint32(&p.req, 0)
+	p.buf = vm.captureStack(p.buf[:0], 0)
+	if len(p.buf) == 0 {
+		return
+	}
+	key := p.key[:0]
+	for i := range p.buf {
+		f := &p.buf[i]
+		key = appendUint64(key, uint64(uintptr(unsafe.Pointer(f.prg))))
+		key = appendUint64(key, uint64(f.pc))
+		key = append(key, f.funcName...)
+		key = append(key, 0)
+	}
+	p.key = key
+	if idx, exists := p.index[string(key)]; exists {
+		p.samples[idx].Count++
+		return
+	}
+	frames := make([]StackFrame, len(p.buf))
+	copy(frames, p.buf)
+	p.index[string(key)] = len(p.samples)
+	p.samples = append(p.samples, ProfileSample{Frames: frames, Count: 1})
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56))
+}
+
+// StartProfile starts collecting a CPU profile of the script code by sampling the call stack sampleHz
+// times per second (100 if sampleHz is not positive). A profile that is already being collected is discarded.
+// The samples are taken by the vm goroutine itself (a separate goroutine only signals that a sample is due),
+// so the profiling is safe to use while the Runtime is running.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) StartProfile(sampleHz int) {
+	r.StopProfile()
+	if sampleHz <= 0 {
+		sampleHz = defaultProfileHz
+	}
+	p := &profiler{
+		period: time.Second / time.Duration(sampleHz),
+		start:  time.Now(),
+		stop:   make(chan struct{}),
+		index:  make(map[string]int),
+
+		interrupted: &r.vm.interrupted,
+	}
+	p.stopped.Add(1)
+	go p.run()
+	r.vm.profiler = p
+}
+
+// StopProfile stops collecting the profile started with StartProfile() and returns it. It returns nil if
+// the profile has not been started.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) StopProfile() *Profile {
+	p := r.vm.profiler
+	if p == nil {
+		return nil
+	}
+	r.vm.profiler = nil
+	close(p.stop)
+	p.stopped.Wait()
+	return &Profile{
+		Samples:  p.samples,
+		Period:   p.period,
+		Start:    p.start,
+		Duration: time.Since(p.start),
+	}
+}
+
+// WritePprof writes the profile in the gzip-compressed protocol buffer format used by pprof
+// (see https://github.com/google/pprof/blob/main/proto/profile.proto), so it can be analysed with
+// 'go tool pprof' which can also render it as a flame graph.
+func (p *Profile) WritePprof(w io.Writer) error {
+	var b pprofBuilder
+	b.strings = map[string]int64{"": 0}
+	b.stringList = []string{""}
+	b.functions = make(map[interface{}]uint64)
+	b.locations = make(map[StackFrame]uint64)
+
+	var out protoBuffer
+	samplesType := b.valueType("samples", "count")
+	cpuType := b.valueType("cpu", "nanoseconds")
+	out.bytesField(1, samplesType)
+	out.bytesField(1, cpuType)
+	for _, s := range p.Samples {
+		var sample protoBuffer
+		ids := make([]uint64, len(s.Frames))
+		for i := range s.Frames {
+			ids[i] = b.location(&s.Frames[i])
+		}
+		sample.packedUint64Field(1, ids)
+		sample.packedUint64Field(2, []uint64{uint64(s.Count), uint64(int64(s.Count) * int64(p.Period))})
+		out.bytesField(2, sample.Bytes())
+	}
+	out.Write(b.locationData.Bytes())
+	out.Write(b.functionData.Bytes())
+	for _, s := range b.stringList {
+		out.stringField(6, s)
+	}
+	out.uint64Field(9, uint64(p.Start.UnixNano()))
+	out.uint64Field(10, uint64(p.Duration))
+	out.bytesField(11, cpuType)
+	out.uint64Field(12, uint64(p.Period))
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(out.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type pprofBuilder struct {
+	strings    map[string]int64
+	stringList []string
+
+	functions    map[interface{}]uint64
+	functionData protoBuffer
+
+	locations    map[StackFrame]uint64
+	locationData protoBuffer
+}
+
+func (b *pprofBuilder) str(s string) uint64 {
+	if idx, exists := b.strings[s]; exists {
+		return uint64(idx)
+	}
+	idx := int64(len(b.stringList))
+	b.strings[s] = idx
+	b.stringList = append(b.stringList, s)
+	return uint64(idx)
+}
+
+func (b *pprofBuilder) valueType(typ, unit string) []byte {
+	var vt protoBuffer
+	vt.uint64Field(1, b.str(typ))
+	vt.uint64Field(2, b.str(unit))
+	return vt.Bytes()
+}
+
+func (b *pprofBuilder) function(f *StackFrame) uint64 {
+	// the functions are identified by their Programs, native ones by their names
+	var key interface{} = f.prg
+	if f.prg == nil {
+		key = f.funcName
+	}
+	if id, exists := b.functions[key]; exists {
+		return id
+	}
+	id := uint64(len(b.functions) + 1)
+	b.functions[key] = id
+	var fn protoBuffer
+	fn.uint64Field(1, id)
+	fn.uint64Field(2, b.str(f.FuncName()))
+	fn.uint64Field(4, b.str(f.SrcName()))
+	if f.prg != nil && f.prg.src != nil {
+		fn.uint64Field(5, uint64(f.prg.src.Position(f.prg.sourceOffset(0)).Line))
+	}
+	b.functionData.bytesField(5, fn.Bytes())
+	return id
+}
+
+func (b *pprofBuilder) location(f *StackFrame) uint64 {
+	key := *f
+	if key.prg == nil {
+		key.pc = 0
+	}
+	if id, exists := b.locations[key]; exists {
+		return id
+	}
+	id := uint64(len(b.locations) + 1)
+	b.locations[key] = id
+	var line, loc protoBuffer
+	line.uint64Field(1, b.function(f))
+	line.uint64Field(2, uint64(f.Position().Line))
+	loc.uint64Field(1, id)
+	loc.bytesField(4, line.Bytes())
+	b.locationData.bytesField(4, loc.Bytes())
+	return id
+}
+
+// protoBuffer is a minimal protocol buffer encoder.
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	b.WriteByte(byte(v))
+}
+
+func (b *protoBuffer) tag(field, wireType uint64) {
+	b.varint(field<<3 | wireType)
+}
+
+func (b *protoBuffer) uint64Field(field, v uint64) {
+	if v != 0 {
+		b.tag(field, 0)
+		b.varint(v)
+	}
+}
+
+func (b *protoBuffer) bytesField(field uint64, data []byte) {
+	b.tag(field, 2)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protoBuffer) stringField(field uint64, s string) {
+	b.tag(field, 2)
+	b.varint(uint64(len(s)))
+	b.WriteString(s)
+}
+
+func (b *protoBuffer) packedUint64Field(field uint64, values []uint64) {
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(v)
+	}
+	b.bytesField(field, packed.Bytes())
+}
@@ -0,0 +1,74 @@
+package goja
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	vm := New()
+	if vm.StopProfile() != nil {
+		t.Fatal("StopProfile() without StartProfile()")
+	}
+	vm.StartProfile(1000)
+	_, err := vm.RunScript("prof.js", `
+	function hot(n) {
+		var s = 0;
+		for (var i = 0; i < n; i++) {
+			s += i % 7;
+		}
+		return s;
+	}
+	var start = Date.now();
+	while (Date.now() - start < 100) {
+		[1, 2].forEach(function(x) { hot(x * 1000); });
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := vm.StopProfile()
+	if p == nil || len(p.Samples) == 0 {
+		t.Fatal("no samples")
+	}
+	if vm.StopProfile() != nil {
+		t.Fatal("the profile has not been stopped")
+	}
+
+	total, inHot := 0, 0
+	for _, s := range p.Samples {
+		total += s.Count
+		if f := s.Frames[0]; f.FuncName() == "hot" {
+			inHot += s.Count
+			if len(s.Frames) != 4 || s.Frames[2].FuncName() != "forEach" || s.Frames[3].SrcName() != "prof.js" {
+				t.Fatalf("Unexpected stack: %v", s.Frames)
+			}
+			if line := f.Position().Line; line < 3 || line > 7 {
+				t.Fatalf("Unexpected line: %d", line)
+			}
+		}
+	}
+	if inHot < total/2 {
+		t.Fatalf("Unexpected number of samples in hot(): %d of %d", inHot, total)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"hot", "forEach", "prof.js", "nanoseconds"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Fatalf("%q is missing", s)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package goja
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja/unistring"
+)
+
+type disassembler struct {
+	w   io.Writer
+	err error
+
+	// the programs referenced by the instruction being formatted, they are printed after it
+	nested []disasmNested
+}
+
+type disasmNested struct {
+	label string
+	prg   *Program
+}
+
+// Disassemble writes a human-readable listing of the compiled code to w. Each instruction is printed on a separate
+// line with its index (pc), the source position it was compiled from (line:column), the opcode name and
+// the operands. The code of the functions and classes defined in the Program follows the instruction that creates
+// them, indented.
+// The format is intended for debugging and is not stable, it may change between versions.
+// Returns the first error returned by w.
+func (p *Program) Disassemble(w io.Writer) error {
+	d := &disassembler{w: w}
+	name := "<unknown>"
+	if p.src != nil {
+		name = p.src.Name()
+	}
+	d.printf("program %s\n", name)
+	d.program(p, "")
+	return d.err
+}
+
+func (d *disassembler) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+func (d *disassembler) program(p *Program, indent string) {
+	for pc, ins := range p.code {
+		pos := ""
+		if p.src != nil {
+			position := p.src.Position(p.sourceOffset(pc))
+			pos = strconv.Itoa(position.Line) + ":" + strconv.Itoa(position.Column)
+		}
+		d.nested = d.nested[:0]
+		line := d.instruction(p, ins)
+		d.printf("%s%6d %8s  %s\n", indent, pc, pos, line)
+		nested := append([]disasmNested(nil), d.nested...)
+		for _, n := range nested {
+			d.printf("%s  %s %s\n", indent, n.label, disasmFuncName(n.prg))
+			d.program(n.prg, indent+"  ")
+		}
+	}
+}
+
+func disasmFuncName(p *Program) string {
+	if p.funcName == "" {
+		return "<anonymous>"
+	}
+	return p.funcName.String()
+}
+
+// opcodeName returns the name of an instruction type, i.e. the name of the type without the leading underscore.
+func opcodeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimPrefix(t.Name(), "_")
+}
+
+func (d *disassembler) instruction(p *Program, ins instruction) string {
+	var b strings.Builder
+	b.WriteString(opcodeName(reflect.TypeOf(ins)))
+	if l, ok := ins.(loadVal); ok {
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatUint(uint64(l), 10))
+		if int(l) < len(p.values) {
+			b.WriteString(" ; ")
+			b.WriteString(disasmValue(p.values[l]))
+		}
+		return b.String()
+	}
+	v := reflect.ValueOf(ins)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	} else {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	if v.Kind() == reflect.Struct && v.Type() != typeNewRegexp {
+		d.fields(&b, v)
+	} else {
+		b.WriteByte(' ')
+		d.operand(&b, "", v)
+	}
+	return b.String()
+}
+
+func (d *disassembler) fields(b *strings.Builder, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := fieldOf(v, i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			d.fields(b, fv)
+			continue
+		}
+		if f.Name == "source" {
+			// the source text of a function, the position is shown instead
+			continue
+		}
+		b.WriteByte(' ')
+		b.WriteString(f.Name)
+		b.WriteByte('=')
+		d.operand(b, f.Name, fv)
+	}
+}
+
+func (d *disassembler) operand(b *strings.Builder, name string, v reflect.Value) {
+	t := v.Type()
+	switch t {
+	case typeProgramPtr:
+		prg := v.Interface().(*Program)
+		if prg == nil {
+			b.WriteString("nil")
+			return
+		}
+		label := name
+		if label == "" || label == "prg" {
+			label = "function"
+		}
+		d.nested = append(d.nested, disasmNested{label: label, prg: prg})
+		b.WriteString(disasmFuncName(prg))
+		return
+	case typeValue:
+		b.WriteString(disasmValue(v.Interface().(Value)))
+		return
+	case typeNewRegexp:
+		r := v.Addr().Interface().(*newRegexp)
+		b.WriteByte('/')
+		b.WriteString(r.src.String())
+		b.WriteByte('/')
+		b.WriteString(regexpFlags(r.pattern))
+		return
+	case typeEmptyInterface:
+		if v.IsNil() {
+			b.WriteString("nil")
+		} else {
+			fmt.Fprintf(b, "%v", v.Interface())
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.String:
+		b.WriteString(strconv.Quote(unistring.String(v.String()).String()))
+	case reflect.Slice:
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			d.operand(b, "", v.Index(i))
+		}
+		b.WriteByte(']')
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			d.operand(b, "", k)
+			b.WriteByte(':')
+			d.operand(b, "", v.MapIndex(k))
+		}
+		b.WriteByte('}')
+	case reflect.Struct:
+		b.WriteByte('{')
+		var fb strings.Builder
+		d.fields(&fb, v)
+		b.WriteString(strings.TrimPrefix(fb.String(), " "))
+		b.WriteByte('}')
+	case reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("nil")
+		} else {
+			d.operand(b, name, v.Elem())
+		}
+	default:
+		b.WriteString(t.String())
+	}
+}
+
+func disasmValue(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case valueString:
+		return strconv.Quote(v.String())
+	case *Symbol:
+		return v.descriptiveString().String()
+	}
+	return v.String()
+}
@@ -0,0 +1,54 @@
+package goja
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProgramDisassemble(t *testing.T) {
+	prg := MustCompile("test.js", `var x = "hi";
+function f(a) {
+	return () => a + x;
+}
+class C {
+	static s = /ab+/g;
+}
+`, false)
+	var b strings.Builder
+	if err := prg.Disassemble(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{
+		"program test.js\n",
+		` bindGlobal vars=["x"] funcs=["f"] lets=["C"] consts=[] deletable=false` + "\n",
+		` loadVal 0 ; "hi"` + "\n",
+		` newFunc prg=f name="f" length=1 strict=false` + "\n",
+		"\n  function f\n",
+		"\n    function <anonymous>\n",
+		`3:19  loadDynamic "x"` + "\n",
+		"\n  initFields <static_initializer>\n",
+		" newRegexp /ab+/g\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("%q not found in:\n%s", s, out)
+		}
+	}
+	if !strings.HasPrefix(out[strings.Index(out, "\n")+1:], "     0      1:1  ") {
+		t.Fatalf("Unexpected format:\n%s", out)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestProgramDisassembleWriteError(t *testing.T) {
+	err := MustCompile("test.js", `1 + 1`, false).Disassemble(failingWriter{})
+	if err == nil || err.Error() != "write failed" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,930 @@
+package goja
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/unistring"
+)
+
+const (
+	programMagic         = "goja"
+	programFormatVersion = 3
+)
+
+var (
+	errProgramFormat  = errors.New("goja: invalid compiled program data")
+	errProgramVersion = errors.New("goja: compiled program data was produced by a different version of goja")
+)
+
+// instructionRegistry lists the zero values of all instruction types. The index of a type in this list is its opcode
+// in the marshalled form of a Program.
+var instructionRegistry = [...]instruction{
+	_add{},
+	_and{},
+	_asyncIterCloseResult{},
+	_bnot{},
+	_boxThis{},
+	_callEvalVariadic{},
+	_callEvalVariadicStrict{},
+	_callVariadic{},
+	_checkObjectCoercible{},
+	_clearResult{},
+	_copyRest{},
+	_copySpread{},
+	_createArgsRestStash{},
+	_createDestructSrc{},
+	_debugStmt{},
+	_debugger{},
+	_dec{},
+	_deleteElem{},
+	_deleteElemStrict{},
+	_div{},
+	_dup{},
+	_endVariadic{},
+	_enterFinally{},
+	_enterWith{},
+	_enumGet{},
+	_enumPop{},
+	_enumPopClose{},
+	_enumerate{},
+	_exp{},
+	_getElem{},
+	_getElemCallee{},
+	_getElemRecv{},
+	_getElemRecvCallee{},
+	_getElemRef{},
+	_getElemRefRecv{},
+	_getElemRefRecvStrict{},
+	_getElemRefStrict{},
+	_getKey{},
+	_getValue{},
+	_halt{},
+	_inc{},
+	_initGenerator{},
+	_initValueP{},
+	_iterate{},
+	_iterateAsyncP{},
+	_iterateP{},
+	_leaveFinally{},
+	_leaveTry{},
+	_leaveWith{},
+	_loadCallee{},
+	_loadGlobalObject{},
+	_loadNewTarget{},
+	_loadNil{},
+	_loadSuper{},
+	_loadUndef{},
+	_mod{},
+	_mul{},
+	_neg{},
+	_new(0),
+	_newArrayFromIter{},
+	_newObject{},
+	_newVariadic{},
+	_not{},
+	_op_eq{},
+	_op_gt{},
+	_op_gte{},
+	_op_in{},
+	_op_instanceof{},
+	_op_lt{},
+	_op_lte{},
+	_op_neq{},
+	_op_strict_eq{},
+	_op_strict_neq{},
+	_or{},
+	_plus{},
+	_pop{},
+	_popRef{},
+	_pushArrayItem{},
+	_pushArraySpread{},
+	_pushSpread{},
+	_putValue{},
+	_putValueP{},
+	_ret{},
+	_sal{},
+	_sar{},
+	_saveResult{},
+	_setElem{},
+	_setElem1{},
+	_setElem1Named{},
+	_setElemP{},
+	_setElemRecv{},
+	_setElemRecvP{},
+	_setElemRecvStrict{},
+	_setElemRecvStrictP{},
+	_setElemStrict{},
+	_setElemStrictP{},
+	_setProto{},
+	_shr{},
+	_startVariadic{},
+	_sub{},
+	_superCallVariadic{},
+	_throw{},
+	_throwAssignToConst{},
+	_toNumber{},
+	_toPropertyKey{},
+	_toString{},
+	_typeof{},
+	_xor{},
+	_yield{},
+	_yieldDelegate{},
+	_yieldDelegateStart{},
+	(*bindGlobal)(nil),
+	(*bindVars)(nil),
+	call(0),
+	callEval(0),
+	callEvalStrict(0),
+	concatStrings(0),
+	copyStash{},
+	createArgsMapped(0),
+	createArgsRestStack(0),
+	asyncIterClose(0),
+	asyncIterNext(0),
+	asyncIterResult(0),
+	createArgsUnmapped(0),
+	cret(0),
+	defineComputedKey(0),
+	(*defineGetter)(nil),
+	(*defineGetterKeyed)(nil),
+	(*defineMethod)(nil),
+	(*defineMethodKeyed)(nil),
+	(*definePrivateGetter)(nil),
+	(*definePrivateMethod)(nil),
+	(*definePrivateProp)(nil),
+	(*definePrivateSetter)(nil),
+	defineProp{},
+	definePropKeyed(""),
+	(*defineSetter)(nil),
+	(*defineSetterKeyed)(nil),
+	deleteGlobal(""),
+	deleteProp(""),
+	deletePropStrict(""),
+	deleteVar(""),
+	dupLast(0),
+	dupN(0),
+	(*enterBlock)(nil),
+	(*enterCatchBlock)(nil),
+	(*enterFunc)(nil),
+	(*enterFunc1)(nil),
+	(*enterFuncBody)(nil),
+	(*enterFuncStashless)(nil),
+	enumNext(0),
+	(*getPrivatePropId)(nil),
+	(*getPrivatePropIdCallee)(nil),
+	(*getPrivatePropRes)(nil),
+	(*getPrivatePropResCallee)(nil),
+	(*getPrivateRefId)(nil),
+	(*getPrivateRefRes)(nil),
+	getProp(""),
+	getPropCallee(""),
+	getPropRecv(""),
+	getPropRecvCallee(""),
+	getPropRef(""),
+	getPropRefRecv(""),
+	getPropRefRecvStrict(""),
+	getPropRefStrict(""),
+	(*getTaggedTmplObject)(nil),
+	getThisDynamic{},
+	initGlobal(""),
+	initGlobalP(""),
+	initStack(0),
+	initStack1(0),
+	initStack1P(0),
+	initStackP(0),
+	initStash(0),
+	initStashP(0),
+	(*initStaticElements)(nil),
+	iterGetNextOrUndef{},
+	iterNext(0),
+	jcoalesc(0),
+	jdef(0),
+	jdefP(0),
+	jeq(0),
+	jeq1(0),
+	jne(0),
+	jneq1(0),
+	jopt(0),
+	joptc(0),
+	jump(0),
+	(*leaveBlock)(nil),
+	loadComputedKey(0),
+	loadDynamic(""),
+	loadDynamicCallee(""),
+	loadDynamicRef(""),
+	(*loadMixed)(nil),
+	(*loadMixedLex)(nil),
+	(*loadMixedStack)(nil),
+	(*loadMixedStack1)(nil),
+	(*loadMixedStack1Lex)(nil),
+	(*loadMixedStackLex)(nil),
+	loadStack(0),
+	loadStack1(0),
+	loadStack1Lex(0),
+	loadStackLex(0),
+	loadStash(0),
+	loadStashLex(0),
+	loadThisStack{},
+	loadThisStash(0),
+	loadVal(0),
+	newArray(0),
+	(*newArrowFunc)(nil),
+	(*newAsyncArrowFunc)(nil),
+	(*newAsyncFunc)(nil),
+	(*newClass)(nil),
+	(*newDerivedClass)(nil),
+	(*newFunc)(nil),
+	(*newGeneratorFunc)(nil),
+	(*newMethod)(nil),
+	(*newRegexp)(nil),
+	(*newStaticFieldInit)(nil),
+	popPrivateEnv{},
+	(*privateInId)(nil),
+	(*privateInRes)(nil),
+	putProp(""),
+	rdupN(0),
+	(*resolveMixed)(nil),
+	(*resolveMixedStack)(nil),
+	(*resolveMixedStack1)(nil),
+	resolveThisDynamic{},
+	resolveThisStack{},
+	resolveThisStash(0),
+	resolveVar1(""),
+	resolveVar1Strict(""),
+	setGlobal(""),
+	setGlobalStrict(""),
+	(*setPrivatePropId)(nil),
+	(*setPrivatePropIdP)(nil),
+	(*setPrivatePropRes)(nil),
+	(*setPrivatePropResP)(nil),
+	setProp(""),
+	setPropP(""),
+	setPropRecv(""),
+	setPropRecvP(""),
+	setPropRecvStrict(""),
+	setPropRecvStrictP(""),
+	setPropStrict(""),
+	setPropStrictP(""),
+	storeStack(0),
+	storeStack1(0),
+	storeStack1Lex(0),
+	storeStack1LexP(0),
+	storeStack1P(0),
+	storeStackLex(0),
+	storeStackLexP(0),
+	storeStackP(0),
+	storeStash(0),
+	storeStashLex(0),
+	storeStashLexP(0),
+	storeStashP(0),
+	superCall(0),
+	tailCall(0),
+	throwConst{},
+	try{},
+}
+
+var (
+	instructionOpcodes     map[reflect.Type]uint32
+	instructionFingerprint uint64
+
+	typeProgramPtr      = reflect.TypeOf((*Program)(nil))
+	typeNewRegexp       = reflect.TypeOf(newRegexp{})
+	typePrivateEnvType  = reflect.TypeOf((*privateEnvType)(nil))
+	typeEmptyInterface  = reflect.TypeOf((*interface{})(nil)).Elem()
+	typeThrowConstValue = [...]reflect.Type{
+		reflect.TypeOf(typeError("")),
+		reflect.TypeOf(rangeError("")),
+		reflect.TypeOf(referenceError("")),
+		reflect.TypeOf(syntaxError("")),
+	}
+)
+
+func init() {
+	instructionOpcodes = make(map[reflect.Type]uint32, len(instructionRegistry))
+	h := fnv.New64a()
+	for i, ins := range instructionRegistry {
+		t := reflect.TypeOf(ins)
+		instructionOpcodes[t] = uint32(i)
+		describeType(h, t)
+	}
+	instructionFingerprint = h.Sum64()
+}
+
+// describeType writes the layout of an instruction type so that any change to the set of instructions or their
+// fields results in a different fingerprint.
+func describeType(w interface{ Write([]byte) (int, error) }, t reflect.Type) {
+	fmt.Fprintf(w, "%s:%s;", t.String(), t.Kind())
+	switch t.Kind() {
+	case reflect.Struct:
+		w.Write([]byte("{"))
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fmt.Fprintf(w, "%s ", f.Name)
+			describeType(w, f.Type)
+		}
+		w.Write([]byte("}"))
+	case reflect.Slice:
+		describeType(w, t.Elem())
+	case reflect.Map:
+		describeType(w, t.Key())
+		describeType(w, t.Elem())
+	}
+}
+
+// fieldOf returns a settable view of a struct field, including the unexported ones. sv must be addressable.
+func fieldOf(sv reflect.Value, i int) reflect.Value {
+	f := sv.Field(i)
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+type programMarshalError struct {
+	err error
+}
+
+type programEncoder struct {
+	buf      []byte
+	programs map[*Program]uint64
+	files    map[*file.File]uint64
+}
+
+// MarshalBinary encodes the Program so that it can be cached (e.g. on disk) and restored with UnmarshalBinary(),
+// which is faster than compiling the source again. The encoding includes the code, the literal values, the source
+// code (for the source positions in stack traces) and the nested functions. The source map attached to the source
+// file (if any) is not preserved.
+// The encoded form is only valid for the same version of goja, UnmarshalBinary() rejects data produced by a version
+// with a different set of instructions.
+// Programs compiled for eval() that refer to private names of the calling context cannot be encoded.
+func (p *Program) MarshalBinary() (data []byte, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*programMarshalError); ok {
+				err = e.err
+				return
+			}
+			panic(x)
+		}
+	}()
+	e := &programEncoder{
+		programs: make(map[*Program]uint64),
+		files:    make(map[*file.File]uint64),
+	}
+	e.buf = append(e.buf, programMagic...)
+	e.writeUint(programFormatVersion)
+	e.writeUint64(instructionFingerprint)
+	e.writeProgram(p)
+	return e.buf, nil
+}
+
+func (e *programEncoder) fail(format string, args ...interface{}) {
+	panic(&programMarshalError{err: fmt.Errorf("goja: cannot marshal program: "+format, args...)})
+}
+
+func (e *programEncoder) writeUint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (e *programEncoder) writeInt(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func (e *programEncoder) writeUint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *programEncoder) writeBool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *programEncoder) writeString(s string) {
+	e.writeUint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *programEncoder) writeProgram(p *Program) {
+	if p == nil {
+		e.writeUint(0)
+		return
+	}
+	if id, exists := e.programs[p]; exists {
+		e.writeUint(id)
+		return
+	}
+	id := uint64(len(e.programs) + 1)
+	e.programs[p] = id
+	e.writeUint(id)
+
+	e.writeUint(uint64(len(p.code)))
+	for _, ins := range p.code {
+		e.writeInstruction(ins)
+	}
+	e.writeUint(uint64(len(p.values)))
+	for _, v := range p.values {
+		e.writeValue(v)
+	}
+	e.writeString(string(p.funcName))
+	e.writeFile(p.src)
+	e.writeUint(uint64(len(p.srcMap)))
+	for _, item := range p.srcMap {
+		e.writeUint(uint64(item.pc))
+		e.writeUint(uint64(item.srcPos))
+	}
+	e.writeBool(p.sandboxGlobals)
+}
+
+func (e *programEncoder) writeFile(f *file.File) {
+	if f == nil {
+		e.writeUint(0)
+		return
+	}
+	if id, exists := e.files[f]; exists {
+		e.writeUint(id)
+		return
+	}
+	id := uint64(len(e.files) + 1)
+	e.files[f] = id
+	e.writeUint(id)
+	e.writeString(f.Name())
+	e.writeString(f.Source())
+	e.writeUint(uint64(f.Base()))
+}
+
+func (e *programEncoder) writeInstruction(ins instruction) {
+	t := reflect.TypeOf(ins)
+	op, exists := instructionOpcodes[t]
+	if !exists {
+		e.fail("unsupported instruction type %s", t)
+	}
+	e.writeUint(uint64(op))
+	v := reflect.ValueOf(ins)
+	if t.Kind() == reflect.Ptr {
+		v = v.Elem()
+	} else {
+		c := reflect.New(t).Elem()
+		c.Set(v)
+		v = c
+	}
+	e.writeField(v)
+}
+
+func (e *programEncoder) writeField(v reflect.Value) {
+	t := v.Type()
+	switch t {
+	case typeProgramPtr:
+		e.writeProgram(v.Interface().(*Program))
+		return
+	case typeValue:
+		e.writeValue(v.Interface().(Value))
+		return
+	case typeNewRegexp:
+		r := v.Addr().Interface().(*newRegexp)
+		e.writeValue(r.src)
+		e.writeString(regexpFlags(r.pattern))
+		return
+	case typePrivateEnvType:
+		e.fail("the program refers to private names of the calling context")
+	case typeEmptyInterface:
+		x := v.Interface()
+		xt := reflect.TypeOf(x)
+		for i, ct := range typeThrowConstValue {
+			if xt == ct {
+				e.writeUint(uint64(i))
+				e.writeString(reflect.ValueOf(x).String())
+				return
+			}
+		}
+		e.fail("unsupported constant type %T", x)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		e.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.writeUint(v.Uint())
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.writeUint(0)
+			return
+		}
+		e.writeUint(uint64(v.Len()) + 1)
+		for i := 0; i < v.Len(); i++ {
+			e.writeField(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.writeUint(0)
+			return
+		}
+		e.writeUint(uint64(v.Len()) + 1)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			e.writeField(k)
+			e.writeField(v.MapIndex(k))
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			e.writeField(fieldOf(v, i))
+		}
+	default:
+		e.fail("unsupported field type %s", t)
+	}
+}
+
+const (
+	valueTagUndefined byte = iota
+	valueTagNull
+	valueTagFalse
+	valueTagTrue
+	valueTagInt
+	valueTagFloat
+	valueTagASCIIString
+	valueTagUnicodeString
+	valueTagBigInt
+	valueTagProperty
+)
+
+func (e *programEncoder) writeValue(v Value) {
+	switch v := v.(type) {
+	case valueUndefined:
+		e.buf = append(e.buf, valueTagUndefined)
+	case valueNull:
+		e.buf = append(e.buf, valueTagNull)
+	case valueBool:
+		if v {
+			e.buf = append(e.buf, valueTagTrue)
+		} else {
+			e.buf = append(e.buf, valueTagFalse)
+		}
+	case valueInt:
+		e.buf = append(e.buf, valueTagInt)
+		e.writeInt(int64(v))
+	case valueFloat:
+		e.buf = append(e.buf, valueTagFloat)
+		e.writeUint64(math.Float64bits(float64(v)))
+	case asciiString:
+		e.buf = append(e.buf, valueTagASCIIString)
+		e.writeString(string(v))
+	case unicodeString:
+		e.buf = append(e.buf, valueTagUnicodeString)
+		e.writeUint(uint64(len(v)))
+		for _, c := range v {
+			e.buf = append(e.buf, byte(c), byte(c>>8))
+		}
+	case *valueBigInt:
+		e.buf = append(e.buf, valueTagBigInt)
+		b := v.big()
+		e.writeBool(b.Sign() < 0)
+		e.writeString(string(b.Bytes()))
+	case *valueProperty:
+		if v.accessor {
+			e.fail("unsupported accessor property")
+		}
+		e.buf = append(e.buf, valueTagProperty)
+		e.writeBool(v.writable)
+		e.writeBool(v.configurable)
+		e.writeBool(v.enumerable)
+		e.writeValue(v.value)
+	default:
+		e.fail("unsupported value type %T", v)
+	}
+}
+
+func regexpFlags(p *regexpPattern) string {
+	var sb strings.Builder
+	if p.global {
+		sb.WriteByte('g')
+	}
+	if p.ignoreCase {
+		sb.WriteByte('i')
+	}
+	if p.multiline {
+		sb.WriteByte('m')
+	}
+	if p.sticky {
+		sb.WriteByte('y')
+	}
+	if p.unicode {
+		sb.WriteByte('u')
+	}
+	return sb.String()
+}
+
+type programDecoder struct {
+	data     []byte
+	programs []*Program
+	files    []*file.File
+}
+
+// UnmarshalBinary restores a Program encoded by MarshalBinary(). It returns an error if the data is malformed
+// or was produced by a different version of goja. Note that the data is otherwise trusted, running a Program
+// restored from data that has been tampered with may cause a panic.
+func (p *Program) UnmarshalBinary(data []byte) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*programMarshalError); ok {
+				err = e.err
+				return
+			}
+			panic(x)
+		}
+	}()
+	if len(data) < len(programMagic) || string(data[:len(programMagic)]) != programMagic {
+		return errProgramFormat
+	}
+	d := &programDecoder{
+		data: data[len(programMagic):],
+	}
+	if d.readUint() != programFormatVersion {
+		return errProgramVersion
+	}
+	if d.readUint64() != instructionFingerprint {
+		return errProgramVersion
+	}
+	d.programs = append(d.programs, p)
+	if d.readUint() != 1 {
+		return errProgramFormat
+	}
+	*p = Program{}
+	d.readProgramBody(p)
+	if len(d.data) > 0 {
+		return errProgramFormat
+	}
+	return nil
+}
+
+func (d *programDecoder) fail() {
+	panic(&programMarshalError{err: errProgramFormat})
+}
+
+func (d *programDecoder) readUint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *programDecoder) readInt() int64 {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail()
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *programDecoder) readUint64() uint64 {
+	if len(d.data) < 8 {
+		d.fail()
+	}
+	v := binary.LittleEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	return v
+}
+
+func (d *programDecoder) readByte() byte {
+	if len(d.data) < 1 {
+		d.fail()
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *programDecoder) readBool() bool {
+	return d.readByte() != 0
+}
+
+// readLen reads a length of a sequence which elements occupy at least minSize bytes each.
+func (d *programDecoder) readLen(minSize int) int {
+	l := d.readUint()
+	if l > uint64(len(d.data)/minSize) {
+		d.fail()
+	}
+	return int(l)
+}
+
+func (d *programDecoder) readString() string {
+	l := d.readLen(1)
+	s := string(d.data[:l])
+	d.data = d.data[l:]
+	return s
+}
+
+func (d *programDecoder) readProgram() *Program {
+	id := d.readUint()
+	if id == 0 {
+		return nil
+	}
+	if id <= uint64(len(d.programs)) {
+		return d.programs[id-1]
+	}
+	if id != uint64(len(d.programs))+1 {
+		d.fail()
+	}
+	p := &Program{}
+	d.programs = append(d.programs, p)
+	d.readProgramBody(p)
+	return p
+}
+
+func (d *programDecoder) readProgramBody(p *Program) {
+	if l := d.readLen(1); l > 0 {
+		p.code = make([]instruction, l)
+		for i := range p.code {
+			p.code[i] = d.readInstruction()
+		}
+	}
+	if l := d.readLen(1); l > 0 {
+		p.values = make([]Value, l)
+		for i := range p.values {
+			p.values[i] = d.readValue()
+		}
+	}
+	p.funcName = unistring.String(d.readString())
+	p.src = d.readFile()
+	if l := d.readLen(2); l > 0 {
+		p.srcMap = make([]srcMapItem, l)
+		for i := range p.srcMap {
+			p.srcMap[i] = srcMapItem{pc: int(d.readUint()), srcPos: int(d.readUint())}
+		}
+	}
+	p.sandboxGlobals = d.readBool()
+}
+
+func (d *programDecoder) readFile() *file.File {
+	id := d.readUint()
+	if id == 0 {
+		return nil
+	}
+	if id <= uint64(len(d.files)) {
+		return d.files[id-1]
+	}
+	if id != uint64(len(d.files))+1 {
+		d.fail()
+	}
+	name := d.readString()
+	src := d.readString()
+	f := file.NewFile(name, src, int(d.readUint()))
+	d.files = append(d.files, f)
+	return f
+}
+
+func (d *programDecoder) readInstruction() instruction {
+	op := d.readUint()
+	if op >= uint64(len(instructionRegistry)) {
+		d.fail()
+	}
+	t := reflect.TypeOf(instructionRegistry[op])
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		d.readField(v.Elem())
+		return v.Interface().(instruction)
+	}
+	v := reflect.New(t).Elem()
+	d.readField(v)
+	return v.Interface().(instruction)
+}
+
+func (d *programDecoder) readField(v reflect.Value) {
+	t := v.Type()
+	switch t {
+	case typeProgramPtr:
+		if p := d.readProgram(); p != nil {
+			v.Set(reflect.ValueOf(p))
+		}
+		return
+	case typeValue:
+		v.Set(reflect.ValueOf(d.readValue()))
+		return
+	case typeNewRegexp:
+		src, ok := d.readValue().(valueString)
+		if !ok {
+			d.fail()
+		}
+		pattern, err := compileRegexp(src.String(), d.readString())
+		if err != nil {
+			d.fail()
+		}
+		v.Set(reflect.ValueOf(newRegexp{pattern: pattern, src: src}))
+		return
+	case typeEmptyInterface:
+		idx := d.readUint()
+		if idx >= uint64(len(typeThrowConstValue)) {
+			d.fail()
+		}
+		c := reflect.New(typeThrowConstValue[idx]).Elem()
+		c.SetString(d.readString())
+		v.Set(c)
+		return
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		v.SetBool(d.readBool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(d.readInt())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(d.readUint())
+	case reflect.String:
+		v.SetString(d.readString())
+	case reflect.Slice:
+		l := d.readLen(1)
+		if l == 0 {
+			return
+		}
+		l--
+		s := reflect.MakeSlice(t, l, l)
+		for i := 0; i < l; i++ {
+			d.readField(s.Index(i))
+		}
+		v.Set(s)
+	case reflect.Map:
+		l := d.readLen(1)
+		if l == 0 {
+			return
+		}
+		l--
+		m := reflect.MakeMapWithSize(t, l)
+		for i := 0; i < l; i++ {
+			k := reflect.New(t.Key()).Elem()
+			d.readField(k)
+			e := reflect.New(t.Elem()).Elem()
+			d.readField(e)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			d.readField(fieldOf(v, i))
+		}
+	default:
+		d.fail()
+	}
+}
+
+func (d *programDecoder) readValue() Value {
+	switch d.readByte() {
+	case valueTagUndefined:
+		return _undefined
+	case valueTagNull:
+		return _null
+	case valueTagFalse:
+		return valueFalse
+	case valueTagTrue:
+		return valueTrue
+	case valueTagInt:
+		return valueInt(d.readInt())
+	case valueTagFloat:
+		return valueFloat(math.Float64frombits(d.readUint64()))
+	case valueTagASCIIString:
+		return asciiString(d.readString())
+	case valueTagUnicodeString:
+		l := d.readLen(2)
+		s := make(unicodeString, l)
+		for i := range s {
+			s[i] = binary.LittleEndian.Uint16(d.data)
+			d.data = d.data[2:]
+		}
+		return s
+	case valueTagBigInt:
+		neg := d.readBool()
+		b := new(big.Int).SetBytes([]byte(d.readString()))
+		if neg {
+			b.Neg(b)
+		}
+		return newBigInt(b)
+	case valueTagProperty:
+		return &valueProperty{
+			writable:     d.readBool(),
+			configurable: d.readBool(),
+			enumerable:   d.readBool(),
+			value:        d.readValue(),
+		}
+	}
+	d.fail()
+	panic("unreachable")
+}
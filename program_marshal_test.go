@@ -0,0 +1,130 @@
+package goja
+
+import (
+	"strings"
+	"testing"
+)
+
+func marshalRoundTrip(t *testing.T, prg *Program) *Program {
+	data, err := prg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res Program
+	if err := res.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	data1, err := res.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(data1) {
+		t.Fatal("the restored program has a different encoding")
+	}
+	return &res
+}
+
+func TestProgramMarshalBinary(t *testing.T) {
+	const SCRIPT = `
+	"use strict";
+	function* gen(n) {
+		for (let i = 0; i < n; i++) {
+			try {
+				yield i;
+			} finally {
+				count++;
+			}
+		}
+	}
+	var count = 0;
+	class A {
+		#x = 1;
+		static #s = "s";
+		get x() { return this.#x; }
+		static s() { return A.#s; }
+		#m() { return 2; }
+		m() { return this.#m() + (#x in this ? 1 : 0); }
+	}
+	class B extends A {
+		constructor() {
+			super();
+			this.y = [..."ab"];
+		}
+	}
+	function tag(s, ...args) {
+		return s.raw.join("|") + args.join();
+	}
+	var b = new B();
+	var res = [
+		[...gen(3)].join(), count,
+		b.x, A.s(), b.m(), b.y.join(),
+		/a(b+)/gi.exec("xABbb")[1], /\u{1F600}/u.test("\u{1F600}"),
+		tag` + "`a${1}\\n${2}`" + `,
+		2n ** 70n, -1.5, "юникод", null, undefined === void 0,
+		eval("count + 1"),
+		(() => { let x = 1; return () => x++; })()(),
+		JSON.stringify({a: [1, {b: 2}]}),
+	];
+	res.join(";");
+	`
+	prg := MustCompile("test.js", SCRIPT, false)
+	vm := New()
+	exp, err := vm.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := marshalRoundTrip(t, prg)
+	vm = New()
+	res, err := vm.RunProgram(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SameAs(exp) {
+		t.Fatalf("Unexpected result: %v, expected: %v", res, exp)
+	}
+}
+
+func TestProgramMarshalBinarySourcePositions(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		throw new Error("test");
+	}
+	f();
+	`
+	prg := marshalRoundTrip(t, MustCompile("test.js", SCRIPT, false))
+	_, err := New().RunProgram(prg)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := ex.String(); !strings.Contains(s, "at f (test.js:3:9(3))") || !strings.Contains(s, "test.js:5:3(") {
+		t.Fatalf("Unexpected stack: %s", s)
+	}
+}
+
+func TestProgramUnmarshalBinaryErrors(t *testing.T) {
+	data, err := MustCompile("test.js", "1 + 2", false).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Program
+	if err := p.UnmarshalBinary([]byte("test")); err != errProgramFormat {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := p.UnmarshalBinary(data[:len(data)-1]); err != errProgramFormat {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	badVersion := append([]byte(nil), data...)
+	badVersion[len(programMagic)]++
+	if err := p.UnmarshalBinary(badVersion); err != errProgramVersion {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	badFingerprint := append([]byte(nil), data...)
+	badFingerprint[len(programMagic)+1] ^= 0xff
+	if err := p.UnmarshalBinary(badFingerprint); err != errProgramVersion {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package goja
+
+import (
+	"sort"
+
+	"github.com/dop251/goja/unistring"
+)
+
+// nestedPrograms returns the Programs of the functions and classes created by ins, if any.
+func nestedPrograms(ins instruction) []*Program {
+	var res []*Program
+	add := func(p *Program) {
+		if p != nil {
+			res = append(res, p)
+		}
+	}
+	switch ins := ins.(type) {
+	case *newFunc:
+		add(ins.prg)
+	case *newMethod:
+		add(ins.prg)
+	case *newGeneratorFunc:
+		add(ins.prg)
+	case *newAsyncFunc:
+		add(ins.prg)
+	case *newArrowFunc:
+		add(ins.prg)
+	case *newAsyncArrowFunc:
+		add(ins.prg)
+	case *newClass:
+		add(ins.ctor)
+		add(ins.initFields)
+	case *newDerivedClass:
+		add(ins.ctor)
+		add(ins.initFields)
+	case *newStaticFieldInit:
+		add(ins.initFields)
+	}
+	return res
+}
+
+type freeNamesCollector struct {
+	declared map[unistring.String]struct{}
+	refs     map[unistring.String]struct{}
+}
+
+func (c *freeNamesCollector) declare(names ...unistring.String) {
+	for _, name := range names {
+		c.declared[name] = struct{}{}
+	}
+}
+
+func (c *freeNamesCollector) declareMap(names map[unistring.String]uint32) {
+	for name := range names {
+		c.declared[name] = struct{}{}
+	}
+}
+
+func (c *freeNamesCollector) ref(name unistring.String) {
+	c.refs[name] = struct{}{}
+}
+
+func (c *freeNamesCollector) visit(p *Program) {
+	for _, ins := range p.code {
+		switch ins := ins.(type) {
+		case loadDynamic:
+			c.ref(unistring.String(ins))
+		case loadDynamicRef:
+			c.ref(unistring.String(ins))
+		case loadDynamicCallee:
+			c.ref(unistring.String(ins))
+		case resolveVar1:
+			c.ref(unistring.String(ins))
+		case resolveVar1Strict:
+			c.ref(unistring.String(ins))
+		case setGlobal:
+			c.ref(unistring.String(ins))
+		case setGlobalStrict:
+			c.ref(unistring.String(ins))
+		case deleteVar:
+			c.ref(unistring.String(ins))
+		case deleteGlobal:
+			c.ref(unistring.String(ins))
+		case *bindGlobal:
+			c.declare(ins.vars...)
+			c.declare(ins.funcs...)
+			c.declare(ins.lets...)
+			c.declare(ins.consts...)
+		case *bindVars:
+			c.declare(ins.names...)
+		case *enterBlock:
+			c.declareMap(ins.names)
+		case *enterCatchBlock:
+			c.declareMap(ins.names)
+		case *enterFunc:
+			c.declareMap(ins.names)
+		case *enterFunc1:
+			c.declareMap(ins.names)
+		case *enterFuncBody:
+			c.declareMap(ins.names)
+		default:
+			for _, prg := range nestedPrograms(ins) {
+				c.visit(prg)
+			}
+		}
+	}
+}
+
+// FreeNames returns the sorted list of the identifiers the Program (including the functions defined in it) refers
+// to but does not declare, i.e. the ones that are resolved at run time against the global object and the global
+// lexical declarations. This can be used to check a script for references to undefined globals without running it
+// (see also Runtime.UnresolvedNames()).
+// The result is an approximation: names in the scope of a 'with' statement are included as they may or may not be
+// resolved to the properties of the object, and a name is not included if it's declared in a function scope
+// that can be accessed by name (i.e. one that contains a direct eval() call), even if the reference is elsewhere.
+func (p *Program) FreeNames() []string {
+	c := &freeNamesCollector{
+		declared: make(map[unistring.String]struct{}),
+		refs:     make(map[unistring.String]struct{}),
+	}
+	c.visit(p)
+	var res []string
+	for name := range c.refs {
+		if _, exists := c.declared[name]; !exists {
+			res = append(res, name.String())
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// UnresolvedNames returns the names from p.FreeNames() which are neither global lexical declarations nor
+// properties of the global object of this Runtime (including the inherited ones), i.e. the ones that would cause
+// a ReferenceError if accessed when the Program is run in its current state.
+func (r *Runtime) UnresolvedNames(p *Program) []string {
+	var res []string
+	for _, name := range p.FreeNames() {
+		n := unistring.NewFromString(name)
+		if _, exists := r.global.stash.names[n]; exists {
+			continue
+		}
+		if r.globalObject.self.hasPropertyStr(n) {
+			continue
+		}
+		res = append(res, name)
+	}
+	return res
+}
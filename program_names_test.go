@@ -0,0 +1,78 @@
+package goja
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProgramFreeNames(t *testing.T) {
+	prg := MustCompile("test.js", `
+	var declaredVar = 1;
+	let declaredLet = 2;
+	function declaredFunc(arg) {
+		var local = arg + outerFree;
+		return function() {
+			return local + closureFree(Math.max(1, 2));
+		};
+	}
+	class C {
+		field = fieldFree;
+		static s = staticFree;
+		m() {
+			return methodFree;
+		}
+	}
+	const arrow = async () => await asyncFree;
+	function* gen() {
+		yield genFree;
+	}
+	try {
+		assignedFree = typeof typeofFree;
+	} catch (e) {
+		e;
+	}
+	delete deletedFree;
+	console.log(declaredVar, declaredLet, declaredFunc, C, arrow, gen);
+	`, false)
+	exp := []string{"Math", "assignedFree", "asyncFree", "closureFree", "console", "deletedFree", "fieldFree", "genFree",
+		"methodFree", "outerFree", "staticFree", "typeofFree"}
+	if names := prg.FreeNames(); !reflect.DeepEqual(names, exp) {
+		t.Fatalf("Unexpected names: %v", names)
+	}
+
+	prg = MustCompile("test.js", `
+	function f() {
+		var x = 1;
+		eval("");
+		return x + y;
+	}
+	`, false)
+	if names := prg.FreeNames(); !reflect.DeepEqual(names, []string{"eval", "y"}) {
+		t.Fatalf("Unexpected names with eval: %v", names)
+	}
+
+	if names := MustCompile("test.js", `1 + 2`, false).FreeNames(); len(names) != 0 {
+		t.Fatalf("Unexpected names: %v", names)
+	}
+}
+
+func TestRuntimeUnresolvedNames(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	let globalLet = 1;
+	var globalVar = 2;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.Set("hostFunc", func() {})
+	prg := MustCompile("test.js", `
+	hostFunc(globalLet, globalVar, Object.keys({}), toString(), missing);
+	function f() {
+		return alsoMissing;
+	}
+	`, false)
+	if names := vm.UnresolvedNames(prg); !reflect.DeepEqual(names, []string{"alsoMissing", "missing"}) {
+		t.Fatalf("Unexpected names: %v", names)
+	}
+}
@@ -0,0 +1,88 @@
+package goja
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/dop251/goja/unistring"
+)
+
+const propCacheSize = 64
+
+// propSlot holds an own string-keyed property of a baseObject.
+type propSlot struct {
+	name  unistring.String
+	value Value
+}
+
+// lastShape is the last shape assigned to a baseObject. The shapes are unique across all Runtimes, so
+// a cache entry cannot match an object it has not been filled from, even if the memory has been reused.
+var lastShape uint64
+
+// propCacheEntry records in which slot a property access instruction (identified by its Program and pc) has last
+// found an own property of a plain object. As long as the same object is accessed at the same instruction and
+// none of its properties has been deleted since (which gives the object a new shape), the map lookup can be
+// skipped. The values themselves are not cached, so writing to the properties does not invalidate the entries,
+// and neither are the objects, so the cache does not keep them alive.
+// Note, the cache is kept in the vm rather than in the instructions because a Program can be run by several
+// Runtimes at the same time.
+type propCacheEntry struct {
+	prg   *Program
+	pc    int
+	shape uint64
+	slot  int
+}
+
+func (vm *vm) propCacheEntry() *propCacheEntry {
+	h := uint(vm.pc) ^ uint(uintptr(unsafe.Pointer(vm.prg))>>4)
+	return &vm.propCache[h%propCacheSize]
+}
+
+// getOwnSlotCached returns the slot of the own property of a plain object using the cache.
+func (vm *vm) getOwnSlotCached(o *baseObject, name unistring.String) (int, bool) {
+	e := vm.propCacheEntry()
+	if o.shape != 0 && e.shape == o.shape && e.pc == vm.pc && e.prg == vm.prg {
+		return e.slot, true
+	}
+	slot, exists := o.slotIdx[name]
+	if exists {
+		if o.shape == 0 {
+			o.shape = atomic.AddUint64(&lastShape, 1)
+		}
+		*e = propCacheEntry{
+			prg:   vm.prg,
+			pc:    vm.pc,
+			shape: o.shape,
+			slot:  slot,
+		}
+	}
+	return slot, exists
+}
+
+func (vm *vm) getStrCached(obj *Object, name unistring.String, receiver Value) Value {
+	o, ok := obj.self.(*baseObject)
+	if !ok {
+		return obj.self.getStr(name, receiver)
+	}
+	slot, exists := vm.getOwnSlotCached(o, name)
+	if !exists {
+		return o.getStr(name, receiver)
+	}
+	prop := o.slots[slot].value
+	if prop, ok := prop.(*valueProperty); ok {
+		return prop.get(receiver)
+	}
+	return prop
+}
+
+func (vm *vm) setOwnStrCached(obj *Object, name unistring.String, val Value, throw bool) {
+	if o, ok := obj.self.(*baseObject); ok {
+		if slot, exists := vm.getOwnSlotCached(o, name); exists {
+			if _, ok := o.slots[slot].value.(*valueProperty); !ok {
+				o.slots[slot].value = val
+				return
+			}
+		}
+	}
+	obj.self.setOwnStr(name, val, throw)
+}
@@ -0,0 +1,161 @@
+package goja
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestPropCache(t *testing.T) {
+	const SCRIPT = `
+	function get(o) {
+		return o.x;
+	}
+	function set(o, v) {
+		o.x = v;
+	}
+	var o = {x: 1, y: 2};
+	var res = [];
+	for (var i = 0; i < 3; i++) {
+		res.push(get(o));
+	}
+	o.x = 2;
+	res.push(get(o));
+	set(o, 3);
+	res.push(get(o));
+	res.push(get({x: 4}));
+	delete o.x;
+	res.push(get(o));
+	o.x = 5;
+	res.push(get(o));
+	Object.defineProperty(o, "x", {get: function() { return this.y; }});
+	res.push(get(o));
+	o.y = 6;
+	res.push(get(o));
+	var p = {x: 7};
+	set(p, 8);
+	Object.freeze(p);
+	set(p, 9);
+	res.push(get(p));
+	var q = Object.create({x: 10});
+	res.push(get(q));
+	set(q, 11);
+	res.push(get(q), Object.getPrototypeOf(q).x);
+	res.push(get("str".length === 3 ? {x: 12} : null));
+	res.join();
+	`
+	testScript(SCRIPT, asciiString("1,1,1,2,3,4,,5,2,6,8,10,11,10,12"), t)
+}
+
+func TestPropCacheStrict(t *testing.T) {
+	const SCRIPT = `
+	"use strict";
+	var o = {x: 1};
+	function set(v) {
+		o.x = v;
+	}
+	set(2);
+	set(3);
+	Object.defineProperty(o, "x", {writable: false});
+	assert.throws(TypeError, function() { set(4); });
+	assert.sameValue(o.x, 3);
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestPropCacheSlots(t *testing.T) {
+	const SCRIPT = `
+	function get(o) {
+		return o.c;
+	}
+	function set(o, v) {
+		o.c = v;
+	}
+	var o = {a: 1, b: 2, c: 3};
+	assert.sameValue(get(o), 3);
+	set(o, 4);
+	assert.sameValue(get(o), 4);
+	delete o.a; // moves c into the slot of a
+	assert.sameValue(get(o), 4);
+	set(o, 5);
+	assert.sameValue(get(o), 5);
+	assert.sameValue(o.b, 2);
+	assert(compareArray(Object.keys(o), ["b", "c"]), Object.keys(o));
+	o.a = 6;
+	assert.sameValue(get(o), 5);
+	assert(compareArray(Object.keys(o), ["b", "c", "a"]), Object.keys(o));
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestPropCacheDoesNotRetainObjects(t *testing.T) {
+	// See TestWeakMapCollectsEntries() for why the finalizer is set on a Go value held by the object.
+	vm := New()
+	collected := make(chan struct{}, 1)
+	vm.Set("payload", func() *testWeakMapPayload {
+		p := &testWeakMapPayload{}
+		runtime.SetFinalizer(p, func(*testWeakMapPayload) {
+			collected <- struct{}{}
+		})
+		return p
+	})
+	_, err := vm.RunString(`
+	(function() {
+		var o = {x: 1, p: payload()};
+		for (var i = 0; i < 3; i++) {
+			o.x = o.x + 1;
+		}
+	})();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// overwrite the stack slots that may still hold the object
+	if _, err := vm.RunString("(function(a, b, c, d, e, f, g, h) {})(1, 2, 3, 4, 5, 6, 7, 8)"); err != nil {
+		t.Fatal(err)
+	}
+	done := false
+	deadline := time.Now().Add(5 * time.Second)
+	for !done && time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case <-collected:
+			done = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	// the Runtime must stay reachable, otherwise the object is collected along with it
+	runtime.KeepAlive(vm)
+	if !done {
+		t.Fatal("Expected the object to be collected")
+	}
+}
+
+func BenchmarkPropCacheGet(b *testing.B) {
+	const SCRIPT = `
+	var obj = {a: 0, b: 1, x: 2, y: 3};
+	function f() {
+		var sum = 0;
+		for (var i = 0; i < 1000; i++) {
+			sum += obj.x;
+		}
+		return sum;
+	}
+	`
+	vm := New()
+	_, err := vm.RunString(SCRIPT)
+	if err != nil {
+		b.Fatal(err)
+	}
+	f, _ := AssertFunction(vm.Get("f"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, err := f(nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if v.ToInteger() != 2000 {
+			b.Fatalf("Unexpected result: %v", v)
+		}
+	}
+}
@@ -520,6 +520,8 @@ func (r *regexpObject) execResultToArray(target valueString, result []int) Value
 	match := r.val.runtime.newArrayValues(valueArray)
 	match.self.setOwnStr("input", target, false)
 	match.self.setOwnStr("index", intToValue(int64(matchIndex)), false)
+	// named groups are not supported
+	match.self.setOwnStr("groups", _undefined, false)
 	return match
 }
 
@@ -0,0 +1,189 @@
+package goja
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+const defaultRegexpCacheSize = 64
+
+// RegexpCache is a cache of compiled regular expressions that can be shared by multiple Runtimes
+// (see Runtime.SetRegexpCache()). It is used by the RegExp constructor and RegExp.prototype.compile() so that
+// a pattern built at runtime (e.g. from configuration) is only compiled once, no matter how many times and
+// in how many Runtimes it is used. Regular expression literals are compiled together with the script and
+// do not use the cache.
+//
+// The cache holds up to the specified number of patterns, the least recently used one is evicted when
+// this number is exceeded. Patterns that fail to compile are not cached.
+//
+// RegexpCache is goroutine-safe.
+type RegexpCache struct {
+	mu      sync.Mutex
+	maxSize int
+	lru     *list.List
+	entries map[regexpCacheKey]*list.Element
+}
+
+type regexpCacheKey struct {
+	pattern, flags string
+}
+
+type regexpCacheEntry struct {
+	key     regexpCacheKey
+	pattern *regexpPattern
+}
+
+// NewRegexpCache creates a RegexpCache that holds up to maxSize patterns. It panics if maxSize is not positive.
+func NewRegexpCache(maxSize int) *RegexpCache {
+	if maxSize <= 0 {
+		panic("RegexpCache size must be positive")
+	}
+	return &RegexpCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[regexpCacheKey]*list.Element),
+	}
+}
+
+// Len returns the number of patterns currently in the cache.
+func (c *RegexpCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *RegexpCache) get(key regexpCacheKey) *regexpPattern {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.entries[key]; e != nil {
+		c.lru.MoveToFront(e)
+		return e.Value.(*regexpCacheEntry).pattern
+	}
+	return nil
+}
+
+func (c *RegexpCache) put(key regexpCacheKey, pattern *regexpPattern) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.entries[key]; e != nil {
+		// compiled concurrently by someone else
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&regexpCacheEntry{key: key, pattern: pattern})
+	for c.lru.Len() > c.maxSize {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*regexpCacheEntry).key)
+	}
+}
+
+// compile returns a copy of the cached pattern for the key, compiling and caching it if necessary.
+// The cached instance itself is never used for matching, so it can be safely shared.
+func (c *RegexpCache) compile(patternStr valueString, flags string) (*regexpPattern, error) {
+	key := regexpCacheKey{pattern: escapeInvalidUtf16(patternStr), flags: flags}
+	if p := c.get(key); p != nil {
+		return p.clone(), nil
+	}
+	p, err := compileRegexp(key.pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, p)
+	return p.clone(), nil
+}
+
+// SetRegexpCache sets the cache of compiled regular expressions used by this Runtime. The same cache can
+// be shared by multiple Runtimes. Passing nil (the default) disables caching.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetRegexpCache(cache *RegexpCache) {
+	r.regexpCache = cache
+}
+
+// SetRegexpCacheSize sets the maximum number of compiled regular expressions kept by this Runtime
+// (the default is 64). Regular expression literals and the RegExp constructor calls that have the same
+// source and flags share the same compiled pattern instead of making a copy every time. Unlike RegexpCache
+// (see SetRegexpCache()), this cache is local to the Runtime, which means the patterns can be used without
+// copying. Passing 0 or a negative value disables the cache.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetRegexpCacheSize(n int) {
+	r.localRegexpCache = nil
+	r.localRegexpCacheSize = n
+}
+
+// getLocalRegexpCache returns the Runtime's cache of compiled patterns creating it if necessary, or nil
+// if it's disabled. The cached patterns belong to the Runtime and can be used without cloning, because
+// all of the pattern state that changes during matching is only accessed from the vm goroutine and none of
+// it is specific to a RegExp object (lastIndex is a property of the object).
+func (r *Runtime) getLocalRegexpCache() *RegexpCache {
+	if r.localRegexpCache == nil && r.localRegexpCacheSize > 0 {
+		r.localRegexpCache = NewRegexpCache(r.localRegexpCacheSize)
+	}
+	return r.localRegexpCache
+}
+
+// canonicalRegexpFlags returns the flags in the order used by regexpFlags() so that the patterns
+// compiled with the same flags in a different order share the cache entry. If the flags are invalid
+// they are returned as is.
+func canonicalRegexpFlags(flags string) string {
+	if len(flags) < 2 {
+		return flags
+	}
+	const order = "gimyu"
+	var present [len(order)]bool
+	for _, chr := range flags {
+		i := strings.IndexRune(order, chr)
+		if i < 0 || present[i] {
+			return flags
+		}
+		present[i] = true
+	}
+	b := make([]byte, 0, len(flags))
+	for i, p := range present {
+		if p {
+			b = append(b, order[i])
+		}
+	}
+	return string(b)
+}
+
+func (r *Runtime) compileRegexp(patternStr valueString, flags string) (*regexpPattern, error) {
+	local := r.getLocalRegexpCache()
+	var key regexpCacheKey
+	if local != nil {
+		key = regexpCacheKey{pattern: escapeInvalidUtf16(patternStr), flags: canonicalRegexpFlags(flags)}
+		if p := local.get(key); p != nil {
+			return p, nil
+		}
+	}
+	var p *regexpPattern
+	var err error
+	if c := r.regexpCache; c != nil {
+		p, err = c.compile(patternStr, flags)
+	} else {
+		p, err = compileRegexpFromValueString(patternStr, flags)
+	}
+	if err == nil && local != nil {
+		local.put(key, p)
+	}
+	return p, err
+}
+
+// literalRegexpPattern returns the pattern for a new object created by a regular expression literal.
+// The pattern compiled along with the Program can be shared between Runtimes, so it's never used directly.
+func (r *Runtime) literalRegexpPattern(n *newRegexp) *regexpPattern {
+	local := r.getLocalRegexpCache()
+	if local == nil {
+		return n.pattern.clone()
+	}
+	key := regexpCacheKey{pattern: n.src.String(), flags: regexpFlags(n.pattern)}
+	if p := local.get(key); p != nil {
+		return p
+	}
+	p := n.pattern.clone()
+	local.put(key, p)
+	return p
+}
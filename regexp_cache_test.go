@@ -0,0 +1,141 @@
+package goja
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRegexpCache(t *testing.T) {
+	cache := NewRegexpCache(2)
+	const SCRIPT = `
+	var re = new RegExp("a(" + "b+)", "g");
+	var m = "xabbyab".match(re);
+	assert(compareArray(m, ["abb", "ab"]), "match");
+	assert.sameValue(new RegExp("a(b+)", "g").exec("ab")[1], "b", "exec");
+	assert.sameValue(re.source, "a(b+)", "source");
+	assert.sameValue(new RegExp("a(b+)", "y").test("ab"), true, "different flags");
+	`
+	for i := 0; i < 2; i++ {
+		vm := New()
+		vm.SetRegexpCache(cache)
+		vm.testScriptWithTestLib(SCRIPT, _undefined, t)
+	}
+	if l := cache.Len(); l != 2 {
+		t.Fatalf("Unexpected cache length: %d", l)
+	}
+
+	vm := New()
+	vm.SetRegexpCache(cache)
+	vm.testScriptWithTestLib(`
+	assert.throws(SyntaxError, function() {
+		new RegExp("(", "");
+	}, "invalid pattern");
+	assert.throws(SyntaxError, function() {
+		new RegExp("a", "gg");
+	}, "invalid flags");
+	var re = /x/;
+	re.compile("c+", "i");
+	assert.sameValue(re.exec("aCCb")[0], "CC", "compile");
+	`, _undefined, t)
+	if l := cache.Len(); l != 2 {
+		t.Fatalf("Unexpected cache length: %d", l)
+	}
+	if cache.get(regexpCacheKey{pattern: "c+", flags: "i"}) == nil {
+		t.Fatal("most recently used pattern is not cached")
+	}
+	if cache.get(regexpCacheKey{pattern: "a(b+)", flags: "g"}) != nil {
+		t.Fatal("least recently used pattern was not evicted")
+	}
+}
+
+func TestRegexpCacheConcurrent(t *testing.T) {
+	cache := NewRegexpCache(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vm := New()
+			vm.SetRegexpCache(cache)
+			v, err := vm.RunString(`
+			var res = 0;
+			for (var i = 0; i < 100; i++) {
+				var re = new RegExp("(\\d)+" + (i % 3), "g");
+				if (re.test("x123" + (i % 3)) && re.lastIndex === 5) {
+					res++;
+				}
+			}
+			res;
+			`)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if v.ToInteger() != 100 {
+				t.Errorf("Unexpected result: %v", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if l := cache.Len(); l != 3 {
+		t.Fatalf("Unexpected cache length: %d", l)
+	}
+}
+
+func TestRegexpCacheSize(t *testing.T) {
+	const SCRIPT = `
+	var res = [];
+	for (var i = 0; i < 2; i++) {
+		res.push(/(a)\1|b+/g);
+	}
+	res.push(new RegExp("(a)\\1|b+", "g"), /(a)\1|b+/gi, new RegExp("(a)\\1|b+", "ig"));
+
+	res[0].exec("xaab");
+	assert.sameValue(res[0].lastIndex, 3, "lastIndex #0");
+	assert.sameValue(res[1].lastIndex, 0, "lastIndex #1");
+	assert.sameValue(res[1].exec("bbaa")[0], "bb", "exec #1");
+	assert.sameValue(res[0].exec("xaab")[0], "b", "exec #0");
+	assert.sameValue(res[3].exec("AA")[0], "AA", "ignoreCase");
+	res;
+	`
+	patterns := func(vm *Runtime) []*regexpPattern {
+		v, err := vm.RunProgram(testLib())
+		if err == nil {
+			v, err = vm.RunString(SCRIPT)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		arr := v.(*Object).self.(*arrayObject)
+		var res []*regexpPattern
+		for _, item := range arr.values {
+			res = append(res, item.(*Object).self.(*regexpObject).pattern)
+		}
+		return res
+	}
+
+	p := patterns(New())
+	if p[0] != p[1] || p[1] != p[2] {
+		t.Fatal("the pattern is not shared between literals and constructor calls")
+	}
+	if p[3] != p[4] || p[3] == p[0] {
+		t.Fatal("the pattern is not shared between the same flags in a different order")
+	}
+
+	vm := New()
+	vm.SetRegexpCacheSize(1)
+	p = patterns(vm)
+	if p[0] != p[2] || p[3] != p[4] {
+		t.Fatal("the pattern is not shared with size 1")
+	}
+	if vm.localRegexpCache.Len() != 1 {
+		t.Fatalf("Unexpected cache length: %d", vm.localRegexpCache.Len())
+	}
+
+	vm = New()
+	vm.SetRegexpCacheSize(0)
+	p = patterns(vm)
+	if p[0] == p[1] || p[0] == p[2] {
+		t.Fatal("the pattern is shared with the cache disabled")
+	}
+}
@@ -2,16 +2,21 @@ package goja
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 	"go/ast"
 	"hash/maphash"
 	"math"
+	"math/big"
 	"math/bits"
 	"math/rand"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/text/collate"
@@ -53,6 +58,7 @@ type global struct {
 	Function *Object
 	String   *Object
 	Number   *Object
+	BigInt   *Object
 	Boolean  *Object
 	RegExp   *Object
 	Date     *Object
@@ -89,9 +95,13 @@ type global struct {
 
 	GoError *Object
 
+	IntlNumberFormat   *Object
+	IntlDateTimeFormat *Object
+
 	ObjectPrototype   *Object
 	ArrayPrototype    *Object
 	NumberPrototype   *Object
+	BigIntPrototype   *Object
 	StringPrototype   *Object
 	BooleanPrototype  *Object
 	FunctionPrototype *Object
@@ -108,6 +118,15 @@ type global struct {
 	SetPrototype         *Object
 	PromisePrototype     *Object
 
+	IntlNumberFormatPrototype   *Object
+	IntlDateTimeFormatPrototype *Object
+
+	GeneratorFunction          *Object
+	GeneratorFunctionPrototype *Object
+	AsyncFunction              *Object
+	AsyncFunctionPrototype     *Object
+	GeneratorPrototype         *Object
+
 	IteratorPrototype             *Object
 	ArrayIteratorPrototype        *Object
 	MapIteratorPrototype          *Object
@@ -164,6 +183,37 @@ type RandSource func() float64
 
 type Now func() time.Time
 
+// HostPanicPolicy defines what happens when Go code called from a script (such as a native function) panics
+// with a value that is neither a JavaScript value nor an *Exception. See Runtime.SetHostPanicPolicy().
+type HostPanicPolicy int
+
+const (
+	// HostPanicPropagate re-panics with the original value, i.e. the panic propagates up the Go stack as
+	// if there was no Runtime between the panicking function and the caller. This is the default.
+	HostPanicPropagate HostPanicPolicy = iota
+
+	// HostPanicThrow converts the panic into a GoError which can be caught by the script. The error message
+	// is the string form of the recovered value and the value itself is available as the 'value' property.
+	HostPanicThrow
+
+	// HostPanicAbort converts the panic into an uncatchable exception which aborts the script. The corresponding
+	// Go call returns a *HostPanicError containing the recovered value.
+	HostPanicAbort
+)
+
+// RunStats contains diagnostic information collected during a run. See Runtime.LastRunStats().
+type RunStats struct {
+	// DictionaryObjects is the number of objects that had more properties added to them by assignment
+	// than the threshold set by Runtime.SetDictionaryThreshold(). Properties defined by object literals,
+	// Object.defineProperty() and similar are not counted. Such objects are effectively used as dictionaries
+	// rather than records with a stable set of properties. Always 0 if the threshold is not set.
+	DictionaryObjects int
+
+	// Closures is the number of function objects created by function declarations and expressions,
+	// arrow functions and methods. See also Runtime.SetMaxClosures().
+	Closures int
+}
+
 type Runtime struct {
 	global          global
 	globalObject    *Object
@@ -185,14 +235,47 @@ type Runtime struct {
 	jobQueue []func()
 
 	promiseRejectionTracker PromiseRejectionTracker
+
+	hostPanicPolicy    HostPanicPolicy
+	strictNativePanics bool
+	unwrapGoErrors     bool
+	safeNativeArgs     bool
+	globalResolver     func(name string) (Value, bool)
+	exceptionFormatter func(Value) string
+
+	dictThreshold int
+	maxClosures   int
+	dynPropCounts map[*Object]int
+	runStats      RunStats
+
+	hostJSONMarshaler func(Value) (Value, bool)
+
+	regexpCache *RegexpCache
+
+	// the patterns compiled by this Runtime, see SetRegexpCacheSize()
+	localRegexpCache     *RegexpCache
+	localRegexpCacheSize int
+
+	onceValues map[string]*onceValue
+
+	// the template objects of the tagged templates evaluated by this Runtime, see getTaggedTmplObject
+	taggedTemplates map[*getTaggedTmplObject]*Object
+}
+
+type onceValue struct {
+	value   Value
+	err     error
+	running bool
 }
 
 type StackFrame struct {
 	prg      *Program
 	funcName unistring.String
 	pc       int
+	async    bool
 }
 
+// SrcName returns the name of the source the frame's code comes from, or "<native>" for native functions.
 func (f *StackFrame) SrcName() string {
 	if f.prg == nil {
 		return "<native>"
@@ -200,6 +283,8 @@ func (f *StackFrame) SrcName() string {
 	return f.prg.src.Name()
 }
 
+// FuncName returns the name of the function executed in the frame, "<anonymous>" for unnamed functions
+// and the top-level code, and "<native>" for unnamed native functions.
 func (f *StackFrame) FuncName() string {
 	if f.funcName == "" && f.prg == nil {
 		return "<native>"
@@ -210,6 +295,14 @@ func (f *StackFrame) FuncName() string {
 	return f.funcName.String()
 }
 
+// Async returns true if the frame belongs to an async function suspended in an await expression which waits
+// for the completion of the frames above it, i.e. the frame is not on the actual call stack.
+func (f *StackFrame) Async() bool {
+	return f.async
+}
+
+// Position returns the location of the current instruction in the frame, taking into account the source map
+// of the Program if there is one. The result is zero for native functions.
 func (f *StackFrame) Position() file.Position {
 	if f.prg == nil || f.prg.src == nil {
 		return file.Position{}
@@ -218,6 +311,9 @@ func (f *StackFrame) Position() file.Position {
 }
 
 func (f *StackFrame) WriteToValueBuilder(b *valueStringBuilder) {
+	if f.async {
+		b.WriteASCII("async ")
+	}
 	if f.prg != nil {
 		if n := f.prg.funcName; n != "" {
 			b.WriteString(stringValueFromRaw(n))
@@ -252,6 +348,9 @@ func (f *StackFrame) WriteToValueBuilder(b *valueStringBuilder) {
 }
 
 func (f *StackFrame) Write(b *bytes.Buffer) {
+	if f.async {
+		b.WriteString("async ")
+	}
 	if f.prg != nil {
 		if n := f.prg.funcName; n != "" {
 			b.WriteString(n.String())
@@ -288,10 +387,14 @@ func (f *StackFrame) Write(b *bytes.Buffer) {
 type Exception struct {
 	val   Value
 	stack []StackFrame
+
+	format func(Value) string
 }
 
 type uncatchableException struct {
 	err error
+	// if set, the 'finally' blocks are run while the exception propagates (see Runtime.InterruptWithFinally())
+	unwind bool
 }
 
 func (ue *uncatchableException) Unwrap() error {
@@ -300,7 +403,8 @@ func (ue *uncatchableException) Unwrap() error {
 
 type InterruptedError struct {
 	Exception
-	iface interface{}
+	iface  interface{}
+	locals map[string]Value
 }
 
 func (e *InterruptedError) Unwrap() error {
@@ -314,10 +418,86 @@ type StackOverflowError struct {
 	Exception
 }
 
+// MemoryLimitError is returned when the estimated size of the reachable part of the heap exceeds the limit
+// set with Runtime.SetMemoryLimit(). It cannot be caught by the script.
+type MemoryLimitError struct {
+	Exception
+	limit uint64
+}
+
+// Limit returns the limit that has been exceeded, in bytes.
+func (e *MemoryLimitError) Limit() uint64 {
+	return e.limit
+}
+
+// InstructionLimitError is returned when the script has executed the number of instructions set with
+// Runtime.SetInstructionLimit(). It cannot be caught by the script.
+type InstructionLimitError struct {
+	Exception
+	limit uint64
+}
+
+// Limit returns the limit that has been reached.
+func (e *InstructionLimitError) Limit() uint64 {
+	return e.limit
+}
+
+// HostPanicError is returned when Go code called from a script panics and the HostPanicAbort policy
+// is in effect (see Runtime.SetHostPanicPolicy()).
+type HostPanicError struct {
+	Exception
+	iface interface{}
+}
+
+// Value returns the recovered value.
+func (e *HostPanicError) Value() interface{} {
+	return e.iface
+}
+
+func (e *HostPanicError) Unwrap() error {
+	if err, ok := e.iface.(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (e *HostPanicError) String() string {
+	if e == nil {
+		return "<nil>"
+	}
+	var b bytes.Buffer
+	b.WriteString("panic: ")
+	b.WriteString(fmt.Sprint(e.iface))
+	b.WriteByte('\n')
+	e.writeFullStack(&b)
+	return b.String()
+}
+
+func (e *HostPanicError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	var b bytes.Buffer
+	b.WriteString("panic: ")
+	b.WriteString(fmt.Sprint(e.iface))
+	e.writeShortStack(&b)
+	return b.String()
+}
+
 func (e *InterruptedError) Value() interface{} {
 	return e.iface
 }
 
+// Locals returns a read-only snapshot of the local bindings that were visible by name where the script has been
+// interrupted, in the same way as DebugContext.Locals(). Note, only the bindings that are kept in scope objects
+// are included, i.e. the ones captured by closures, the ones in functions that use eval() or 'arguments' in
+// non-strict mode, and all of them in code compiled while a DebugHandler is set (see Runtime.SetDebugHandler()).
+// The other local variables are optimised to live on the vm stack and are not available by name.
+// The returned map must not be modified.
+func (e *InterruptedError) Locals() map[string]Value {
+	return e.locals
+}
+
 func (e *InterruptedError) String() string {
 	if e == nil {
 		return "<nil>"
@@ -356,13 +536,27 @@ func (e *Exception) writeShortStack(b *bytes.Buffer) {
 	}
 }
 
+// valString returns the string representation of the thrown value, using the formatter set with
+// Runtime.SetExceptionFormatter() unless it's an Error object.
+func (e *Exception) valString() string {
+	if e.format != nil {
+		if o, ok := e.val.(*Object); ok {
+			if _, ok := o.self.(*errorObject); ok {
+				return e.val.String()
+			}
+		}
+		return e.format(e.val)
+	}
+	return e.val.String()
+}
+
 func (e *Exception) String() string {
 	if e == nil {
 		return "<nil>"
 	}
 	var b bytes.Buffer
 	if e.val != nil {
-		b.WriteString(e.val.String())
+		b.WriteString(e.valString())
 		b.WriteByte('\n')
 	}
 	e.writeFullStack(&b)
@@ -374,7 +568,7 @@ func (e *Exception) Error() string {
 		return "<nil>"
 	}
 	var b bytes.Buffer
-	b.WriteString(e.val.String())
+	b.WriteString(e.valString())
 	e.writeShortStack(&b)
 	return b.String()
 }
@@ -383,6 +577,54 @@ func (e *Exception) Value() Value {
 	return e.val
 }
 
+// Unwrap returns the Go error the exception was created from, or nil if there is none. This is the case when
+// the thrown value is a GoError, i.e. a Go function called from the script has returned or panicked with an error
+// (see NewGoError() and HostPanicThrow), or when the thrown value is a Go error itself (i.e. ToValue(err)).
+// This allows using errors.Is() and errors.As() to check for a particular Go error that has propagated
+// through the script.
+func (e *Exception) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	obj, ok := e.val.(*Object)
+	if !ok {
+		return nil
+	}
+	if err := wrappedGoError(obj); err != nil {
+		return err
+	}
+	if _, ok := obj.self.(*errorObject); ok {
+		v := obj.self.getOwnPropStr("value")
+		if prop, ok := v.(*valueProperty); ok && !prop.accessor {
+			v = prop.value
+		}
+		if v, ok := v.(*Object); ok {
+			return wrappedGoError(v)
+		}
+	}
+	return nil
+}
+
+func wrappedGoError(obj *Object) error {
+	if o, ok := obj.self.(*objectGoReflect); ok {
+		if err, ok := o.origValue.Interface().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frames returns the call stack captured when the exception was thrown, the innermost frame first.
+// The returned slice is a copy and can be modified by the caller.
+func (e *Exception) Frames() []StackFrame {
+	if e == nil || len(e.stack) == 0 {
+		return nil
+	}
+	frames := make([]StackFrame, len(e.stack))
+	copy(frames, e.stack)
+	return frames
+}
+
 func (r *Runtime) addToGlobal(name string, value Value) {
 	r.globalObject.self._putProp(unistring.String(name), value, true, false, true)
 }
@@ -397,6 +639,7 @@ func (r *Runtime) createIterProto(val *Object) objectImpl {
 func (r *Runtime) init() {
 	r.rand = rand.Float64
 	r.now = time.Now
+	r.localRegexpCacheSize = defaultRegexpCacheSize
 	r.global.ObjectPrototype = r.newBaseObject(nil, classObject).val
 	r.globalObject = r.NewObject()
 
@@ -419,6 +662,7 @@ func (r *Runtime) init() {
 	r.initString()
 	r.initGlobalObject()
 	r.initNumber()
+	r.initBigInt()
 	r.initRegExp()
 	r.initDate()
 	r.initBoolean()
@@ -440,6 +684,9 @@ func (r *Runtime) init() {
 	r.initMap()
 	r.initSet()
 	r.initPromise()
+	r.initGenerators()
+	r.initAsyncFunctions()
+	r.initIntl()
 
 	r.global.thrower = r.newNativeFunc(r.builtin_thrower, nil, "", nil, 0)
 	r.global.throwerProperty = &valueProperty{
@@ -532,6 +779,46 @@ func (r *Runtime) CreateObject(proto *Object) *Object {
 	return r.newBaseObject(proto, classObject).val
 }
 
+// SetPrototype sets the prototype of obj to proto, same as Object.setPrototypeOf(obj, proto) but returns an error
+// instead of throwing. proto must be an Object or null (nil is treated as null). If obj is a primitive value other
+// than undefined or null, it's left unchanged. The operation fails with a TypeError if obj is not extensible, if
+// proto is obj or inherits from it (i.e. it would create a cycle in the prototype chain), or if obj is a Proxy and
+// its setPrototypeOf trap returns false.
+func (r *Runtime) SetPrototype(obj, proto Value) error {
+	if obj == nil {
+		obj = _undefined
+	}
+	if proto == nil {
+		proto = _null
+	}
+	return r.try(func() {
+		r.checkObjectCoercible(obj)
+		p := r.toProto(proto)
+		if o, ok := obj.(*Object); ok {
+			o.self.setProto(p, true)
+		}
+	})
+}
+
+// NewConstantsObject creates a frozen object (as if by Object.freeze()) holding the supplied constants. Each value
+// is converted using ToValue() and becomes an enumerable, non-writable and non-configurable property. The properties
+// are created in the lexicographical order of the keys.
+// This is a convenient way to expose a group of Go constants (such as status codes or flags) to scripts: they can be
+// read, but any attempt to modify them fails (and throws a TypeError in strict mode).
+func (r *Runtime) NewConstantsObject(constants map[string]interface{}) *Object {
+	o := r.newBaseObject(r.global.ObjectPrototype, classObject)
+	keys := make([]string, 0, len(constants))
+	for k := range constants {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o._putProp(unistring.NewFromString(k), r.ToValue(constants[k]), false, true, false)
+	}
+	o.extensible = false
+	return o.val
+}
+
 func (r *Runtime) NewArray(items ...interface{}) *Object {
 	values := make([]Value, len(items))
 	for i, item := range items {
@@ -540,13 +827,186 @@ func (r *Runtime) NewArray(items ...interface{}) *Object {
 	return r.newArrayValues(values)
 }
 
-func (r *Runtime) NewTypeError(args ...interface{}) *Object {
+// NewIterable creates an iterator object driven by the supplied Go function, so that a sequence produced by Go code
+// can be consumed by scripts using for...of, the spread syntax, destructuring, Array.from(), etc.
+// The object inherits from %IteratorPrototype%, so its [Symbol.iterator]() returns the object itself and it can
+// only be iterated once (like a generator object).
+//
+// next is called for each step and should return the next value and true, or false when the sequence is exhausted.
+// Once next returns false (or panics) it is not called again, nor is it after the iteration has been terminated
+// early (e.g. by a break statement in a for...of loop). See NewIterableWithCleanup() if the sequence holds resources
+// that need to be released in that case.
+func (r *Runtime) NewIterable(next func() (Value, bool)) *Object {
+	return r.NewIterableWithCleanup(next, nil)
+}
+
+// NewIterableWithCleanup is like NewIterable(), but cleanup, if not nil, is called by return() when the iteration
+// is terminated early (e.g. by a break statement in a for...of loop or because the loop body has thrown) and can
+// be used to release the resources held by the sequence. It is not called after the sequence is exhausted.
+func (r *Runtime) NewIterableWithCleanup(next func() (Value, bool), cleanup func()) *Object {
+	done := false
+	o := r.newBaseObject(r.global.IteratorPrototype, classObject)
+	o._putProp("next", r.newNativeFunc(func(FunctionCall) Value {
+		if !done {
+			// if next panics, the iterator stays done
+			done = true
+			if v, ok := next(); ok {
+				done = false
+				return r.createIterResultObject(nilSafe(v), false)
+			}
+		}
+		return r.createIterResultObject(_undefined, true)
+	}, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(func(call FunctionCall) Value {
+		if !done {
+			done = true
+			if cleanup != nil {
+				cleanup()
+			}
+		}
+		return r.createIterResultObject(call.Argument(0), true)
+	}, nil, "return", nil, 1), true, false, true)
+	return o.val
+}
+
+// Iterate iterates over v using the iteration protocol, i.e. in the same way as a for...of loop does, and calls
+// step for each value. The iteration stops when the iterator is exhausted or when step returns false. In the latter
+// case, as well as when step panics, the iterator is closed by calling its return() method (if any), so that the
+// resources held by the iterator (e.g. a suspended generator) are released. This is meant for native functions
+// that accept an iterable argument.
+// This method will panic with an *Exception if a JavaScript exception is thrown in the process (e.g. if v is not
+// iterable), which is then thrown in the calling script if Iterate is called from a native function.
+func (r *Runtime) Iterate(v Value, step func(Value) bool) {
+	r.tryPanic(func() {
+		iter := r.getIterator(v, nil)
+		for {
+			value, ex := iter.step()
+			if ex != nil {
+				panic(ex)
+			}
+			if value == nil {
+				return
+			}
+			cont := true
+			if ret := tryFunc(func() {
+				cont = step(value)
+			}); ret != nil {
+				_ = tryFunc(iter.returnIter)
+				panic(ret)
+			}
+			if !cont {
+				iter.returnIter()
+				return
+			}
+		}
+	})
+}
+
+// Iterator is a JavaScript iterator driven manually from Go code, see Runtime.WithIterator().
+type Iterator struct {
+	r    *Runtime
+	iter *iteratorRecord
+}
+
+// WithIterator obtains an iterator for v using the iteration protocol (i.e. in the same way as a for...of loop does)
+// and calls f with it. Unlike Iterate(), the iteration is driven by f which may call Next() as many times as it needs,
+// for example to consume a fixed number of values or to iterate several iterables simultaneously.
+// Once f returns or panics, the iterator is closed by calling its return() method (if any) unless it has been
+// exhausted or closed already, so that the resources held by the iterator (e.g. a suspended generator) are released
+// on early exit, just as it happens when a for...of loop body returns, breaks or throws. If f panics, an exception
+// thrown by return() is ignored and the original panic is propagated.
+// This method will panic with an *Exception if a JavaScript exception is thrown in the process (e.g. if v is not
+// iterable), which is then thrown in the calling script if WithIterator is called from a native function.
+func (r *Runtime) WithIterator(v Value, f func(iter *Iterator)) {
+	r.tryPanic(func() {
+		it := &Iterator{
+			r:    r,
+			iter: r.getIterator(v, nil),
+		}
+		if ret := tryFunc(func() {
+			f(it)
+		}); ret != nil {
+			_ = tryFunc(it.iter.returnIter)
+			panic(ret)
+		}
+		it.iter.returnIter()
+	})
+}
+
+// Next returns the next value produced by the iterator. The second return value is false if the iterator is
+// exhausted or closed. Panics with an *Exception if next() throws, in which case the iterator is considered done
+// and it is not closed.
+func (it *Iterator) Next() (Value, bool) {
+	if it.iter.iterator == nil {
+		return nil, false
+	}
+	value, ex := it.iter.step()
+	if ex != nil {
+		it.iter.close()
+		panic(ex)
+	}
+	return value, value != nil
+}
+
+// Close closes the iterator by calling its return() method (if any), unless it has been exhausted or closed
+// already. After that Next() always returns false. Panics with an *Exception if return() throws.
+func (it *Iterator) Close() {
+	it.r.tryPanic(it.iter.returnIter)
+}
+
+func (r *Runtime) newFormattedError(ctor *Object, args []interface{}) *Object {
 	msg := ""
 	if len(args) > 0 {
 		f, _ := args[0].(string)
 		msg = fmt.Sprintf(f, args[1:]...)
 	}
-	return r.builtin_new(r.global.TypeError, []Value{newStringValue(msg)})
+	return r.builtin_new(ctor, []Value{newStringValue(msg)})
+}
+
+// NewError creates a new Error object. The message is formatted from args using fmt.Sprintf() with the first
+// argument being the format string. The result can be thrown using Throw().
+func (r *Runtime) NewError(args ...interface{}) *Object {
+	return r.newFormattedError(r.global.Error, args)
+}
+
+// NewTypeError creates a new TypeError object, see NewError() for the meaning of args.
+func (r *Runtime) NewTypeError(args ...interface{}) *Object {
+	return r.newFormattedError(r.global.TypeError, args)
+}
+
+// NewRangeError creates a new RangeError object, see NewError() for the meaning of args.
+func (r *Runtime) NewRangeError(args ...interface{}) *Object {
+	return r.newFormattedError(r.global.RangeError, args)
+}
+
+// NewReferenceError creates a new ReferenceError object, see NewError() for the meaning of args.
+func (r *Runtime) NewReferenceError(args ...interface{}) *Object {
+	return r.newFormattedError(r.global.ReferenceError, args)
+}
+
+// NewSyntaxError creates a new SyntaxError object, see NewError() for the meaning of args.
+func (r *Runtime) NewSyntaxError(args ...interface{}) *Object {
+	return r.newFormattedError(r.global.SyntaxError, args)
+}
+
+// Throw raises v as a JavaScript exception. It may only be called from Go code invoked by the Runtime (such as
+// a function exposed with Set() or a Proxy trap) and it never returns. The exception can be caught by a try/catch
+// statement in the script, otherwise it's returned as an *Exception by RunString(), RunProgram() or the Callable
+// that started the execution. Example:
+//
+//	vm.Set("f", func(call goja.FunctionCall) goja.Value {
+//		if len(call.Arguments) == 0 {
+//			vm.Throw(vm.NewTypeError("f() requires an argument"))
+//		}
+//		...
+//	})
+//
+// Panicking with a Value has the same effect.
+func (r *Runtime) Throw(v Value) {
+	if v == nil {
+		v = _undefined
+	}
+	panic(v)
 }
 
 func (r *Runtime) NewGoError(err error) *Object {
@@ -555,6 +1015,18 @@ func (r *Runtime) NewGoError(err error) *Object {
 	return e
 }
 
+func (r *Runtime) newHostPanicError(x interface{}) *Object {
+	var msg string
+	if err, ok := x.(error); ok {
+		msg = err.Error()
+	} else {
+		msg = fmt.Sprint(x)
+	}
+	e := r.newError(r.global.GoError, "%s", msg).(*Object)
+	e.self._putProp("value", r.ToValue(x), true, false, true)
+	return e
+}
+
 func (r *Runtime) newFunc(name unistring.String, length int, strict bool) (f *funcObject) {
 	v := &Object{runtime: r}
 
@@ -598,6 +1070,50 @@ func (r *Runtime) newMethod(name unistring.String, length int, strict bool) (f *
 	return
 }
 
+func (r *Runtime) newGeneratorFunc(name unistring.String, length int, strict bool) (f *generatorFuncObject) {
+	v := &Object{runtime: r}
+
+	f = &generatorFuncObject{}
+	f.class = classFunction
+	f.val = v
+	f.extensible = true
+	f.strict = strict
+	v.self = f
+	f.prototype = r.global.GeneratorFunctionPrototype
+	f.init(name, intToValue(int64(length)))
+	f._putProp("prototype", r.newBaseObject(r.global.GeneratorPrototype, classObject).val, true, false, false)
+	return
+}
+
+func (r *Runtime) newAsyncFunc(name unistring.String, length int, strict bool) (f *asyncFuncObject) {
+	v := &Object{runtime: r}
+
+	f = &asyncFuncObject{}
+	f.class = classFunction
+	f.val = v
+	f.extensible = true
+	f.strict = strict
+	v.self = f
+	f.prototype = r.global.AsyncFunctionPrototype
+	f.init(name, intToValue(int64(length)))
+	return
+}
+
+func (r *Runtime) newAsyncArrowFunc(name unistring.String, length int, strict bool) (f *asyncArrowFuncObject) {
+	v := &Object{runtime: r}
+
+	f = &asyncArrowFuncObject{}
+	f.class = classFunction
+	f.val = v
+	f.extensible = true
+	f.strict = strict
+	f.newTarget = r.vm.newTarget
+	v.self = f
+	f.prototype = r.global.AsyncFunctionPrototype
+	f.init(name, intToValue(int64(length)))
+	return
+}
+
 func (r *Runtime) newArrowFunc(name unistring.String, length int, strict bool) (f *arrowFuncObject) {
 	v := &Object{runtime: r}
 
@@ -802,7 +1318,7 @@ func (r *Runtime) newPrimitiveObject(value Value, proto *Object, class string) *
 
 func (r *Runtime) builtin_Number(call FunctionCall) Value {
 	if len(call.Arguments) > 0 {
-		return call.Arguments[0].ToNumber()
+		return numericToNumber(toNumeric(call.Arguments[0]))
 	} else {
 		return valueInt(0)
 	}
@@ -811,7 +1327,7 @@ func (r *Runtime) builtin_Number(call FunctionCall) Value {
 func (r *Runtime) builtin_newNumber(args []Value, proto *Object) *Object {
 	var v Value
 	if len(args) > 0 {
-		v = args[0].ToNumber()
+		v = numericToNumber(toNumeric(args[0]))
 	} else {
 		v = intToValue(0)
 	}
@@ -1078,6 +1594,9 @@ func toUint16(v Value) uint16 {
 }
 
 func toInt32(v Value) int32 {
+	if i, ok := v.(valueInt); ok {
+		return int32(i)
+	}
 	v = v.ToNumber()
 	if i, ok := v.(valueInt); ok {
 		return int32(i)
@@ -1093,6 +1612,9 @@ func toInt32(v Value) int32 {
 }
 
 func toUint32(v Value) uint32 {
+	if i, ok := v.(valueInt); ok {
+		return uint32(i)
+	}
 	v = v.ToNumber()
 	if i, ok := v.(valueInt); ok {
 		return uint32(i)
@@ -1108,6 +1630,9 @@ func toUint32(v Value) uint32 {
 }
 
 func toInt64(v Value) int64 {
+	if i, ok := v.(valueInt); ok {
+		return int64(i)
+	}
 	v = v.ToNumber()
 	if i, ok := v.(valueInt); ok {
 		return int64(i)
@@ -1123,6 +1648,9 @@ func toInt64(v Value) int64 {
 }
 
 func toUint64(v Value) uint64 {
+	if i, ok := v.(valueInt); ok {
+		return uint64(i)
+	}
 	v = v.ToNumber()
 	if i, ok := v.(valueInt); ok {
 		return uint64(i)
@@ -1276,14 +1804,46 @@ func New() *Runtime {
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
 func Compile(name, src string, strict bool) (*Program, error) {
-	return compile(name, src, strict, true, nil)
+	return compile(name, src, strict, true, false, nil)
 }
 
 // CompileAST creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
 func CompileAST(prg *js_ast.Program, strict bool) (*Program, error) {
-	return compileAST(prg, strict, true, nil)
+	return compileAST(prg, strict, true, false, nil)
+}
+
+// CompileWithSourceMap is like Compile but uses the supplied source map (e.g. the one a bundler has produced along
+// with src) instead of the one referenced by the sourceMappingURL comment. The positions reported by
+// StackFrame.Position() and in the exception stack traces then refer to the original source files.
+// It is a shortcut for Parse() with parser.WithSourceMap() followed by CompileAST().
+func CompileWithSourceMap(name, src string, sourceMap []byte, strict bool) (*Program, error) {
+	return compile(name, src, strict, true, false, nil, parser.WithSourceMap(sourceMap))
+}
+
+// CompileOptions control how CompileWithOptions compiles the code.
+type CompileOptions struct {
+	// Strict forces the strict mode regardless of the presence of the 'use strict' directive.
+	Strict bool
+
+	// SandboxGlobals makes the top-level lexical declarations (let, const and class) of the script go into
+	// a fresh scope created for each run of the Program rather than into the shared global lexical scope
+	// of the Runtime. This way the declarations do not leak between runs and the same script can be run
+	// repeatedly without 'Identifier has already been declared' errors. They are still visible to the
+	// functions created by the script. Note that var and function declarations still create properties
+	// of the global object.
+	SandboxGlobals bool
+}
+
+// CompileWithOptions is like Compile but accepts CompileOptions.
+func CompileWithOptions(name, src string, opts CompileOptions) (*Program, error) {
+	p, err := compile(name, src, opts.Strict, true, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.sandboxGlobals = opts.SandboxGlobals
+	return p, nil
 }
 
 // MustCompile is like Compile but panics if the code cannot be compiled.
@@ -1319,17 +1879,18 @@ func Parse(name, src string, options ...parser.Option) (prg *js_ast.Program, err
 	return
 }
 
-func compile(name, src string, strict, inGlobal bool, evalVm *vm, parserOptions ...parser.Option) (p *Program, err error) {
+func compile(name, src string, strict, inGlobal, debug bool, evalVm *vm, parserOptions ...parser.Option) (p *Program, err error) {
 	prg, err := Parse(name, src, parserOptions...)
 	if err != nil {
 		return
 	}
 
-	return compileAST(prg, strict, inGlobal, evalVm)
+	return compileAST(prg, strict, inGlobal, debug, evalVm)
 }
 
-func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm) (p *Program, err error) {
+func compileAST(prg *js_ast.Program, strict, inGlobal, debug bool, evalVm *vm) (p *Program, err error) {
 	c := newCompiler()
+	c.debug = debug
 
 	defer func() {
 		if x := recover(); x != nil {
@@ -1349,7 +1910,7 @@ func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm) (p *Prog
 }
 
 func (r *Runtime) compile(name, src string, strict, inGlobal bool, evalVm *vm) (p *Program, err error) {
-	p, err = compile(name, src, strict, inGlobal, evalVm, r.parserOptions...)
+	p, err = compile(name, src, strict, inGlobal, r.vm.debugHandler != nil, evalVm, r.parserOptions...)
 	if err != nil {
 		switch x1 := err.(type) {
 		case *CompilerSyntaxError:
@@ -1383,11 +1944,18 @@ func (r *Runtime) RunScript(name, src string) (Value, error) {
 
 // RunProgram executes a pre-compiled (see Compile()) code in the global context.
 func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
+	vm := r.vm
+	recursive := len(vm.callStack) > 0
+	sp := vm.sp
 	defer func() {
 		if x := recover(); x != nil {
 			if ex, ok := x.(*uncatchableException); ok {
 				err = ex.err
-				if len(r.vm.callStack) == 0 {
+				if !recursive {
+					vm.prg = nil
+					vm.funcName = ""
+					vm.stash = &r.global.stash
+					vm.sp = sp
 					r.leaveAbrupt()
 				}
 			} else {
@@ -1395,14 +1963,19 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 			}
 		}
 	}()
-	vm := r.vm
-	recursive := false
-	if len(vm.callStack) > 0 {
-		recursive = true
+	if recursive {
 		vm.pushCtx()
 		vm.stash = &r.global.stash
 		vm.sb = vm.sp - 1
 	}
+	if !recursive {
+		r.resetRunStats()
+	}
+	if p.sandboxGlobals {
+		vm.stash = &stash{
+			outer: &r.global.stash,
+		}
+	}
 	vm.prg = p
 	vm.pc = 0
 	vm.result = _undefined
@@ -1411,6 +1984,86 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 		result = r.vm.result
 	} else {
 		err = ex
+		if r.unwrapGoErrors {
+			if goErr := r.goErrorOf(ex); goErr != nil {
+				err = goErr
+			}
+		}
+	}
+	if recursive {
+		vm.popCtx()
+		vm.halt = false
+		vm.clearStack()
+	} else {
+		vm.stack = nil
+		vm.prg = nil
+		vm.funcName = ""
+		vm.stash = &r.global.stash
+		r.dynPropCounts = nil
+		r.leave()
+	}
+	return
+}
+
+// EvalWith evaluates src as if it was passed to a direct eval() called from a non-strict function
+// with thisVal as 'this' (nil means undefined) and a local binding for each entry of scope. The bindings
+// shadow global ones and, together with any variables and functions declared by src, are discarded after
+// the evaluation, so that globals are not affected unless assigned explicitly.
+// Returns the completion value of src. This method can be used to evaluate user-provided expressions
+// against a data context.
+func (r *Runtime) EvalWith(src string, thisVal Value, scope map[string]Value) (result Value, err error) {
+	s := &stash{
+		funcType: funcRegular,
+		outer:    &r.global.stash,
+	}
+	for name, v := range scope {
+		n := unistring.NewFromString(name)
+		s.createBinding(n, false)
+		s.values[s.names[n]&^maskTyp] = nilSafe(v)
+	}
+	s.createBinding(thisBindingName, false)
+	s.values[s.names[thisBindingName]&^maskTyp] = nilSafe(thisVal)
+
+	vm := r.vm
+	recursive := len(vm.callStack) > 0
+	sp := vm.sp
+	defer func() {
+		if x := recover(); x != nil {
+			if ex, ok := x.(*uncatchableException); ok {
+				err = ex.err
+				if !recursive {
+					vm.stash = &r.global.stash
+					vm.sp = sp
+					r.leaveAbrupt()
+				}
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	if recursive {
+		vm.pushCtx()
+	} else {
+		r.resetRunStats()
+	}
+	vm.stash = s
+	vm.privEnv = nil
+	p, err := r.compile("<eval>", src, false, false, vm)
+	if err == nil {
+		vm.prg = p
+		vm.pc = 0
+		vm.args = 0
+		vm.result = _undefined
+		vm.push(_undefined)
+		vm.sb = vm.sp
+		vm.push(nil) // this
+		ex := vm.runTry()
+		if ex == nil {
+			result = vm.result
+		} else {
+			err = ex
+		}
+		vm.sp -= 2
 	}
 	if recursive {
 		vm.popCtx()
@@ -1420,6 +2073,8 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 		vm.stack = nil
 		vm.prg = nil
 		vm.funcName = ""
+		vm.stash = &r.global.stash
+		r.dynPropCounts = nil
 		r.leave()
 	}
 	return
@@ -1445,17 +2100,81 @@ func (r *Runtime) CaptureCallStack(depth int, stack []StackFrame) []StackFrame {
 	return r.vm.captureStack(stack, offset)
 }
 
-// Interrupt a running JavaScript. The corresponding Go call will return an *InterruptedError containing v.
+// CurrentFrames returns the call stack of the running script, the most recent frame first, or nil if the Runtime
+// is not running. Unlike CaptureCallStack() it is safe to call from any goroutine, but not from the one that
+// runs the script (e.g. from a Go function called by the script) as that would deadlock.
+//
+// The state of the vm is only read by the goroutine running it, so the frames are captured in the same way as
+// by a function scheduled with InterruptFunc(): before the next instruction, while the execution is not interrupted
+// otherwise. The call blocks until then, i.e. if a Go function is being executed, until it returns to
+// JavaScript code (or waits in the read() method of an object created by NewReader() in a way that can be
+// stopped, see NewReader()). If the run completes or is interrupted in the meantime, nil is returned.
+func (r *Runtime) CurrentFrames() []StackFrame {
+	vm := r.vm
+	vm.interruptLock.Lock()
+	if atomic.LoadUint32(&vm.running) == 0 {
+		vm.interruptLock.Unlock()
+		return nil
+	}
+	req := &framesRequest{
+		res: make(chan []StackFrame, 1),
+	}
+	vm.interruptQueue = append(vm.interruptQueue, req)
+	atomic.StoreUint32(&vm.interrupted, 1)
+	vm.wakeWaiter()
+	vm.interruptLock.Unlock()
+	if atomic.LoadUint32(&vm.running) == 0 {
+		// The run may have completed before the request was queued without seeing it (see vm.leaveRun()).
+		vm.interruptLock.Lock()
+		if atomic.LoadUint32(&vm.running) == 0 {
+			vm.cancelFramesRequests()
+		}
+		vm.interruptLock.Unlock()
+	}
+	return <-req.res
+}
+
+// Interrupt a running JavaScript. The corresponding Go call will return an *InterruptedError containing v
+// along with the stack trace and the local variables at the point of interruption (see InterruptedError.Locals()).
 // If the interrupt propagates until the stack is empty the currently queued promise resolve/reject jobs will be cleared
 // without being executed. This is the same time they would be executed otherwise.
 // Note, it only works while in JavaScript code, it does not interrupt native Go functions (which includes all built-ins).
 // If the runtime is currently not running, it will be immediately interrupted on the next Run*() call.
 // To avoid that use ClearInterrupt()
+//
+// The interrupts are queued along with the functions scheduled by InterruptFunc() so that none of them is lost
+// if several are requested before the vm gets to handle them: the functions are called in order until the first
+// interrupt is reached, which stops the execution. Anything queued after it is discarded.
+//
+// The interruption unwinds the stack without running any 'catch' or 'finally' blocks. The same applies to
+// the cancellation of the context passed to RunContext() and to the errors caused by the limits (see
+// SetMemoryLimit(), SetInstructionLimit() and SetMaxCallStackSize()). Use InterruptWithFinally() if the
+// pending 'finally' blocks need to run.
 func (r *Runtime) Interrupt(v interface{}) {
 	r.vm.Interrupt(v)
 }
 
-// ClearInterrupt resets the interrupt flag. Typically this needs to be called before the runtime
+// InterruptWithFinally is like Interrupt(), but the pending 'finally' blocks are run while the stack is unwound,
+// so that the script can release the resources it holds. The 'catch' blocks are skipped and the interruption
+// cannot be cancelled: if a 'finally' block completes abruptly (e.g. by a 'return' or by throwing an exception
+// that is caught by an outer 'try') the unwinding resumes before the next instruction outside it.
+// The code in the 'finally' blocks is not limited in any way, so if it does not complete, the execution can be
+// stopped with Interrupt(), which has precedence.
+func (r *Runtime) InterruptWithFinally(v interface{}) {
+	r.vm.Interrupt(&unwindInterrupt{v: v})
+}
+
+// InterruptFunc schedules f to be called from the vm goroutine before the next instruction of a running
+// JavaScript, after which the execution continues, e.g. to pause the script or to inspect its state with
+// CaptureCallStack(). The function must not run any code in this Runtime. Once called, it is removed, so it can be
+// scheduled again without ClearInterrupt(). The functions are queued together with the interrupts, see Interrupt().
+// Like Interrupt(), it only works while in JavaScript code and if the runtime is currently not running, f is called
+// on the next Run*() call.
+func (r *Runtime) InterruptFunc(f func()) {
+	r.vm.Interrupt(interruptFunc(f))
+}
+
+// ClearInterrupt resets the interrupt flag and discards the queued interrupts. Typically this needs to be called before the runtime
 // is made available for re-use if there is a chance it could have been interrupted with Interrupt().
 // Otherwise if Interrupt() was called when runtime was not running (e.g. if it had already finished)
 // so that Interrupt() didn't actually trigger, an attempt to use the runtime will immediately cause
@@ -1684,6 +2403,11 @@ If you need to convert it to a `Date`, it can be done either in JS:
 
 Note that Value.Export() for a `Date` value returns time.Time in local timezone.
 
+# Handling of *big.Int
+
+A *big.Int is converted into a BigInt primitive. The value is copied, so modifying the original *big.Int afterwards
+does not affect it.
+
 # Maps
 
 Maps with string or integer key type are converted into host objects that largely behave like a JavaScript Object.
@@ -1731,6 +2455,11 @@ func (r *Runtime) ToValue(i interface{}) Value {
 		return i.toValue(r)
 	case Value:
 		return i
+	case *big.Int:
+		if i == nil {
+			return _null
+		}
+		return newBigInt(new(big.Int).Set(i))
 	case string:
 		// return newStringValue(i)
 		if len(i) <= 16 {
@@ -2344,6 +3073,399 @@ func (r *Runtime) SetMaxCallStackSize(size int) {
 	r.vm.maxCallStackSize = size
 }
 
+// SetStackOverflowHandler sets a function which is called when a function call is about to exceed the limit set
+// by SetMaxCallStackSize(). The handler is called before the new frame is pushed. If it returns a non-nil Value,
+// the value is thrown in place of the *StackOverflowError and can be caught by the script, otherwise the
+// *StackOverflowError is raised as usual. An overflow that occurs while the handler is running (e.g. if it calls
+// a script function) is not passed to the handler again. Passing nil removes the handler.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetStackOverflowHandler(handler func() Value) {
+	r.vm.stackOverflowHandler = handler
+}
+
+// CallStackDepth returns the current depth of the call stack, i.e. the value which is checked against the
+// limit set by SetMaxCallStackSize(). It may only be called from the vm goroutine (e.g. from a native function).
+func (r *Runtime) CallStackDepth() int {
+	return len(r.vm.callStack)
+}
+
+// SetMaxStackSize sets the maximum number of values the vm stack can hold. The stack holds the arguments and
+// local variables of the active function calls, so it can grow large even when the call depth is small,
+// e.g. when calling a function with a huge number of arguments using the spread syntax or apply().
+// When exceeded, a RangeError is thrown which can be caught by the script. The default value is math.MaxInt32.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetMaxStackSize(size int) {
+	r.vm.maxStackSize = size
+}
+
+// SetYieldInterval sets the number of instructions executed between the calls to runtime.Gosched() (or the function
+// set with SetYieldFunc()) while a script is running. A smaller value gives the other goroutines more chances to run
+// when there are more of them than available CPUs, at the cost of a slower execution. A value of 0 or less sets
+// the default (10000).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetYieldInterval(n int) {
+	if n <= 0 {
+		n = defaultYieldInterval
+	}
+	r.vm.yieldInterval = n
+	r.vm.nextYield = r.vm.ticks + uint64(n)
+	r.vm.scheduleTick()
+}
+
+// SetYieldFunc sets a function which is called instead of runtime.Gosched() periodically while a script is running
+// (see SetYieldInterval()). This allows integrating with a custom scheduler or checking an external budget,
+// e.g. by calling Interrupt() when it's exhausted. The function must not run any code in this Runtime. Passing nil
+// restores the default behaviour.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetYieldFunc(f func()) {
+	r.vm.yieldFunc = f
+}
+
+// SetContext sets a context which is polled while a script is running. Once the context is done (i.e. cancelled
+// or its deadline is exceeded) the script is stopped in the same way as with Interrupt(): an *InterruptedError
+// is returned, its Value() is ctx.Err() (so errors.Is(err, context.DeadlineExceeded) works as expected).
+// This allows enforcing deadlines without a goroutine calling Interrupt().
+// Iterators that are closed as a result (e.g. by for...of loops that were running) have their return() methods
+// called without polling the context, use Interrupt() to stop those as well if needed.
+// The context is polled every n instructions, see SetContextPollInterval(). Passing nil removes the context.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetContext(ctx gocontext.Context) {
+	r.vm.goCtx = ctx
+	r.vm.nextCtxPoll = r.vm.ticks + uint64(r.vm.ctxPollInterval)
+	r.vm.scheduleTick()
+}
+
+// SetContextPollInterval sets the number of instructions executed between the checks of the context set with
+// SetContext(). A smaller value makes the script stop sooner after the context is done, at the cost of
+// a slower execution. A value of 0 or less sets the default (1000).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetContextPollInterval(n int) {
+	if n <= 0 {
+		n = defaultContextPollInterval
+	}
+	r.vm.ctxPollInterval = n
+	r.vm.nextCtxPoll = r.vm.ticks + uint64(n)
+	r.vm.scheduleTick()
+}
+
+// SetMemoryLimit sets the maximum amount of memory (in bytes) the reachable objects of this Runtime may hold.
+// While a script is running, the amount is sampled every n instructions (see SetMemoryCheckInterval()) and once
+// it exceeds the limit, the script is stopped with a *MemoryLimitError, which cannot be caught by the script.
+// The amount is estimated using the same traversal as HeapSnapshot() (so the memory held by Go values is not
+// included), the traversal stops as soon as the limit is exceeded so the cost of a check is proportional
+// to the limit rather than to the size of the heap. The estimate is approximate and it is not meant to replace
+// the limits enforced by the operating system or the Go runtime.
+// A value of 0 (the default) removes the limit.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetMemoryLimit(limit uint64) {
+	vm := r.vm
+	vm.memLimit = limit
+	vm.nextMemCheck = vm.ticks + uint64(vm.memCheckInterval)
+	vm.scheduleTick()
+}
+
+// SetMemoryCheckInterval sets the number of instructions executed between the checks of the limit set with
+// SetMemoryLimit(). A value of 0 or less sets the default (100000).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetMemoryCheckInterval(n int) {
+	if n <= 0 {
+		n = defaultMemoryCheckInterval
+	}
+	vm := r.vm
+	vm.memCheckInterval = n
+	vm.nextMemCheck = vm.ticks + uint64(n)
+	vm.scheduleTick()
+}
+
+// SetInstructionLimit limits the number of VM instructions the scripts may execute to n. Once the limit is
+// reached, the script is stopped with an *InstructionLimitError, which cannot be caught by the script.
+// Unlike Interrupt() or a context deadline, the point at which the execution stops depends only on the code and
+// the data, so it is the same on every run and every machine, which makes it suitable for fuzzing and golden tests.
+// The limit is shared by all the subsequent runs (including the calls of JavaScript functions from Go) until it is
+// set again, so call this method before each run to give it a budget of its own. Once exhausted, any attempt to run
+// a script fails straight away. See InstructionsLeft().
+// A value of 0 (the default) removes the limit.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetInstructionLimit(n uint64) {
+	vm := r.vm
+	vm.instrLimit = n
+	if n > math.MaxUint64-vm.ticks {
+		n = math.MaxUint64 - vm.ticks
+	}
+	vm.instrEnd = vm.ticks + n
+	vm.scheduleTick()
+}
+
+// InstructionsLeft returns the number of instructions that can be executed before the limit set with
+// SetInstructionLimit() is reached. It returns 0 if there is no limit or if it has been exhausted.
+func (r *Runtime) InstructionsLeft() uint64 {
+	vm := r.vm
+	if vm.instrLimit == 0 || vm.ticks >= vm.instrEnd {
+		return 0
+	}
+	return vm.instrEnd - vm.ticks
+}
+
+// EnableOpcodeStats starts counting the executed VM instructions by their type (see OpcodeStats()), e.g. to find
+// out which instructions dominate a particular workload. The counters are reset. Counting slows the execution
+// down, so it should only be enabled for analysis.
+// This method is not safe for concurrent use and may only be called from the vm goroutine or when the vm
+// is not running.
+func (r *Runtime) EnableOpcodeStats() {
+	vm := r.vm
+	vm.opcodeStats = make([]uint64, 0, 64)
+	vm.statsPrgOps = make(map[*Program][]uint32)
+	vm.statsPrg, vm.statsOps = nil, nil
+	vm.scheduleTick()
+}
+
+// DisableOpcodeStats stops counting the executed instructions and discards the counters.
+// This method is not safe for concurrent use and may only be called from the vm goroutine or when the vm
+// is not running.
+func (r *Runtime) DisableOpcodeStats() {
+	vm := r.vm
+	vm.opcodeStats = nil
+	vm.statsPrgOps = nil
+	vm.statsPrg, vm.statsOps = nil, nil
+	vm.scheduleTick()
+}
+
+// OpcodeStats returns the number of executed instructions of each type since EnableOpcodeStats() has been called,
+// keyed by the opcode name (as printed by Program.Disassemble()). Returns nil if the counting is not enabled.
+// The returned map is a copy.
+func (r *Runtime) OpcodeStats() map[string]uint64 {
+	if r.vm.opcodeStats == nil {
+		return nil
+	}
+	res := make(map[string]uint64)
+	opcodes.Lock()
+	for idx, n := range r.vm.opcodeStats {
+		if n != 0 {
+			res[opcodeName(opcodes.types[idx])] += n
+		}
+	}
+	opcodes.Unlock()
+	return res
+}
+
+// SetHostPanicPolicy sets the policy for handling panics in Go code called from a script (such as native
+// functions) when the panic value is neither a JavaScript value nor an *Exception (these are always thrown
+// as JavaScript exceptions). See HostPanicPolicy for the available options, the default is HostPanicPropagate.
+// Note, the policy applies to all such panics, including runtime errors (e.g. a nil pointer dereference).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetHostPanicPolicy(policy HostPanicPolicy) {
+	r.hostPanicPolicy = policy
+}
+
+// WrapNativeFunc returns a function that calls f and converts a panic with a value that is neither a JavaScript
+// value nor an *Exception (e.g. a runtime error caused by a bug in f) into a GoError thrown to the script,
+// the same way as the HostPanicThrow policy does, but regardless of the policy set with SetHostPanicPolicy().
+// This makes it possible to guard individual host functions so that they can't crash the process.
+// The interruptions and the exceptions thrown by the JavaScript code called from f are not affected.
+// See also SetStrictNativePanics().
+func (r *Runtime) WrapNativeFunc(f func(FunctionCall) Value) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		if r.strictNativePanics {
+			return f(call)
+		}
+		defer func() {
+			if x := recover(); x != nil {
+				switch x.(type) {
+				case Value, *Exception, *uncatchableException, *generatorReturn,
+					typeError, rangeError, referenceError, syntaxError:
+					panic(x)
+				}
+				panic(r.newHostPanicError(x))
+			}
+		}()
+		return f(call)
+	}
+}
+
+// SetUnwrapGoErrors makes RunProgram() (and therefore RunString() and RunScript()) return the original Go error
+// instead of an *Exception when the script fails because of an uncaught GoError, i.e. when a Go function called
+// from the script has returned (or, with HostPanicThrow, panicked with) an error and the resulting exception has
+// propagated to the top unchanged. The stack trace of the exception is not available in this case.
+// Other exceptions (including the errors thrown by the script which have a GoError as the cause) are returned
+// as *Exception. By default the exceptions are never unwrapped, Exception.Unwrap() can be used instead.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetUnwrapGoErrors(unwrap bool) {
+	r.unwrapGoErrors = unwrap
+}
+
+// SetSafeNativeArgs makes the calls from the script to Go functions (including native constructors and Proxy
+// traps) pass a copy of the arguments in FunctionCall.Arguments (ConstructorCall.Arguments). By default, for
+// performance reasons, the slice refers to the part of the vm stack holding the arguments, which is re-used
+// once the function returns. So if the function retains the slice (e.g. in a closure or in a Go object) or
+// modifies it, the retained values may change unexpectedly. Enabling this option makes such functions
+// safe at the cost of an allocation per call.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetSafeNativeArgs(safe bool) {
+	r.safeNativeArgs = safe
+}
+
+// SetGlobalResolver sets a function which is called when a script refers to a global name that is not bound,
+// i.e. which would otherwise result in a ReferenceError (or in "undefined" for typeof). If the resolver returns
+// true, the returned value is defined as a writable, configurable and non-enumerable property of the global object
+// and used as the value of the reference, so the resolver is only called once for each name (unless the property
+// is deleted). This allows providing globals lazily (e.g. synthesizing a module on the first reference), which is
+// useful for large global namespaces.
+// Only the references to identifiers are resolved, accessing the properties of the global object
+// (e.g. globalThis.name or "name" in globalThis) does not call the resolver.
+// Pass nil to remove the resolver.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetGlobalResolver(resolver func(name string) (Value, bool)) {
+	r.globalResolver = resolver
+}
+
+// resolveGlobal calls the global resolver for an unbound global name. If it provides a value, the value is
+// defined on the global object and returned, otherwise nil is returned.
+func (r *Runtime) resolveGlobal(name unistring.String) Value {
+	if r.globalResolver == nil {
+		return nil
+	}
+	v, ok := r.globalResolver(name.String())
+	if !ok {
+		return nil
+	}
+	if v == nil {
+		v = _undefined
+	}
+	r.globalObject.self.defineOwnPropertyStr(name, PropertyDescriptor{
+		Value:        v,
+		Writable:     FLAG_TRUE,
+		Configurable: FLAG_TRUE,
+	}, false)
+	return v
+}
+
+// goErrorOf returns the Go error the exception has been created from if its value is a GoError, otherwise nil.
+func (r *Runtime) goErrorOf(ex *Exception) error {
+	if obj, ok := ex.val.(*Object); ok && obj.self.proto() == r.global.GoErrorPrototype {
+		return ex.Unwrap()
+	}
+	return nil
+}
+
+// SetStrictNativePanics disables the recovery done by the functions returned by WrapNativeFunc(), so that
+// the panics are handled according to the policy set with SetHostPanicPolicy(), which by default means they
+// propagate with the original stack trace. This is intended for debugging. The setting applies to the functions
+// that have already been wrapped.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetStrictNativePanics(strict bool) {
+	r.strictNativePanics = strict
+}
+
+// SetExceptionFormatter sets a function that produces the string representation of the thrown values which are
+// not Error objects (such as strings or plain objects) for Exception.Error() and Exception.String(). This allows
+// rendering the values thrown by the scripts in a domain-specific way. The Error objects are always rendered as
+// their string representation (i.e. "name: message"), which is also used for the other values if the formatter
+// is nil (the default). The formatter applies to the exceptions thrown after it has been set.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetExceptionFormatter(f func(Value) string) {
+	r.exceptionFormatter = f
+}
+
+// SetDictionaryThreshold enables tracing of properties added to objects by assignment. An object which
+// gets more than n properties added this way during a run is counted in RunStats.DictionaryObjects.
+// A value of 0 (the default) disables tracing.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetDictionaryThreshold(n int) {
+	r.dictThreshold = n
+	r.dynPropCounts = nil
+}
+
+// SetMaxClosures limits the number of function objects that can be created during a top-level run (see
+// RunStats.Closures). Once the limit is reached, any attempt to create another function throws a RangeError.
+// A value of 0 (the default) means no limit.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetMaxClosures(n int) {
+	r.maxClosures = n
+}
+
+// SetHostJSONMarshaler sets a function that is consulted by JSON.stringify() for host objects, i.e. objects
+// wrapping Go values (see ToValue()) and dynamic objects (see NewDynamicObject() and NewDynamicArray()).
+// It is called after the object's toJSON() method (if any) and the replacer function (if any), before the
+// default serialization. If it returns true, the returned value is serialized instead of the object (the
+// marshaler is not consulted again for that value), otherwise the object is serialized as usual.
+// Passing nil removes the marshaler.
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetHostJSONMarshaler(marshaler func(Value) (Value, bool)) {
+	r.hostJSONMarshaler = marshaler
+}
+
+// LastRunStats returns the statistics collected during the last (or the current) top-level run, i.e. since
+// the most recent call to RunProgram() (or RunString() or RunScript()) which was not made from within a script.
+func (r *Runtime) LastRunStats() RunStats {
+	return r.runStats
+}
+
+func (r *Runtime) resetRunStats() {
+	r.runStats = RunStats{}
+	r.dynPropCounts = nil
+}
+
+// Once calls init the first time it is called with the specified key and returns its result. Subsequent
+// calls with the same key return the same result (including the error, if any) without calling init.
+// This is useful for deferring expensive initialisation (e.g. running a helper script or building a lookup
+// table) until it is actually needed, which is especially beneficial when Runtimes are reused.
+//
+// If init calls Once with the same key (directly or indirectly), that call returns an error. If init panics,
+// nothing is cached and the next call with the key will call init again.
+//
+// This method is not safe for concurrent use and may only be called from the vm goroutine or when
+// the vm is not running.
+func (r *Runtime) Once(key string, init func() (Value, error)) (Value, error) {
+	if v := r.onceValues[key]; v != nil {
+		if v.running {
+			return nil, fmt.Errorf("recursive Once() call for key %q", key)
+		}
+		return v.value, v.err
+	}
+	if r.onceValues == nil {
+		r.onceValues = make(map[string]*onceValue)
+	}
+	v := &onceValue{running: true}
+	r.onceValues[key] = v
+	defer func() {
+		if v.running {
+			delete(r.onceValues, key)
+		}
+	}()
+	v.value, v.err = init()
+	v.running = false
+	return v.value, v.err
+}
+
+func (r *Runtime) traceDynamicProp(o *Object) {
+	if r.dynPropCounts == nil {
+		r.dynPropCounts = make(map[*Object]int)
+	}
+	n := r.dynPropCounts[o] + 1
+	r.dynPropCounts[o] = n
+	if n == r.dictThreshold+1 {
+		r.runStats.DictionaryObjects++
+	}
+}
+
 // New is an equivalent of the 'new' operator allowing to call it directly from Go.
 func (r *Runtime) New(construct Value, args ...Value) (o *Object, err error) {
 	err = r.try(func() {
@@ -2375,6 +3497,33 @@ func AssertFunction(v Value) (Callable, bool) {
 	return nil, false
 }
 
+// CallableContext represents a JavaScript function that can be called from Go with a context.
+// See AssertFunctionContext().
+type CallableContext func(ctx gocontext.Context, this Value, args ...Value) (Value, error)
+
+// AssertFunctionContext is like AssertFunction, but the returned function takes a context. If the context
+// is done before the call returns, the call is interrupted and an *InterruptedError wrapping ctx.Err()
+// is returned (i.e. errors.Is(err, context.DeadlineExceeded) can be used to check for a timeout).
+// The interruption cannot be caught by the JavaScript code, however it only affects this call,
+// so if the function is called from a Go function which in turn was called by a script, the script
+// continues normally once the Go function returns.
+func AssertFunctionContext(v Value) (CallableContext, bool) {
+	if obj, ok := v.(*Object); ok {
+		if f, ok := obj.self.assertCallable(); ok {
+			return func(ctx gocontext.Context, this Value, args ...Value) (ret Value, err error) {
+				err = obj.runtime.runWrappedContext(ctx, func() {
+					ret = f(FunctionCall{
+						This:      this,
+						Arguments: args,
+					})
+				})
+				return
+			}, true
+		}
+	}
+	return nil, false
+}
+
 // Constructor is a type that can be used to call constructors. The first argument (newTarget) can be nil
 // which sets it to the constructor function itself.
 type Constructor func(newTarget *Object, args ...Value) (*Object, error)
@@ -2418,6 +3567,73 @@ func (r *Runtime) runWrapped(f func()) (err error) {
 	return
 }
 
+// callInterrupt is used as the interrupt value when a call made with AssertFunctionContext() is interrupted.
+type callInterrupt struct {
+	err error
+}
+
+func (c *callInterrupt) Error() string {
+	return c.err.Error()
+}
+
+func (c *callInterrupt) Unwrap() error {
+	return c.err
+}
+
+func (r *Runtime) runWrappedContext(ctx gocontext.Context, f func()) error {
+	done := ctx.Done()
+	if done == nil {
+		return r.runWrapped(f)
+	}
+	if err := ctx.Err(); err != nil {
+		return &InterruptedError{
+			iface: &callInterrupt{err: err},
+		}
+	}
+	vm := r.vm
+	marker := &callInterrupt{}
+	vm.ctxCalls++
+	defer func() {
+		vm.ctxCalls--
+	}()
+	finished := make(chan struct{})
+	var mu sync.Mutex
+	stopped := false
+	go func() {
+		select {
+		case <-done:
+			mu.Lock()
+			if !stopped {
+				marker.err = ctx.Err()
+				vm.Interrupt(marker)
+			}
+			mu.Unlock()
+		case <-finished:
+		}
+	}()
+	err := r.runWrapped(f)
+	mu.Lock()
+	stopped = true
+	mu.Unlock()
+	close(finished)
+	// the interrupt may have been set after the call had returned
+	vm.interruptLock.Lock()
+	if vm.interruptStopped && vm.interruptVal == marker {
+		vm.interruptVal, vm.interruptStopped = nil, false
+	}
+	for i, v := range vm.interruptQueue {
+		if v == marker {
+			vm.interruptQueue = append(vm.interruptQueue[:i], vm.interruptQueue[i+1:]...)
+			break
+		}
+	}
+	if !vm.interruptStopped && len(vm.interruptQueue) == 0 {
+		atomic.StoreUint32(&vm.interrupted, 0)
+	}
+	vm.interruptLock.Unlock()
+	return err
+}
+
 // IsUndefined returns true if the supplied Value is undefined. Note, it checks against the real undefined, not
 // against the global object's 'undefined' property.
 func IsUndefined(v Value) bool {
@@ -2568,6 +3784,16 @@ func toPropertyKey(key Value) Value {
 	return key.ToString()
 }
 
+// toElemKey is like toPropertyKey(), but leaves integer keys as they are to avoid boxing them again as strings.
+// The result may only be passed to the methods of *Object that handle the valueInt keys as indexes (get, set,
+// setOwn, etc.).
+func toElemKey(key Value) Value {
+	if _, ok := key.(valueInt); ok {
+		return key
+	}
+	return key.ToString()
+}
+
 func (r *Runtime) getVStr(v Value, p unistring.String) Value {
 	o := v.ToObject(r)
 	return o.self.getStr(p, v)
@@ -2581,6 +3807,11 @@ func (r *Runtime) getV(v Value, p Value) Value {
 type iteratorRecord struct {
 	iterator *Object
 	next     func(FunctionCall) Value
+
+	// set for the sync iterator a for-await-of loop falls back to if there is no Symbol.asyncIterator method
+	fromSync bool
+	// set while a for-await-of loop awaits the result of next(), the iterator is not closed if it gets rejected
+	awaitingNext bool
 }
 
 func (r *Runtime) getIterator(obj Value, method func(FunctionCall) Value) *iteratorRecord {
@@ -2601,6 +3832,18 @@ func (r *Runtime) getIterator(obj Value, method func(FunctionCall) Value) *itera
 	}
 }
 
+// getAsyncIterator is GetIterator(obj, async) used by the for-await-of loops. If obj does not have
+// a Symbol.asyncIterator method, its sync iterator is used instead and the values it produces are awaited.
+func (r *Runtime) getAsyncIterator(obj Value) *iteratorRecord {
+	method := toMethod(r.getV(obj, SymAsyncIterator))
+	if method == nil {
+		iter := r.getIterator(obj, nil)
+		iter.fromSync = true
+		return iter
+	}
+	return r.getIterator(obj, method)
+}
+
 func (ir *iteratorRecord) iterate(step func(Value)) {
 	r := ir.iterator.runtime
 	for {
@@ -2636,7 +3879,7 @@ func (ir *iteratorRecord) step() (value Value, ex *Exception) {
 }
 
 func (ir *iteratorRecord) returnIter() {
-	if ir.iterator == nil {
+	if ir.iterator == nil || ir.awaitingNext {
 		return
 	}
 	retMethod := toMethod(ir.iterator.self.getStr("return", nil))
@@ -2676,6 +3919,32 @@ func (r *Runtime) getHash() *maphash.Hash {
 	return r.hash
 }
 
+// PerformJobs runs the pending Promise jobs (i.e. the reactions of the settled promises) until the queue is empty.
+// The queue is drained automatically every time the control is passed outside the Runtime (e.g. when RunString()
+// or a function obtained with AssertFunction() returns), this method can be used by an event loop to make sure
+// there are no pending jobs, for example before handing the Runtime over to another goroutine.
+// If a job is interrupted (see Interrupt()), the corresponding *InterruptedError is returned and the rest of the queue
+// is discarded. Errors thrown by the jobs themselves do not propagate, they reject the corresponding promises.
+// If called while the Runtime is running (e.g. from a native function), it does nothing, the jobs will be performed
+// once the control is passed outside the Runtime.
+func (r *Runtime) PerformJobs() (err error) {
+	if len(r.vm.callStack) > 0 {
+		return nil
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			if ex, ok := x.(*uncatchableException); ok {
+				err = ex.err
+				r.leaveAbrupt()
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	r.leave()
+	return
+}
+
 // called when the top level function returns normally (i.e. control is passed outside the Runtime).
 func (r *Runtime) leave() {
 	for {
@@ -2792,7 +4061,7 @@ func (r *Runtime) setGlobal(name unistring.String, v Value, strict bool) {
 	} else {
 		o := r.globalObject.self
 		if strict {
-			if o.hasOwnPropertyStr(name) {
+			if o.hasOwnPropertyStr(name) || r.resolveGlobal(name) != nil {
 				o.setOwnStr(name, v, true)
 			} else {
 				r.throwReferenceError(name)
@@ -3026,6 +4295,11 @@ func strToIntNum(s unistring.String) (int, bool) {
 		}
 		return int(n), true
 	}
+	if c := s[0]; !(c >= '0' && c <= '9' || c == '-' || c == 'I' || c == 'N') {
+		// not a canonical numeric string (the result of Number::toString() always starts with one of these),
+		// this avoids the conversion for the regular property names, such as 'length'
+		return -1, false
+	}
 	str := stringValueFromRaw(s)
 	if str.ToNumber().toString().SameAs(str) {
 		return 0, false
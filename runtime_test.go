@@ -1,11 +1,13 @@
 package goja
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -370,22 +372,1647 @@ func TestIPowZero(t *testing.T) {
 	testScript(SCRIPT, intToValue(1), t)
 }
 
+func TestModEdgeCases(t *testing.T) {
+	const SCRIPT = `
+	var cases = [
+		[-0, 5, -0], [0, 5, 0], [5, -0, NaN], [5, 0, NaN], [-0, -0, NaN], [0, 0, NaN],
+		[Infinity, 5, NaN], [-Infinity, 5, NaN], [Infinity, Infinity, NaN], [5, Infinity, 5], [5, -Infinity, 5],
+		[-5, Infinity, -5], [-0, Infinity, -0], [0, -Infinity, 0], [NaN, 5, NaN], [5, NaN, NaN], [NaN, 0, NaN],
+		[-4, 2, -0], [4, -2, 0], [-5, 3, -2], [5, -3, 2], [5.5, 2, 1.5], [-5.5, 2, -1.5], [-1, 1, -0],
+		[-2, 2.5, -2], [0.5, -0, NaN], [-0.5, 1, -0.5], [-9007199254740991, 9007199254740991, -0],
+	];
+	cases.forEach(function(c) {
+		var a = c[0], b = c[1];
+		var desc = String(Object.is(a, -0) ? "-0" : a) + " % " + String(Object.is(b, -0) ? "-0" : b);
+		assert.sameValue(a % b, c[2], desc);
+		a %= b;
+		assert.sameValue(a, c[2], desc + " (assignment)");
+	});
+	assert.sameValue(-0 % 5, -0, "literal -0 % 5");
+	assert.sameValue(-1 % 1, -0, "literal -1 % 1");
+	assert.sameValue(5 % -0, NaN, "literal 5 % -0");
+	assert.sameValue(Infinity % 5, NaN, "literal Infinity % 5");
+	assert.sameValue(5 % Infinity, 5, "literal 5 % Infinity");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestExpEdgeCases(t *testing.T) {
+	const SCRIPT = `
+	var cases = [
+		[NaN, 0, 1], [NaN, -0, 1], [Infinity, 0, 1], [0, 0, 1], [-0, -0, 1], [5, NaN, NaN], [NaN, 1, NaN],
+		[1, NaN, NaN], [1, Infinity, NaN], [-1, Infinity, NaN], [1, -Infinity, NaN], [-1, -Infinity, NaN],
+		[2, Infinity, Infinity], [2, -Infinity, 0], [0.5, Infinity, 0], [0.5, -Infinity, Infinity],
+		[-2, Infinity, Infinity], [-0.5, -Infinity, Infinity],
+		[Infinity, 1, Infinity], [Infinity, -1, 0], [-Infinity, 3, -Infinity], [-Infinity, 2, Infinity],
+		[-Infinity, -3, -0], [-Infinity, -2, 0], [-Infinity, 0.5, Infinity], [-Infinity, -0.5, 0],
+		[0, 1, 0], [0, -1, Infinity], [-0, 1, -0], [-0, 3, -0], [-0, 2, 0], [-0, -3, -Infinity],
+		[-0, -2, Infinity], [-0, 0.5, 0], [-0, -0.5, Infinity],
+		[-8, 1/3, NaN], [-2, 3, -8], [-2, -1, -0.5], [2, 0.5, Math.SQRT2], [2, 53, 9007199254740992],
+		[2, 64, 18446744073709552000], [-2, 63, -9223372036854775808], [10, 309, Infinity], [10, -324, 0],
+	];
+	cases.forEach(function(c) {
+		var a = c[0], b = c[1];
+		var desc = String(Object.is(a, -0) ? "-0" : a) + " ** " + String(Object.is(b, -0) ? "-0" : b);
+		assert.sameValue(a ** b, c[2], desc);
+		assert.sameValue(Math.pow(a, b), c[2], "Math.pow: " + desc);
+		a **= b;
+		assert.sameValue(a, c[2], desc + " (assignment)");
+	});
+	assert.sameValue((-0) ** 3, -0, "literal (-0) ** 3");
+	assert.sameValue((-2) ** 2, 4, "literal (-2) ** 2");
+	assert.sameValue(2 ** -1, 0.5, "literal 2 ** -1");
+	assert.sameValue(2 ** 3 ** 2, 512, "right associativity");
+	assert.sameValue(1 ** Infinity, NaN, "literal 1 ** Infinity");
+	`
+
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestNewConstantsObject(t *testing.T) {
+	vm := New()
+	c := vm.NewConstantsObject(map[string]interface{}{
+		"OK":       200,
+		"NotFound": int64(404),
+		"Name":     "status",
+		"Ratio":    0.5,
+	})
+	if v := c.Get("OK"); v != valueInt(200) {
+		t.Fatalf("Unexpected value: %#v", v)
+	}
+	vm.Set("c", c)
+	vm.testScriptWithTestLib(`
+	assert(Object.isFrozen(c), "isFrozen");
+	assert(compareArray(Object.keys(c), ["Name", "NotFound", "OK", "Ratio"]), "keys");
+	var desc = Object.getOwnPropertyDescriptor(c, "OK");
+	assert(desc.enumerable && !desc.writable && !desc.configurable, "descriptor");
+	assert.sameValue(c.NotFound, 404, "NotFound");
+	assert.sameValue(c.Name, "status", "Name");
+
+	c.OK = 1;
+	assert.sameValue(c.OK, 200, "sloppy assignment");
+	assert(!delete c.OK, "sloppy delete");
+	c.extra = 1;
+	assert(!c.hasOwnProperty("extra"), "sloppy extension");
+
+	assert.throws(TypeError, function() {
+		"use strict";
+		c.OK = 1;
+	}, "strict assignment");
+	assert.throws(TypeError, function() {
+		"use strict";
+		delete c.OK;
+	}, "strict delete");
+	`, _undefined, t)
+}
+
+func TestNewIterable(t *testing.T) {
+	vm := New()
+	i := 0
+	vm.Set("r", vm.NewIterable(func() (Value, bool) {
+		if i < 3 {
+			i++
+			return vm.ToValue(i), true
+		}
+		return nil, false
+	}))
+	vm.testScriptWithTestLib(`
+	var res = [];
+	for (var x of r) {
+		if (x > 1) {
+			break;
+		}
+		res.push(x);
+	}
+	assert(compareArray(res, [1]), "break");
+	assert.sameValue([...r].length, 0, "done after return");
+	`, _undefined, t)
+	if i != 2 {
+		t.Fatalf("next called %d times", i)
+	}
+}
+
+func TestNewIterableWithCleanup(t *testing.T) {
+	vm := New()
+	newRange := func(n int) (*Object, *int) {
+		i := 0
+		cleanups := 0
+		return vm.NewIterableWithCleanup(func() (Value, bool) {
+			if i < n {
+				i++
+				return vm.ToValue(i), true
+			}
+			return nil, false
+		}, func() {
+			cleanups++
+		}), &cleanups
+	}
+
+	r, cleanups := newRange(3)
+	vm.Set("r", r)
+	vm.testScriptWithTestLib(`
+	var res = [];
+	for (var x of r) {
+		res.push(x);
+	}
+	assert(compareArray(res, [1, 2, 3]), "for...of");
+	assert.sameValue([...r].length, 0, "exhausted");
+	assert.sameValue(r[Symbol.iterator](), r, "Symbol.iterator");
+	`, _undefined, t)
+	if *cleanups != 0 {
+		t.Fatalf("cleanup called after exhaustion: %d", *cleanups)
+	}
+
+	r, cleanups = newRange(10)
+	vm.Set("r", r)
+	vm.testScriptWithTestLib(`
+	var res = [];
+	for (var x of r) {
+		if (x > 2) {
+			break;
+		}
+		res.push(x);
+	}
+	assert(compareArray(res, [1, 2]), "break");
+	var [a] = r;
+	assert.sameValue(a, undefined, "destructuring after return");
+	`, _undefined, t)
+	if *cleanups != 1 {
+		t.Fatalf("cleanups after break: %d", *cleanups)
+	}
+
+	r, cleanups = newRange(10)
+	vm.Set("r", r)
+	vm.testScriptWithTestLib(`
+	assert.throws(Error, function() {
+		for (var x of r) {
+			throw new Error("boom");
+		}
+	});
+	`, _undefined, t)
+	if *cleanups != 1 {
+		t.Fatalf("cleanups after throw: %d", *cleanups)
+	}
+
+	r, cleanups = newRange(10)
+	vm.Set("r", r)
+	vm.testScriptWithTestLib(`
+	var [a, b] = r;
+	assert.sameValue(a + b, 3, "destructuring");
+	assert.sameValue(Array.from(r).length, 0, "closed by destructuring");
+	`, _undefined, t)
+	if *cleanups != 1 {
+		t.Fatalf("cleanups after destructuring: %d", *cleanups)
+	}
+}
+
+func TestRuntimeIterate(t *testing.T) {
+	vm := New()
+	vm.Set("sum", func(call FunctionCall) Value {
+		limit := call.Argument(1).ToInteger()
+		var sum int64
+		vm.Iterate(call.Argument(0), func(v Value) bool {
+			if v.ToInteger() == 13 {
+				panic(vm.NewTypeError("unlucky"))
+			}
+			sum += v.ToInteger()
+			return limit <= 0 || sum < limit
+		})
+		return vm.ToValue(sum)
+	})
+	vm.testScriptWithTestLib(`
+	var closed = 0;
+	function* gen(n) {
+		try {
+			for (var i = 1; i <= n; i++) {
+				yield i;
+			}
+		} finally {
+			closed++;
+		}
+	}
+	assert.sameValue(sum([1, 2, 3]), 6, "array");
+	assert.sameValue(sum(new Set([1, 2, 2])), 3, "Set");
+	assert.sameValue(sum(gen(4)), 10, "generator");
+	assert.sameValue(closed, 1, "exhausted");
+	assert.sameValue(sum(gen(100), 6), 6, "early exit");
+	assert.sameValue(closed, 2, "closed on early exit");
+	assert.throws(TypeError, function() {
+		sum(gen(20));
+	}, "the callback throws");
+	assert.sameValue(closed, 3, "closed when the callback throws");
+	assert.throws(TypeError, function() {
+		sum({});
+	}, "not iterable");
+	var iter = {
+		[Symbol.iterator]() {
+			return {
+				next() { throw new RangeError(); },
+				return() { closed = -1; }
+			};
+		}
+	};
+	assert.throws(RangeError, function() {
+		sum(iter);
+	}, "next() throws");
+	assert.sameValue(closed, 3, "not closed when next() throws");
+	`, _undefined, t)
+
+	var values []int64
+	vm.Iterate(vm.ToValue([]int{1, 2, 3}), func(v Value) bool {
+		values = append(values, v.ToInteger())
+		return true
+	})
+	if len(values) != 3 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	func() {
+		defer func() {
+			if _, ok := recover().(*Exception); !ok {
+				t.Fatal("Expected an *Exception panic")
+			}
+		}()
+		vm.Iterate(_undefined, func(Value) bool {
+			return true
+		})
+	}()
+}
+
+func TestRuntimeWithIterator(t *testing.T) {
+	vm := New()
+	// zip(a, b) returns the pairs of values from a and b until one of them is exhausted
+	vm.Set("zip", func(call FunctionCall) Value {
+		var res []interface{}
+		vm.WithIterator(call.Argument(0), func(a *Iterator) {
+			vm.WithIterator(call.Argument(1), func(b *Iterator) {
+				for {
+					v1, ok := a.Next()
+					if !ok {
+						return
+					}
+					v2, ok := b.Next()
+					if !ok {
+						return
+					}
+					if v1.ToInteger() == 13 {
+						panic(vm.NewTypeError("unlucky"))
+					}
+					res = append(res, []interface{}{v1, v2})
+				}
+			})
+		})
+		return vm.ToValue(res)
+	})
+	vm.Set("first", func(call FunctionCall) Value {
+		var res Value = _undefined
+		vm.WithIterator(call.Argument(0), func(iter *Iterator) {
+			if v, ok := iter.Next(); ok {
+				res = v
+			}
+			iter.Close()
+			if _, ok := iter.Next(); ok {
+				panic("Next() after Close()")
+			}
+		})
+		return res
+	})
+	vm.testScriptWithTestLib(`
+	var closed = 0;
+	function* gen(n) {
+		try {
+			for (var i = 1; i <= n; i++) {
+				yield i;
+			}
+		} finally {
+			closed++;
+		}
+	}
+	assert(compareArray(zip([1, 2], ["a", "b", "c"]).map(String), ["1,a", "2,b"]), "arrays");
+	assert.sameValue(zip(gen(2), gen(3)).length, 2, "generators");
+	assert.sameValue(closed, 2, "the exhausted and the unfinished generators are both closed");
+	assert.throws(TypeError, function() {
+		zip(gen(20), gen(20));
+	}, "the callback throws");
+	assert.sameValue(closed, 4, "closed when the callback throws");
+	assert.sameValue(first(gen(5)), 1, "first");
+	assert.sameValue(closed, 5, "closed by Close()");
+	assert.throws(TypeError, function() {
+		zip([], {});
+	}, "not iterable");
+	var iter = {
+		[Symbol.iterator]() {
+			return {
+				next() { throw new RangeError(); },
+				return() { closed = -1; }
+			};
+		}
+	};
+	assert.throws(RangeError, function() {
+		zip(iter, []);
+	}, "next() throws");
+	assert.sameValue(closed, 5, "not closed when next() throws");
+	var badReturn = {
+		[Symbol.iterator]() {
+			return {
+				next() { return { value: 13, done: false }; },
+				return() { throw new RangeError(); }
+			};
+		}
+	};
+	assert.throws(TypeError, function() {
+		zip(badReturn, [1]);
+	}, "the original exception is preserved");
+	assert.throws(RangeError, function() {
+		first(badReturn);
+	}, "return() throws");
+	`, _undefined, t)
+}
+
+func TestHostPanicPolicy(t *testing.T) {
+	const SCRIPT = `
+	var caught;
+	try {
+		f();
+	} catch (e) {
+		caught = e;
+	}
+	caught;
+	`
+	newVM := func(policy HostPanicPolicy) *Runtime {
+		vm := New()
+		vm.SetHostPanicPolicy(policy)
+		vm.Set("f", func(FunctionCall) Value {
+			panic("boom")
+		})
+		return vm
+	}
+
+	t.Run("propagate", func(t *testing.T) {
+		vm := newVM(HostPanicPropagate)
+		res := tryFunc(func() {
+			_, _ = vm.RunString(SCRIPT)
+		})
+		if res != "boom" {
+			t.Fatalf("Unexpected panic value: %v", res)
+		}
+	})
+
+	t.Run("throw", func(t *testing.T) {
+		vm := newVM(HostPanicThrow)
+		v, err := vm.RunString(SCRIPT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := v.(*Object)
+		if e.Get("name").String() != "GoError" || e.Get("message").String() != "boom" {
+			t.Fatalf("Unexpected error: %v", e)
+		}
+		if val := e.Get("value").Export(); val != "boom" {
+			t.Fatalf("Unexpected value: %v", val)
+		}
+	})
+
+	t.Run("throw error", func(t *testing.T) {
+		vm := New()
+		vm.SetHostPanicPolicy(HostPanicThrow)
+		var m map[string]int
+		vm.Set("f", func() {
+			m["x"] = 1 // nil map
+		})
+		v, err := vm.RunString(SCRIPT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(v.String(), "nil map") {
+			t.Fatalf("Unexpected error: %v", v)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		vm := newVM(HostPanicAbort)
+		_, err := vm.RunString(SCRIPT)
+		if err, ok := err.(*HostPanicError); !ok || err.Value() != "boom" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// the runtime must remain usable
+		v, err := vm.RunString("1 + 1")
+		if err != nil || v.ToInteger() != 2 {
+			t.Fatal(v, err)
+		}
+	})
+}
+
+func TestWrapNativeFunc(t *testing.T) {
+	vm := New()
+	if _, err := vm.RunProgram(testLib()); err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]int
+	vm.Set("f", vm.WrapNativeFunc(func(call FunctionCall) Value {
+		switch call.Argument(0).String() {
+		case "nil map":
+			m["x"] = 1
+		case "throw":
+			panic(vm.NewTypeError("thrown"))
+		case "callback":
+			fn, _ := vm.toObject(call.Argument(1)).self.assertCallable()
+			return fn(FunctionCall{This: _undefined})
+		}
+		return valueTrue
+	}))
+	_, err := vm.RunString(`
+	try {
+		f("nil map");
+		throw new Error("should have thrown");
+	} catch (e) {
+		assert.sameValue(e.name, "GoError", "name");
+		assert(e.message.indexOf("nil map") !== -1, "message: " + e.message);
+	}
+	assert.throws(TypeError, function() {
+		f("throw");
+	});
+	assert.throws(SyntaxError, function() {
+		f("callback", function() {
+			throw new SyntaxError();
+		});
+	});
+	assert(f("ok"), "result");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm.SetStrictNativePanics(true)
+	res := tryFunc(func() {
+		_, _ = vm.RunString(`f("nil map")`)
+	})
+	if err, ok := res.(error); !ok || !strings.Contains(err.Error(), "nil map") {
+		t.Fatalf("Unexpected panic value: %v", res)
+	}
+}
+
+func TestSetUnwrapGoErrors(t *testing.T) {
+	vm := New()
+	goErr := errors.New("go error")
+	vm.Set("f", func() error { return goErr })
+
+	_, err := vm.RunString("f()")
+	if _, ok := err.(*Exception); !ok {
+		t.Fatalf("Expected an *Exception by default, got %T", err)
+	}
+
+	vm.SetUnwrapGoErrors(true)
+	for _, src := range []string{
+		"f()",
+		"try { f() } catch (e) { throw e }",
+		"function g() { return f() } g()",
+	} {
+		_, err = vm.RunString(src)
+		if err != goErr {
+			t.Fatalf("%s: unexpected error: %v", src, err)
+		}
+	}
+	for _, src := range []string{
+		"try { f() } catch (e) { throw new Error('wrapped', { cause: e }) }",
+		"throw new Error('js')",
+		"throw 1",
+	} {
+		_, err = vm.RunString(src)
+		if _, ok := err.(*Exception); !ok {
+			t.Fatalf("%s: expected an *Exception, got %T", src, err)
+		}
+	}
+	if _, err = vm.RunString("try { f() } catch (e) { }; 1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetSafeNativeArgs(t *testing.T) {
+	const SCRIPT = `
+	save(1, 2, 3);
+	(function() {
+		var a = 4, b = 5, c = 6;
+		return a + b + c;
+	})();
+	new Saver("x", "y");
+	`
+	run := func(safe bool) (saved, ctorSaved []Value) {
+		vm := New()
+		vm.SetSafeNativeArgs(safe)
+		vm.Set("save", func(call FunctionCall) Value {
+			saved = call.Arguments
+			return _undefined
+		})
+		vm.Set("Saver", func(call ConstructorCall) *Object {
+			ctorSaved = call.Arguments
+			return nil
+		})
+		if _, err := vm.RunString(SCRIPT); err != nil {
+			t.Fatal(err)
+		}
+		// re-use the stack
+		if _, err := vm.RunString("(function(a, b, c, d) { return a + b + c + d; })(7, 8, 9, 10)"); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	saved, _ := run(false)
+	if len(saved) != 3 || saved[0].SameAs(intToValue(1)) && saved[1].SameAs(intToValue(2)) && saved[2].SameAs(intToValue(3)) {
+		t.Fatalf("Expected the retained arguments to be overwritten by default: %v", saved)
+	}
+
+	saved, ctorSaved := run(true)
+	if len(saved) != 3 || !saved[0].SameAs(intToValue(1)) || !saved[1].SameAs(intToValue(2)) || !saved[2].SameAs(intToValue(3)) {
+		t.Fatalf("saved: %v", saved)
+	}
+	if len(ctorSaved) != 2 || ctorSaved[0].String() != "x" || ctorSaved[1].String() != "y" {
+		t.Fatalf("ctorSaved: %v", ctorSaved)
+	}
+}
+
+func TestRuntimeThrow(t *testing.T) {
+	vm := New()
+	vm.Set("f", func(call FunctionCall) Value {
+		switch call.Argument(0).String() {
+		case "error":
+			vm.Throw(vm.NewError("error %d", 1))
+		case "type":
+			vm.Throw(vm.NewTypeError("type %s", "error"))
+		case "range":
+			vm.Throw(vm.NewRangeError("range"))
+		case "reference":
+			vm.Throw(vm.NewReferenceError("reference"))
+		case "syntax":
+			vm.Throw(vm.NewSyntaxError("syntax"))
+		case "nil":
+			vm.Throw(nil)
+		}
+		vm.Throw(call.Argument(0))
+		panic("unreachable")
+	})
+	vm.testScriptWithTestLib(`
+	function check(arg, ctor, msg) {
+		try {
+			f(arg);
+		} catch (e) {
+			assert(e instanceof ctor, arg + ": instanceof");
+			assert.sameValue(e.message, msg, arg + ": message");
+			return;
+		}
+		throw new Error(arg + ": not thrown");
+	}
+	check("error", Error, "error 1");
+	check("type", TypeError, "type error");
+	check("range", RangeError, "range");
+	check("reference", ReferenceError, "reference");
+	check("syntax", SyntaxError, "syntax");
+	assert.throws(TypeError, function() {
+		f("type");
+	});
+	var thrown = false;
+	try {
+		f("nil");
+	} catch (e) {
+		thrown = true;
+		assert.sameValue(e, undefined, "nil");
+	}
+	assert(thrown, "nil thrown");
+	try {
+		f(42);
+	} catch (e) {
+		assert.sameValue(e, 42, "value");
+	}
+	`, _undefined, t)
+
+	_, err := vm.RunString(`f("range")`)
+	if ex, ok := err.(*Exception); !ok || ex.Value().(*Object).Get("message").String() != "range" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestSetGlobalResolver(t *testing.T) {
+	vm := New()
+	calls := make(map[string]int)
+	vm.SetGlobalResolver(func(name string) (Value, bool) {
+		calls[name]++
+		switch name {
+		case "lazyModule":
+			o := vm.NewObject()
+			_ = o.Set("answer", 42)
+			return o, true
+		case "lazyFunc":
+			return vm.ToValue(func(x int) int { return x * 2 }), true
+		case "lazyCounter", "lazyStrict", "lazyTypeof", "lazyAssigned":
+			return vm.ToValue(1), true
+		case "lazyUndefined":
+			return nil, true
+		}
+		return nil, false
+	})
+	vm.testScriptWithTestLib(`
+	assert.sameValue(lazyModule.answer, 42, "module");
+	assert.sameValue(lazyModule, globalThis.lazyModule, "defined on the global object");
+	assert.sameValue(lazyFunc(21), 42, "function call");
+	lazyCounter++;
+	assert.sameValue(lazyCounter, 2, "update expression");
+	(function() {
+		"use strict";
+		lazyStrict += 1;
+		assert.sameValue(lazyStrict, 2, "strict compound assignment");
+	})();
+	assert.sameValue(typeof lazyTypeof, "number", "typeof");
+	assert.sameValue(lazyUndefined, undefined, "undefined value");
+	assert.sameValue(typeof missing, "undefined", "typeof missing");
+	assert.throws(ReferenceError, function() {
+		missing;
+	}, "missing");
+	assert.sameValue("lazyNotReferenced" in globalThis, false, "property access");
+	var desc = Object.getOwnPropertyDescriptor(globalThis, "lazyModule");
+	assert(desc.writable && desc.configurable && !desc.enumerable, "property attributes");
+	`, _undefined, t)
+
+	_, err := vm.RunString(`"use strict"; lazyAssigned = 5; if (lazyAssigned !== 5) throw new Error("strict assignment");`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunString(`"use strict"; missingStrict = 1;`)
+	if ex, ok := err.(*Exception); !ok || !strings.Contains(ex.Error(), "ReferenceError") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"lazyModule", "lazyFunc", "lazyCounter", "lazyStrict", "lazyTypeof", "lazyUndefined"} {
+		if calls[name] != 1 {
+			t.Fatalf("%s: resolver called %d times", name, calls[name])
+		}
+	}
+	if calls["lazyNotReferenced"] != 0 {
+		t.Fatal("The resolver was called for a property access")
+	}
+	if _, err := vm.RunString("lazyModule; lazyFunc(1); delete globalThis.lazyModule; lazyModule"); err != nil {
+		t.Fatal(err)
+	}
+	if calls["lazyModule"] != 2 || calls["lazyFunc"] != 1 {
+		t.Fatalf("Unexpected calls after delete: %v", calls)
+	}
+
+	vm.SetGlobalResolver(nil)
+	if _, err := vm.RunString("anotherMissing"); err == nil {
+		t.Fatal("Expected a ReferenceError")
+	}
+}
+
+type testUnwrapError struct {
+	code int
+}
+
+func (e *testUnwrapError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func TestExceptionUnwrap(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	vm := New()
+	vm.SetHostPanicPolicy(HostPanicThrow)
+	vm.Set("returnsError", func() (int, error) {
+		return 0, fmt.Errorf("wrapped: %w", sentinel)
+	})
+	vm.Set("panicsWithError", func() {
+		panic(gocontext.DeadlineExceeded)
+	})
+	vm.Set("customError", func() error {
+		return &testUnwrapError{code: 42}
+	})
+	vm.Set("goErr", sentinel)
+
+	for _, tc := range []struct {
+		src    string
+		target error
+	}{
+		{"returnsError()", sentinel},
+		{"[1].forEach(function() { panicsWithError(); })", gocontext.DeadlineExceeded},
+		{"try { returnsError(); } catch (e) { throw e; }", sentinel},
+		{"throw goErr", sentinel},
+	} {
+		_, err := vm.RunString(tc.src)
+		var ex *Exception
+		if !errors.As(err, &ex) {
+			t.Fatalf("%s: unexpected error: %v", tc.src, err)
+		}
+		if !errors.Is(err, tc.target) {
+			t.Fatalf("%s: errors.Is() is false for %v", tc.src, err)
+		}
+	}
+
+	_, err := vm.RunString("customError()")
+	var custom *testUnwrapError
+	if !errors.As(err, &custom) || custom.code != 42 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, src := range []string{"null.x", "throw 1", "var e = new Error(); e.value = 1; throw e"} {
+		_, err = vm.RunString(src)
+		if ex, ok := err.(*Exception); !ok || ex.Unwrap() != nil {
+			t.Fatalf("%s: unexpected error: %v", src, err)
+		}
+	}
+}
+
+func TestDictionaryThreshold(t *testing.T) {
+	const SCRIPT = `
+	var literal = {a: 1, b: 2, c: 3, d: 4};
+	var defined = {};
+	for (var i = 0; i < 10; i++) {
+		Object.defineProperty(defined, "p" + i, {value: i, writable: true});
+	}
+	var dict = {};
+	for (var i = 0; i < 10; i++) {
+		dict["k" + i] = i;
+	}
+	var symDict = {};
+	for (var i = 0; i < 4; i++) {
+		symDict[Symbol()] = i;
+	}
+	var stable = {};
+	stable.x = 1;
+	stable.x = 2;
+	stable.y = 3;
+	`
+	vm := New()
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	if n := vm.LastRunStats().DictionaryObjects; n != 0 {
+		t.Fatalf("Tracing is disabled, got %d", n)
+	}
+
+	vm = New()
+	vm.SetDictionaryThreshold(3)
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	if n := vm.LastRunStats().DictionaryObjects; n != 2 {
+		t.Fatalf("Unexpected DictionaryObjects: %d", n)
+	}
+
+	// stats are per run
+	if _, err := vm.RunString("dict.more = true; stable.z = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := vm.LastRunStats().DictionaryObjects; n != 0 {
+		t.Fatalf("Unexpected DictionaryObjects after second run: %d", n)
+	}
+}
+
+func TestMaxClosures(t *testing.T) {
+	const SCRIPT = `
+	function decl() {}
+	var expr = function() {};
+	var o = {
+		m() {}
+	};
+	[1, 2, 3].forEach(v => v);
+	[1, 2, 3].map(function(v) { return () => v; });
+	`
+	vm := New()
+	if _, err := vm.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	if n := vm.LastRunStats().Closures; n != 8 {
+		t.Fatalf("Unexpected Closures: %d", n)
+	}
+
+	vm.SetMaxClosures(5)
+	v, err := vm.RunString(`
+	var created = 0;
+	try {
+		for (var i = 0; i < 10; i++) {
+			[i].forEach(function() {});
+			created++;
+		}
+	} catch (e) {
+		if (!(e instanceof RangeError)) {
+			throw e;
+		}
+	}
+	created;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 5 {
+		t.Fatalf("Unexpected number of closures created: %v", v)
+	}
+
+	// the limit is per run
+	if _, err := vm.RunString("(function() {})()"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssertFunctionContext(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	function loop() {
+		try {
+			for (;;) {}
+		} catch (e) {
+			// must not be reached
+		}
+	}
+	function add(a, b) {
+		return a + b;
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loop, _ := AssertFunctionContext(vm.Get("loop"))
+	add, _ := AssertFunctionContext(vm.Get("add"))
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := loop(ctx, nil)
+		if _, ok := err.(*InterruptedError); !ok || !errors.Is(err, gocontext.DeadlineExceeded) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		v, err := vm.RunString("add(1, 2)")
+		if err != nil || v.ToInteger() != 3 {
+			t.Fatal(v, err)
+		}
+	})
+
+	t.Run("done before call", func(t *testing.T) {
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		cancel()
+		_, err := add(ctx, nil, vm.ToValue(1), vm.ToValue(2))
+		if !errors.Is(err, gocontext.Canceled) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Minute)
+		defer cancel()
+		v, err := add(ctx, nil, vm.ToValue(1), vm.ToValue(2))
+		if err != nil || v.ToInteger() != 3 {
+			t.Fatal(v, err)
+		}
+		v, err = add(gocontext.Background(), nil, vm.ToValue(2), vm.ToValue(2))
+		if err != nil || v.ToInteger() != 4 {
+			t.Fatal(v, err)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		vm.Set("callWithTimeout", func(cb Value) string {
+			f, _ := AssertFunctionContext(cb)
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := f(ctx, nil)
+			if errors.Is(err, gocontext.DeadlineExceeded) {
+				return "timeout"
+			}
+			return fmt.Sprint(err)
+		})
+		v, err := vm.RunString(`
+		var res = callWithTimeout(loop);
+		res + " " + add(1, 2);
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := v.String(); s != "timeout 3" {
+			t.Fatal(s)
+		}
+	})
+
+	t.Run("outer interrupt", func(t *testing.T) {
+		vm.Set("callNoTimeout", func(cb Value) {
+			f, _ := AssertFunctionContext(cb)
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Minute)
+			defer cancel()
+			vm.Interrupt("halt")
+			_, _ = f(ctx, nil)
+		})
+		_, err := vm.RunString(`
+		callNoTimeout(loop);
+		`)
+		if err, ok := err.(*InterruptedError); !ok || err.Value() != "halt" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestOnce(t *testing.T) {
+	vm := New()
+	calls := 0
+	helpers := func() (Value, error) {
+		calls++
+		return vm.RunString(`({double: function(x) { return x * 2; }})`)
+	}
+	vm.Set("helpers", func() Value {
+		v, err := vm.Once("helpers", helpers)
+		if err != nil {
+			panic(err)
+		}
+		return v
+	})
+
+	res, err := vm.RunString(`helpers().double(21) === 42 && helpers() === helpers()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != valueTrue || calls != 1 {
+		t.Fatalf("res: %v, calls: %d", res, calls)
+	}
+
+	errCalls := 0
+	for i := 0; i < 2; i++ {
+		_, err = vm.Once("failing", func() (Value, error) {
+			errCalls++
+			return nil, errors.New("init failed")
+		})
+		if err == nil || err.Error() != "init failed" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if errCalls != 1 {
+		t.Fatalf("errCalls: %d", errCalls)
+	}
+
+	_, err = vm.Once("recursive", func() (Value, error) {
+		return vm.Once("recursive", func() (Value, error) {
+			t.Fatal("nested init called")
+			return nil, nil
+		})
+	})
+	if err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if x := recover(); x != "boom" {
+				t.Fatalf("Unexpected panic value: %v", x)
+			}
+		}()
+		vm.Once("panicking", func() (Value, error) {
+			panic("boom")
+		})
+	}()
+	v, err := vm.Once("panicking", func() (Value, error) {
+		return valueInt(1), nil
+	})
+	if err != nil || v != valueInt(1) {
+		t.Fatalf("After panic: %v, %v", v, err)
+	}
+}
+
+func TestEvalWith(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var x = "global";
+	var shadowed = "global";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	this := vm.NewObject()
+	_ = this.Set("name", "obj")
+	v, err := vm.EvalWith(`
+	var local = a + b;
+	function f() {
+		return this.name;
+	}
+	[local, shadowed, x, this.name, f.call(this), typeof arguments].join()
+	`, this, map[string]Value{
+		"a":        vm.ToValue(1),
+		"b":        vm.ToValue(2),
+		"shadowed": vm.ToValue("local"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "3,local,global,obj,obj,undefined" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+
+	v, err = vm.RunString(`[typeof a, typeof local, typeof f, shadowed].join()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "undefined,undefined,undefined,global" {
+		t.Fatalf("Globals polluted: %q", s)
+	}
+
+	t.Run("assign global", func(t *testing.T) {
+		_, err := vm.EvalWith("x = shadowed = 'changed'", nil, map[string]Value{"shadowed": nil})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x := vm.Get("x").String(); x != "changed" {
+			t.Fatal(x)
+		}
+		if s := vm.Get("shadowed").String(); s != "global" {
+			t.Fatal(s)
+		}
+	})
+
+	t.Run("exception", func(t *testing.T) {
+		_, err := vm.EvalWith("throw new Error(msg)", nil, map[string]Value{"msg": vm.ToValue("boom")})
+		if ex, ok := err.(*Exception); !ok || ex.Value().(*Object).Get("message").String() != "boom" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err = vm.EvalWith("(", nil, nil)
+		if ex, ok := err.(*Exception); !ok || ex.Value().(*Object).Get("name").String() != "SyntaxError" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		vm.Set("evalWith", func(src string, v Value) Value {
+			res, err := vm.EvalWith(src, nil, map[string]Value{"v": v})
+			if err != nil {
+				panic(err)
+			}
+			return res
+		})
+		v, err := vm.RunString(`
+		var v = 1;
+		(function() {
+			var local = 10;
+			return evalWith("v * 2", 21) + v + local;
+		})();
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.ToInteger() != 53 {
+			t.Fatal(v)
+		}
+	})
+
+	t.Run("interrupt", func(t *testing.T) {
+		vm.Interrupt("halt")
+		_, err := vm.EvalWith("for (;;) {}", nil, map[string]Value{"local": vm.ToValue(1)})
+		if _, ok := err.(*InterruptedError); !ok {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		v, err := vm.RunString("typeof local")
+		if err != nil || v.String() != "undefined" {
+			t.Fatal(v, err)
+		}
+	})
+}
+
 func TestInterrupt(t *testing.T) {
 	const SCRIPT = `
 	var i = 0;
 	for (;;) {
 		i++;
 	}
-	`
+	`
+
+	vm := New()
+	time.AfterFunc(200*time.Millisecond, func() {
+		vm.Interrupt("halt")
+	})
+
+	_, err := vm.RunString(SCRIPT)
+	if err == nil {
+		t.Fatal("Err is nil")
+	}
+}
+
+func TestInterruptQueue(t *testing.T) {
+	vm := New()
+	var log []string
+	vm.Set("queue", func() {
+		vm.InterruptFunc(func() {
+			log = append(log, "soft1")
+		})
+		vm.InterruptFunc(func() {
+			log = append(log, "soft2")
+			vm.InterruptFunc(func() {
+				log = append(log, "queued by soft2")
+			})
+		})
+		vm.Interrupt("kill")
+		vm.InterruptFunc(func() {
+			log = append(log, "after kill")
+		})
+		vm.Interrupt("kill2")
+	})
+	_, err := vm.RunString(`queue(); for (;;) {}`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() != "kill" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(log) != 2 || log[0] != "soft1" || log[1] != "soft2" {
+		t.Fatalf("Unexpected log: %v", log)
+	}
+
+	log = nil
+	vm.Set("soft", func() {
+		vm.InterruptFunc(func() {
+			log = append(log, "soft")
+		})
+	})
+	v, err := vm.RunString(`soft(); soft(); var x = 1; soft(); x + 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 2 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+	if len(log) != 3 {
+		t.Fatalf("Unexpected log (the discarded interrupts must not run): %v", log)
+	}
+
+	// a func() passed to Interrupt() is a value like any other
+	called := false
+	vm.Set("kill", func() {
+		vm.Interrupt(func() {
+			called = true
+		})
+	})
+	_, err = vm.RunString(`kill(); for (;;) {}`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() == nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if called {
+		t.Fatal("The interrupt value has been called")
+	}
+}
+
+func TestCurrentFrames(t *testing.T) {
+	vm := New()
+	if frames := vm.CurrentFrames(); frames != nil {
+		t.Fatalf("Frames of an idle runtime: %v", frames)
+	}
+	started := make(chan struct{})
+	vm.Set("started", func() {
+		close(started)
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := vm.RunString(`
+		function inner() {
+			started();
+			for (;;) {}
+		}
+		function outer() {
+			inner();
+		}
+		outer();
+		`)
+		errCh <- err
+	}()
+	<-started
+	frames := vm.CurrentFrames()
+	var names []string
+	for _, f := range frames {
+		names = append(names, f.FuncName())
+	}
+	if !reflect.DeepEqual(names, []string{"inner", "outer", "<anonymous>"}) {
+		t.Fatalf("Unexpected frames: %v", names)
+	}
+	vm.Interrupt("stop")
+	if _, ok := (<-errCh).(*InterruptedError); !ok {
+		t.Fatal("Expected an InterruptedError")
+	}
+	vm.ClearInterrupt()
+	if frames := vm.CurrentFrames(); frames != nil {
+		t.Fatalf("Frames after the run: %v", frames)
+	}
+}
+
+func TestInterruptWithFinally(t *testing.T) {
+	vm := New()
+	vm.Set("stop", func() {
+		vm.InterruptWithFinally("stop")
+	})
+	vm.Set("kill", func() {
+		vm.Interrupt("kill")
+	})
+	_, err := vm.RunString(`
+	var log = [];
+	function f() {
+		try {
+			stop();
+			log.push("not reached");
+		} finally {
+			return 1;
+		}
+	}
+	try {
+		try {
+			f();
+			log.push("after return");
+		} catch (e) {
+			log.push("catch");
+		} finally {
+			log.push("inner");
+			[1].forEach(function(v) {
+				log.push("callback " + v);
+			});
+		}
+	} finally {
+		log.push("outer");
+	}
+	log.push("not reached");
+	`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() != "stop" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if log := vm.Get("log").Export().([]interface{}); !reflect.DeepEqual(log, []interface{}{"inner", "callback 1", "outer"}) {
+		t.Fatalf("Unexpected log: %v", log)
+	}
+
+	_, err = vm.RunString(`
+	log = [];
+	try {
+		try {
+			stop();
+		} finally {
+			log.push("inner");
+			kill();
+			for (;;) {}
+		}
+	} finally {
+		log.push("outer");
+	}
+	`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() != "kill" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if log := vm.Get("log").Export().([]interface{}); !reflect.DeepEqual(log, []interface{}{"inner"}) {
+		t.Fatalf("Unexpected log: %v", log)
+	}
+
+	_, err = vm.RunString(`
+	log = [];
+	try {
+		kill();
+	} finally {
+		log.push("finally");
+	}
+	`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() != "kill" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if l := vm.Get("log").Export().([]interface{}); len(l) != 0 {
+		t.Fatalf("Unexpected log: %v", l)
+	}
+}
+
+func TestInterruptLocals(t *testing.T) {
+	const SCRIPT = `
+	var g = "global";
+	function outer(a) {
+		let captured = a + 1;
+		function inner() {
+			var x = "x";
+			eval("");
+			for (;;) {
+				captured++;
+			}
+		}
+		inner();
+	}
+	outer(1);
+	`
+
+	vm := New()
+	time.AfterFunc(50*time.Millisecond, func() {
+		vm.Interrupt("halt")
+	})
+
+	_, err := vm.RunString(SCRIPT)
+	ierr, ok := err.(*InterruptedError)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	locals := ierr.Locals()
+	if v := locals["x"]; v == nil || v.String() != "x" {
+		t.Fatalf("x: %v", v)
+	}
+	if v := locals["captured"]; v == nil || v.ToInteger() <= 2 {
+		t.Fatalf("captured: %v", v)
+	}
+	if _, exists := locals["inner"]; !exists {
+		t.Fatal("inner is missing")
+	}
+	if _, exists := locals["g"]; exists {
+		t.Fatal("Globals should not be included")
+	}
+}
+
+func TestSetYieldFunc(t *testing.T) {
+	vm := New()
+	calls, budget := 0, 5
+	vm.SetYieldFunc(func() {
+		calls++
+		if calls == budget {
+			vm.Interrupt("budget exhausted")
+		}
+	})
+	vm.SetYieldInterval(100)
+	_, err := vm.RunString(`for (;;) {}`)
+	if err, ok := err.(*InterruptedError); !ok || err.Value() != "budget exhausted" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 5 {
+		t.Fatalf("Unexpected number of calls: %d", calls)
+	}
+
+	vm.ClearInterrupt()
+	calls, budget = 0, -1
+	vm.SetYieldInterval(0)
+	_, err = vm.RunString(`for (var i = 0; i < 20000; i++) {}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls == 0 || calls > 20 {
+		t.Fatalf("Unexpected number of calls with the default interval: %d", calls)
+	}
+
+	vm.SetYieldFunc(nil)
+	calls = 0
+	if _, err = vm.RunString(`for (var i = 0; i < 20000; i++) {}`); err != nil || calls != 0 {
+		t.Fatal(err, calls)
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	t.Run("deadline", func(t *testing.T) {
+		vm := New()
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+		defer cancel()
+		vm.SetContext(ctx)
+		_, err := vm.RunString(`
+		try {
+			for (;;) {}
+		} finally {
+			for (;;) {}
+		}
+		`)
+		if _, ok := err.(*InterruptedError); !ok || !errors.Is(err, gocontext.DeadlineExceeded) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		vm := New()
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		vm.SetContext(ctx)
+		vm.SetContextPollInterval(1)
+		vm.Set("cancel", func() {
+			cancel()
+		})
+		_, err := vm.RunString(`
+		var i = 0;
+		cancel();
+		i++;
+		i++;
+		`)
+		if _, ok := err.(*InterruptedError); !ok || !errors.Is(err, gocontext.Canceled) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if i := vm.Get("i"); i.ToInteger() != 0 {
+			t.Fatalf("Unexpected i: %v", i)
+		}
+
+		vm.SetContext(nil)
+		res, err := vm.RunString(`++i`)
+		if err != nil || res.ToInteger() != 1 {
+			t.Fatalf("After removing the context: %v, %v", res, err)
+		}
+	})
+
+	t.Run("iterators are closed", func(t *testing.T) {
+		vm := New()
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		defer cancel()
+		vm.SetContext(ctx)
+		vm.SetContextPollInterval(1)
+		vm.Set("cancel", func() {
+			cancel()
+		})
+		_, err := vm.RunString(`
+		var closed = [];
+		function iter(name) {
+			var it = {};
+			it[Symbol.iterator] = function() {
+				return {
+					next: function() {
+						return {value: 1, done: false};
+					},
+					return: function() {
+						// this must not be interrupted
+						for (var i = 0; i < 10; i++) {}
+						closed.push(name);
+						return {};
+					}
+				};
+			};
+			return it;
+		}
+		for (var x of iter("outer")) {
+			for (var y of iter("inner")) {
+				cancel();
+				for (;;) {}
+			}
+		}
+		`)
+		if _, ok := err.(*InterruptedError); !ok || !errors.Is(err, gocontext.Canceled) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var closed []string
+		if err := vm.ExportTo(vm.Get("closed"), &closed); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(closed)
+		if !reflect.DeepEqual(closed, []string{"inner", "outer"}) {
+			t.Fatalf("Unexpected closed: %v", closed)
+		}
+	})
+
+	t.Run("default poll interval", func(t *testing.T) {
+		vm := New()
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		cancel()
+		vm.SetContext(ctx)
+		vm.SetContextPollInterval(0)
+		res, err := vm.RunString(`1 + 1`)
+		if err != nil || res.ToInteger() != 2 {
+			t.Fatalf("Short script: %v, %v", res, err)
+		}
+		_, err = vm.RunString(`for (var i = 0; i < 1000; i++) {}`)
+		if !errors.Is(err, gocontext.Canceled) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSetMemoryLimit(t *testing.T) {
+	vm := New()
+	vm.SetMemoryLimit(1 << 20)
+	vm.SetMemoryCheckInterval(1000)
+	_, err := vm.RunString(`
+	var small = [];
+	for (var i = 0; i < 100; i++) {
+		small.push({i: i});
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = vm.RunString(`
+	var leak = [];
+	try {
+		for (;;) {
+			leak.push("item" + leak.length);
+		}
+	} catch (e) {
+		leak = "caught";
+	}
+	`)
+	if ex, ok := err.(*MemoryLimitError); !ok || ex.Limit() != 1<<20 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if leak := vm.Get("leak"); leak.ExportType().Kind() == reflect.String {
+		t.Fatal("the error has been caught")
+	}
+
+	vm.SetMemoryLimit(0)
+	res, err := vm.RunString(`leak = null; small.length`)
+	if err != nil || res.ToInteger() != 100 {
+		t.Fatalf("After removing the limit: %v, %v", res, err)
+	}
+}
+
+func TestSetInstructionLimit(t *testing.T) {
+	prg := MustCompile("test.js", `
+	var count = 0;
+	try {
+		for (;;) {
+			count++;
+		}
+	} catch (e) {
+		count = -1;
+	} finally {
+		count = -2;
+	}
+	`, false)
+	run := func() (uint64, error) {
+		vm := New()
+		vm.SetInstructionLimit(10000)
+		_, err := vm.RunProgram(prg)
+		return uint64(vm.Get("count").ToInteger()), err
+	}
+	count, err := run()
+	if ex, ok := err.(*InstructionLimitError); !ok || ex.Limit() != 10000 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count <= 0 {
+		t.Fatalf("Unexpected count: %d", count)
+	}
+	for i := 0; i < 5; i++ {
+		count1, _ := run()
+		if count1 != count {
+			t.Fatalf("Not deterministic: %d, %d", count1, count)
+		}
+	}
 
 	vm := New()
-	time.AfterFunc(200*time.Millisecond, func() {
-		vm.Interrupt("halt")
-	})
+	vm.SetInstructionLimit(1000)
+	_, err = vm.RunString(`var x = 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left := vm.InstructionsLeft(); left == 0 || left >= 1000 {
+		t.Fatalf("Unexpected InstructionsLeft(): %d", left)
+	}
+	vm.SetInstructionLimit(1)
+	_, err = vm.RunString(`x = 2; x = 3`)
+	if _, ok := err.(*InstructionLimitError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = vm.RunString(`x = 4`)
+	if _, ok := err.(*InstructionLimitError); !ok {
+		t.Fatalf("Unexpected error after the limit is exhausted: %v", err)
+	}
+	vm.SetInstructionLimit(0)
+	res, err := vm.RunString(`x = 5`)
+	if err != nil || res.ToInteger() != 5 {
+		t.Fatalf("After removing the limit: %v, %v", res, err)
+	}
+}
 
-	_, err := vm.RunString(SCRIPT)
-	if err == nil {
-		t.Fatal("Err is nil")
+func TestOpcodeStats(t *testing.T) {
+	vm := New()
+	if stats := vm.OpcodeStats(); stats != nil {
+		t.Fatalf("Unexpected stats: %v", stats)
+	}
+	vm.EnableOpcodeStats()
+	_, err := vm.RunString(`
+	var sum = 0;
+	for (var i = 0; i < 10; i++) {
+		sum += i;
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := vm.OpcodeStats()
+	if stats["add"] != 10 {
+		t.Fatalf("Unexpected number of add instructions: %d", stats["add"])
+	}
+	if stats["inc"] != 10 {
+		t.Fatalf("Unexpected number of inc instructions: %d", stats["inc"])
+	}
+	if stats["bindGlobal"] != 1 {
+		t.Fatalf("Unexpected number of bindGlobal instructions: %d", stats["bindGlobal"])
+	}
+	stats["add"] = 0
+	if vm.OpcodeStats()["add"] != 10 {
+		t.Fatal("The stats have not been copied")
+	}
+
+	vm.EnableOpcodeStats()
+	if stats := vm.OpcodeStats(); len(stats) != 0 {
+		t.Fatalf("The stats have not been reset: %v", stats)
+	}
+	vm.DisableOpcodeStats()
+	if _, err := vm.RunString(`sum + 1`); err != nil {
+		t.Fatal(err)
+	}
+	if stats := vm.OpcodeStats(); stats != nil {
+		t.Fatalf("Unexpected stats: %v", stats)
+	}
+
+	// enabled and disabled while running
+	vm.Set("enable", vm.EnableOpcodeStats)
+	vm.Set("disable", vm.DisableOpcodeStats)
+	var inner map[string]uint64
+	vm.Set("snapshot", func() {
+		inner = vm.OpcodeStats()
+	})
+	if _, err := vm.RunString(`
+	sum = sum + 1;
+	enable();
+	sum = sum + 2 + sum;
+	snapshot();
+	disable();
+	sum = sum + 3;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if inner["add"] != 2 {
+		t.Fatalf("Unexpected stats: %v", inner)
 	}
 }
 
@@ -1471,6 +3098,88 @@ func TestCreateObject(t *testing.T) {
 	}
 }
 
+func TestRuntimeSetPrototype(t *testing.T) {
+	r := New()
+	a := r.NewObject()
+	b := r.CreateObject(a)
+	c := r.CreateObject(b)
+
+	if err := r.SetPrototype(b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.Prototype() != nil {
+		t.Fatal("Expected null prototype")
+	}
+	if err := r.SetPrototype(b, a); err != nil {
+		t.Fatal(err)
+	}
+	if b.Prototype() != a {
+		t.Fatal("Prototype has not been set")
+	}
+
+	isTypeError := func(err error) bool {
+		var ex *Exception
+		if !errors.As(err, &ex) {
+			return false
+		}
+		return ex.Value().(*Object).Prototype() == r.global.TypeErrorPrototype
+	}
+	if err := r.SetPrototype(a, c); !isTypeError(err) {
+		t.Fatalf("Cycle: %v", err)
+	}
+	if err := r.SetPrototype(a, a); !isTypeError(err) {
+		t.Fatalf("Self: %v", err)
+	}
+	if a.Prototype() != r.global.ObjectPrototype {
+		t.Fatal("Prototype has been changed")
+	}
+	if err := r.SetPrototype(a, r.ToValue(1)); !isTypeError(err) {
+		t.Fatalf("Primitive proto: %v", err)
+	}
+	if err := r.SetPrototype(_undefined, a); !isTypeError(err) {
+		t.Fatalf("undefined: %v", err)
+	}
+	if err := r.SetPrototype(r.ToValue(1), a); err != nil {
+		t.Fatalf("Primitive: %v", err)
+	}
+	nonExt, err := r.RunString("Object.preventExtensions({})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPrototype(nonExt, a); !isTypeError(err) {
+		t.Fatalf("Non-extensible: %v", err)
+	}
+
+	v, err := r.RunString(`
+	var calls = [];
+	var target = {};
+	var p = new Proxy(target, {
+		setPrototypeOf(t, proto) {
+			calls.push(proto);
+			return proto !== null;
+		}
+	});
+	p;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := v.(*Object)
+	if err := r.SetPrototype(p, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPrototype(p, nil); !isTypeError(err) {
+		t.Fatalf("Trap returned false: %v", err)
+	}
+	if n := r.Get("calls").(*Object).Get("length").ToInteger(); n != 2 {
+		t.Fatalf("Trap calls: %d", n)
+	}
+	// the cycle check does not look past a proxy in the prototype chain, its trap decides
+	if err := r.SetPrototype(a, r.CreateObject(p)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestInterruptInWrappedFunction(t *testing.T) {
 	rt := New()
 	v, err := rt.RunString(`
@@ -1556,6 +3265,52 @@ func TestInterruptInWrappedFunction2(t *testing.T) {
 	}
 }
 
+func TestPerformJobs(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var log = [];
+	function job(v) {
+		log.push(v);
+		if (v < 2) {
+			Promise.resolve(v + 1).then(job);
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := vm.toCallable(vm.Get("job"))
+	vm.enqueuePromiseJob(func() {
+		job(FunctionCall{Arguments: []Value{intToValue(0)}})
+	})
+	if err := vm.PerformJobs(); err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("log").String(); res != "0,1,2" {
+		t.Fatalf("Unexpected log: %q", res)
+	}
+
+	loop, err := vm.RunString(`(function() { for (;;) {} })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := vm.toCallable(loop)
+	vm.enqueuePromiseJob(func() {
+		vm.Interrupt("stop")
+		f(FunctionCall{})
+	})
+	vm.enqueuePromiseJob(func() {
+		t.Fatal("the queue should have been discarded")
+	})
+	err = vm.PerformJobs()
+	if ie, ok := err.(*InterruptedError); !ok || ie.Value() != "stop" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := vm.RunString("1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestInterruptInWrappedFunction2Recover(t *testing.T) {
 	rt := New()
 	// this test panics as otherwise goja will recover and possibly loop
@@ -2055,6 +3810,156 @@ func TestRuntime_SetParserOptions_Eval(t *testing.T) {
 	}
 }
 
+func TestCompileWithSourceMap(t *testing.T) {
+	// each line of the bundle maps to the beginning of the line 11 lines further in src/orig.js
+	const SOURCE_MAP = `{"version":3,"sources":["src/orig.js"],"names":[],"mappings":"AAUA;AACA;AACA;AACA"}`
+	const SCRIPT = `function f() {
+throw new Error("boom");
+}
+f();
+//# sourceMappingURL=/dev/zero`
+
+	prg, err := CompileWithSourceMap("dist/bundle.js", SCRIPT, []byte(SOURCE_MAP), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := New()
+	_, err = vm.RunProgram(prg)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if frames := ex.stack; len(frames) < 2 {
+		t.Fatalf("Unexpected stack: %v", frames)
+	} else if pos := frames[0].Position(); pos.Filename != "dist/src/orig.js" || pos.Line != 12 {
+		t.Fatalf("Unexpected position: %v", pos)
+	}
+	if s := ex.String(); !strings.Contains(s, "at f (dist/src/orig.js:12:") {
+		t.Fatalf("Unexpected stack trace: %s", s)
+	}
+
+	_, err = CompileWithSourceMap("bundle.js", SCRIPT, []byte("{"), false)
+	if err == nil {
+		t.Fatal("Expected an error for an invalid source map")
+	}
+}
+
+func TestCompileWithOptions(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`let shared = 1; var getters = [];`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prg, err := CompileWithOptions("repl.js", `
+	let x = shared + 1;
+	const y = x * 2;
+	class C {}
+	let shared = x;
+	getters.push(() => [x, y, shared]);
+	var g = 42;
+	y;
+	`, CompileOptions{SandboxGlobals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = vm.RunProgram(prg)
+		if err == nil || !strings.Contains(err.Error(), "before initialization") {
+			t.Fatalf("Expected a TDZ error, got: %v", err)
+		}
+	}
+
+	prg, err = CompileWithOptions("repl.js", `
+	let x = shared + 1;
+	const y = x * 2;
+	class C {}
+	getters.push(() => [x, y, typeof C]);
+	var g = 42;
+	y;
+	`, CompileOptions{SandboxGlobals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := vm.RunProgram(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ToInteger() != 4 {
+			t.Fatalf("Unexpected result: %v", res)
+		}
+	}
+	_, err = vm.RunProgram(testLib())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunString(`
+	assert.sameValue(typeof x, "undefined", "x");
+	assert.sameValue(typeof y, "undefined", "y");
+	assert.sameValue(typeof C, "undefined", "C");
+	assert.sameValue(g, 42, "var");
+	assert.sameValue(getters.length, 2, "getters");
+	assert(compareArray(getters[0](), [2, 4, "function"]), "closure");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunString(`let x = "global";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := vm.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToInteger() != 4 {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+	if x := vm.Get("x"); x == nil || x.String() != "global" {
+		t.Fatalf("Unexpected global x: %v", x)
+	}
+	if !marshalRoundTrip(t, prg).sandboxGlobals {
+		t.Fatal("SandboxGlobals has not been preserved by MarshalBinary")
+	}
+
+	prg, err = CompileWithOptions("strict.js", `undeclared = 1`, CompileOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunProgram(prg)
+	if ex, ok := err.(*Exception); !ok || !strings.Contains(ex.Error(), "ReferenceError") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestSandboxGlobalsInterrupted(t *testing.T) {
+	vm := New()
+	prg, err := CompileWithOptions("sandbox.js", `let a = 1; for (;;) {}`, CompileOptions{SandboxGlobals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() {
+		vm.Interrupt("halt")
+	})
+	_, err = vm.RunProgram(prg)
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vm.SetInstructionLimit(1000)
+	_, err = vm.RunProgram(prg)
+	if _, ok := err.(*InstructionLimitError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vm.SetInstructionLimit(0)
+	res, err := vm.RunString(`typeof a`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.String() != "undefined" {
+		t.Fatalf("The sandbox binding has leaked: %v", res)
+	}
+}
+
 func TestNativeCallWithRuntimeParameter(t *testing.T) {
 	vm := New()
 	vm.Set("f", func(_ FunctionCall, r *Runtime) Value {
@@ -2169,6 +4074,102 @@ func TestStackOverflowError(t *testing.T) {
 	}
 }
 
+func TestStackOverflowHandler(t *testing.T) {
+	vm := New()
+	vm.SetMaxCallStackSize(10)
+	var depths []int
+	vm.Set("depth", func() int {
+		return vm.CallStackDepth()
+	})
+	calls := 0
+	vm.SetStackOverflowHandler(func() Value {
+		calls++
+		depths = append(depths, vm.CallStackDepth())
+		return vm.ToValue("too deep")
+	})
+	v, err := vm.RunString(`
+	var d0 = depth();
+	function f(n) {
+		return n > 0 ? f(n - 1) : depth();
+	}
+	var d1 = f(2);
+	function g() {
+		g();
+	}
+	var res;
+	try {
+		g();
+	} catch (e) {
+		res = e;
+	}
+	[d0, d1, res];
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := v.Export().([]interface{})
+	if d0, d1 := res[0].(int64), res[1].(int64); d1 != d0+3 {
+		t.Fatalf("Unexpected depths: %d, %d", d0, d1)
+	}
+	if res[2] != "too deep" {
+		t.Fatalf("Unexpected result: %v", res[2])
+	}
+	if calls != 1 || depths[0] != 11 {
+		t.Fatalf("calls: %d, depths: %v", calls, depths)
+	}
+
+	// an overflow caused by the handler itself is not handled again
+	vm.SetStackOverflowHandler(func() Value {
+		calls++
+		_, err := vm.RunString("g()")
+		if _, ok := err.(*StackOverflowError); !ok {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		return nil
+	})
+	_, err = vm.RunString("g()")
+	if _, ok := err.(*StackOverflowError); !ok {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatal(calls)
+	}
+}
+
+func TestMaxStackSize(t *testing.T) {
+	vm := New()
+	vm.SetMaxStackSize(1000)
+	vm.Set("callF", func(call FunctionCall) Value {
+		f, _ := AssertFunction(call.Argument(0))
+		args := make([]Value, 2000)
+		for i := range args {
+			args[i] = vm.ToValue(i)
+		}
+		_, err := f(nil, args...)
+		if err != nil {
+			panic(err)
+		}
+		return nil
+	})
+	vm.testScriptWithTestLib(`
+	function count() {
+		return arguments.length;
+	}
+	assert.sameValue(count.apply(null, new Array(500)), 500, "within the limit");
+	var big = new Array(5000);
+	assert.throws(RangeError, function() {
+		count(...big);
+	}, "spread");
+	assert.throws(RangeError, function() {
+		count.apply(null, big);
+	}, "apply");
+	assert.throws(RangeError, function() {
+		callF(count);
+	}, "call from Go");
+	assert.sameValue(count(...new Array(500)), 500, "the stack is usable after the error");
+	`, _undefined, t)
+}
+
 func TestStacktraceLocationThrowFromCatch(t *testing.T) {
 	vm := New()
 	_, err := vm.RunString(`
@@ -2201,7 +4202,7 @@ func TestStacktraceLocationThrowFromCatch(t *testing.T) {
 	if len(stack) != 2 {
 		t.Fatalf("Unexpected stack len: %v", stack)
 	}
-	if frame := stack[0]; frame.funcName != "main" || frame.pc != 30 {
+	if frame := stack[0]; frame.funcName != "main" || frame.pc != 29 {
 		t.Fatalf("Unexpected stack frame 0: %#v", frame)
 	}
 	if frame := stack[1]; frame.funcName != "" || frame.pc != 7 {
@@ -2246,6 +4247,91 @@ func TestStacktraceLocationThrowFromGo(t *testing.T) {
 	}
 }
 
+func TestSetExceptionFormatter(t *testing.T) {
+	vm := New()
+	vm.SetExceptionFormatter(func(v Value) string {
+		if o, ok := v.(*Object); ok {
+			if code := o.Get("code"); code != nil {
+				return "AppError " + code.String()
+			}
+		}
+		return "thrown " + v.String()
+	})
+	for _, test := range []struct {
+		src, err string
+	}{
+		{`throw {code: 42}`, "AppError 42 at test.js:1:1(3)"},
+		{`throw "str"`, "thrown str at test.js:1:1(1)"},
+		{`throw new TypeError("type")`, "TypeError: type at test.js:1:7(2)"},
+		{`notDefined`, "ReferenceError: notDefined is not defined at test.js:1:1(0)"},
+	} {
+		_, err := vm.RunScript("test.js", test.src)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: unexpected error: %v", test.src, err)
+		}
+	}
+	_, err := vm.RunScript("test.js", `throw {code: 1}`)
+	if s := err.(*Exception).String(); !strings.HasPrefix(s, "AppError 1\n\tat test.js:1:1(3)") {
+		t.Fatalf("Unexpected String(): %q", s)
+	}
+
+	vm.SetExceptionFormatter(nil)
+	_, err = vm.RunScript("test.js", `throw {code: 42}`)
+	if err == nil || err.Error() != "[object Object] at test.js:1:1(3)" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestExceptionFrames(t *testing.T) {
+	vm := New()
+	vm.Set("f", func() {
+		panic(vm.NewTypeError("test"))
+	})
+	_, err := vm.RunScript("test.js", `function main() {
+	return callee();
+}
+var callee = function() {
+	f();
+}
+main();
+`)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	frames := ex.Frames()
+	if len(frames) != 4 {
+		t.Fatalf("Unexpected number of frames: %d", len(frames))
+	}
+	if frames[0].SrcName() != "<native>" {
+		t.Fatalf("Unexpected frame 0 source: %q", frames[0].SrcName())
+	}
+	type frame struct {
+		funcName     string
+		line, column int
+	}
+	for i, exp := range []frame{
+		{"callee", 5, 2},
+		{"main", 2, 15},
+		{"<anonymous>", 7, 5},
+	} {
+		f := frames[i+1]
+		if f.SrcName() != "test.js" || f.FuncName() != exp.funcName {
+			t.Fatalf("Unexpected frame %d: %s %s", i+1, f.SrcName(), f.FuncName())
+		}
+		if p := f.Position(); p.Filename != "test.js" || p.Line != exp.line || p.Column != exp.column {
+			t.Fatalf("Unexpected frame %d position: %v", i+1, p)
+		}
+	}
+	frames[1] = StackFrame{}
+	if ex.Frames()[1].FuncName() != "callee" {
+		t.Fatal("Frames() does not return a copy")
+	}
+	if (*Exception)(nil).Frames() != nil {
+		t.Fatal("nil Exception has frames")
+	}
+}
+
 func TestStrToInt64(t *testing.T) {
 	if _, ok := strToInt64(""); ok {
 		t.Fatal("<empty>")
@@ -2490,6 +4576,44 @@ func TestErrorStack(t *testing.T) {
 	testScript(SCRIPT, _undefined, t)
 }
 
+func TestErrorCause(t *testing.T) {
+	const SCRIPT = `
+	const cause = new TypeError("inner");
+	const err = new Error("outer", { cause });
+	assert.sameValue(err.cause, cause, "cause");
+	const desc = Object.getOwnPropertyDescriptor(err, "cause");
+	assert(!desc.enumerable && desc.writable && desc.configurable, "attributes");
+	assert.sameValue(err.stack, "Error\n\tat test.js:3:14(10)\n", "stack");
+
+	assert(!new Error("x").hasOwnProperty("cause"), "no options");
+	assert(!new Error("x", {}).hasOwnProperty("cause"), "no cause");
+	assert(!new Error("x", "cause").hasOwnProperty("cause"), "primitive options");
+	assert(new Error("x", { cause: undefined }).hasOwnProperty("cause"), "undefined cause");
+	assert.sameValue(new RangeError("x", Object.create({ cause: 1 })).cause, 1, "inherited");
+	assert.sameValue(new AggregateError([], "x", { cause: 2 }).cause, 2, "AggregateError");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+
+	vm := New()
+	goErr := errors.New("go error")
+	vm.Set("f", func() error { return goErr })
+	_, err := vm.RunString(`
+	try {
+		f();
+	} catch (e) {
+		throw new Error("wrapped", { cause: e });
+	}
+	`)
+	var ex *Exception
+	if !errors.As(err, &ex) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cause := ex.Value().(*Object).Get("cause").(*Object)
+	if v := cause.Get("value").Export(); v != goErr {
+		t.Fatalf("cause: %v", v)
+	}
+}
+
 func TestErrorFormatSymbols(t *testing.T) {
 	vm := New()
 	vm.Set("a", func() (Value, error) { return nil, errors.New("something %s %f") })
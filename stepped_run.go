@@ -0,0 +1,217 @@
+package goja
+
+import (
+	"errors"
+)
+
+// SteppedRun is a Program execution started by Runtime.RunSteps() which can be paused after a number of
+// instructions and continued later. While paused, the state of the execution (the current position, the stack
+// and the call stack) is kept in the SteppedRun and the Runtime can be used for other tasks (including other
+// SteppedRuns).
+type SteppedRun struct {
+	r   *Runtime
+	prg *Program
+
+	started, paused, done bool
+	result                Value
+
+	ctx       context
+	stack     []Value
+	callStack []context
+	iterStack []iterStackItem
+	refStack  []ref
+	tryStack  []tryFrame
+}
+
+var (
+	errSteppedRunBusy = errors.New("RunSteps() cannot be called while the Runtime is running")
+	errSteppedRunDone = errors.New("the run has already completed")
+)
+
+// RunSteps starts executing the Program and pauses after approximately n instructions. It returns a SteppedRun
+// which can be used to continue the execution (see SteppedRun.RunSteps()). If the Program completes or throws
+// within the budget, the returned SteppedRun is done and the error is returned as in RunProgram().
+//
+// Only the script itself (including the functions called from it) can be paused. A callback invoked by native
+// code (e.g. the function passed to Array.prototype.forEach()) always runs to completion, consuming the budget,
+// so the actual number of executed instructions may exceed n.
+//
+// RunSteps may only be called when the Runtime is not running, i.e. not from within a native function.
+// The promise jobs are run when the execution completes, not when it's paused.
+func (r *Runtime) RunSteps(p *Program, n uint64) (*SteppedRun, error) {
+	s := &SteppedRun{
+		r:   r,
+		prg: p,
+	}
+	err := s.RunSteps(n)
+	if err == errSteppedRunBusy {
+		return nil, err
+	}
+	return s, err
+}
+
+// RunSteps continues the execution for approximately n more instructions (see Runtime.RunSteps() for details).
+// Returns an error if the execution throws or is interrupted, in which case the run is done, or if it has
+// already completed.
+func (s *SteppedRun) RunSteps(n uint64) (err error) {
+	if s.done {
+		return errSteppedRunDone
+	}
+	r := s.r
+	vm := r.vm
+	if vm.prg != nil || len(vm.callStack) > 0 {
+		return errSteppedRunBusy
+	}
+	defer func() {
+		vm.stepping = false
+		vm.stepTop = false
+		vm.scheduleTick()
+		if x := recover(); x != nil {
+			if ex, ok := x.(*uncatchableException); ok {
+				err = ex.err
+				s.finish(nil)
+				r.leaveAbrupt()
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	if !s.started {
+		s.started = true
+		r.resetRunStats()
+	}
+	if n > 1<<62 {
+		n = 1 << 62
+	}
+	vm.stepping = true
+	vm.stepEnd = vm.ticks + n
+	vm.scheduleTick()
+	vm.paused = false
+	// The state is restored inside try() so that the vm is left idle if an exception is thrown. All try
+	// statements in the restored tryStack belong to this run and must handle the exceptions, hence runFrom(0).
+	ex := vm.try(func() {
+		s.restore()
+		vm.stepTop = true
+		vm.runFrom(0, nil)
+	})
+	vm.stepping = false
+	vm.scheduleTick()
+	if ex != nil {
+		err = ex
+		s.finish(nil)
+		r.leave()
+		return
+	}
+	if vm.paused {
+		vm.paused = false
+		s.save()
+		return
+	}
+	s.finish(vm.result)
+	r.leave()
+	return
+}
+
+// Done returns true if the execution has completed, either normally or with an error.
+func (s *SteppedRun) Done() bool {
+	return s.done
+}
+
+// Result returns the completion value of the Program if it has completed normally, or nil otherwise.
+func (s *SteppedRun) Result() Value {
+	return s.result
+}
+
+func (s *SteppedRun) finish(result Value) {
+	s.done = true
+	s.paused = false
+	s.result = result
+	s.ctx = context{}
+	s.stack = nil
+	s.callStack = nil
+	s.iterStack = nil
+	s.refStack = nil
+	s.tryStack = nil
+	vm := s.r.vm
+	vm.callStack = vm.callStack[:0]
+	vm.iterStack = vm.iterStack[:0]
+	vm.refStack = vm.refStack[:0]
+	vm.tryStack = vm.tryStack[:0]
+	vm.stack = nil
+	vm.sp = 0
+	vm.prg = nil
+	vm.funcName = ""
+	vm.stash = &s.r.global.stash
+	s.r.dynPropCounts = nil
+}
+
+// save moves the state of the paused execution from the vm into s and leaves the vm idle.
+func (s *SteppedRun) save() {
+	vm := s.r.vm
+	s.paused = true
+	vm.saveCtx(&s.ctx)
+	s.stack = append([]Value(nil), vm.stack[:vm.sp]...)
+	s.callStack = append([]context(nil), vm.callStack...)
+	s.iterStack = append([]iterStackItem(nil), vm.iterStack...)
+	s.refStack = append([]ref(nil), vm.refStack...)
+	s.tryStack = append([]tryFrame(nil), vm.tryStack...)
+
+	for i := range vm.callStack {
+		vm.callStack[i] = context{}
+	}
+	vm.callStack = vm.callStack[:0]
+	for i := range vm.iterStack {
+		vm.iterStack[i] = iterStackItem{}
+	}
+	vm.iterStack = vm.iterStack[:0]
+	for i := range vm.refStack {
+		vm.refStack[i] = nil
+	}
+	vm.refStack = vm.refStack[:0]
+	for i := range vm.tryStack {
+		vm.tryStack[i] = tryFrame{}
+	}
+	vm.tryStack = vm.tryStack[:0]
+	vm.stack = nil
+	vm.sp = 0
+	vm.sb = 0
+	vm.args = 0
+	vm.prg = nil
+	vm.funcName = ""
+	vm.privEnv = nil
+	vm.newTarget = nil
+	vm.result = nil
+	vm.stash = &s.r.global.stash
+}
+
+// restore moves the state saved by save() back into the vm, or prepares the vm to run the Program if it has not
+// been started yet.
+func (s *SteppedRun) restore() {
+	vm := s.r.vm
+	if !s.paused {
+		vm.stash = &s.r.global.stash
+		if s.prg.sandboxGlobals {
+			vm.stash = &stash{
+				outer: &s.r.global.stash,
+			}
+		}
+		vm.prg = s.prg
+		vm.pc = 0
+		vm.result = _undefined
+		return
+	}
+	vm.restoreCtx(&s.ctx)
+	vm.stack = append(vm.stack[:0], s.stack...)
+	vm.sp = len(s.stack)
+	vm.callStack = append(vm.callStack[:0], s.callStack...)
+	vm.iterStack = append(vm.iterStack[:0], s.iterStack...)
+	vm.refStack = append(vm.refStack[:0], s.refStack...)
+	vm.tryStack = append(vm.tryStack[:0], s.tryStack...)
+	s.paused = false
+	s.ctx = context{}
+	s.stack = nil
+	s.callStack = nil
+	s.iterStack = nil
+	s.refStack = nil
+	s.tryStack = nil
+}
@@ -0,0 +1,152 @@
+package goja
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunSteps(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function inner(i) {
+		try {
+			if (i === 7) {
+				throw new Error("seven");
+			}
+			return i * 2;
+		} catch (e) {
+			return -1;
+		} finally {
+			log.push(i);
+		}
+	}
+	var sum = 0;
+	for (var i = 0; i < 10; i++) {
+		sum += inner(i);
+	}
+	sum;
+	`
+	r := New()
+	prg := MustCompile("test.js", SCRIPT, false)
+	s, err := r.RunSteps(prg, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pauses := 0
+	for !s.Done() {
+		pauses++
+		// the Runtime can be used while the run is paused
+		v, err := r.RunString("log.length")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := v.ToInteger(); l > 10 {
+			t.Fatalf("log.length: %d", l)
+		}
+		if err := s.RunSteps(10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pauses < 10 {
+		t.Fatalf("pauses: %d", pauses)
+	}
+	if res := s.Result(); res == nil || res.ToInteger() != 2*(45-7)-1 {
+		t.Fatalf("Result: %v", res)
+	}
+	if err := s.RunSteps(10); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if len(r.vm.callStack) != 0 || len(r.vm.tryStack) != 0 || r.vm.prg != nil {
+		t.Fatal("vm is not idle")
+	}
+	v, err := r.RunString("log.join()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "0,1,2,3,4,5,6,7,8,9" {
+		t.Fatalf("log: %s", s)
+	}
+}
+
+func TestRunStepsThrow(t *testing.T) {
+	r := New()
+	prg := MustCompile("test.js", `
+	var i = 0;
+	while (i < 100) {
+		i++;
+	}
+	throw new Error("done: " + i);
+	`, false)
+	s, err := r.RunSteps(prg, 5)
+	for err == nil && !s.Done() {
+		err = s.RunSteps(5)
+	}
+	var ex *Exception
+	if !errors.As(err, &ex) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if msg := ex.Value().(*Object).Get("message").String(); msg != "done: 100" {
+		t.Fatal(msg)
+	}
+	if !s.Done() || s.Result() != nil {
+		t.Fatal("Expected the run to be done with no result")
+	}
+}
+
+func TestRunStepsInterrupt(t *testing.T) {
+	r := New()
+	prg := MustCompile("test.js", "for (;;) {}", false)
+	s, err := r.RunSteps(prg, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Interrupt("stop")
+	err = s.RunSteps(100)
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !s.Done() {
+		t.Fatal("Expected the run to be done")
+	}
+	if _, err := r.RunString("1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunStepsWhileRunning(t *testing.T) {
+	r := New()
+	prg := MustCompile("test.js", "1", false)
+	var err error
+	r.Set("f", func() {
+		_, err = r.RunSteps(prg, 10)
+	})
+	if _, err := r.RunString("f()"); err != nil {
+		t.Fatal(err)
+	}
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestRunStepsNativeCallback(t *testing.T) {
+	r := New()
+	prg := MustCompile("test.js", `
+	var count = 0;
+	[1, 2, 3].forEach(function(v) {
+		for (var i = 0; i < 10; i++) {
+			count += v;
+		}
+	});
+	count;
+	`, false)
+	s, err := r.RunSteps(prg, 3)
+	for err == nil && !s.Done() {
+		err = s.RunSteps(3)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := s.Result(); res.ToInteger() != 60 {
+		t.Fatalf("Result: %v", res)
+	}
+}
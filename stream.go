@@ -0,0 +1,176 @@
+package goja
+
+import "io"
+
+const (
+	defaultStreamReadSize = 4096
+	// the maximum size accepted by read(), the buffer is allocated up front so it must be bounded
+	maxStreamReadSize = 16 << 20
+
+	maxConsecutiveEmptyReads = 100
+)
+
+// NewReader creates a JavaScript object that wraps the specified io.Reader. The object has a single method,
+// read([size]), that reads up to size (4096 if omitted, at most 16 MiB, a RangeError is thrown otherwise) bytes
+// from the reader and returns them as a Uint8Array, or null if the end of the stream has been reached. Errors
+// returned by the reader (other than io.EOF) are thrown as GoError.
+//
+// The call to read() blocks the script until the underlying Read() returns. If the run can be stopped while
+// waiting, i.e. if the context set with SetContext() (or passed to AssertFunctionContext()) can be cancelled or
+// SetInterruptibleReads() has been enabled, Read() is called from a separate goroutine and once the context is
+// done or the execution is interrupted (see Interrupt()), the script is stopped with an *InterruptedError straight
+// away. The Read() call is not cancelled (io.Reader does not support that), the data it returns is kept and
+// returned by the subsequent calls to read(), no more than their size at a time.
+func (r *Runtime) NewReader(rd io.Reader) *Object {
+	var pendingErr error
+	// the data read but not returned yet
+	var buffered []byte
+	// the Read() call the script has stopped waiting for, if any
+	var pending *streamRead
+	readBuf := func(size int) ([]byte, error) {
+		buf := make([]byte, size)
+		if !r.vm.canStopWaiting() {
+			n, err := rd.Read(buf)
+			return buf[:n], err
+		}
+		pending = startStreamRead(rd, buf)
+		r.vm.wait(pending.done)
+		sr := pending
+		pending = nil
+		return sr.buf[:sr.n], sr.err
+	}
+	read := func(call FunctionCall) Value {
+		size := defaultStreamReadSize
+		if arg := call.Argument(0); arg != _undefined {
+			s := arg.ToInteger()
+			if s < 0 || s > maxStreamReadSize {
+				panic(r.newError(r.global.RangeError, "Invalid read size: %s", arg.String()))
+			}
+			size = int(s)
+		}
+		if pending != nil {
+			r.vm.wait(pending.done)
+			buffered, pendingErr = pending.buf[:pending.n], pending.err
+			pending = nil
+		}
+		if len(buffered) > 0 {
+			n := size
+			if n > len(buffered) {
+				n = len(buffered)
+			}
+			buf := buffered[:n:n]
+			buffered = buffered[n:]
+			return r.newUint8ArrayFromBytes(buf)
+		}
+		if pendingErr != nil {
+			err := pendingErr
+			pendingErr = nil
+			if err == io.EOF {
+				return _null
+			}
+			panic(r.NewGoError(err))
+		}
+		if size == 0 {
+			return r.newUint8ArrayFromBytes([]byte{})
+		}
+		for i := 0; ; i++ {
+			buf, err := readBuf(size)
+			if len(buf) > 0 {
+				// the error, if any, is reported by the next call
+				pendingErr = err
+				return r.newUint8ArrayFromBytes(buf)
+			}
+			if err == io.EOF {
+				return _null
+			}
+			if err != nil {
+				panic(r.NewGoError(err))
+			}
+			if i >= maxConsecutiveEmptyReads {
+				panic(r.NewGoError(io.ErrNoProgress))
+			}
+		}
+	}
+
+	o := r.NewObject()
+	o.self._putProp("read", r.newNativeFunc(read, nil, "read", nil, 1), true, false, true)
+	return o
+}
+
+// SetInterruptibleReads makes the read() method of the objects created by NewReader() handle the interrupts
+// (see Interrupt(), InterruptFunc() and CurrentFrames()) while waiting for the data, even if no context is set.
+// This requires calling Read() from a separate goroutine, so it's only worth enabling if the reads can block
+// for a long time (e.g. when reading from a network connection).
+// This method (as the rest of the Set* methods) is not safe for concurrent use and may only be called
+// from the vm goroutine or when the vm is not running.
+func (r *Runtime) SetInterruptibleReads(enable bool) {
+	r.vm.interruptibleReads = enable
+}
+
+// streamRead is a Read() call made by a separate goroutine, so that the vm goroutine can stop waiting for it.
+type streamRead struct {
+	buf  []byte
+	n    int
+	err  error
+	done chan struct{}
+}
+
+func startStreamRead(rd io.Reader, buf []byte) *streamRead {
+	sr := &streamRead{
+		buf:  buf,
+		done: make(chan struct{}),
+	}
+	go func() {
+		sr.n, sr.err = rd.Read(sr.buf)
+		close(sr.done)
+	}()
+	return sr
+}
+
+// NewWriter creates a JavaScript object that wraps the specified io.Writer. The object has a single method,
+// write(data), that writes data to the writer and returns the number of bytes written. The data can be
+// a string (which is written in UTF-8), an ArrayBuffer, a TypedArray or a DataView. Errors returned by
+// the writer are thrown as GoError.
+func (r *Runtime) NewWriter(w io.Writer) *Object {
+	write := func(call FunctionCall) Value {
+		b := r.streamBytes(call.Argument(0))
+		n, err := w.Write(b)
+		if err == nil && n < len(b) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+		return intToValue(int64(n))
+	}
+
+	o := r.NewObject()
+	o.self._putProp("write", r.newNativeFunc(write, nil, "write", nil, 1), true, false, true)
+	return o
+}
+
+func (r *Runtime) newUint8ArrayFromBytes(data []byte) Value {
+	buf := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+	buf.data = data
+	return r.typedArrayCreate(r.global.Uint8Array, buf.val).val
+}
+
+func (r *Runtime) streamBytes(v Value) []byte {
+	if s, ok := v.(valueString); ok {
+		return []byte(s.String())
+	}
+	if o, ok := v.(*Object); ok {
+		switch obj := o.self.(type) {
+		case *arrayBufferObject:
+			obj.ensureNotDetached(true)
+			return obj.data
+		case *typedArrayObject:
+			obj.viewedArrayBuf.ensureNotDetached(true)
+			return obj.viewedArrayBuf.data[obj.offset*obj.elemSize : (obj.offset+obj.length)*obj.elemSize]
+		case *dataViewObject:
+			obj.viewedArrayBuf.ensureNotDetached(true)
+			return obj.viewedArrayBuf.data[obj.byteOffset : obj.byteOffset+obj.byteLen]
+		}
+	}
+	panic(r.NewTypeError("data must be a string, an ArrayBuffer, a TypedArray or a DataView"))
+}
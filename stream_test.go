@@ -0,0 +1,186 @@
+package goja
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	vm := New()
+	vm.Set("reader", vm.NewReader(iotest.HalfReader(strings.NewReader("Hello, world!"))))
+	vm.Set("failing", vm.NewReader(iotest.DataErrReader(iotest.ErrReader(errors.New("boom")))))
+	vm.Set("partial", vm.NewReader(iotest.TimeoutReader(strings.NewReader("abcd"))))
+	_, err := vm.RunString(TESTLIB + `
+	var chunks = [], chunk;
+	while ((chunk = reader.read(4)) !== null) {
+		assert(chunk instanceof Uint8Array, "chunk type");
+		assert(chunk.length > 0 && chunk.length <= 4, "chunk length");
+		chunks.push(String.fromCharCode.apply(null, chunk));
+	}
+	assert.sameValue(chunks.join(""), "Hello, world!");
+	assert.sameValue(reader.read(), null, "after EOF");
+	assert.throws(RangeError, function() {
+		reader.read(-1);
+	});
+	assert.throws(RangeError, function() {
+		reader.read(2 ** 40);
+	});
+	assert.throws(RangeError, function() {
+		reader.read(16 * 1024 * 1024 + 1);
+	});
+	assert.sameValue(reader.read(0).length, 0, "empty read");
+
+	assert.throws(GoError, function() {
+		failing.read();
+	});
+
+	assert.sameValue(partial.read(2).length, 2);
+	assert.throws(GoError, function() {
+		partial.read(2);
+	}, "timeout");
+	assert.sameValue(partial.read(2).length, 2, "after timeout");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderInterrupt(t *testing.T) {
+	vm := New()
+	pr, pw := io.Pipe()
+	vm.Set("reader", vm.NewReader(pr))
+	vm.SetInterruptibleReads(true)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		vm.Interrupt("stop")
+	}()
+	_, err := vm.RunString(`reader.read()`)
+	if ierr, ok := err.(*InterruptedError); !ok || ierr.Value() != "stop" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vm.ClearInterrupt()
+
+	// the data of the abandoned Read() is returned by the next calls, no more than their size at a time
+	go pw.Write([]byte("abc"))
+	v, err := vm.RunString(`
+	var chunks = [String.fromCharCode.apply(null, reader.read(1)), String.fromCharCode.apply(null, reader.read(4))];
+	chunks.join(",");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "a,bc" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+}
+
+type stackCheckReader struct {
+	t *testing.T
+	r io.Reader
+}
+
+func (s stackCheckReader) Read(p []byte) (int, error) {
+	buf := make([]byte, 64*1024)
+	if stack := string(buf[:runtime.Stack(buf, false)]); strings.Contains(stack, "startStreamRead") {
+		s.t.Error("Read() has been called from a separate goroutine")
+	}
+	return s.r.Read(p)
+}
+
+func TestReaderSameGoroutine(t *testing.T) {
+	vm := New()
+	vm.Set("reader", vm.NewReader(stackCheckReader{t: t, r: strings.NewReader("abc")}))
+	v, err := vm.RunString(`reader.read().length`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 3 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
+func TestReaderContext(t *testing.T) {
+	vm := New()
+	pr, _ := io.Pipe()
+	vm.Set("reader", vm.NewReader(pr))
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	vm.SetContext(ctx)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err := vm.RunString(`
+	try {
+		reader.read();
+	} catch (e) {
+		// not reached, the interruption cannot be caught
+	}
+	`)
+	if ierr, ok := err.(*InterruptedError); !ok || !errors.Is(ierr, gocontext.Canceled) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestReaderInterruptFunc(t *testing.T) {
+	vm := New()
+	pr, pw := io.Pipe()
+	vm.Set("reader", vm.NewReader(pr))
+	vm.SetInterruptibleReads(true)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		frames := vm.CurrentFrames()
+		if len(frames) == 0 {
+			t.Error("No frames")
+		}
+		pw.Write([]byte("x"))
+	}()
+	v, err := vm.RunString(`reader.read().length`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 1 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestWriter(t *testing.T) {
+	vm := New()
+	var buf bytes.Buffer
+	vm.Set("writer", vm.NewWriter(&buf))
+	vm.Set("short", vm.NewWriter(shortWriter{}))
+	_, err := vm.RunString(TESTLIB + `
+	assert.sameValue(writer.write("abc,"), 4);
+	assert.sameValue(writer.write("é,"), 3, "UTF-8");
+	var u8 = new Uint8Array([0x30, 0x31, 0x32, 0x33, 0x34]);
+	writer.write(u8.subarray(1, 3));
+	writer.write(",");
+	writer.write(new DataView(u8.buffer, 3));
+	writer.write(",");
+	writer.write(new Uint8Array([0x35]).buffer);
+	assert.throws(TypeError, function() {
+		writer.write(42);
+	});
+	assert.throws(GoError, function() {
+		short.write("abcd");
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "abc,é,12,34,5" {
+		t.Fatalf("Unexpected output: %q", s)
+	}
+}
@@ -24,6 +24,7 @@ var (
 	stringString      valueString = asciiString("string")
 	stringSymbol      valueString = asciiString("symbol")
 	stringNumber      valueString = asciiString("number")
+	stringBigInt      valueString = asciiString("bigint")
 	stringNaN         valueString = asciiString("NaN")
 	stringInfinity                = asciiString("Infinity")
 	stringNegInfinity             = asciiString("-Infinity")
@@ -88,6 +88,10 @@ func (s asciiString) _toFloat() (float64, error) {
 		var f float64
 		return -f, nil
 	}
+	if strings.IndexByte(ss, '_') >= 0 {
+		// strconv accepts Go-style digit separators, StringNumericLiteral does not
+		return 0, strconv.ErrSyntax
+	}
 	f, err := strconv.ParseFloat(ss, 64)
 	if isRangeErr(err) {
 		err = nil
@@ -182,6 +186,10 @@ func (s asciiString) SameAs(other Value) bool {
 	return s.StrictEquals(other)
 }
 
+func (s asciiString) SameValueZero(other Value) bool {
+	return s.SameAs(other)
+}
+
 func (s asciiString) Equals(other Value) bool {
 	if s.StrictEquals(other) {
 		return true
@@ -205,6 +213,10 @@ func (s asciiString) Equals(other Value) bool {
 		return false
 	}
 
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(s)
+	}
+
 	if o, ok := other.(*Object); ok {
 		return s.Equals(o.toPrimitive())
 	}
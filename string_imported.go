@@ -95,6 +95,10 @@ func (i *importedString) SameAs(other Value) bool {
 	return i.StrictEquals(other)
 }
 
+func (i *importedString) SameValueZero(other Value) bool {
+	return i.SameAs(other)
+}
+
 func (i *importedString) Equals(other Value) bool {
 	if i.StrictEquals(other) {
 		return true
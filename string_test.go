@@ -149,6 +149,62 @@ func TestImportedString(t *testing.T) {
 	}
 }
 
+func TestMixedConcat(t *testing.T) {
+	vm := New()
+	fragments := []string{"a", "\U0001F600", "bc", "\u00e9", "\U0001F4A9", "", "xyz"}
+	vm.Set("fragments", fragments)
+
+	var expected strings.Builder
+	for i := 0; i < 100; i++ {
+		expected.WriteString(fragments[i%len(fragments)])
+	}
+	expectedStr := expected.String()
+	expectedLen := int64(len(utf16.Encode([]rune(expectedStr))))
+
+	for _, script := range []string{
+		`var s = ""; for (var i = 0; i < 100; i++) { s += fragments[i % fragments.length]; } s`,
+		"var s = ''; for (var i = 0; i < 100; i++) { s = `${s}${fragments[i % fragments.length]}`; } s",
+		"var s = ''; for (var i = 0; i < 100; i += 2) { s = `${s}${fragments[i % fragments.length]}${fragments[(i + 1) % fragments.length]}`; } s",
+	} {
+		res, err := vm.RunString(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := res.String(); s != expectedStr {
+			t.Fatalf("%s: unexpected result: %q", script, s)
+		}
+		l, err := vm.RunString("s.length")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := l.ToInteger(); l != expectedLen {
+			t.Fatalf("%s: unexpected length: %d, expected: %d", script, l, expectedLen)
+		}
+	}
+
+	const SCRIPT = `
+	var hi = "\uD83D", lo = "\uDE00";
+	var joined = hi + lo, tmpl = ` + "`${hi}${lo}`" + `;
+	assert.sameValue(joined, "\u{1F600}", "joined surrogates");
+	assert.sameValue(tmpl, "\u{1F600}", "joined surrogates (template)");
+	assert.sameValue([...tmpl].length, 1, "code points");
+	assert.sameValue(tmpl.codePointAt(0), 0x1F600, "codePointAt");
+
+	var s = "a\u{1F600}b\u00e9c";
+	assert.sameValue(s.length, 6, "length");
+	assert.sameValue(s.charCodeAt(1), 0xD83D, "charCodeAt(1)");
+	assert.sameValue(s.charCodeAt(3), 0x62, "charCodeAt(3)");
+	assert.sameValue(s.indexOf("b\u00e9"), 3, "indexOf");
+
+	var ascii = "\u00e9ab".slice(1) + "cd";
+	assert.sameValue(ascii, "abcd", "ascii result");
+	assert.sameValue(ascii.length, 4, "ascii length");
+	var empty = "";
+	assert.sameValue(` + "`${\"\u00e9ab\".slice(1)}${empty}cd`" + `, "abcd", "ascii result (template)");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
 func BenchmarkASCIIConcat(b *testing.B) {
 	vm := New()
 
@@ -362,6 +362,10 @@ func (s unicodeString) SameAs(other Value) bool {
 	return s.StrictEquals(other)
 }
 
+func (s unicodeString) SameValueZero(other Value) bool {
+	return s.SameAs(other)
+}
+
 func (s unicodeString) Equals(other Value) bool {
 	if s.StrictEquals(other) {
 		return true
@@ -226,17 +226,6 @@ var (
 		"test/language/literals/string/S7.8.4_A4.3_T2.js":             true,
 		"test/language/literals/string/S7.8.4_A4.3_T1.js":             true,
 
-		// integer separators
-		"test/language/expressions/object/cpn-obj-lit-computed-property-name-from-integer-separators.js":                  true,
-		"test/language/expressions/class/cpn-class-expr-accessors-computed-property-name-from-integer-separators.js":      true,
-		"test/language/statements/class/cpn-class-decl-fields-computed-property-name-from-integer-separators.js":          true,
-		"test/language/statements/class/cpn-class-decl-computed-property-name-from-integer-separators.js":                 true,
-		"test/language/statements/class/cpn-class-decl-accessors-computed-property-name-from-integer-separators.js":       true,
-		"test/language/statements/class/cpn-class-decl-fields-methods-computed-property-name-from-integer-separators.js":  true,
-		"test/language/expressions/class/cpn-class-expr-fields-computed-property-name-from-integer-separators.js":         true,
-		"test/language/expressions/class/cpn-class-expr-computed-property-name-from-integer-separators.js":                true,
-		"test/language/expressions/class/cpn-class-expr-fields-methods-computed-property-name-from-integer-separators.js": true,
-
 		// BigInt
 		"test/built-ins/Object/seal/seal-biguint64array.js": true,
 		"test/built-ins/Object/seal/seal-bigint64array.js":  true,
@@ -257,12 +246,6 @@ var (
 	}
 
 	featuresBlackList = []string{
-		"async-iteration",
-		"Symbol.asyncIterator",
-		"async-functions",
-		"BigInt",
-		"generators",
-		"String.prototype.replaceAll",
 		"resizable-arraybuffer",
 		"array-find-from-last",
 		"regexp-named-groups",
@@ -274,13 +257,11 @@ var (
 		"Temporal",
 		"import-assertions",
 		"dynamic-import",
-		"logical-assignment-operators",
 		"import.meta",
 		"Atomics",
 		"Atomics.waitAsync",
 		"FinalizationRegistry",
 		"WeakRef",
-		"numeric-separator-literal",
 		"Object.fromEntries",
 		"Object.hasOwn",
 		"__getter__",
@@ -307,60 +288,19 @@ func init() {
 		"test/language/identifiers/start-unicode-14.",
 		"test/language/identifiers/part-unicode-14.",
 
-		// async
-		"test/language/eval-code/direct/async-",
-		"test/language/expressions/async-",
-		"test/language/expressions/await/",
-		"test/language/statements/async-function/",
-		"test/built-ins/Async",
-		"test/language/statements/class/elements/private-static-async-",
-		"test/language/statements/class/elements/wrapped-in-sc-rs-static-async-",
-		"test/language/expressions/class/elements/wrapped-in-sc-rs-static-async-",
-		"test/language/statements/class/elements/after-same-line-static-method-rs-static-async-",
-		"test/language/expressions/class/elements/after-same-line-static-method-rs-static-async-",
-		"test/language/statements/class/elements/after-same-line-method-rs-static-async-",
-		"test/language/expressions/class/elements/after-same-line-method-rs-static-async-",
-		"test/language/statements/class/elements/new-sc-line-method-rs-static-async-",
-		"test/language/expressions/class/elements/new-sc-line-method-rs-static-async-",
-		"test/language/statements/class/elements/new-no-sc-line-method-rs-static-async-",
-		"test/language/expressions/class/elements/new-no-sc-line-method-rs-static-async-",
-		"test/language/statements/class/elements/same-line-method-rs-static-async-",
-		"test/language/expressions/class/elements/same-line-method-rs-static-async-",
-		"test/language/statements/class/elements/regular-definitions-rs-static-async-",
-		"test/language/expressions/class/elements/regular-definitions-rs-static-async-",
-		"test/language/statements/class/elements/multiple-stacked-definitions-rs-static-async-",
-		"test/language/expressions/class/elements/multiple-stacked-definitions-rs-static-async-",
-		"test/language/statements/class/elements/multiple-definitions-rs-static-async-",
-		"test/language/expressions/class/elements/multiple-definitions-rs-static-async-",
-
-		// generators
-		"test/language/eval-code/direct/gen-",
-		"test/built-ins/GeneratorFunction/",
-		"test/built-ins/Function/prototype/toString/generator-",
-		"test/language/statements/class/elements/private-static-generator-",
-		"test/language/statements/class/subclass/builtin-objects/GeneratorFunction/",
-		"test/language/statements/class/elements/wrapped-in-sc-rs-static-generator-",
-		"test/language/expressions/class/elements/wrapped-in-sc-rs-static-generator-",
-		"test/language/statements/class/elements/after-same-line-method-rs-static-generator-",
-		"test/language/expressions/class/elements/after-same-line-method-rs-static-generator-",
-		"test/language/statements/class/elements/after-same-line-static-method-rs-static-generator-",
-		"test/language/expressions/class/elements/after-same-line-static-method-rs-static-generator-",
-		"test/language/statements/class/elements/new-sc-line-method-rs-static-generator-",
-		"test/language/expressions/class/elements/new-sc-line-method-rs-static-generator-",
-		"test/language/statements/class/elements/new-no-sc-line-method-rs-static-generator-",
-		"test/language/expressions/class/elements/new-no-sc-line-method-rs-static-generator-",
-		"test/language/statements/class/elements/same-line-method-rs-static-generator-",
-		"test/language/expressions/class/elements/same-line-method-rs-static-generator-",
-		"test/language/statements/class/elements/regular-definitions-rs-static-generator-",
-		"test/language/expressions/class/elements/regular-definitions-rs-static-generator-",
-		"test/language/statements/class/elements/multiple-stacked-definitions-rs-static-generator-",
-		"test/language/expressions/class/elements/multiple-stacked-definitions-rs-static-generator-",
-		"test/language/statements/class/elements/multiple-definitions-rs-static-generator-",
-		"test/language/expressions/class/elements/multiple-definitions-rs-static-generator-",
+		// async generators
+		"test/built-ins/AsyncGenerator",
+		"test/built-ins/AsyncFromSyncIteratorPrototype/",
+		"test/built-ins/AsyncIteratorPrototype/",
 
-		// BigInt
+		// BigInt typed arrays and DataView methods
 		"test/built-ins/TypedArrayConstructors/BigUint64Array/",
 		"test/built-ins/TypedArrayConstructors/BigInt64Array/",
+		"test/built-ins/TypedArrayConstructors/ctors-bigint/",
+		"test/built-ins/DataView/prototype/getBigInt64/",
+		"test/built-ins/DataView/prototype/getBigUint64/",
+		"test/built-ins/DataView/prototype/setBigInt64/",
+		"test/built-ins/DataView/prototype/setBigUint64/",
 
 		// restricted unicode regexp syntax
 		"test/language/literals/regexp/u-",
@@ -547,6 +487,10 @@ func (ctx *tc39TestCtx) runTC39Test(name, src string, meta *tc39Meta, t testing.
 					t.Skip("Test threw IgnorableTestError")
 				}
 			}
+			if early && strings.Contains(err.Error(), "Async generators are not supported") {
+				// FIXME async generators
+				t.Skip("Async generators are not supported")
+			}
 			t.Fatalf("%s: %v", name, err)
 		} else {
 			if (meta.Negative.Phase == "early" || meta.Negative.Phase == "parse") && !early || meta.Negative.Phase == "runtime" && early {
@@ -621,6 +565,10 @@ func (ctx *tc39TestCtx) runTC39File(name string, t testing.TB) {
 	if skipPrefixes.Match(name) {
 		t.Skip("Excluded")
 	}
+	if strings.HasPrefix(name, "test/built-ins/TypedArray") && strings.Contains(name, "/BigInt/") {
+		// FIXME BigInt typed arrays
+		t.Skip("BigInt typed arrays are not supported")
+	}
 	p := path.Join(ctx.base, name)
 	meta, src, err := parseTC39File(p)
 	if err != nil {
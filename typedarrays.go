@@ -116,6 +116,30 @@ func (r *Runtime) NewArrayBuffer(data []byte) ArrayBuffer {
 	}
 }
 
+// Transfer moves the contents of the ArrayBuffer buf into a new ArrayBuffer created in this Runtime, similar to
+// the transfer list of structuredClone(). The backing []byte is handed over without copying and buf becomes
+// detached, so any subsequent attempt to use it (or the views on it) results in a TypeError.
+// The source ArrayBuffer may belong to a different Runtime (which is the main use case, e.g. passing large binary
+// data to a worker), however that Runtime must not be running at the time of the call.
+// Returns a TypeError if buf is not an ArrayBuffer or if it is detached.
+func (r *Runtime) Transfer(buf Value) (res ArrayBuffer, err error) {
+	err = r.try(func() {
+		var src *arrayBufferObject
+		if o, ok := buf.(*Object); ok {
+			src, _ = o.self.(*arrayBufferObject)
+		}
+		if src == nil {
+			panic(r.NewTypeError("Value is not an ArrayBuffer"))
+		}
+		if src.detached {
+			panic(r.NewTypeError("ArrayBuffer is detached"))
+		}
+		res = r.NewArrayBuffer(src.data)
+		src.detach()
+	})
+	return
+}
+
 func (a *uint8Array) get(idx int) Value {
 	return intToValue(int64((*a)[idx]))
 }
@@ -340,3 +340,49 @@ func TestTypedArrayGetInvalidIndex(t *testing.T) {
 	`
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
+
+func TestArrayBufferTransfer(t *testing.T) {
+	src := New()
+	v, err := src.RunString(`
+	var u8 = new Uint8Array(1024);
+	for (var i = 0; i < u8.length; i++) {
+		u8[i] = i & 0xff;
+	}
+	u8.buffer;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := v.Export().(ArrayBuffer).Bytes()
+
+	dst := New()
+	buf, err := dst.Transfer(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := buf.Bytes(); len(b) != 1024 || &b[0] != &data[0] {
+		t.Fatal("Expected the backing []byte to be moved")
+	}
+	dst.Set("buf", buf)
+	dst.testScriptWithTestLib(`
+	var u8 = new Uint8Array(buf);
+	assert.sameValue(buf.byteLength, 1024, "byteLength");
+	assert.sameValue(u8[1], 1, "u8[1]");
+	assert.sameValue(u8[257], 1, "u8[257]");
+	`, _undefined, t)
+
+	src.testScriptWithTestLib(`
+	assert.sameValue(u8.buffer.byteLength, 0, "byteLength");
+	assert.sameValue(u8[0], undefined, "u8[0]");
+	assert.throws(TypeError, function() {
+		u8.fill(0);
+	});
+	`, _undefined, t)
+
+	if _, err := dst.Transfer(v); err == nil {
+		t.Fatal("Expected an error for a detached ArrayBuffer")
+	}
+	if _, err := dst.Transfer(src.ToValue(1)); err == nil {
+		t.Fatal("Expected an error for a non-ArrayBuffer")
+	}
+}
@@ -53,7 +53,38 @@ var (
 	reflectTypeFunc   = reflect.TypeOf((func(FunctionCall) Value)(nil))
 )
 
-var intCache [256]Value
+// The range of integers for which the boxed Values are preallocated by intToValue() (see SetIntCacheRange()).
+// Converting an integer outside of this range into a Value requires an allocation.
+var (
+	intCacheMin int64 = -128
+	intCacheMax int64 = 1023
+	intCache    []Value
+)
+
+// SetIntCacheRange sets the range of integers for which the Values are preallocated, so that converting them
+// into Values (array indexes and lengths, loop counters, etc.) does not allocate. The range is extended to include
+// at least -128..127. The default is -128..1023. Each cached value takes 24 bytes of memory.
+// The cache is shared by all Runtimes, so this function must be called before any of them is created (e.g. from
+// an init() function). It is not safe for concurrent use.
+func SetIntCacheRange(min, max int64) {
+	if min > -128 {
+		min = -128
+	}
+	if max < 127 {
+		max = 127
+	}
+	if max > maxInt {
+		max = maxInt
+	}
+	if min < -maxInt {
+		min = -maxInt
+	}
+	cache := make([]Value, max-min+1)
+	for i := range cache {
+		cache[i] = valueInt(int64(i) + min)
+	}
+	intCache, intCacheMin, intCacheMax = cache, min, max
+}
 
 // Value represents an ECMAScript value.
 //
@@ -63,6 +94,8 @@ var intCache [256]Value
 //
 // For any other numbers (including Infinities, NaN and negative zero) it's float64.
 //
+// For BigInt it's *big.Int (a copy, modifying it does not affect the Value).
+//
 // For string it's a string. Note that unicode strings are converted into UTF-8 with invalid code points replaced with utf8.RuneError.
 //
 // For boolean it's bool.
@@ -80,7 +113,12 @@ type Value interface {
 	ToNumber() Value
 	ToBoolean() bool
 	ToObject(*Runtime) *Object
+	// SameAs implements the SameValue algorithm (as used by Object.is()): it's the same as StrictEquals() except
+	// that NaN is equal to itself and +0 is not equal to -0.
 	SameAs(Value) bool
+	// SameValueZero implements the SameValueZero algorithm (as used by Array.prototype.includes() and Map/Set keys):
+	// it's the same as SameAs() except that +0 is equal to -0.
+	SameValueZero(Value) bool
 	Equals(Value) bool
 	StrictEquals(Value) bool
 	Export() interface{}
@@ -212,6 +250,16 @@ func (i valueInt) SameAs(other Value) bool {
 	return i == other
 }
 
+func (i valueInt) SameValueZero(other Value) bool {
+	switch o := other.(type) {
+	case valueInt:
+		return i == o
+	case valueFloat:
+		return float64(i) == float64(o)
+	}
+	return false
+}
+
 func (i valueInt) Equals(other Value) bool {
 	switch o := other.(type) {
 	case valueInt:
@@ -222,6 +270,8 @@ func (i valueInt) Equals(other Value) bool {
 		return o.ToNumber().Equals(i)
 	case valueBool:
 		return int64(i) == o.ToInteger()
+	case *valueBigInt:
+		return o.Equals(i)
 	case *Object:
 		return i.Equals(o.toPrimitive())
 	}
@@ -314,6 +364,10 @@ func (b valueBool) SameAs(other Value) bool {
 	return false
 }
 
+func (b valueBool) SameValueZero(other Value) bool {
+	return b.SameAs(other)
+}
+
 func (b valueBool) Equals(other Value) bool {
 	if o, ok := other.(valueBool); ok {
 		return b == o
@@ -399,6 +453,10 @@ func (u valueUndefined) SameAs(other Value) bool {
 	return same
 }
 
+func (u valueUndefined) SameValueZero(other Value) bool {
+	return u.SameAs(other)
+}
+
 func (u valueUndefined) StrictEquals(other Value) bool {
 	_, same := other.(valueUndefined)
 	return same
@@ -435,6 +493,10 @@ func (n valueNull) SameAs(other Value) bool {
 	return same
 }
 
+func (n valueNull) SameValueZero(other Value) bool {
+	return n.SameAs(other)
+}
+
 func (n valueNull) Equals(other Value) bool {
 	switch other.(type) {
 	case valueUndefined, valueNull:
@@ -536,6 +598,10 @@ func (p *valueProperty) SameAs(other Value) bool {
 	return false
 }
 
+func (p *valueProperty) SameValueZero(other Value) bool {
+	return p.SameAs(other)
+}
+
 func (p *valueProperty) Equals(Value) bool {
 	return false
 }
@@ -635,6 +701,16 @@ func (f valueFloat) SameAs(other Value) bool {
 	return false
 }
 
+func (f valueFloat) SameValueZero(other Value) bool {
+	switch o := other.(type) {
+	case valueFloat:
+		return f == o || math.IsNaN(float64(f)) && math.IsNaN(float64(o))
+	case valueInt:
+		return float64(f) == float64(o)
+	}
+	return false
+}
+
 func (f valueFloat) Equals(other Value) bool {
 	switch o := other.(type) {
 	case valueFloat:
@@ -643,6 +719,8 @@ func (f valueFloat) Equals(other Value) bool {
 		return float64(f) == float64(o)
 	case valueString, valueBool:
 		return float64(f) == o.ToFloat()
+	case *valueBigInt:
+		return o.Equals(f)
 	case *Object:
 		return f.Equals(o.toPrimitive())
 	}
@@ -723,13 +801,17 @@ func (o *Object) SameAs(other Value) bool {
 	return false
 }
 
+func (o *Object) SameValueZero(other Value) bool {
+	return o.SameAs(other)
+}
+
 func (o *Object) Equals(other Value) bool {
 	if other, ok := other.(*Object); ok {
 		return o == other || o.self.equal(other.self)
 	}
 
 	switch o1 := other.(type) {
-	case valueInt, valueFloat, valueString, *Symbol:
+	case valueInt, valueFloat, valueString, *Symbol, *valueBigInt:
 		return o.toPrimitive().Equals(other)
 	case valueBool:
 		return o.Equals(o1.ToNumber())
@@ -939,6 +1021,72 @@ func (o *Object) ClassName() string {
 	return o.self.className()
 }
 
+// IsArray returns true if the Object is an array, same as Array.isArray(). This includes wrapped Go slices
+// and arrays, dynamic arrays (see Runtime.NewDynamicArray()) and Proxies whose target is an array. Unlike
+// Array.isArray() it returns false (instead of throwing a TypeError) for a revoked Proxy.
+func (o *Object) IsArray() bool {
+	for {
+		if proxy, ok := o.self.(*proxyObject); ok {
+			if proxy.target == nil {
+				return false
+			}
+			o = proxy.target
+			continue
+		}
+		return o.self.className() == classArray
+	}
+}
+
+// IsDate returns true if the Object is a Date.
+func (o *Object) IsDate() bool {
+	_, ok := o.self.(*dateObject)
+	return ok
+}
+
+// IsRegExp returns true if the Object is a RegExp. Note, unlike the check performed by
+// String.prototype.startsWith() and friends, this does not take Symbol.match into account.
+func (o *Object) IsRegExp() bool {
+	_, ok := o.self.(*regexpObject)
+	return ok
+}
+
+// IsMap returns true if the Object is a Map.
+func (o *Object) IsMap() bool {
+	_, ok := o.self.(*mapObject)
+	return ok
+}
+
+// IsSet returns true if the Object is a Set.
+func (o *Object) IsSet() bool {
+	_, ok := o.self.(*setObject)
+	return ok
+}
+
+// IsPromise returns true if the Object is a Promise.
+func (o *Object) IsPromise() bool {
+	_, ok := o.self.(*Promise)
+	return ok
+}
+
+// IsTypedArray returns true if the Object is a TypedArray (e.g. a Uint8Array).
+func (o *Object) IsTypedArray() bool {
+	_, ok := o.self.(*typedArrayObject)
+	return ok
+}
+
+// IsCallable returns true if the Object is a function, i.e. if it can be called.
+// See also AssertFunction().
+func (o *Object) IsCallable() bool {
+	_, ok := o.self.assertCallable()
+	return ok
+}
+
+// IsConstructor returns true if the Object is a constructor, i.e. if it can be used with 'new'.
+// See also AssertConstructor().
+func (o *Object) IsConstructor() bool {
+	return o.self.assertConstructor() != nil
+}
+
 func (o valueUnresolved) throw() {
 	o.r.throwReferenceError(o.ref)
 }
@@ -993,6 +1141,11 @@ func (o valueUnresolved) SameAs(Value) bool {
 	return false
 }
 
+func (o valueUnresolved) SameValueZero(Value) bool {
+	o.throw()
+	return false
+}
+
 func (o valueUnresolved) Equals(Value) bool {
 	o.throw()
 	return false
@@ -1072,6 +1225,10 @@ func (s *Symbol) SameAs(other Value) bool {
 	return false
 }
 
+func (s *Symbol) SameValueZero(other Value) bool {
+	return s.SameAs(other)
+}
+
 func (s *Symbol) Equals(o Value) bool {
 	switch o := o.(type) {
 	case *Object:
@@ -1163,8 +1320,6 @@ func typeErrorResult(throw bool, args ...interface{}) {
 }
 
 func init() {
-	for i := 0; i < 256; i++ {
-		intCache[i] = valueInt(i - 128)
-	}
+	SetIntCacheRange(intCacheMin, intCacheMax)
 	_positiveZero = intToValue(0)
 }
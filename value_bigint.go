@@ -0,0 +1,320 @@
+package goja
+
+import (
+	"hash/maphash"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/dop251/goja/parser"
+	"github.com/dop251/goja/unistring"
+)
+
+// maxBigIntBits is the maximum size of a BigInt value. Operations that would produce a larger
+// value (such as 2n ** 10000000000n) throw a RangeError rather than exhausting the memory.
+const maxBigIntBits = 1 << 30
+
+// valueBigInt is an arbitrary precision integer. Instances are immutable, every arithmetic operation
+// produces a new one.
+type valueBigInt big.Int
+
+var (
+	reflectTypeBigInt = reflect.TypeOf((*big.Int)(nil))
+
+	bigInt1 = big.NewInt(1)
+)
+
+func newBigInt(i *big.Int) *valueBigInt {
+	return (*valueBigInt)(i)
+}
+
+func (i *valueBigInt) big() *big.Int {
+	return (*big.Int)(i)
+}
+
+func (i *valueBigInt) ToInteger() int64 {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (i *valueBigInt) toString() valueString {
+	return asciiString(i.big().String())
+}
+
+func (i *valueBigInt) string() unistring.String {
+	return unistring.String(i.big().String())
+}
+
+func (i *valueBigInt) ToString() Value {
+	return i.toString()
+}
+
+func (i *valueBigInt) String() string {
+	return i.big().String()
+}
+
+func (i *valueBigInt) ToFloat() float64 {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (i *valueBigInt) ToNumber() Value {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (i *valueBigInt) ToBoolean() bool {
+	return i.big().Sign() != 0
+}
+
+func (i *valueBigInt) ToObject(r *Runtime) *Object {
+	return i.baseObject(r)
+}
+
+func (i *valueBigInt) SameAs(other Value) bool {
+	if o, ok := other.(*valueBigInt); ok {
+		return i.big().Cmp(o.big()) == 0
+	}
+	return false
+}
+
+func (i *valueBigInt) SameValueZero(other Value) bool {
+	return i.SameAs(other)
+}
+
+func (i *valueBigInt) Equals(other Value) bool {
+	switch o := other.(type) {
+	case *valueBigInt:
+		return i.big().Cmp(o.big()) == 0
+	case valueInt:
+		return i.big().IsInt64() && i.big().Int64() == int64(o)
+	case valueFloat:
+		c, ok := cmpBigIntFloat(i.big(), float64(o))
+		return ok && c == 0
+	case valueString:
+		if b, ok := stringToBigInt(o); ok {
+			return i.big().Cmp(b) == 0
+		}
+		return false
+	case valueBool:
+		return i.Equals(o.ToNumber())
+	case *Object:
+		return i.Equals(o.toPrimitive())
+	}
+	return false
+}
+
+func (i *valueBigInt) StrictEquals(other Value) bool {
+	return i.SameAs(other)
+}
+
+func (i *valueBigInt) Export() interface{} {
+	return new(big.Int).Set(i.big())
+}
+
+func (i *valueBigInt) ExportType() reflect.Type {
+	return reflectTypeBigInt
+}
+
+func (i *valueBigInt) baseObject(r *Runtime) *Object {
+	return r.newPrimitiveObject(i, r.global.BigIntPrototype, classBigInt)
+}
+
+func (i *valueBigInt) hash(hasher *maphash.Hash) uint64 {
+	hasher.Reset()
+	if i.big().Sign() < 0 {
+		hasher.WriteByte('-')
+	}
+	hasher.Write(i.big().Bytes())
+	return hasher.Sum64()
+}
+
+// cmpBigIntFloat compares a BigInt with a Number mathematically. The second return value is false
+// if f is NaN.
+func cmpBigIntFloat(b *big.Int, f float64) (int, bool) {
+	if math.IsNaN(f) {
+		return 0, false
+	}
+	if math.IsInf(f, 1) {
+		return -1, true
+	}
+	if math.IsInf(f, -1) {
+		return 1, true
+	}
+	return new(big.Float).SetInt(b).Cmp(big.NewFloat(f)), true
+}
+
+// cmpBigInt compares a BigInt with a primitive value. The second return value is false if the result
+// is undefined, i.e. v is NaN or a string that cannot be converted to a BigInt.
+func cmpBigInt(b *big.Int, v Value) (int, bool) {
+	switch v := v.(type) {
+	case *valueBigInt:
+		return b.Cmp(v.big()), true
+	case valueString:
+		if o, ok := stringToBigInt(v); ok {
+			return b.Cmp(o), true
+		}
+		return 0, false
+	}
+	return cmpBigIntFloat(b, v.ToFloat())
+}
+
+// stringToBigInt implements StringToBigInt: the string may contain a decimal integer with an optional sign
+// or a hexadecimal, octal or binary integer with a prefix, surrounded by optional whitespace.
+// An empty string is 0n.
+func stringToBigInt(s valueString) (*big.Int, bool) {
+	str := strings.Trim(s.String(), parser.WhitespaceChars)
+	if str == "" {
+		return new(big.Int), true
+	}
+	base := 10
+	if len(str) > 2 && str[0] == '0' {
+		switch str[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			str = str[2:]
+			if str[0] == '+' || str[0] == '-' {
+				return nil, false
+			}
+		}
+	}
+	if strings.IndexByte(str, '_') >= 0 {
+		return nil, false
+	}
+	return new(big.Int).SetString(str, base)
+}
+
+// toNumeric implements ToNumeric: the result is either a Number or a BigInt.
+func toNumeric(v Value) Value {
+	switch v := v.(type) {
+	case valueInt, valueFloat, *valueBigInt:
+		return v
+	case *Object:
+		if b, ok := v.toPrimitiveNumber().(*valueBigInt); ok {
+			return b
+		}
+	}
+	return v.ToNumber()
+}
+
+// numericToNumber converts the result of toNumeric() to a Number (used by the Number constructor).
+// A BigInt is rounded to the nearest representable value.
+func numericToNumber(v Value) Value {
+	if b, ok := v.(*valueBigInt); ok {
+		if b.big().IsInt64() {
+			if i := b.big().Int64(); i >= -maxInt && i <= maxInt {
+				return intToValue(i)
+			}
+		}
+		f, _ := new(big.Float).SetInt(b.big()).Float64()
+		return floatToValue(f)
+	}
+	return v
+}
+
+// bigIntOperands returns the operands of a binary operation as big.Ints if both of them are BigInts.
+// If only one of them is a BigInt it throws a TypeError, as BigInts and Numbers cannot be mixed
+// in arithmetic operations. Both values must be the results of toNumeric().
+func bigIntOperands(left, right Value) (*big.Int, *big.Int, bool) {
+	lb, lok := left.(*valueBigInt)
+	rb, rok := right.(*valueBigInt)
+	if lok && rok {
+		return lb.big(), rb.big(), true
+	}
+	if lok || rok {
+		panic(typeError("Cannot mix BigInt and other types, use explicit conversions"))
+	}
+	return nil, nil, false
+}
+
+func checkBigIntSize(bits int64) {
+	if bits > maxBigIntBits {
+		panic(rangeError("Maximum BigInt size exceeded"))
+	}
+}
+
+func bigIntDiv(left, right *big.Int) *valueBigInt {
+	if right.Sign() == 0 {
+		panic(rangeError("Division by zero"))
+	}
+	return newBigInt(new(big.Int).Quo(left, right))
+}
+
+func bigIntMod(left, right *big.Int) *valueBigInt {
+	if right.Sign() == 0 {
+		panic(rangeError("Division by zero"))
+	}
+	return newBigInt(new(big.Int).Rem(left, right))
+}
+
+func bigIntExp(base, exponent *big.Int) *valueBigInt {
+	if exponent.Sign() < 0 {
+		panic(rangeError("Exponent must be non-negative"))
+	}
+	if base.CmpAbs(bigInt1) > 0 {
+		if !exponent.IsInt64() || exponent.Int64() > maxBigIntBits {
+			checkBigIntSize(maxBigIntBits + 1)
+		}
+		checkBigIntSize(int64(base.BitLen()-1) * exponent.Int64())
+	}
+	return newBigInt(new(big.Int).Exp(base, exponent, nil))
+}
+
+// bigIntShiftLeft shifts left by the specified number of bits or right if the number is negative.
+func bigIntShiftLeft(left, right *big.Int) *valueBigInt {
+	if right.Sign() < 0 {
+		return bigIntShiftRight(left, new(big.Int).Neg(right))
+	}
+	if left.Sign() == 0 {
+		return newBigInt(new(big.Int))
+	}
+	if !right.IsInt64() || right.Int64() > maxBigIntBits {
+		checkBigIntSize(maxBigIntBits + 1)
+	}
+	n := right.Int64()
+	checkBigIntSize(int64(left.BitLen()) + n)
+	return newBigInt(new(big.Int).Lsh(left, uint(n)))
+}
+
+// bigIntShiftRight performs an arithmetic (sign-preserving) right shift, or a left shift if the number
+// of bits is negative.
+func bigIntShiftRight(left, right *big.Int) *valueBigInt {
+	if right.Sign() < 0 {
+		return bigIntShiftLeft(left, new(big.Int).Neg(right))
+	}
+	if !right.IsInt64() || right.Int64() >= int64(left.BitLen()) {
+		if left.Sign() < 0 {
+			return newBigInt(big.NewInt(-1))
+		}
+		return newBigInt(new(big.Int))
+	}
+	return newBigInt(new(big.Int).Rsh(left, uint(right.Int64())))
+}
+
+func bigIntAsIntN(bits int64, b *big.Int) *big.Int {
+	if bits == 0 {
+		return new(big.Int)
+	}
+	res := bigIntAsUintN(bits, b)
+	if res.Bit(int(bits-1)) == 1 {
+		res.Sub(res, new(big.Int).Lsh(bigInt1, uint(bits)))
+	}
+	return res
+}
+
+func bigIntAsUintN(bits int64, b *big.Int) *big.Int {
+	if bits == 0 {
+		return new(big.Int)
+	}
+	if b.Sign() >= 0 && int64(b.BitLen()) <= bits {
+		return new(big.Int).Set(b)
+	}
+	checkBigIntSize(bits)
+	mask := new(big.Int).Lsh(bigInt1, uint(bits))
+	mask.Sub(mask, bigInt1)
+	return new(big.Int).And(b, mask)
+}
@@ -1,8 +1,11 @@
 package goja
 
 import (
+	gocontext "context"
 	"fmt"
 	"math"
+	"math/big"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -14,6 +17,10 @@ import (
 
 const (
 	maxInt = 1 << 53
+
+	defaultContextPollInterval = 1000
+	defaultMemoryCheckInterval = 100000
+	defaultYieldInterval       = 10000
 )
 
 type valueStack []Value
@@ -29,17 +36,34 @@ type stash struct {
 	// If this is a top-level function stash, sets the type of the function. If set, dynamic var declarations
 	// created by direct eval go here.
 	funcType funcType
+
+	// The stash has been taken from stashPool and can be returned there when its scope is left,
+	// unless it has been captured.
+	pooled bool
+	// The stash is referenced by a closure and therefore may outlive its scope. If a stash is captured,
+	// so are all of its outer stashes.
+	captured bool
+}
+
+// stashPool holds the stashes of the scopes that have been left (see vm.releaseStash()). Only the scopes
+// which are known at compile time not to be reachable by a direct eval() or a mapped arguments object
+// use it, the closures are tracked at run time (see vm.captureStash()).
+var stashPool = sync.Pool{
+	New: func() interface{} {
+		return &stash{}
+	},
 }
 
 type context struct {
-	prg       *Program
-	funcName  unistring.String // only valid when prg is nil
-	stash     *stash
-	privEnv   *privateEnv
-	newTarget Value
-	result    Value
-	pc, sb    int
-	args      int
+	prg         *Program
+	funcName    unistring.String // only valid when prg is nil
+	stash       *stash
+	privEnv     *privateEnv
+	newTarget   Value
+	result      Value
+	pc, sb      int
+	args        int
+	variadicOff int
 }
 
 type iterStackItem struct {
@@ -48,6 +72,23 @@ type iterStackItem struct {
 	iter *iteratorRecord
 }
 
+// tryFrame is created when a try statement is entered and holds the state to return to when an exception is thrown.
+type tryFrame struct {
+	// an exception (or a *generatorReturn) which is re-thrown once the 'finally' block completes
+	exception interface{}
+
+	callStackLen, iterLen, refLen uint32
+
+	sp      int32
+	stash   *stash
+	privEnv *privateEnv
+
+	// the positions of the 'catch' and 'finally' blocks, or -1 if there is no such block or it has been entered
+	catchPos, finallyPos int32
+	// where to continue after the 'finally' block completes, -1 means the next instruction
+	finallyRet int32
+}
+
 type ref interface {
 	get() Value
 	set(Value)
@@ -254,17 +295,93 @@ type vm struct {
 	callStack []context
 	iterStack []iterStackItem
 	refStack  []ref
+	tryStack  []tryFrame
 	newTarget Value
 	result    Value
+	// the position of the innermost variadic marker relative to sb (see startVariadic)
+	variadicOff int
+
+	// the generator whose frame is being executed (see generator.resume())
+	curGenerator *generator
+	// the async function resumed by a promise job and the length of the call stack at that point, the frames
+	// below it are replaced by the awaiting async functions in the stack traces (see Runtime.resumeAsync())
+	asyncResumed   *asyncFrame
+	asyncResumedAt int
 
-	maxCallStackSize int
+	maxCallStackSize     int
+	maxStackSize         int
+	stackOverflowHandler func() Value
 
 	stashAllocs int
 	halt        bool
 
-	interrupted   uint32
-	interruptVal  interface{}
-	interruptLock sync.Mutex
+	interrupted uint32
+	// the value the execution has been stopped with, it stays set until ClearInterrupt()
+	interruptVal     interface{}
+	interruptStopped bool
+	// the execution has been stopped by InterruptWithFinally() and the 'finally' blocks are being run
+	interruptUnwind bool
+	// the interrupts that have not been handled yet, see runInterrupts()
+	interruptQueue []interface{}
+	interruptLock  sync.Mutex
+	// set while the vm goroutine is blocked in wait(), receives a value when an interrupt is queued
+	interruptWake chan struct{}
+	// the number of active runFrom() calls, only accessed by the vm goroutine
+	runDepth int
+	// 1 while runDepth > 0, updated atomically so that it can be read by other goroutines
+	// (see Runtime.CurrentFrames())
+	running uint32
+
+	// the number of instructions executed so far, the periodic tasks are run by tick() once it reaches nextTick
+	ticks    uint64
+	nextTick uint64
+	// the values of ticks at which the next yield and the next context poll are due
+	nextYield   uint64
+	nextCtxPoll uint64
+
+	goCtx           gocontext.Context
+	ctxPollInterval int
+	// the number of active calls made with a context that can be cancelled (see Runtime.runWrappedContext())
+	ctxCalls int
+	// see Runtime.SetInterruptibleReads()
+	interruptibleReads bool
+	// the number of iterators being closed while unwinding, the context is not polled during that time
+	closingIters int
+
+	memLimit         uint64
+	memCheckInterval int
+	nextMemCheck     uint64
+
+	// the instruction limit is exceeded once vm.ticks reaches instrEnd
+	instrLimit uint64
+	instrEnd   uint64
+
+	// the number of executed instructions by opcode index (see opcodeIndexes()), nil unless
+	// Runtime.EnableOpcodeStats() has been called
+	opcodeStats []uint64
+	// the opcode indexes of the instructions of the programs executed while counting, statsOps is the entry
+	// of statsPrg
+	statsPrgOps map[*Program][]uint32
+	statsPrg    *Program
+	statsOps    []uint32
+
+	// a SteppedRun is being executed, its budget is exhausted once vm.ticks reaches stepEnd (all the instructions
+	// are counted, including the ones executed by nested runs)
+	stepping bool
+	stepEnd  uint64
+	// set before entering the top level run of a SteppedRun, the only one that can be paused
+	stepTop bool
+	paused  bool
+
+	yieldInterval int
+	yieldFunc     func()
+
+	profiler *profiler
+
+	debugHandler DebugHandler
+	debugBreak   uint32
+
+	propCache [propCacheSize]propCacheEntry
 }
 
 type instruction interface {
@@ -273,8 +390,8 @@ type instruction interface {
 
 func intToValue(i int64) Value {
 	if i >= -maxInt && i <= maxInt {
-		if i >= -128 && i <= 127 {
-			return intCache[i+128]
+		if i >= intCacheMin && i <= intCacheMax {
+			return intCache[i-intCacheMin]
 		}
 		return valueInt(i)
 	}
@@ -306,6 +423,9 @@ func floatToValue(f float64) (result Value) {
 }
 
 func assertInt64(v Value) (int64, bool) {
+	if i, ok := v.(valueInt); ok {
+		return int64(i), true
+	}
 	num := v.ToNumber()
 	if i, ok := num.(valueInt); ok {
 		return int64(i), true
@@ -338,6 +458,18 @@ func (s *valueStack) expand(idx int) {
 	}
 }
 
+// expandStack is like vm.stack.expand(), but it throws a RangeError if the resulting size of the stack
+// exceeds the limit set by Runtime.SetMaxStackSize().
+func (vm *vm) expandStack(idx int) {
+	if idx < len(vm.stack) {
+		return
+	}
+	if idx >= vm.maxStackSize {
+		panic(rangeError("stack overflow"))
+	}
+	vm.stack.expand(idx)
+}
+
 func stashObjHas(obj *Object, name unistring.String) bool {
 	if obj.self.hasPropertyStr(name) {
 		if unscopables, ok := obj.self.getSym(SymUnscopables, nil).(*Object); ok {
@@ -444,7 +576,8 @@ func (s *stash) createBinding(name unistring.String, deletable bool) {
 		s.names = make(map[unistring.String]uint32)
 	}
 	if _, exists := s.names[name]; !exists {
-		idx := uint32(len(s.names)) | maskVar
+		// not len(s.names) because deleted bindings keep their slots
+		idx := uint32(len(s.values)) | maskVar
 		if deletable {
 			idx |= maskDeletable
 		}
@@ -458,7 +591,7 @@ func (s *stash) createLexBinding(name unistring.String, isConst bool) {
 		s.names = make(map[unistring.String]uint32)
 	}
 	if _, exists := s.names[name]; !exists {
-		idx := uint32(len(s.names))
+		idx := uint32(len(s.values))
 		if isConst {
 			idx |= maskConst | maskStrict
 		}
@@ -478,52 +611,475 @@ func (vm *vm) newStash() {
 	vm.stashAllocs++
 }
 
+// newStashValues creates a new stash with the specified number of values. If pooled is true, the stash
+// and its values are taken from stashPool if possible.
+func (vm *vm) newStashValues(size uint32, pooled bool) *stash {
+	if !pooled {
+		vm.newStash()
+		vm.stash.values = make([]Value, size)
+		return vm.stash
+	}
+	s := stashPool.Get().(*stash)
+	if uint32(cap(s.values)) >= size {
+		s.values = s.values[:size]
+	} else {
+		s.values = make([]Value, size)
+	}
+	s.outer = vm.stash
+	s.pooled = true
+	vm.stash = s
+	vm.stashAllocs++
+	return s
+}
+
+// captureStash marks the current stash and all its outer stashes as captured, so that they are never
+// returned to the pool. It must be called whenever the current stash is stored anywhere other than the
+// call stack, e.g. in a function object.
+func (vm *vm) captureStash() *stash {
+	for s := vm.stash; s != nil && !s.captured; s = s.outer {
+		s.captured = true
+	}
+	return vm.stash
+}
+
+// releaseStash returns s to the pool if it was taken from there and has not been captured.
+func releaseStash(s *stash) {
+	if !s.pooled || s.captured {
+		return
+	}
+	values := s.values
+	for i := range values {
+		values[i] = nil
+	}
+	*s = stash{
+		values: values[:0],
+	}
+	stashPool.Put(s)
+}
+
+// releaseFuncStashes is called when a function returns. It releases the stashes created by the function,
+// i.e. everything up to the function's own closure stash, which is always captured.
+func (vm *vm) releaseFuncStashes() {
+	for s := vm.stash; s != nil && s.pooled && !s.captured; {
+		outer := s.outer
+		releaseStash(s)
+		s = outer
+	}
+}
+
 func (vm *vm) init() {
 	vm.sb = -1
 	vm.stash = &vm.r.global.stash
 	vm.maxCallStackSize = math.MaxInt32
+	vm.maxStackSize = math.MaxInt32
+	vm.ctxPollInterval = defaultContextPollInterval
+	vm.memCheckInterval = defaultMemoryCheckInterval
+	vm.yieldInterval = defaultYieldInterval
+	vm.nextYield = defaultYieldInterval
+	vm.scheduleTick()
 }
 
+// run executes the code until it halts. The exceptions are handled by the try statements entered during
+// the call (see handleThrow()), the ones that are not handled propagate as panics.
 func (vm *vm) run() {
+	vm.runFrom(len(vm.tryStack), nil)
+}
+
+// runFrom is like run(), but only the try statements entered after the first tryLen ones handle the exceptions.
+// If f is not nil, it is called before the first instruction as if it was a part of it, i.e. if it panics
+// the exception is handled in the same way.
+func (vm *vm) runFrom(tryLen int, f func()) {
+	pausable := vm.stepTop
+	vm.stepTop = false
+	if vm.runDepth == 0 {
+		atomic.StoreUint32(&vm.running, 1)
+	}
+	vm.runDepth++
+	for !vm.runUntilThrow(tryLen, f, pausable) {
+		f = nil
+	}
+	vm.leaveRun()
+}
+
+func (vm *vm) runUntilThrow(tryLen int, f func(), pausable bool) (halted bool) {
+	defer func() {
+		if x := recover(); x != nil {
+			if x = vm.handleThrow(x, tryLen); x != nil {
+				vm.leaveRun()
+				panic(x)
+			}
+		}
+	}()
+	if f != nil {
+		f()
+	}
+	vm.runLoop(pausable)
+	return true
+}
+
+// runLoop executes the instructions until the vm halts. If pausable is true and the budget set by
+// Runtime.RunSteps() is exhausted, it stops before the next instruction and sets vm.paused.
+func (vm *vm) runLoop(pausable bool) {
 	vm.halt = false
 	interrupted := false
-	ticks := 0
 	for !vm.halt {
-		if interrupted = atomic.LoadUint32(&vm.interrupted) != 0; interrupted {
+		if atomic.LoadUint32(&vm.interrupted) != 0 {
+			if interrupted = vm.runInterrupts(); interrupted {
+				break
+			}
+			if p := vm.profiler; p != nil && atomic.LoadUint32(&p.req) != 0 {
+				vm.takeProfileSample(p)
+			}
+		}
+		if vm.ticks >= vm.nextTick && vm.tick(pausable) {
 			break
 		}
+		vm.ticks++
 		vm.prg.code[vm.pc].exec(vm)
-		ticks++
-		if ticks > 10000 {
-			runtime.Gosched()
-			ticks = 0
-		}
 	}
 
 	if interrupted {
+		vm.panicInterrupted()
+	}
+}
+
+// panicInterrupted stops the execution after runInterrupts() has returned true.
+func (vm *vm) panicInterrupted() {
+	vm.interruptLock.Lock()
+	v := &InterruptedError{
+		iface: vm.interruptVal,
+	}
+	unwind := vm.interruptUnwind
+	vm.interruptLock.Unlock()
+	v.stack = vm.captureStack(nil, 0)
+	v.locals = vm.locals()
+	panic(&uncatchableException{
+		err:    v,
+		unwind: unwind,
+	})
+}
+
+// wait blocks the vm goroutine (which is executing a Go function) until done is closed. In the meantime the
+// queued interrupts are run, and if the execution is interrupted or the context set with Runtime.SetContext()
+// is done, it stops in the same way as it would before the next instruction.
+func (vm *vm) wait(done <-chan struct{}) {
+	var ctxDone <-chan struct{}
+	if vm.goCtx != nil {
+		ctxDone = vm.goCtx.Done()
+	}
+	wake := make(chan struct{}, 1)
+	vm.interruptLock.Lock()
+	vm.interruptWake = wake
+	vm.interruptLock.Unlock()
+	defer func() {
 		vm.interruptLock.Lock()
-		v := &InterruptedError{
-			iface: vm.interruptVal,
+		vm.interruptWake = nil
+		vm.interruptLock.Unlock()
+	}()
+	for {
+		if atomic.LoadUint32(&vm.interrupted) != 0 && vm.runInterrupts() {
+			vm.panicInterrupted()
+		}
+		select {
+		case <-done:
+			return
+		case <-ctxDone:
+			vm.interruptByContext()
+		case <-wake:
+		}
+	}
+}
+
+// canStopWaiting returns true if wait() may return before the awaited operation is done, i.e. if a context that
+// can be cancelled applies to the current run or the interrupts have to be handled while waiting.
+func (vm *vm) canStopWaiting() bool {
+	return vm.interruptibleReads || vm.ctxCalls > 0 || vm.goCtx != nil && vm.goCtx.Done() != nil
+}
+
+// wakeWaiter wakes up wait() after an interrupt has been queued. Must be called with interruptLock held.
+func (vm *vm) wakeWaiter() {
+	select {
+	case vm.interruptWake <- struct{}{}:
+	default:
+	}
+}
+
+// leaveRun is called when runFrom() returns or panics. If it was the outermost one and there are pending
+// interrupts, the frame requests are answered as there are no frames to capture.
+func (vm *vm) leaveRun() {
+	vm.runDepth--
+	if vm.runDepth == 0 {
+		atomic.StoreUint32(&vm.running, 0)
+		if atomic.LoadUint32(&vm.interrupted) != 0 {
+			vm.interruptLock.Lock()
+			vm.cancelFramesRequests()
+			vm.interruptLock.Unlock()
+		}
+	}
+}
+
+// cancelFramesRequests answers the pending frame requests with nil. Must be called with interruptLock held.
+func (vm *vm) cancelFramesRequests() {
+	n := 0
+	for _, v := range vm.interruptQueue {
+		if req, ok := v.(*framesRequest); ok {
+			req.res <- nil
+		} else {
+			vm.interruptQueue[n] = v
+			n++
+		}
+	}
+	for i := n; i < len(vm.interruptQueue); i++ {
+		vm.interruptQueue[i] = nil
+	}
+	vm.interruptQueue = vm.interruptQueue[:n]
+	if n == 0 && !vm.interruptStopped {
+		atomic.StoreUint32(&vm.interrupted, 0)
+	}
+}
+
+// tick is called before executing an instruction once vm.ticks reaches vm.nextTick. It runs the periodic tasks
+// that are due (checking the instruction limit and the SteppedRun budget, counting the opcodes, yielding, polling
+// the context and checking the memory limit) and schedules the next tick. Returns true if the run must be paused.
+func (vm *vm) tick(pausable bool) bool {
+	if vm.instrLimit != 0 && vm.ticks >= vm.instrEnd {
+		vm.instrLimitExceeded()
+	}
+	if vm.stepping && pausable && vm.ticks >= vm.stepEnd {
+		vm.paused = true
+		return true
+	}
+	if vm.opcodeStats != nil {
+		vm.countOpcode()
+	}
+	if vm.ticks >= vm.nextYield {
+		vm.nextYield = vm.ticks + uint64(vm.yieldInterval)
+		if vm.yieldFunc != nil {
+			vm.yieldFunc()
+		} else {
+			runtime.Gosched()
+		}
+	}
+	if vm.goCtx != nil && vm.ticks >= vm.nextCtxPoll && vm.closingIters == 0 {
+		vm.nextCtxPoll = vm.ticks + uint64(vm.ctxPollInterval)
+		select {
+		case <-vm.goCtx.Done():
+			vm.scheduleTick()
+			vm.interruptByContext()
+		default:
+		}
+	}
+	if vm.memLimit != 0 && vm.ticks >= vm.nextMemCheck {
+		vm.nextMemCheck = vm.ticks + uint64(vm.memCheckInterval)
+		vm.scheduleTick()
+		vm.checkMemLimit()
+	}
+	vm.scheduleTick()
+	return false
+}
+
+// scheduleTick sets nextTick to the earliest of the due periodic tasks. It must be called whenever the schedule
+// of any of them changes.
+func (vm *vm) scheduleTick() {
+	if vm.opcodeStats != nil {
+		// the opcodes are counted on every tick
+		vm.nextTick = vm.ticks
+		return
+	}
+	next := vm.nextYield
+	if vm.goCtx != nil && vm.nextCtxPoll < next {
+		next = vm.nextCtxPoll
+	}
+	if vm.memLimit != 0 && vm.nextMemCheck < next {
+		next = vm.nextMemCheck
+	}
+	if vm.instrLimit != 0 && vm.instrEnd < next {
+		next = vm.instrEnd
+	}
+	if vm.stepping && vm.stepEnd < next {
+		next = vm.stepEnd
+	}
+	vm.nextTick = next
+}
+
+// opcodes assigns a sequential index to each instruction type, so that the opcode stats can be kept in a slice.
+var opcodes struct {
+	sync.Mutex
+	index map[reflect.Type]uint32
+	types []reflect.Type
+}
+
+// opcodeIndexes returns the opcode indexes of the instructions in code.
+func opcodeIndexes(code []instruction) []uint32 {
+	opcodes.Lock()
+	defer opcodes.Unlock()
+	if opcodes.index == nil {
+		opcodes.index = make(map[reflect.Type]uint32)
+	}
+	res := make([]uint32, len(code))
+	for i, ins := range code {
+		t := reflect.TypeOf(ins)
+		idx, exists := opcodes.index[t]
+		if !exists {
+			idx = uint32(len(opcodes.types))
+			opcodes.index[t] = idx
+			opcodes.types = append(opcodes.types, t)
 		}
+		res[i] = idx
+	}
+	return res
+}
+
+// countOpcode counts the instruction that is about to be executed in vm.opcodeStats.
+func (vm *vm) countOpcode() {
+	if vm.prg != vm.statsPrg || len(vm.statsOps) != len(vm.prg.code) {
+		ops, exists := vm.statsPrgOps[vm.prg]
+		if !exists || len(ops) != len(vm.prg.code) {
+			ops = opcodeIndexes(vm.prg.code)
+			vm.statsPrgOps[vm.prg] = ops
+		}
+		vm.statsPrg, vm.statsOps = vm.prg, ops
+	}
+	idx := int(vm.statsOps[vm.pc])
+	if idx >= len(vm.opcodeStats) {
+		vm.opcodeStats = append(vm.opcodeStats, make([]uint64, idx+1-len(vm.opcodeStats))...)
+	}
+	vm.opcodeStats[idx]++
+}
+
+func (vm *vm) interruptByContext() {
+	v := &InterruptedError{
+		iface: vm.goCtx.Err(),
+	}
+	v.stack = vm.captureStack(nil, 0)
+	v.locals = vm.locals()
+	panic(&uncatchableException{
+		err: v,
+	})
+}
+
+func (vm *vm) instrLimitExceeded() {
+	v := &InstructionLimitError{
+		limit: vm.instrLimit,
+	}
+	v.val = asciiString("Instruction limit of " + strconv.FormatUint(vm.instrLimit, 10) + " exceeded")
+	v.stack = vm.captureStack(nil, 0)
+	panic(&uncatchableException{
+		err: v,
+	})
+}
+
+func (vm *vm) checkMemLimit() {
+	w := newHeapWalker()
+	w.maxBytes = vm.memLimit
+	vm.r.walkHeap(w)
+	if w.bytes > vm.memLimit {
+		v := &MemoryLimitError{
+			limit: vm.memLimit,
+		}
+		v.val = asciiString("Memory limit of " + strconv.FormatUint(vm.memLimit, 10) + " bytes exceeded")
 		v.stack = vm.captureStack(nil, 0)
-		vm.interruptLock.Unlock()
 		panic(&uncatchableException{
 			err: v,
 		})
 	}
 }
 
+// unwindInterrupt is queued by Runtime.InterruptWithFinally().
+type unwindInterrupt struct {
+	v interface{}
+}
+
+// interruptFunc is queued by Runtime.InterruptFunc().
+type interruptFunc func()
+
+// framesRequest is queued by Runtime.CurrentFrames(), the captured frames (or nil if the vm is not running)
+// are sent to res.
+type framesRequest struct {
+	res chan []StackFrame
+}
+
+// runInterrupts handles the queued interrupts in order. The interruptFunc ones are called and removed from the queue,
+// the first value of any other type stops the execution and the rest of the queue is discarded. Returns true
+// if the execution has to be stopped.
+// While the 'finally' blocks are run after InterruptWithFinally(), the queue is still handled, so that
+// the unwinding can be turned into a normal interruption.
+func (vm *vm) runInterrupts() bool {
+	vm.interruptLock.Lock()
+	defer vm.interruptLock.Unlock()
+	for !vm.interruptStopped || vm.interruptUnwind {
+		if len(vm.interruptQueue) == 0 {
+			if vm.interruptStopped {
+				return !vm.inUnwindFinally()
+			}
+			atomic.StoreUint32(&vm.interrupted, 0)
+			return false
+		}
+		v := vm.interruptQueue[0]
+		vm.interruptQueue[0] = nil
+		vm.interruptQueue = vm.interruptQueue[1:]
+		if f, ok := v.(interruptFunc); ok {
+			vm.interruptLock.Unlock()
+			f()
+			vm.interruptLock.Lock()
+			continue
+		}
+		if req, ok := v.(*framesRequest); ok {
+			req.res <- vm.captureStack(nil, 0)
+			continue
+		}
+		if u, ok := v.(*unwindInterrupt); ok {
+			if !vm.interruptStopped {
+				vm.interruptVal, vm.interruptStopped, vm.interruptUnwind = u.v, true, true
+				return true
+			}
+			continue
+		}
+		vm.interruptVal, vm.interruptStopped, vm.interruptUnwind = v, true, false
+		vm.clearInterruptQueue()
+	}
+	return true
+}
+
+// inUnwindFinally returns true if a 'finally' block entered because of InterruptWithFinally() is being run.
+func (vm *vm) inUnwindFinally() bool {
+	for i := len(vm.tryStack) - 1; i >= 0; i-- {
+		if ex, ok := vm.tryStack[i].exception.(*uncatchableException); ok && ex.unwind {
+			return true
+		}
+	}
+	return false
+}
+
+func (vm *vm) clearInterruptQueue() {
+	for i, v := range vm.interruptQueue {
+		if req, ok := v.(*framesRequest); ok {
+			req.res <- nil
+		}
+		vm.interruptQueue[i] = nil
+	}
+	vm.interruptQueue = vm.interruptQueue[:0]
+}
+
 func (vm *vm) Interrupt(v interface{}) {
 	vm.interruptLock.Lock()
-	vm.interruptVal = v
+	vm.interruptQueue = append(vm.interruptQueue, v)
 	atomic.StoreUint32(&vm.interrupted, 1)
+	vm.wakeWaiter()
 	vm.interruptLock.Unlock()
 }
 
 func (vm *vm) ClearInterrupt() {
+	vm.interruptLock.Lock()
+	vm.interruptVal, vm.interruptStopped, vm.interruptUnwind = nil, false, false
+	vm.clearInterruptQueue()
 	atomic.StoreUint32(&vm.interrupted, 0)
+	vm.interruptLock.Unlock()
 }
 
+// captureStack appends the frames of the call stack starting from ctxOffset to stack. It reads the state of
+// the vm without any synchronisation, so it may only be called from the vm goroutine (or when the vm is not
+// running). Other goroutines have to use Runtime.CurrentFrames() which makes the vm goroutine do it.
 func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
 	// Unroll the context stack
 	if vm.pc != -1 {
@@ -536,6 +1092,9 @@ func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
 		stack = append(stack, StackFrame{prg: vm.prg, pc: vm.pc, funcName: funcName})
 	}
 	for i := len(vm.callStack) - 1; i > ctxOffset-1; i-- {
+		if f := vm.asyncResumed; f != nil && i <= vm.asyncResumedAt {
+			return f.appendAwaiters(stack)
+		}
 		frame := &vm.callStack[i]
 		if frame.pc != -1 {
 			var funcName unistring.String
@@ -550,6 +1109,66 @@ func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
 	return stack
 }
 
+// exceptionFromValue converts a recovered panic value into an Exception. It returns nil if the value
+// must not be caught by the script (i.e. an uncatchableException or a Go panic, unless the HostPanicPolicy
+// says otherwise).
+func (vm *vm) exceptionFromValue(x interface{}) (ex *Exception) {
+	switch x1 := x.(type) {
+	case *Object:
+		ex = &Exception{
+			val: x1,
+		}
+		if er, ok := x1.self.(*errorObject); ok {
+			ex.stack = er.stack
+		}
+	case Value:
+		ex = &Exception{
+			val: x1,
+		}
+	case *Exception:
+		ex = x1
+	case *uncatchableException:
+		return nil
+	case typeError:
+		ex = &Exception{
+			val: vm.r.NewTypeError(string(x1)),
+		}
+	case referenceError:
+		ex = &Exception{
+			val: vm.r.newError(vm.r.global.ReferenceError, string(x1)),
+		}
+	case rangeError:
+		ex = &Exception{
+			val: vm.r.newError(vm.r.global.RangeError, string(x1)),
+		}
+	case syntaxError:
+		ex = &Exception{
+			val: vm.r.newError(vm.r.global.SyntaxError, string(x1)),
+		}
+	default:
+		/*
+			if vm.prg != nil {
+				vm.prg.dumpCode(log.Printf)
+			}
+			log.Print("Stack: ", string(debug.Stack()))
+			panic(fmt.Errorf("Panic at %d: %v", vm.pc, x))
+		*/
+		if vm.r.hostPanicPolicy != HostPanicThrow {
+			return nil
+		}
+		ex = &Exception{
+			val: vm.r.newHostPanicError(x),
+		}
+	}
+	if ex.stack == nil {
+		ex.stack = vm.captureStack(make([]StackFrame, 0, len(vm.callStack)+1), 0)
+	}
+	if ex.format == nil {
+		ex.format = vm.r.exceptionFormatter
+	}
+	return
+}
+
 func (vm *vm) try(f func()) (ex *Exception) {
 	var ctx context
 	vm.saveCtx(&ctx)
@@ -558,6 +1177,7 @@ func (vm *vm) try(f func()) (ex *Exception) {
 	sp := vm.sp
 	iterLen := len(vm.iterStack)
 	refLen := len(vm.refStack)
+	tryLen := len(vm.tryStack)
 
 	defer func() {
 		if x := recover(); x != nil {
@@ -567,67 +1187,31 @@ func (vm *vm) try(f func()) (ex *Exception) {
 				vm.sp = sp
 
 				// Restore other stacks
-				iterTail := vm.iterStack[iterLen:]
-				for i := range iterTail {
-					if iter := iterTail[i].iter; iter != nil {
-						_ = vm.try(func() {
-							iter.returnIter()
-						})
-					}
-					iterTail[i] = iterStackItem{}
-				}
+				vm.closeIters(vm.iterStack[iterLen:])
 				vm.iterStack = vm.iterStack[:iterLen]
 				refTail := vm.refStack[refLen:]
 				for i := range refTail {
 					refTail[i] = nil
 				}
 				vm.refStack = vm.refStack[:refLen]
+				vm.truncateTryStack(tryLen)
 			}()
-			switch x1 := x.(type) {
-			case *Object:
-				ex = &Exception{
-					val: x1,
+			ex = vm.exceptionFromValue(x)
+			if ex == nil {
+				if x1, ok := x.(*uncatchableException); ok {
+					panic(x1)
 				}
-				if er, ok := x1.self.(*errorObject); ok {
-					ex.stack = er.stack
-				}
-			case Value:
-				ex = &Exception{
-					val: x1,
-				}
-			case *Exception:
-				ex = x1
-			case *uncatchableException:
-				panic(x1)
-			case typeError:
-				ex = &Exception{
-					val: vm.r.NewTypeError(string(x1)),
-				}
-			case referenceError:
-				ex = &Exception{
-					val: vm.r.newError(vm.r.global.ReferenceError, string(x1)),
-				}
-			case rangeError:
-				ex = &Exception{
-					val: vm.r.newError(vm.r.global.RangeError, string(x1)),
-				}
-			case syntaxError:
-				ex = &Exception{
-					val: vm.r.newError(vm.r.global.SyntaxError, string(x1)),
-				}
-			default:
-				/*
-					if vm.prg != nil {
-						vm.prg.dumpCode(log.Printf)
+				if vm.r.hostPanicPolicy == HostPanicAbort {
+					err := &HostPanicError{
+						iface: x,
 					}
-					log.Print("Stack: ", string(debug.Stack()))
-					panic(fmt.Errorf("Panic at %d: %v", vm.pc, x))
-				*/
+					err.stack = vm.captureStack(nil, 0)
+					panic(&uncatchableException{
+						err: err,
+					})
+				}
 				panic(x)
 			}
-			if ex.stack == nil {
-				ex.stack = vm.captureStack(make([]StackFrame, 0, len(vm.callStack)+1), 0)
-			}
 		}
 	}()
 
@@ -635,12 +1219,130 @@ func (vm *vm) try(f func()) (ex *Exception) {
 	return
 }
 
+func (vm *vm) pushTryFrame(catchPos, finallyPos int32) {
+	vm.tryStack = append(vm.tryStack, tryFrame{
+		callStackLen: uint32(len(vm.callStack)),
+		iterLen:      uint32(len(vm.iterStack)),
+		refLen:       uint32(len(vm.refStack)),
+		sp:           int32(vm.sp),
+		stash:        vm.stash,
+		privEnv:      vm.privEnv,
+		catchPos:     catchPos,
+		finallyPos:   finallyPos,
+		finallyRet:   -1,
+	})
+}
+
+func (vm *vm) popTryFrame() {
+	l := len(vm.tryStack) - 1
+	vm.tryStack[l] = tryFrame{}
+	vm.tryStack = vm.tryStack[:l]
+}
+
+func (vm *vm) truncateTryStack(l int) {
+	tail := vm.tryStack[l:]
+	for i := range tail {
+		tail[i] = tryFrame{}
+	}
+	vm.tryStack = vm.tryStack[:l]
+}
+
+// handleThrow is called when a panic is recovered while running the code. If the panic value is an exception
+// and there is a try statement entered after the first tryLen ones that can handle it, the vm state is restored to
+// the point of that try statement, the execution continues from its 'catch' or 'finally' block and nil is
+// returned. Otherwise the value that has to be propagated as a panic is returned. A *generatorReturn and
+// an uncatchableException raised by InterruptWithFinally() are not caught, they only run the 'finally' blocks.
+func (vm *vm) handleThrow(x interface{}, tryLen int) interface{} {
+	if len(vm.tryStack) == tryLen {
+		return x
+	}
+	var ex *Exception
+	_, isReturn := x.(*generatorReturn)
+	if !isReturn {
+		if ue, ok := x.(*uncatchableException); ok && ue.unwind {
+			isReturn = true
+		} else {
+			ex = vm.exceptionFromValue(x)
+			if ex == nil {
+				vm.truncateTryStack(tryLen)
+				return x
+			}
+		}
+	}
+	for len(vm.tryStack) > tryLen {
+		tf := &vm.tryStack[len(vm.tryStack)-1]
+		if tf.finallyPos == -1 && (tf.catchPos == -1 || isReturn) {
+			vm.popTryFrame()
+			continue
+		}
+		if l := int(tf.callStackLen); l < len(vm.callStack) {
+			ctx := &vm.callStack[l]
+			vm.prg, vm.funcName, vm.newTarget, vm.result, vm.sb, vm.args =
+				ctx.prg, ctx.funcName, ctx.newTarget, ctx.result, ctx.sb, ctx.args
+			tail := vm.callStack[l:]
+			for i := range tail {
+				tail[i] = context{}
+			}
+			vm.callStack = vm.callStack[:l]
+		}
+		vm.sp = int(tf.sp)
+		vm.stash = tf.stash
+		vm.privEnv = tf.privEnv
+		if l := int(tf.iterLen); l < len(vm.iterStack) {
+			vm.closeIters(vm.iterStack[l:])
+			vm.iterStack = vm.iterStack[:l]
+		}
+		if l := int(tf.refLen); l < len(vm.refStack) {
+			tail := vm.refStack[l:]
+			for i := range tail {
+				tail[i] = nil
+			}
+			vm.refStack = vm.refStack[:l]
+		}
+		if tf.catchPos >= 0 && !isReturn {
+			vm.pc = int(tf.catchPos)
+			tf.catchPos = -1
+			vm.push(ex.val)
+		} else {
+			vm.pc = int(tf.finallyPos)
+			tf.catchPos = -1
+			tf.finallyPos = -1
+			tf.finallyRet = -1
+			if isReturn {
+				tf.exception = x
+			} else {
+				tf.exception = ex
+			}
+		}
+		return nil
+	}
+	if isReturn {
+		return x
+	}
+	return ex
+}
+
+func (vm *vm) closeIters(iters []iterStackItem) {
+	vm.closingIters++
+	defer func() {
+		vm.closingIters--
+	}()
+	for i := range iters {
+		if iter := iters[i].iter; iter != nil {
+			_ = vm.try(func() {
+				iter.returnIter()
+			})
+		}
+		iters[i] = iterStackItem{}
+	}
+}
+
 func (vm *vm) runTry() (ex *Exception) {
 	return vm.try(vm.run)
 }
 
 func (vm *vm) push(v Value) {
-	vm.stack.expand(vm.sp)
+	vm.expandStack(vm.sp)
 	vm.stack[vm.sp] = v
 	vm.sp++
 }
@@ -655,12 +1357,22 @@ func (vm *vm) peek() Value {
 }
 
 func (vm *vm) saveCtx(ctx *context) {
-	ctx.prg, ctx.stash, ctx.privEnv, ctx.newTarget, ctx.result, ctx.pc, ctx.sb, ctx.args, ctx.funcName =
-		vm.prg, vm.stash, vm.privEnv, vm.newTarget, vm.result, vm.pc, vm.sb, vm.args, vm.funcName
+	ctx.prg, ctx.stash, ctx.privEnv, ctx.newTarget, ctx.result, ctx.pc, ctx.sb, ctx.args, ctx.funcName, ctx.variadicOff =
+		vm.prg, vm.stash, vm.privEnv, vm.newTarget, vm.result, vm.pc, vm.sb, vm.args, vm.funcName, vm.variadicOff
 }
 
 func (vm *vm) pushCtx() {
 	if len(vm.callStack) > vm.maxCallStackSize {
+		if h := vm.stackOverflowHandler; h != nil {
+			// The handler is removed for the duration of the call so that an overflow it causes
+			// is not handled again.
+			vm.stackOverflowHandler = nil
+			v := h()
+			vm.stackOverflowHandler = h
+			if v != nil {
+				panic(v)
+			}
+		}
 		ex := &StackOverflowError{}
 		ex.stack = vm.captureStack(nil, 0)
 		panic(&uncatchableException{
@@ -673,8 +1385,8 @@ func (vm *vm) pushCtx() {
 }
 
 func (vm *vm) restoreCtx(ctx *context) {
-	vm.prg, vm.funcName, vm.stash, vm.privEnv, vm.newTarget, vm.result, vm.pc, vm.sb, vm.args =
-		ctx.prg, ctx.funcName, ctx.stash, ctx.privEnv, ctx.newTarget, ctx.result, ctx.pc, ctx.sb, ctx.args
+	vm.prg, vm.funcName, vm.stash, vm.privEnv, vm.newTarget, vm.result, vm.pc, vm.sb, vm.args, vm.variadicOff =
+		ctx.prg, ctx.funcName, ctx.stash, ctx.privEnv, ctx.newTarget, ctx.result, ctx.pc, ctx.sb, ctx.args, ctx.variadicOff
 }
 
 func (vm *vm) popCtx() {
@@ -989,7 +1701,7 @@ type _toNumber struct{}
 var toNumber _toNumber
 
 func (_toNumber) exec(vm *vm) {
-	vm.stack[vm.sp-1] = vm.stack[vm.sp-1].ToNumber()
+	vm.stack[vm.sp-1] = toNumeric(vm.stack[vm.sp-1])
 	vm.pc++
 }
 
@@ -1026,14 +1738,19 @@ func (_add) exec(vm *vm) {
 		if leftInt, ok := left.(valueInt); ok {
 			if rightInt, ok := right.(valueInt); ok {
 				ret = intToValue(int64(leftInt) + int64(rightInt))
-			} else {
-				ret = floatToValue(float64(leftInt) + right.ToFloat())
+				goto end
 			}
+		}
+		left, right = toNumeric(left), toNumeric(right)
+		if lb, rb, ok := bigIntOperands(left, right); ok {
+			ret = newBigInt(new(big.Int).Add(lb, rb))
 		} else {
 			ret = floatToValue(left.ToFloat() + right.ToFloat())
 		}
 	}
 
+end:
+
 	vm.stack[vm.sp-2] = ret
 	vm.sp--
 	vm.pc++
@@ -1056,6 +1773,12 @@ func (_sub) exec(vm *vm) {
 		}
 	}
 
+	left, right = toNumeric(left), toNumeric(right)
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		result = newBigInt(new(big.Int).Sub(lb, rb))
+		goto end
+	}
+
 	result = floatToValue(left.ToFloat() - right.ToFloat())
 end:
 	vm.sp--
@@ -1068,11 +1791,16 @@ type _mul struct{}
 var mul _mul
 
 func (_mul) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		result = newBigInt(new(big.Int).Mul(lb, rb))
+		goto end
+	}
+
 	if left, ok := assertInt64(left); ok {
 		if right, ok := assertInt64(right); ok {
 			if left == 0 && right == -1 || left == -1 && right == 0 {
@@ -1103,7 +1831,13 @@ var exp _exp
 
 func (_exp) exec(vm *vm) {
 	vm.sp--
-	vm.stack[vm.sp-1] = pow(vm.stack[vm.sp-1], vm.stack[vm.sp])
+	x := toNumeric(vm.stack[vm.sp-1])
+	y := toNumeric(vm.stack[vm.sp])
+	if xb, yb, ok := bigIntOperands(x, y); ok {
+		vm.stack[vm.sp-1] = bigIntExp(xb, yb)
+	} else {
+		vm.stack[vm.sp-1] = pow(x, y)
+	}
 	vm.pc++
 }
 
@@ -1112,10 +1846,19 @@ type _div struct{}
 var div _div
 
 func (_div) exec(vm *vm) {
-	left := vm.stack[vm.sp-2].ToFloat()
-	right := vm.stack[vm.sp-1].ToFloat()
+	leftNum := toNumeric(vm.stack[vm.sp-2])
+	rightNum := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
+	var left, right float64
+
+	if lb, rb, ok := bigIntOperands(leftNum, rightNum); ok {
+		result = bigIntDiv(lb, rb)
+		goto end
+	}
+
+	left = leftNum.ToFloat()
+	right = rightNum.ToFloat()
 
 	if math.IsNaN(left) || math.IsNaN(right) {
 		result = _NaN
@@ -1171,11 +1914,16 @@ type _mod struct{}
 var mod _mod
 
 func (_mod) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		result = bigIntMod(lb, rb)
+		goto end
+	}
+
 	if leftInt, ok := assertInt64(left); ok {
 		if rightInt, ok := assertInt64(right); ok {
 			if rightInt == 0 {
@@ -1204,11 +1952,13 @@ type _neg struct{}
 var neg _neg
 
 func (_neg) exec(vm *vm) {
-	operand := vm.stack[vm.sp-1]
+	operand := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
-	if i, ok := assertInt64(operand); ok {
+	if b, ok := operand.(*valueBigInt); ok {
+		result = newBigInt(new(big.Int).Neg(b.big()))
+	} else if i, ok := assertInt64(operand); ok {
 		if i == 0 {
 			result = _negativeZero
 		} else {
@@ -1242,6 +1992,11 @@ var inc _inc
 func (_inc) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 
+	if b, ok := v.(*valueBigInt); ok {
+		v = newBigInt(new(big.Int).Add(b.big(), bigInt1))
+		goto end
+	}
+
 	if i, ok := assertInt64(v); ok {
 		v = intToValue(i + 1)
 		goto end
@@ -1261,6 +2016,11 @@ var dec _dec
 func (_dec) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 
+	if b, ok := v.(*valueBigInt); ok {
+		v = newBigInt(new(big.Int).Sub(b.big(), bigInt1))
+		goto end
+	}
+
 	if i, ok := assertInt64(v); ok {
 		v = intToValue(i - 1)
 		goto end
@@ -1278,9 +2038,13 @@ type _and struct{}
 var and _and
 
 func (_and) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left & right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = newBigInt(new(big.Int).And(lb, rb))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) & toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1290,9 +2054,13 @@ type _or struct{}
 var or _or
 
 func (_or) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left | right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = newBigInt(new(big.Int).Or(lb, rb))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) | toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1302,9 +2070,13 @@ type _xor struct{}
 var xor _xor
 
 func (_xor) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left ^ right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = newBigInt(new(big.Int).Xor(lb, rb))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) ^ toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1314,8 +2086,12 @@ type _bnot struct{}
 var bnot _bnot
 
 func (_bnot) exec(vm *vm) {
-	op := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-1] = intToValue(int64(^op))
+	op := toNumeric(vm.stack[vm.sp-1])
+	if b, ok := op.(*valueBigInt); ok {
+		vm.stack[vm.sp-1] = newBigInt(new(big.Int).Not(b.big()))
+	} else {
+		vm.stack[vm.sp-1] = intToValue(int64(^toInt32(op)))
+	}
 	vm.pc++
 }
 
@@ -1324,9 +2100,13 @@ type _sal struct{}
 var sal _sal
 
 func (_sal) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left << (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = bigIntShiftLeft(lb, rb)
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) << (toUint32(right) & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1336,9 +2116,13 @@ type _sar struct{}
 var sar _sar
 
 func (_sar) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if lb, rb, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = bigIntShiftRight(lb, rb)
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) >> (toUint32(right) & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -1348,9 +2132,12 @@ type _shr struct{}
 var shr _shr
 
 func (_shr) exec(vm *vm) {
-	left := toUint32(vm.stack[vm.sp-2])
-	right := toUint32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if _, _, ok := bigIntOperands(left, right); ok {
+		panic(vm.r.NewTypeError("BigInts have no unsigned right shift, use >> instead"))
+	}
+	vm.stack[vm.sp-2] = intToValue(int64(toUint32(left) >> (toUint32(right) & 0x1F)))
 	vm.sp--
 	vm.pc++
 }
@@ -1456,7 +2243,7 @@ var setElem _setElem
 
 func (_setElem) exec(vm *vm) {
 	obj := vm.stack[vm.sp-3].ToObject(vm.r)
-	propName := toPropertyKey(vm.stack[vm.sp-2])
+	propName := toElemKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
 	obj.setOwn(propName, val, false)
@@ -1529,7 +2316,7 @@ var setElemP _setElemP
 
 func (_setElemP) exec(vm *vm) {
 	obj := vm.stack[vm.sp-3].ToObject(vm.r)
-	propName := toPropertyKey(vm.stack[vm.sp-2])
+	propName := toElemKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
 	obj.setOwn(propName, val, false)
@@ -1543,7 +2330,7 @@ type _setElemStrict struct{}
 var setElemStrict _setElemStrict
 
 func (_setElemStrict) exec(vm *vm) {
-	propName := toPropertyKey(vm.stack[vm.sp-2])
+	propName := toElemKey(vm.stack[vm.sp-2])
 	receiver := vm.stack[vm.sp-3]
 	val := vm.stack[vm.sp-1]
 	if receiverObj, ok := receiver.(*Object); ok {
@@ -1605,7 +2392,7 @@ type _setElemStrictP struct{}
 var setElemStrictP _setElemStrictP
 
 func (_setElemStrictP) exec(vm *vm) {
-	propName := toPropertyKey(vm.stack[vm.sp-2])
+	propName := toElemKey(vm.stack[vm.sp-2])
 	receiver := vm.stack[vm.sp-3]
 	val := vm.stack[vm.sp-1]
 	if receiverObj, ok := receiver.(*Object); ok {
@@ -1761,7 +2548,7 @@ type setProp unistring.String
 
 func (p setProp) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
-	vm.stack[vm.sp-2].ToObject(vm.r).self.setOwnStr(unistring.String(p), val, false)
+	vm.setOwnStrCached(vm.stack[vm.sp-2].ToObject(vm.r), unistring.String(p), val, false)
 	vm.stack[vm.sp-2] = val
 	vm.sp--
 	vm.pc++
@@ -1771,7 +2558,7 @@ type setPropP unistring.String
 
 func (p setPropP) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
-	vm.stack[vm.sp-2].ToObject(vm.r).self.setOwnStr(unistring.String(p), val, false)
+	vm.setOwnStrCached(vm.stack[vm.sp-2].ToObject(vm.r), unistring.String(p), val, false)
 	vm.sp -= 2
 	vm.pc++
 }
@@ -1783,7 +2570,7 @@ func (p setPropStrict) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
 	propName := unistring.String(p)
 	if receiverObj, ok := receiver.(*Object); ok {
-		receiverObj.self.setOwnStr(propName, val, true)
+		vm.setOwnStrCached(receiverObj, propName, val, true)
 	} else {
 		base := receiver.ToObject(vm.r)
 		base.setStr(propName, val, receiver, true)
@@ -1875,7 +2662,7 @@ func (p setPropStrictP) exec(vm *vm) {
 	val := vm.stack[vm.sp-1]
 	propName := unistring.String(p)
 	if receiverObj, ok := receiver.(*Object); ok {
-		receiverObj.self.setOwnStr(propName, val, true)
+		vm.setOwnStrCached(receiverObj, propName, val, true)
 	} else {
 		base := receiver.ToObject(vm.r)
 		base.setStr(propName, val, receiver, true)
@@ -2066,7 +2853,7 @@ func (g getProp) exec(vm *vm) {
 	if obj == nil {
 		panic(vm.r.NewTypeError("Cannot read property '%s' of undefined", g))
 	}
-	vm.stack[vm.sp-1] = nilSafe(obj.self.getStr(unistring.String(g), v))
+	vm.stack[vm.sp-1] = nilSafe(vm.getStrCached(obj, unistring.String(g), v))
 
 	vm.pc++
 }
@@ -2130,7 +2917,7 @@ var getElem _getElem
 func (_getElem) exec(vm *vm) {
 	v := vm.stack[vm.sp-2]
 	obj := v.baseObject(vm.r)
-	propName := toPropertyKey(vm.stack[vm.sp-1])
+	propName := toElemKey(vm.stack[vm.sp-1])
 	if obj == nil {
 		panic(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
 	}
@@ -2185,7 +2972,7 @@ var getElemCallee _getElemCallee
 func (_getElemCallee) exec(vm *vm) {
 	v := vm.stack[vm.sp-2]
 	obj := v.baseObject(vm.r)
-	propName := toPropertyKey(vm.stack[vm.sp-1])
+	propName := toElemKey(vm.stack[vm.sp-1])
 	if obj == nil {
 		panic(vm.r.NewTypeError("Cannot read property '%s' of undefined", propName.String()))
 	}
@@ -2249,7 +3036,7 @@ type dupLast uint32
 
 func (d dupLast) exec(vm *vm) {
 	e := vm.sp + int(d)
-	vm.stack.expand(e)
+	vm.expandStack(e)
 	copy(vm.stack[vm.sp:e], vm.stack[vm.sp-int(d):])
 	vm.sp = e
 	vm.pc++
@@ -2349,7 +3136,7 @@ type newRegexp struct {
 }
 
 func (n *newRegexp) exec(vm *vm) {
-	vm.push(vm.r.newRegExpp(n.pattern.clone(), n.src, vm.r.global.RegExpPrototype).val)
+	vm.push(vm.r.newRegExpp(vm.r.literalRegexpPattern(n), n.src, vm.r.global.RegExpPrototype).val)
 	vm.pc++
 }
 
@@ -2420,18 +3207,32 @@ func (s initStashP) exec(vm *vm) {
 	vm.sp--
 }
 
+// globalLexStash returns the stash that holds the top-level lexical declarations of the running Program. Normally
+// it's the global stash, but for a Program compiled with CompileOptions.SandboxGlobals it's the per-run stash that
+// RunProgram has placed in front of it.
+func (vm *vm) globalLexStash() *stash {
+	if vm.prg.sandboxGlobals {
+		for s := vm.stash; s != nil; s = s.outer {
+			if s.outer == &vm.r.global.stash {
+				return s
+			}
+		}
+	}
+	return &vm.r.global.stash
+}
+
 type initGlobalP unistring.String
 
 func (s initGlobalP) exec(vm *vm) {
 	vm.sp--
-	vm.r.global.stash.initByName(unistring.String(s), vm.stack[vm.sp])
+	vm.globalLexStash().initByName(unistring.String(s), vm.stack[vm.sp])
 	vm.pc++
 }
 
 type initGlobal unistring.String
 
 func (s initGlobal) exec(vm *vm) {
-	vm.r.global.stash.initByName(unistring.String(s), vm.stack[vm.sp])
+	vm.globalLexStash().initByName(unistring.String(s), vm.stack[vm.sp])
 	vm.pc++
 }
 
@@ -2527,7 +3328,7 @@ func (s resolveVar1Strict) exec(vm *vm) {
 		}
 	}
 
-	if vm.r.globalObject.self.hasPropertyStr(name) {
+	if vm.r.globalObject.self.hasPropertyStr(name) || vm.r.resolveGlobal(name) != nil {
 		ref = &objRef{
 			base:    vm.r.globalObject,
 			name:    name,
@@ -2921,7 +3722,13 @@ var getValue _getValue
 
 func (_getValue) exec(vm *vm) {
 	ref := vm.refStack[len(vm.refStack)-1]
-	if v := ref.get(); v != nil {
+	v := ref.get()
+	if v == nil {
+		if r, ok := ref.(*objRef); ok && r.binding && r.base == vm.r.globalObject {
+			v = vm.r.resolveGlobal(r.name)
+		}
+	}
+	if v != nil {
 		vm.push(v)
 	} else {
 		vm.r.throwReferenceError(ref.refname())
@@ -2971,6 +3778,18 @@ func (_initValueP) exec(vm *vm) {
 	vm.pc++
 }
 
+type _popRef struct{}
+
+// popRef discards the reference on top of the reference stack without putting a value.
+var popRef _popRef
+
+func (_popRef) exec(vm *vm) {
+	l := len(vm.refStack) - 1
+	vm.refStack[l] = nil
+	vm.refStack = vm.refStack[:l]
+	vm.pc++
+}
+
 type loadDynamic unistring.String
 
 func (n loadDynamic) exec(vm *vm) {
@@ -2984,6 +3803,9 @@ func (n loadDynamic) exec(vm *vm) {
 	}
 	if val == nil {
 		val = vm.r.globalObject.self.getStr(name, nil)
+		if val == nil {
+			val = vm.r.resolveGlobal(name)
+		}
 		if val == nil {
 			vm.r.throwReferenceError(name)
 		}
@@ -3005,6 +3827,9 @@ func (n loadDynamicRef) exec(vm *vm) {
 	}
 	if val == nil {
 		val = vm.r.globalObject.self.getStr(name, nil)
+		if val == nil {
+			val = vm.r.resolveGlobal(name)
+		}
 		if val == nil {
 			val = valueUnresolved{r: vm.r, ref: name}
 		}
@@ -3028,6 +3853,9 @@ func (n loadDynamicCallee) exec(vm *vm) {
 	}
 	if val == nil {
 		val = vm.r.globalObject.self.getStr(name, nil)
+		if val == nil {
+			val = vm.r.resolveGlobal(name)
+		}
 		if val == nil {
 			val = valueUnresolved{r: vm.r, ref: name}
 		}
@@ -3115,12 +3943,19 @@ func (_boxThis) exec(vm *vm) {
 
 var variadicMarker Value = newSymbol(asciiString("[variadic marker]"))
 
+// startVariadic begins a call with a variable number of arguments (i.e. one with a spread argument). It pushes
+// the position of the enclosing variadic marker (as the calls can be nested) followed by the marker itself
+// and records the position of the latter in vm.variadicOff, so that the number of the arguments is known
+// without scanning the stack. The position is relative to sb which is preserved when the frame is moved
+// (e.g. by a generator) and restored, together with the rest of the context, when a call returns.
 type _startVariadic struct{}
 
 var startVariadic _startVariadic
 
 func (_startVariadic) exec(vm *vm) {
+	vm.push(intToValue(int64(vm.variadicOff)))
 	vm.push(variadicMarker)
+	vm.variadicOff = vm.sp - 1 - vm.sb
 	vm.pc++
 }
 
@@ -3128,28 +3963,31 @@ type _callVariadic struct{}
 
 var callVariadic _callVariadic
 
+// countVariadicArgs returns the number of the values pushed after the innermost variadic marker.
 func (vm *vm) countVariadicArgs() int {
-	count := 0
-	for i := vm.sp - 1; i >= 0; i-- {
-		if vm.stack[i] == variadicMarker {
-			return count
-		}
-		count++
+	idx := vm.sb + vm.variadicOff
+	if debugChecks && (idx < 0 || idx >= vm.sp || vm.stack[idx] != variadicMarker) {
+		panic("Variadic marker was not found. Compiler bug.")
 	}
-	panic("Variadic marker was not found. Compiler bug.")
+	return vm.sp - 1 - idx
 }
 
 func (_callVariadic) exec(vm *vm) {
 	call(vm.countVariadicArgs() - 2).exec(vm)
 }
 
+// endVariadic removes the variadic marker and the enclosing marker's position which are below the result
+// of the call and makes the enclosing marker the current one.
 type _endVariadic struct{}
 
 var endVariadic _endVariadic
 
 func (_endVariadic) exec(vm *vm) {
-	vm.sp--
-	vm.stack[vm.sp-1] = vm.stack[vm.sp]
+	vm.variadicOff = int(vm.stack[vm.sp-3].(valueInt))
+	vm.stack[vm.sp-3] = vm.stack[vm.sp-1]
+	vm.stack[vm.sp-2] = nil
+	vm.stack[vm.sp-1] = nil
+	vm.sp -= 2
 	vm.pc++
 }
 
@@ -3178,6 +4016,16 @@ repeat:
 		vm.pc = 0
 		vm.stack[vm.sp-n-1], vm.stack[vm.sp-n-2] = vm.stack[vm.sp-n-2], vm.stack[vm.sp-n-1]
 		return
+	case *generatorFuncObject:
+		vm.pc++
+		vm.pushCtx()
+		vm.args = n
+		vm.prg = f.prg
+		vm.stash = f.stash
+		vm.privEnv = f.privEnv
+		vm.pc = 0
+		vm.stack[vm.sp-n-1], vm.stack[vm.sp-n-2] = vm.stack[vm.sp-n-2], vm.stack[vm.sp-n-1]
+		return
 	case *funcObject:
 		vm.pc++
 		vm.pushCtx()
@@ -3199,15 +4047,24 @@ repeat:
 		vm.stack[vm.sp-n-1], vm.stack[vm.sp-n-2] = nil, vm.stack[vm.sp-n-1]
 		vm.newTarget = f.newTarget
 		return
+	case *asyncFuncObject:
+		vm._asyncCall(f.Call, n)
+	case *asyncArrowFuncObject:
+		vm._asyncCall(f.Call, n)
 	case *nativeFuncObject:
 		vm._nativeCall(f, n)
 	case *boundFuncObject:
+		if f.isScriptTarget() {
+			n = vm.spliceBoundArgs(f, n)
+			obj = f.wrapped
+			goto repeat
+		}
 		vm._nativeCall(&f.nativeFuncObject, n)
 	case *proxyObject:
 		vm.pushCtx()
 		vm.prg = nil
 		vm.funcName = "proxy"
-		ret := f.apply(FunctionCall{This: vm.stack[vm.sp-n-2], Arguments: vm.stack[vm.sp-n : vm.sp]})
+		ret := f.apply(FunctionCall{This: vm.stack[vm.sp-n-2], Arguments: vm.nativeArgs(vm.sp - n)})
 		if ret == nil {
 			ret = _undefined
 		}
@@ -3223,13 +4080,85 @@ repeat:
 	}
 }
 
+// tailCall is emitted instead of call for a call in a tail position (see compiler.isTailPosition()). If the callee
+// is a script function, the current frame is replaced with the frame of the callee so that the call does not
+// increase the depth of the call stack, otherwise it works like call, followed by ret.
+type tailCall uint32
+
+func (numargs tailCall) exec(vm *vm) {
+	n := int(numargs)
+	if obj, ok := vm.stack[vm.sp-n-1].(*Object); ok {
+		switch f := obj.self.(type) {
+		case *funcObject:
+			vm.replaceFrame(n, &f.baseJsFuncObject)
+			vm.newTarget = nil
+			return
+		case *methodFuncObject:
+			vm.replaceFrame(n, &f.baseJsFuncObject)
+			vm.newTarget = nil
+			return
+		case *arrowFuncObject:
+			vm.replaceFrame(n, &f.baseJsFuncObject)
+			vm.stack[vm.sp-n-1] = nil
+			vm.newTarget = f.newTarget
+			return
+		}
+	}
+	call(numargs).exec(vm)
+}
+
+// replaceFrame moves this, the callee and n arguments from the top of the stack to the beginning of the current
+// frame, releases the frame and sets up the call as call does, but without pushing the context.
+func (vm *vm) replaceFrame(n int, f *baseJsFuncObject) {
+	base := vm.sb - 1
+	copy(vm.stack[base:], vm.stack[vm.sp-n-2:vm.sp])
+	sp := base + n + 2
+	tail := vm.stack[sp:vm.sp]
+	for i := range tail {
+		tail[i] = nil
+	}
+	vm.sp = sp
+	vm.releaseFuncStashes()
+	vm.args = n
+	vm.prg = f.prg
+	vm.stash = f.stash
+	vm.privEnv = f.privEnv
+	vm.pc = 0
+	vm.stack[base], vm.stack[base+1] = vm.stack[base+1], vm.stack[base]
+}
+
+// spliceBoundArgs replaces the callee and this with the target of the bound function and the bound this, and
+// inserts the bound arguments before the n arguments on the stack. Returns the new number of arguments.
+func (vm *vm) spliceBoundArgs(f *boundFuncObject, n int) int {
+	if l := len(f.boundArgs); l > 0 {
+		vm.expandStack(vm.sp + l)
+		copy(vm.stack[vm.sp-n+l:], vm.stack[vm.sp-n:vm.sp])
+		copy(vm.stack[vm.sp-n:], f.boundArgs)
+		vm.sp += l
+		n += l
+	}
+	vm.stack[vm.sp-n-2] = f.boundThis
+	vm.stack[vm.sp-n-1] = f.wrapped
+	return n
+}
+
+// nativeArgs returns the values on the stack starting from sp as the arguments for a call that may end up in Go
+// code. Unless Runtime.SetSafeNativeArgs() is enabled, the returned slice refers to the stack.
+func (vm *vm) nativeArgs(sp int) []Value {
+	args := vm.stack[sp:vm.sp]
+	if vm.r.safeNativeArgs {
+		return append([]Value(nil), args...)
+	}
+	return args
+}
+
 func (vm *vm) _nativeCall(f *nativeFuncObject, n int) {
 	if f.f != nil {
 		vm.pushCtx()
 		vm.prg = nil
 		vm.funcName = nilSafe(f.getStr("name", nil)).string()
 		ret := f.f(FunctionCall{
-			Arguments: vm.stack[vm.sp-n : vm.sp],
+			Arguments: vm.nativeArgs(vm.sp - n),
 			This:      vm.stack[vm.sp-n-2],
 		})
 		if ret == nil {
@@ -3244,6 +4173,17 @@ func (vm *vm) _nativeCall(f *nativeFuncObject, n int) {
 	vm.pc++
 }
 
+// _asyncCall calls an async function. Unlike the other script functions it runs in a nested run() so that
+// the resulting Promise can be set up once the function has returned or suspended at the first await.
+func (vm *vm) _asyncCall(f func(FunctionCall) Value, n int) {
+	vm.stack[vm.sp-n-2] = f(FunctionCall{
+		This:      vm.stack[vm.sp-n-2],
+		Arguments: vm.stack[vm.sp-n : vm.sp],
+	})
+	vm.sp -= n + 1
+	vm.pc++
+}
+
 func (vm *vm) clearStack() {
 	sp := vm.sp
 	stackTail := vm.stack[sp:]
@@ -3257,18 +4197,18 @@ type enterBlock struct {
 	names     map[unistring.String]uint32
 	stashSize uint32
 	stackSize uint32
+	pooled    bool
 }
 
 func (e *enterBlock) exec(vm *vm) {
 	if e.stashSize > 0 {
-		vm.newStash()
-		vm.stash.values = make([]Value, e.stashSize)
+		vm.newStashValues(e.stashSize, e.pooled)
 		if len(e.names) > 0 {
 			vm.stash.names = e.names
 		}
 	}
 	ss := int(e.stackSize)
-	vm.stack.expand(vm.sp + ss - 1)
+	vm.expandStack(vm.sp + ss - 1)
 	vv := vm.stack[vm.sp : vm.sp+ss]
 	for i := range vv {
 		vv[i] = nil
@@ -3281,18 +4221,18 @@ type enterCatchBlock struct {
 	names     map[unistring.String]uint32
 	stashSize uint32
 	stackSize uint32
+	pooled    bool
 }
 
 func (e *enterCatchBlock) exec(vm *vm) {
-	vm.newStash()
-	vm.stash.values = make([]Value, e.stashSize)
+	vm.newStashValues(e.stashSize, e.pooled)
 	if len(e.names) > 0 {
 		vm.stash.names = e.names
 	}
 	vm.sp--
 	vm.stash.values[0] = vm.stack[vm.sp]
 	ss := int(e.stackSize)
-	vm.stack.expand(vm.sp + ss - 1)
+	vm.expandStack(vm.sp + ss - 1)
 	vv := vm.stack[vm.sp : vm.sp+ss]
 	for i := range vv {
 		vv[i] = nil
@@ -3308,7 +4248,9 @@ type leaveBlock struct {
 
 func (l *leaveBlock) exec(vm *vm) {
 	if l.popStash {
-		vm.stash = vm.stash.outer
+		s := vm.stash
+		vm.stash = s.outer
+		releaseStash(s)
 	}
 	if ss := l.stackSize; ss > 0 {
 		vm.sp -= int(ss)
@@ -3324,6 +4266,7 @@ type enterFunc struct {
 	funcType    funcType
 	argsToStash bool
 	extensible  bool
+	pooled      bool
 }
 
 func (e *enterFunc) exec(vm *vm) {
@@ -3343,10 +4286,8 @@ func (e *enterFunc) exec(vm *vm) {
 	// <- sp
 	sp := vm.sp
 	vm.sb = sp - vm.args - 1
-	vm.newStash()
-	stash := vm.stash
+	stash := vm.newStashValues(e.stashSize, e.pooled)
 	stash.funcType = e.funcType
-	stash.values = make([]Value, e.stashSize)
 	if len(e.names) > 0 {
 		if e.extensible {
 			m := make(map[unistring.String]uint32, len(e.names))
@@ -3382,7 +4323,7 @@ func (e *enterFunc) exec(vm *vm) {
 			vm.args = int(e.numArgs)
 		}
 	}
-	vm.stack.expand(sp + ss - 1)
+	vm.expandStack(sp + ss - 1)
 	if ea > 0 {
 		vv := vm.stack[sp : vm.sp+ea]
 		for i := range vv {
@@ -3407,15 +4348,14 @@ type enterFunc1 struct {
 	argsToCopy uint32
 	funcType   funcType
 	extensible bool
+	pooled     bool
 }
 
 func (e *enterFunc1) exec(vm *vm) {
 	sp := vm.sp
 	vm.sb = sp - vm.args - 1
-	vm.newStash()
-	stash := vm.stash
+	stash := vm.newStashValues(e.stashSize, e.pooled)
 	stash.funcType = e.funcType
-	stash.values = make([]Value, e.stashSize)
 	if len(e.names) > 0 {
 		if e.extensible {
 			m := make(map[unistring.String]uint32, len(e.names))
@@ -3452,17 +4392,17 @@ func (e *enterFunc1) exec(vm *vm) {
 // causes the arguments to be removed from the stack.
 type enterFuncBody struct {
 	enterBlock
-	funcType    funcType
-	extensible  bool
+	funcType   funcType
+	extensible bool
+	// the scope can be accessed by name (i.e. there is a direct eval()), the stash is needed even if it's empty
+	dynamic     bool
 	adjustStack bool
 }
 
 func (e *enterFuncBody) exec(vm *vm) {
-	if e.stashSize > 0 || e.extensible {
-		vm.newStash()
-		stash := vm.stash
+	if e.stashSize > 0 || e.dynamic {
+		stash := vm.newStashValues(e.stashSize, e.pooled)
 		stash.funcType = e.funcType
-		stash.values = make([]Value, e.stashSize)
 		if len(e.names) > 0 {
 			if e.extensible {
 				m := make(map[unistring.String]uint32, len(e.names))
@@ -3481,7 +4421,7 @@ func (e *enterFuncBody) exec(vm *vm) {
 	}
 	nsp := sp + int(e.stackSize)
 	if e.stackSize > 0 {
-		vm.stack.expand(nsp - 1)
+		vm.expandStack(nsp - 1)
 		vv := vm.stack[sp:nsp]
 		for i := range vv {
 			vv[i] = nil
@@ -3502,6 +4442,7 @@ func (_ret) exec(vm *vm) {
 
 	vm.stack[vm.sb-1] = vm.stack[vm.sp-1]
 	vm.sp = vm.sb
+	vm.releaseFuncStashes()
 	vm.popCtx()
 	if vm.pc < 0 {
 		vm.halt = true
@@ -3526,7 +4467,7 @@ func (e *enterFuncStashless) exec(vm *vm) {
 	d := int(e.args) - vm.args
 	if d > 0 {
 		ss := sp + int(e.stackSize) + d
-		vm.stack.expand(ss)
+		vm.expandStack(ss)
 		vv := vm.stack[sp : sp+d]
 		for i := range vv {
 			vv[i] = _undefined
@@ -3540,7 +4481,7 @@ func (e *enterFuncStashless) exec(vm *vm) {
 	} else {
 		if e.stackSize > 0 {
 			ss := sp + int(e.stackSize)
-			vm.stack.expand(ss)
+			vm.expandStack(ss)
 			vv := vm.stack[sp:ss]
 			for i := range vv {
 				vv[i] = nil
@@ -3561,9 +4502,10 @@ type newFunc struct {
 }
 
 func (n *newFunc) exec(vm *vm) {
+	vm.countClosure()
 	obj := vm.r.newFunc(n.name, n.length, n.strict)
 	obj.prg = n.prg
-	obj.stash = vm.stash
+	obj.stash = vm.captureStash()
 	obj.privEnv = vm.privEnv
 	obj.src = n.source
 	vm.push(obj.val)
@@ -3576,9 +4518,46 @@ type newMethod struct {
 }
 
 func (n *newMethod) exec(vm *vm) {
+	vm.countClosure()
 	obj := vm.r.newMethod(n.name, n.length, n.strict)
 	obj.prg = n.prg
-	obj.stash = vm.stash
+	obj.stash = vm.captureStash()
+	obj.privEnv = vm.privEnv
+	obj.src = n.source
+	if n.homeObjOffset > 0 {
+		obj.homeObject = vm.r.toObject(vm.stack[vm.sp-int(n.homeObjOffset)])
+	}
+	vm.push(obj.val)
+	vm.pc++
+}
+
+type newGeneratorFunc struct {
+	newMethod
+}
+
+func (n *newGeneratorFunc) exec(vm *vm) {
+	vm.countClosure()
+	obj := vm.r.newGeneratorFunc(n.name, n.length, n.strict)
+	obj.prg = n.prg
+	obj.stash = vm.captureStash()
+	obj.privEnv = vm.privEnv
+	obj.src = n.source
+	if n.homeObjOffset > 0 {
+		obj.homeObject = vm.r.toObject(vm.stack[vm.sp-int(n.homeObjOffset)])
+	}
+	vm.push(obj.val)
+	vm.pc++
+}
+
+type newAsyncFunc struct {
+	newMethod
+}
+
+func (n *newAsyncFunc) exec(vm *vm) {
+	vm.countClosure()
+	obj := vm.r.newAsyncFunc(n.name, n.length, n.strict)
+	obj.prg = n.prg
+	obj.stash = vm.captureStash()
 	obj.privEnv = vm.privEnv
 	obj.src = n.source
 	if n.homeObjOffset > 0 {
@@ -3594,7 +4573,10 @@ type newArrowFunc struct {
 
 func getFuncObject(v Value) *Object {
 	if o, ok := v.(*Object); ok {
-		if fn, ok := o.self.(*arrowFuncObject); ok {
+		switch fn := o.self.(type) {
+		case *arrowFuncObject:
+			return fn.funcObj
+		case *asyncArrowFuncObject:
 			return fn.funcObj
 		}
 		return o
@@ -3610,19 +4592,44 @@ func getHomeObject(v Value) *Object {
 		switch fn := o.self.(type) {
 		case *methodFuncObject:
 			return fn.homeObject
+		case *generatorFuncObject:
+			return fn.homeObject
+		case *asyncFuncObject:
+			return fn.homeObject
 		case *classFuncObject:
 			return o.runtime.toObject(fn.getStr("prototype", nil))
 		case *arrowFuncObject:
 			return getHomeObject(fn.funcObj)
+		case *asyncArrowFuncObject:
+			return getHomeObject(fn.funcObj)
 		}
 	}
 	panic(newTypeError("Compiler bug: getHomeObject() on the wrong value: %T", v))
 }
 
 func (n *newArrowFunc) exec(vm *vm) {
+	vm.countClosure()
 	obj := vm.r.newArrowFunc(n.name, n.length, n.strict)
 	obj.prg = n.prg
-	obj.stash = vm.stash
+	obj.stash = vm.captureStash()
+	obj.privEnv = vm.privEnv
+	obj.src = n.source
+	if vm.sb > 0 {
+		obj.funcObj = getFuncObject(vm.stack[vm.sb-1])
+	}
+	vm.push(obj.val)
+	vm.pc++
+}
+
+type newAsyncArrowFunc struct {
+	newArrowFunc
+}
+
+func (n *newAsyncArrowFunc) exec(vm *vm) {
+	vm.countClosure()
+	obj := vm.r.newAsyncArrowFunc(n.name, n.length, n.strict)
+	obj.prg = n.prg
+	obj.stash = vm.captureStash()
 	obj.privEnv = vm.privEnv
 	obj.src = n.source
 	if vm.sb > 0 {
@@ -3632,6 +4639,14 @@ func (n *newArrowFunc) exec(vm *vm) {
 	vm.pc++
 }
 
+func (vm *vm) countClosure() {
+	r := vm.r
+	r.runStats.Closures++
+	if max := r.maxClosures; max > 0 && r.runStats.Closures > max {
+		panic(r.newError(r.global.RangeError, "Maximum number of closures (%d) exceeded", max))
+	}
+}
+
 func (vm *vm) alreadyDeclared(name unistring.String) Value {
 	return vm.r.newError(vm.r.global.SyntaxError, "Identifier '%s' has already been declared", name)
 }
@@ -3806,7 +4821,11 @@ func (d *bindVars) exec(vm *vm) {
 		}
 	}
 	if target == nil {
+		// the bindings must never be created in an object environment (i.e. 'with')
 		target = vm.stash
+		for target.obj != nil && target.outer != nil {
+			target = target.outer
+		}
 	}
 	deletable := d.deletable
 	for _, name := range d.names {
@@ -3823,11 +4842,13 @@ type bindGlobal struct {
 
 func (b *bindGlobal) exec(vm *vm) {
 	vm.checkBindFuncsGlobal(b.funcs)
-	vm.checkBindLexGlobal(b.lets)
-	vm.checkBindLexGlobal(b.consts)
+	s := vm.globalLexStash()
+	if s == &vm.r.global.stash {
+		vm.checkBindLexGlobal(b.lets)
+		vm.checkBindLexGlobal(b.consts)
+	}
 	vm.checkBindVarsGlobal(b.vars)
 
-	s := &vm.r.global.stash
 	for _, name := range b.lets {
 		s.createLexBinding(name, false)
 	}
@@ -3989,6 +5010,24 @@ func cmp(px, py Value) Value {
 		}
 	}
 
+	if xb, ok := px.(*valueBigInt); ok {
+		c, ok := cmpBigInt(xb.big(), py)
+		if !ok {
+			return _undefined
+		}
+		ret = c < 0
+		goto end
+	}
+
+	if yb, ok := py.(*valueBigInt); ok {
+		c, ok := cmpBigInt(yb.big(), px)
+		if !ok {
+			return _undefined
+		}
+		ret = c > 0
+		goto end
+	}
+
 	nx = px.ToFloat()
 	ny = py.ToFloat()
 
@@ -4178,46 +5217,70 @@ type try struct {
 }
 
 func (t try) exec(vm *vm) {
-	o := vm.pc
-	vm.pc++
-	ex := vm.runTry()
-	if ex != nil && t.catchOffset > 0 {
-		// run the catch block (in try)
-		vm.pc = o + int(t.catchOffset)
-		// TODO: if ex.val is an Error, set the stack property
-		vm.push(ex.val)
-		ex = vm.runTry()
+	catchPos, finallyPos := int32(-1), int32(-1)
+	if t.catchOffset > 0 {
+		catchPos = int32(vm.pc) + t.catchOffset
 	}
-
 	if t.finallyOffset > 0 {
-		pc := vm.pc
-		// Run finally
-		vm.pc = o + int(t.finallyOffset)
-		vm.run()
-		if vm.prg.code[vm.pc] == retFinally {
-			vm.pc = pc
-		} else {
-			// break or continue out of finally, dropping exception
-			ex = nil
-		}
+		finallyPos = int32(vm.pc) + t.finallyOffset
 	}
+	vm.pushTryFrame(catchPos, finallyPos)
+	vm.pc++
+}
 
-	vm.halt = false
+type _leaveTry struct{}
 
-	if ex != nil {
-		vm.pc = -1 // to prevent the current position from being captured in the stacktrace
-		panic(ex)
+// leaveTry is emitted when the control leaves a 'try' or a 'catch' block (including by break, continue or return).
+// If there is a 'finally' block it is run first, and the execution continues from the next instruction
+// once it completes.
+var leaveTry _leaveTry
+
+func (_leaveTry) exec(vm *vm) {
+	tf := &vm.tryStack[len(vm.tryStack)-1]
+	if tf.finallyPos >= 0 {
+		tf.finallyRet = int32(vm.pc + 1)
+		vm.pc = int(tf.finallyPos)
+		tf.finallyPos = -1
+		tf.catchPos = -1
+	} else {
+		vm.popTryFrame()
+		vm.pc++
 	}
 }
 
-type _retFinally struct{}
+type _enterFinally struct{}
 
-var retFinally _retFinally
+// enterFinally precedes the 'finally' block which is entered after the normal completion of the 'try' or
+// the 'catch' block.
+var enterFinally _enterFinally
 
-func (_retFinally) exec(vm *vm) {
+func (_enterFinally) exec(vm *vm) {
+	tf := &vm.tryStack[len(vm.tryStack)-1]
+	tf.finallyPos = -1
+	tf.catchPos = -1
 	vm.pc++
 }
 
+type _leaveFinally struct{}
+
+// leaveFinally is emitted at the end of the 'finally' block. It re-throws the pending exception if there is one,
+// otherwise it continues from where the 'finally' block was entered.
+var leaveFinally _leaveFinally
+
+func (_leaveFinally) exec(vm *vm) {
+	tf := &vm.tryStack[len(vm.tryStack)-1]
+	ex, ret := tf.exception, tf.finallyRet
+	vm.popTryFrame()
+	if ex != nil {
+		panic(ex)
+	}
+	if ret != -1 {
+		vm.pc = int(ret)
+	} else {
+		vm.pc++
+	}
+}
+
 type _throw struct{}
 
 var throw _throw
@@ -4251,6 +5314,7 @@ type _newVariadic struct{}
 var newVariadic _newVariadic
 
 func (_newVariadic) exec(vm *vm) {
+	// the variadic range includes the constructor
 	_new(vm.countVariadicArgs() - 1).exec(vm)
 }
 
@@ -4260,7 +5324,7 @@ func (n _new) exec(vm *vm) {
 	sp := vm.sp - int(n)
 	obj := vm.stack[sp-1]
 	ctor := vm.r.toConstructor(obj)
-	vm.stack[sp-1] = ctor(vm.stack[sp:vm.sp], nil)
+	vm.stack[sp-1] = ctor(vm.nativeArgs(sp), nil)
 	vm.sp = sp
 	vm.pc++
 }
@@ -4280,13 +5344,15 @@ func (s superCall) exec(vm *vm) {
 		cls = fn
 	case *arrowFuncObject:
 		cls, _ = fn.funcObj.self.(*classFuncObject)
+	case *asyncArrowFuncObject:
+		cls, _ = fn.funcObj.self.(*classFuncObject)
 	}
 	if cls == nil {
 		panic(vm.r.NewTypeError("wrong callee type for super()"))
 	}
 	sp := vm.sp - int(s)
 	newTarget := vm.r.toObject(vm.newTarget)
-	v := cls.createInstance(vm.stack[sp:vm.sp], newTarget)
+	v := cls.createInstance(vm.nativeArgs(sp), newTarget)
 	thisRef.set(v)
 	vm.sp = sp
 	cls._initFields(v)
@@ -4329,7 +5395,7 @@ func (_typeof) exec(vm *vm) {
 	case *Object:
 	repeat:
 		switch s := v.self.(type) {
-		case *classFuncObject, *methodFuncObject, *funcObject, *nativeFuncObject, *boundFuncObject, *arrowFuncObject:
+		case *classFuncObject, *methodFuncObject, *generatorFuncObject, *asyncFuncObject, *funcObject, *nativeFuncObject, *boundFuncObject, *arrowFuncObject, *asyncArrowFuncObject:
 			r = stringFunction
 		case *proxyObject:
 			if s.call == nil {
@@ -4349,6 +5415,8 @@ func (_typeof) exec(vm *vm) {
 		r = stringString
 	case valueInt, valueFloat:
 		r = stringNumber
+	case *valueBigInt:
+		r = stringBigInt
 	case *Symbol:
 		r = stringSymbol
 	default:
@@ -4370,6 +5438,8 @@ func (formalArgs createArgsMapped) exec(vm *vm) {
 	args.val = v
 	args.length = vm.args
 	args.init()
+	// the mapped properties point into the stash values
+	stash := vm.captureStash()
 	i := 0
 	c := int(formalArgs)
 	if vm.args < c {
@@ -4382,7 +5452,7 @@ func (formalArgs createArgsMapped) exec(vm *vm) {
 				configurable: true,
 				enumerable:   true,
 			},
-			v: &vm.stash.values[i],
+			v: &stash.values[i],
 		})
 	}
 
@@ -4533,6 +5603,117 @@ func (_iterate) exec(vm *vm) {
 	vm.pc++
 }
 
+type _iterateAsyncP struct{}
+
+var iterateAsyncP _iterateAsyncP
+
+func (_iterateAsyncP) exec(vm *vm) {
+	iter := vm.r.getAsyncIterator(vm.stack[vm.sp-1])
+	vm.iterStack = append(vm.iterStack, iterStackItem{iter: iter})
+	vm.sp--
+	vm.pc++
+}
+
+// asyncIterNext calls next() of the iterator of a for-await-of loop and pushes the result which is then awaited
+// and handled by asyncIterResult. For a sync iterator the value is pushed instead, or, if the iterator is done,
+// the jump is made straight away.
+type asyncIterNext int32
+
+func (jmp asyncIterNext) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	iter := vm.iterStack[l].iter
+	var res Value
+	done := false
+	ex := vm.try(func() {
+		res = iter.next(FunctionCall{This: iter.iterator})
+		if iter.fromSync {
+			o := vm.r.toIterResult(res)
+			done = nilSafe(o.self.getStr("done", nil)).ToBoolean()
+			res = nilSafe(o.self.getStr("value", nil))
+		}
+	})
+	if ex != nil {
+		vm.iterStack[l] = iterStackItem{}
+		vm.iterStack = vm.iterStack[:l]
+		panic(ex.val)
+	}
+	if done {
+		iter.close()
+		vm.pc += int(jmp)
+		return
+	}
+	iter.awaitingNext = !iter.fromSync
+	vm.push(res)
+	vm.pc++
+}
+
+// asyncIterResult takes the awaited result of asyncIterNext and either makes its value available to enumGet or,
+// if the iterator is done, makes the jump.
+type asyncIterResult int32
+
+func (jmp asyncIterResult) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	iter := vm.iterStack[l].iter
+	res := vm.pop()
+	if iter.fromSync {
+		vm.iterStack[l].val = res
+		vm.pc++
+		return
+	}
+	iter.awaitingNext = false
+	var value Value
+	ex := vm.try(func() {
+		o := vm.r.toIterResult(res)
+		if nilSafe(o.self.getStr("done", nil)).ToBoolean() {
+			iter.close()
+		} else {
+			value = nilSafe(o.self.getStr("value", nil))
+		}
+	})
+	if ex != nil {
+		vm.iterStack[l] = iterStackItem{}
+		vm.iterStack = vm.iterStack[:l]
+		panic(ex.val)
+	}
+	if value == nil {
+		vm.pc += int(jmp)
+	} else {
+		vm.iterStack[l].val = value
+		vm.pc++
+	}
+}
+
+// asyncIterClose is emitted instead of enumPopClose for a for-await-of loop. It pops the iterator and, if it has
+// a return() method, calls it and pushes the result to be awaited and checked by asyncIterCloseResult, otherwise
+// it makes the jump.
+type asyncIterClose int32
+
+func (jmp asyncIterClose) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	item := vm.iterStack[l]
+	vm.iterStack[l] = iterStackItem{}
+	vm.iterStack = vm.iterStack[:l]
+	if iter := item.iter; iter != nil && iter.iterator != nil {
+		obj := iter.iterator
+		iter.close()
+		if retMethod := toMethod(obj.self.getStr("return", nil)); retMethod != nil {
+			vm.push(retMethod(FunctionCall{This: obj}))
+			vm.pc++
+			return
+		}
+	}
+	vm.pc += int(jmp)
+}
+
+type _asyncIterCloseResult struct{}
+
+var asyncIterCloseResult _asyncIterCloseResult
+
+func (_asyncIterCloseResult) exec(vm *vm) {
+	vm.r.toIterResult(vm.pop())
+	vm.pc++
+}
+
 type iterNext int32
 
 func (jmp iterNext) exec(vm *vm) {
@@ -4721,9 +5902,14 @@ type getTaggedTmplObject struct {
 	raw, cooked []Value
 }
 
-// As tagged template objects are not cached (because it's hard to ensure the cache is cleaned without using
-// finalizers) this wrapper is needed to override the equality method so that two objects for the same template
-// literal appeared to be equal from the code's point of view.
+// maxTaggedTemplates is the number of the template objects cached by a Runtime. Once reached, the cache is
+// cleared, so that the template literals of the Programs that are no longer used do not stay in memory forever.
+const maxTaggedTemplates = 1024
+
+// The template objects are cached per Runtime (see Runtime.taggedTemplates), so that repeated evaluations of
+// the same template literal return the same object. However the cache is bounded, so a template literal may get
+// a new object after it's been evicted. This wrapper overrides the equality method so that two objects for
+// the same template literal appear to be equal from the code's point of view in that case as well.
 type taggedTemplateArray struct {
 	*arrayObject
 	idPtr *[]Value
@@ -4737,6 +5923,12 @@ func (a *taggedTemplateArray) equal(other objectImpl) bool {
 }
 
 func (c *getTaggedTmplObject) exec(vm *vm) {
+	r := vm.r
+	if obj := r.taggedTemplates[c]; obj != nil {
+		vm.push(obj)
+		vm.pc++
+		return
+	}
 	cooked := vm.r.newArrayObject()
 	setArrayValues(cooked, c.cooked)
 	raw := vm.r.newArrayObject()
@@ -4761,6 +5953,11 @@ func (c *getTaggedTmplObject) exec(vm *vm) {
 		idPtr:       &c.cooked,
 	}
 
+	if r.taggedTemplates == nil || len(r.taggedTemplates) >= maxTaggedTemplates {
+		r.taggedTemplates = make(map[*getTaggedTmplObject]*Object)
+	}
+	r.taggedTemplates[c] = cooked.val
+
 	vm.push(cooked.val)
 	vm.pc++
 }
@@ -4833,7 +6030,7 @@ func (c *newClass) create(protoParent, ctorParent *Object, vm *vm, derived bool)
 	f._putProp("prototype", proto.val, false, false, false)
 	proto._putProp("constructor", f.val, true, false, true)
 	f.prg = c.ctor
-	f.stash = vm.stash
+	f.stash = vm.captureStash()
 	f.src = c.source
 	f.initFields = c.initFields
 	if c.hasPrivateEnv {
@@ -4851,7 +6048,7 @@ func (c *newClass) create(protoParent, ctorParent *Object, vm *vm, derived bool)
 func (c *newClass) exec(vm *vm) {
 	proto, cls := c.create(vm.r.global.ObjectPrototype, vm.r.global.FunctionPrototype, vm, false)
 	sp := vm.sp
-	vm.stack.expand(sp + 1)
+	vm.expandStack(sp + 1)
 	vm.stack[sp] = proto
 	vm.stack[sp+1] = cls
 	vm.sp = sp + 2
@@ -4898,7 +6095,7 @@ func (c *newStaticFieldInit) exec(vm *vm) {
 		vm.createPrivateType(f, c.numPrivateFields, c.numPrivateMethods)
 	}
 	f.initFields = c.initFields
-	f.stash = vm.stash
+	f.stash = vm.captureStash()
 	vm.push(f.val)
 	vm.pc++
 }
@@ -90,6 +90,192 @@ func TestNewArrayFromIterClosed(t *testing.T) {
 	testScriptWithTestLib(SCRIPT, _undefined, t)
 }
 
+func TestStashPooling(t *testing.T) {
+	const SCRIPT = `
+	// the closures are created conditionally, so that most of the stashes are not captured
+	function counter(create) {
+		let n = 0;
+		if (create) {
+			return () => ++n;
+		}
+		n++;
+		return n;
+	}
+	var counters = [];
+	for (var i = 0; i < 100; i++) {
+		counter(false);
+		if (i % 10 === 0) {
+			counters.push(counter(true));
+		}
+	}
+	counters.forEach(function(c, idx) {
+		for (var j = 0; j <= idx; j++) {
+			c();
+		}
+	});
+	assert(compareArray(counters.map(function(c) { return c(); }), [2, 3, 4, 5, 6, 7, 8, 9, 10, 11]), "closures");
+
+	function nested(x) {
+		let a = x;
+		{
+			let b = a * 2;
+			var f = x < 100 ? () => a + b : null;
+		}
+		{
+			let c = a * 3;
+			var g = x < 100 ? () => c : null;
+		}
+		return [f, g];
+	}
+	var fs = [];
+	for (var i = 0; i < 10; i++) {
+		fs.push(nested(i));
+		nested(100);
+	}
+	assert(compareArray(fs.map(function(p) { return p[0]() + p[1](); }), [0, 6, 12, 18, 24, 30, 36, 42, 48, 54]), "blocks");
+
+	function thrower(x) {
+		let y = x;
+		if (x > 0) {
+			throw () => y;
+		}
+		return y;
+	}
+	var caught = [];
+	for (var i = 0; i < 5; i++) {
+		try {
+			thrower(i);
+		} catch (e) {
+			caught.push(e);
+		}
+	}
+	assert(compareArray(caught.map(function(c) { return c(); }), [1, 2, 3, 4]), "exceptions");
+
+	function mapped(a) {
+		if (a < 0) {
+			return () => a;
+		}
+		return arguments;
+	}
+	var args = [];
+	for (var i = 0; i < 5; i++) {
+		args.push(mapped(i));
+	}
+	assert(compareArray(args.map(function(a) { return a[0]; }), [0, 1, 2, 3, 4]), "arguments");
+
+	function withEval(x) {
+		let v = x;
+		return eval("(function() { return v; })");
+	}
+	var evals = [];
+	for (var i = 0; i < 5; i++) {
+		evals.push(withEval(i));
+		withEval(-1);
+	}
+	assert(compareArray(evals.map(function(f) { return f(); }), [0, 1, 2, 3, 4]), "eval");
+	`
+	testScriptWithTestLib(SCRIPT, _undefined, t)
+}
+
+func TestTailCall(t *testing.T) {
+	const SCRIPT = `
+	"use strict";
+	function fact(n, acc) {
+		if (n <= 1) {
+			return acc;
+		}
+		return fact(n - 1, acc * n);
+	}
+	assert.sameValue(fact(20, 1), 2432902008176640000, "fact");
+	function factN(n, acc) {
+		return n <= 1n ? acc : factN(n - 1n, acc * n);
+	}
+	assert.sameValue(factN(1000n, 1n), factN(999n, 1n) * 1000n, "fact bigint");
+
+	function ack(m, n) {
+		if (m === 0) {
+			return n + 1;
+		}
+		if (n === 0) {
+			return ack(m - 1, 1);
+		}
+		return ack(m - 1, ack(m, n - 1));
+	}
+	assert.sameValue(ack(2, 30), 63, "ackermann");
+
+	function isEven(n) {
+		return n === 0 ? true : isOdd(n - 1);
+	}
+	const isOdd = n => n === 0 ? false : isEven(n - 1);
+	assert(isEven(10000), "mutual recursion");
+
+	function args() {
+		return arguments.length;
+	}
+	function count(n, ...rest) {
+		return n > 0 ? count(n - 1, ...rest, n) : args(...rest, 1, 2);
+	}
+	function fewer(n, a, b) {
+		return n > 0 ? fewer(n - 1) : [n, a, b];
+	}
+	assert.sameValue(count(5), 7, "arguments");
+	assert(compareArray(fewer(10000, 1, 2), [0, undefined, undefined]), "missing arguments");
+
+	function closures(n, fns) {
+		let x = n;
+		fns.push(() => x);
+		return n > 0 ? closures(n - 1, fns) : fns;
+	}
+	assert(compareArray(closures(3, []).map(f => f()), [3, 2, 1, 0]), "closures");
+
+	const obj = {
+		m(n) {
+			return n > 0 ? this.m(n - 1) : this;
+		}
+	};
+	assert.sameValue(obj.m(10000), obj, "method");
+
+	function arrowThis(n) {
+		const f = () => this;
+		return n > 0 ? f() : null;
+	}
+	assert.sameValue(arrowThis.call(obj, 1), obj, "arrow this");
+
+	function T(n) {
+		return tgt();
+	}
+	function tgt() {
+		return new.target;
+	}
+	assert.sameValue(new T() instanceof T, true, "new.target of the callee");
+
+	function native(n) {
+		return n > 0 ? native(n - 1) : Math.max(n, 1);
+	}
+	assert.sameValue(native(10000), 1, "native callee");
+	`
+	r := New()
+	r.SetMaxCallStackSize(100)
+	r.testScriptWithTestLibX(SCRIPT, _undefined, t)
+}
+
+func TestTailCallNotInTailPosition(t *testing.T) {
+	r := New()
+	r.SetMaxCallStackSize(100)
+	for _, src := range []string{
+		"function f(n) { return n > 0 ? f(n - 1) : 0; } f(1000);", // non-strict
+		"'use strict'; function f(n) { try { return n > 0 ? f(n - 1) : 0; } finally {} } f(1000);",
+		"'use strict'; function f(n) { for (const x of [1]) { return n > 0 ? f(n - 1) : 0; } } f(1000);",
+		"'use strict'; function f(n) { return n > 0 ? 1 + f(n - 1) : 0; } f(1000);",
+		"'use strict'; function* g(n) { return n > 0 ? g(n - 1).next() : 0; } g(1000).next();",
+	} {
+		_, err := r.RunString(src)
+		if _, ok := err.(*StackOverflowError); !ok {
+			t.Errorf("%s: unexpected error: %v", src, err)
+		}
+	}
+}
+
 func BenchmarkVmNOP2(b *testing.B) {
 	prg := []func(*vm){
 		//loadVal(0).exec,
@@ -281,3 +467,31 @@ func BenchmarkAssertInt(b *testing.B) {
 		}
 	}
 }
+
+var intCacheSink Value
+
+func TestIntCacheRange(t *testing.T) {
+	defer SetIntCacheRange(intCacheMin, intCacheMax)
+
+	SetIntCacheRange(0, 4095)
+	if intCacheMin != -128 || intCacheMax != 4095 {
+		t.Fatalf("Unexpected range: %d..%d", intCacheMin, intCacheMax)
+	}
+	for _, i := range []int64{-128, 0, 127, 4095} {
+		if v := intToValue(i); v != valueInt(i) {
+			t.Fatalf("%d: %v", i, v)
+		}
+	}
+	i := int64(4000)
+	if n := testing.AllocsPerRun(100, func() {
+		intCacheSink = intToValue(i)
+	}); n != 0 {
+		t.Fatalf("allocs: %v", n)
+	}
+	i = 5000
+	if n := testing.AllocsPerRun(100, func() {
+		intCacheSink = intToValue(i)
+	}); n != 1 {
+		t.Fatalf("allocs: %v", n)
+	}
+}